@@ -3,27 +3,122 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
+	"image"
+	_ "image/jpeg"
 	"io"
+	"net/http"
 	"os"
+	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"text/tabwriter"
 	"time"
+	"unicode/utf16"
 
 	"github.com/lsferreira42/figlet-go/figlet"
+	"github.com/lsferreira42/figlet-go/figlethttp"
+	"github.com/lsferreira42/figlet-go/internal/terminal"
 )
 
+// input holds the CLI settings for where the text to render comes from
+var input struct {
+	files         []string // files given with -i, "-" is stdin
+	encoding      string   // encoding of stdin and --watch, empty for raw bytes
+	nullDelimited bool     // records are separated by NUL bytes
+	follow        bool     // render each record as soon as it is read
+	clear         bool     // clear the screen before each followed record
+	delimiter     *string  // line printed between banners, if set
+}
+
+// output holds the CLI settings for where and how banners are written
+var output struct {
+	file   string        // file given with --output, empty for stdout
+	format figlet.Format // format given with --format, empty to guess
+	fit    []string      // fonts tried by --fit, biggest first, nil when off
+	frames string        // directory given with --export-frames, empty for none
+}
+
+// watch holds the settings for re-rendering a file when it changes
+var watch struct {
+	file     string
+	interval time.Duration
+}
+
+// qrcode holds the settings for drawing a QR code next to the banner
+var qrcode struct {
+	data      string // text of the code, empty for none
+	placement figlet.QRPlacement
+}
+
+// mask holds the settings for shaping the banner with an image
+var mask struct {
+	file    string // image given with --mask, empty for none
+	caption bool   // draw the image with the banner as its caption
+	options figlet.MaskOptions
+}
+
+// subcommands maps a first argument to its command. Any other first
+// argument is parsed as classic figlet options and message.
+var subcommands map[string]func(cfg *figlet.Config)
+
+func init() {
+	subcommands = map[string]func(cfg *figlet.Config){
+		"render":     renderCommand,
+		"completion": completionCommand,
+		"demo":       demoCommand,
+		"bench":      benchCommand,
+		"fonts":      fontsCommand,
+		"motd":       motdCommand,
+		"clock":      clockCommand,
+		"badge":      badgeCommand,
+		"comment":    commentCommand,
+		"md-expand":  mdExpandCommand,
+		"git-banner": gitBannerCommand,
+		"replay":     replayCommand,
+		"serve":      serveCommand,
+		"__complete": completeCommand,
+	}
+}
+
 func main() {
 	cfg := figlet.New()
 	cfg.Argv = os.Args
+	// Font and control file names come from the user, who may name any file
+	cfg.AllowFilesystemPaths = true
+
+	if len(os.Args) > 1 {
+		if command, ok := subcommands[os.Args[1]]; ok {
+			cfg.Argv = append([]string{os.Args[0]}, os.Args[2:]...)
+			command(cfg)
+			return
+		}
+	}
+	renderCommand(cfg)
+}
 
+// renderCommand renders the message, the input files or stdin
+func renderCommand(cfg *figlet.Config) {
 	getparams(cfg)
 	if err := cfg.LoadFont(); err != nil {
 		fmt.Fprintf(os.Stderr, "%s: %v\n", getmyname(cfg.Argv), err)
 		os.Exit(1)
 	}
+	if input.encoding != "" {
+		// Decoded input is handed to the renderer as UTF-8
+		cfg.Multibyte = 2
+	}
 
+	if watch.file != "" {
+		watchFile(cfg, watch.file)
+		return
+	}
 	processInput(cfg)
 }
 
@@ -37,12 +132,29 @@ func getmyname(argv []string) string {
 
 func printusage(cfg *figlet.Config, out io.Writer) {
 	myname := getmyname(cfg.Argv)
-	fmt.Fprintf(out, "Usage: %s [ -cklnoprstvxDELNRSWX ] [ -d fontdirectory ]\n", myname)
-	fmt.Fprintf(out, "              [ -f fontfile ] [ -m smushmode ] [ -w outputwidth ]\n")
-	fmt.Fprintf(out, "              [ -C controlfile ] [ -I infocode ]\n")
-	fmt.Fprintf(out, "              [ --colors color1;color2;... ] [ --parser terminal|terminal-color|html ]\n")
-	fmt.Fprintf(out, "              [ --animation reveal|scroll|rain|wave|explosion ] [ --animation-delay ms ]\n")
-	fmt.Fprintf(out, "              [ --animation-file file ] [ --export file ]\n")
+	fmt.Fprintf(out, "Usage: %s [ -cjklnoprstvxDELNRSWX ] [ -d fontdirectory ]\n", myname)
+	fmt.Fprintf(out, "              [ -f fontfile ] [ -m smushmode ] [ --overlap n ] [ -w outputwidth ]\n")
+	fmt.Fprintf(out, "              [ -C controlfile ] [ -I infocode ] [ --fallback font1,font2,... ]\n")
+	fmt.Fprintf(out, "              [ --colors color1;color2;... ] [ --parser terminal|terminal-color|html|cmd|powershell ]\n")
+	fmt.Fprintf(out, "              [ --animation reveal|scroll|rain|wave|explosion|drop|slide|spin|highlight ] [ --animation-delay ms ]\n")
+	fmt.Fprintf(out, "              [ --animation-file file ] [ --export file ] [ --keep-hardblanks ] [ --trim-margin ]\n")
+	fmt.Fprintf(out, "              [ --pad block|width ] [ --charmap latin1|utf8|uppercase|quotes ]\n")
+	fmt.Fprintf(out, "              [ --case upper|lower|title ] [ -i file ] [ --stdin-encoding enc ]\n")
+	fmt.Fprintf(out, "              [ --null-delimited ] [ --delimiter line ] [ --follow ] [ --clear ]\n")
+	fmt.Fprintf(out, "              [ --no-trailing-newline ]\n")
+	fmt.Fprintf(out, "              [ --watch file ] [ --watch-interval ms ]\n")
+	fmt.Fprintf(out, "              [ --output file ] [ --format text|html|svg|json|png|gif ]\n")
+	fmt.Fprintf(out, "              [ --trace-smushing ] [ --debug ] [ --underline ]\n")
+	fmt.Fprintf(out, "              [ --style bold,dim,italic,underline,blink,reverse,strikethrough ]\n")
+	fmt.Fprintf(out, "              [ --fill checkerboard|stripes[:width]|random[:seed] ] [ --theme name ]\n")
+	fmt.Fprintf(out, "              [ --color-depth auto|16|256|truecolor ]\n")
+	fmt.Fprintf(out, "              [ --bubble say|think|round ] [ --bubble-text text ] [ --bubble-tail rows ]\n")
+	fmt.Fprintf(out, "              [ --border single|double|round|heavy|ascii ] [ --preset name ]\n")
+	fmt.Fprintf(out, "              [ --qr text ] [ --qr-position below|right|left ]\n")
+	fmt.Fprintf(out, "              [ --mask image ] [ --mask-width columns ] [ --mask-invert ] [ --mask-caption ]\n")
+	fmt.Fprintf(out, "              [ --fit[=font1,font2,...] ] [ --scale factor[,factor] ]\n")
+	fmt.Fprintf(out, "              [ --transform rotate90|rotate180|mirror-h|mirror-v,... ]\n")
+	fmt.Fprintf(out, "              [ --line-prefix text ] [ --indent n ] [ --line-ending lf|crlf|cr ]\n")
 	fmt.Fprintf(out, "              [ message ]\n")
 }
 
@@ -96,6 +208,8 @@ func getparams(cfg *figlet.Config) {
 	cfg.Cmdinput = false
 	cfg.Outputwidth = figlet.DEFAULTCOLUMNS
 	cfg.AnimationDelay = 50 * time.Millisecond
+	cfg.ColorDepth = figlet.DetectColorDepth()
+	watch.interval = 500 * time.Millisecond
 
 	// Simple getopt implementation
 	optind := 1
@@ -148,6 +262,195 @@ func getparams(cfg *figlet.Config) {
 					cfg.OutputParser = parser
 				}
 				optind++
+			} else if arg == "--keep-hardblanks" {
+				cfg.KeepHardblanks = true
+			} else if arg == "--trim-margin" {
+				cfg.Trim |= figlet.TrimBlockMargin
+			} else if strings.HasPrefix(arg, "--charmap=") {
+				cfg.CharMaps = append(cfg.CharMaps, strings.Split(arg[10:], ",")...)
+			} else if arg == "--charmap" && optind+1 < len(cfg.Argv) {
+				cfg.CharMaps = append(cfg.CharMaps, strings.Split(cfg.Argv[optind+1], ",")...)
+				optind++
+			} else if strings.HasPrefix(arg, "--fallback=") {
+				cfg.FontFallback = append(cfg.FontFallback, strings.Split(arg[11:], ",")...)
+			} else if arg == "--fallback" && optind+1 < len(cfg.Argv) {
+				cfg.FontFallback = append(cfg.FontFallback, strings.Split(cfg.Argv[optind+1], ",")...)
+				optind++
+			} else if strings.HasPrefix(arg, "--case=") {
+				parseCaseArg(cfg, arg[7:])
+			} else if arg == "--case" && optind+1 < len(cfg.Argv) {
+				parseCaseArg(cfg, cfg.Argv[optind+1])
+				optind++
+			} else if strings.HasPrefix(arg, "--pad=") {
+				parsePadArg(cfg, arg[6:])
+			} else if strings.HasPrefix(arg, "--line-ending=") {
+				parseLineEndingArg(cfg, arg[14:])
+			} else if arg == "--line-ending" && optind+1 < len(cfg.Argv) {
+				parseLineEndingArg(cfg, cfg.Argv[optind+1])
+				optind++
+			} else if arg == "--pad" && optind+1 < len(cfg.Argv) {
+				parsePadArg(cfg, cfg.Argv[optind+1])
+				optind++
+			} else if strings.HasPrefix(arg, "--input=") {
+				input.files = append(input.files, arg[8:])
+			} else if arg == "--input" && optind+1 < len(cfg.Argv) {
+				input.files = append(input.files, cfg.Argv[optind+1])
+				optind++
+			} else if strings.HasPrefix(arg, "--stdin-encoding=") {
+				parseEncodingArg(cfg, arg[17:])
+			} else if arg == "--stdin-encoding" && optind+1 < len(cfg.Argv) {
+				parseEncodingArg(cfg, cfg.Argv[optind+1])
+				optind++
+			} else if arg == "--null-delimited" {
+				input.nullDelimited = true
+			} else if arg == "--follow" {
+				input.follow = true
+			} else if arg == "--clear" {
+				input.clear = true
+			} else if arg == "--no-trailing-newline" {
+				cfg.NoTrailingNewline = true
+			} else if arg == "--trace-smushing" {
+				cfg.TraceSmushing = true
+			} else if arg == "--debug" {
+				cfg.Debug(os.Stderr)
+			} else if arg == "--underline" {
+				cfg.Underline = true
+			} else if strings.HasPrefix(arg, "--style=") {
+				parseStyleArg(cfg, arg[8:])
+			} else if arg == "--style" && optind+1 < len(cfg.Argv) {
+				parseStyleArg(cfg, cfg.Argv[optind+1])
+				optind++
+			} else if strings.HasPrefix(arg, "--color-depth=") {
+				parseColorDepthArg(cfg, arg[14:])
+			} else if arg == "--color-depth" && optind+1 < len(cfg.Argv) {
+				parseColorDepthArg(cfg, cfg.Argv[optind+1])
+				optind++
+			} else if strings.HasPrefix(arg, "--theme=") {
+				parseThemeArg(cfg, arg[8:])
+			} else if arg == "--theme" && optind+1 < len(cfg.Argv) {
+				parseThemeArg(cfg, cfg.Argv[optind+1])
+				optind++
+			} else if strings.HasPrefix(arg, "--fill=") {
+				parseFillArg(cfg, arg[7:])
+			} else if arg == "--fill" && optind+1 < len(cfg.Argv) {
+				parseFillArg(cfg, cfg.Argv[optind+1])
+				optind++
+			} else if strings.HasPrefix(arg, "--bubble=") {
+				parseBubbleArg(cfg, arg[9:])
+			} else if arg == "--bubble" && optind+1 < len(cfg.Argv) {
+				parseBubbleArg(cfg, cfg.Argv[optind+1])
+				optind++
+			} else if strings.HasPrefix(arg, "--bubble-text=") {
+				cfg.Bubble.Text = arg[14:]
+			} else if arg == "--bubble-text" && optind+1 < len(cfg.Argv) {
+				cfg.Bubble.Text = cfg.Argv[optind+1]
+				optind++
+			} else if strings.HasPrefix(arg, "--bubble-tail=") {
+				parseBubbleTailArg(cfg, arg[14:])
+			} else if arg == "--bubble-tail" && optind+1 < len(cfg.Argv) {
+				parseBubbleTailArg(cfg, cfg.Argv[optind+1])
+				optind++
+			} else if strings.HasPrefix(arg, "--border=") {
+				parseBorderArg(cfg, arg[9:])
+			} else if arg == "--border" && optind+1 < len(cfg.Argv) {
+				parseBorderArg(cfg, cfg.Argv[optind+1])
+				optind++
+			} else if strings.HasPrefix(arg, "--preset=") {
+				parsePresetArg(cfg, arg[9:])
+			} else if arg == "--preset" && optind+1 < len(cfg.Argv) {
+				parsePresetArg(cfg, cfg.Argv[optind+1])
+				optind++
+			} else if strings.HasPrefix(arg, "--qr=") {
+				qrcode.data = arg[5:]
+			} else if arg == "--qr" && optind+1 < len(cfg.Argv) {
+				qrcode.data = cfg.Argv[optind+1]
+				optind++
+			} else if strings.HasPrefix(arg, "--qr-position=") {
+				parseQRPositionArg(cfg, arg[14:])
+			} else if arg == "--qr-position" && optind+1 < len(cfg.Argv) {
+				parseQRPositionArg(cfg, cfg.Argv[optind+1])
+				optind++
+			} else if strings.HasPrefix(arg, "--mask=") {
+				mask.file = arg[7:]
+			} else if arg == "--mask" && optind+1 < len(cfg.Argv) {
+				mask.file = cfg.Argv[optind+1]
+				optind++
+			} else if strings.HasPrefix(arg, "--mask-width=") {
+				parseMaskWidthArg(cfg, arg[13:])
+			} else if arg == "--mask-width" && optind+1 < len(cfg.Argv) {
+				parseMaskWidthArg(cfg, cfg.Argv[optind+1])
+				optind++
+			} else if arg == "--mask-invert" {
+				mask.options.Invert = true
+			} else if strings.HasPrefix(arg, "--scale=") {
+				parseScaleArg(cfg, arg[8:])
+			} else if arg == "--scale" && optind+1 < len(cfg.Argv) {
+				parseScaleArg(cfg, cfg.Argv[optind+1])
+				optind++
+			} else if strings.HasPrefix(arg, "--transform=") {
+				parseTransformArg(cfg, arg[12:])
+			} else if arg == "--transform" && optind+1 < len(cfg.Argv) {
+				parseTransformArg(cfg, cfg.Argv[optind+1])
+				optind++
+			} else if strings.HasPrefix(arg, "--line-prefix=") {
+				cfg.LinePrefix = arg[14:]
+			} else if arg == "--line-prefix" && optind+1 < len(cfg.Argv) {
+				cfg.LinePrefix = cfg.Argv[optind+1]
+				optind++
+			} else if strings.HasPrefix(arg, "--overlap=") {
+				parseOverlapArg(cfg, arg[10:])
+			} else if arg == "--overlap" && optind+1 < len(cfg.Argv) {
+				parseOverlapArg(cfg, cfg.Argv[optind+1])
+				optind++
+			} else if strings.HasPrefix(arg, "--indent=") {
+				parseIndentArg(cfg, arg[9:])
+			} else if arg == "--indent" && optind+1 < len(cfg.Argv) {
+				parseIndentArg(cfg, cfg.Argv[optind+1])
+				optind++
+			} else if arg == "--fit" {
+				output.fit = figlet.FitFonts
+			} else if strings.HasPrefix(arg, "--fit=") {
+				output.fit = strings.Split(arg[6:], ",")
+			} else if arg == "--mask-caption" {
+				mask.caption = true
+			} else if strings.HasPrefix(arg, "--delimiter=") {
+				line := arg[12:]
+				input.delimiter = &line
+			} else if arg == "--delimiter" && optind+1 < len(cfg.Argv) {
+				line := cfg.Argv[optind+1]
+				input.delimiter = &line
+				optind++
+			} else if strings.HasPrefix(arg, "--output=") {
+				output.file = arg[9:]
+			} else if arg == "--output" && optind+1 < len(cfg.Argv) {
+				output.file = cfg.Argv[optind+1]
+				optind++
+			} else if strings.HasPrefix(arg, "--format=") {
+				parseFormatArg(cfg, arg[9:])
+			} else if arg == "--format" && optind+1 < len(cfg.Argv) {
+				parseFormatArg(cfg, cfg.Argv[optind+1])
+				optind++
+			} else if strings.HasPrefix(arg, "--watch=") {
+				watch.file = arg[8:]
+			} else if arg == "--watch" && optind+1 < len(cfg.Argv) {
+				watch.file = cfg.Argv[optind+1]
+				optind++
+			} else if strings.HasPrefix(arg, "--watch-interval=") {
+				val, _ := strconv.Atoi(arg[17:])
+				if val > 0 {
+					watch.interval = time.Duration(val) * time.Millisecond
+				}
+			} else if arg == "--watch-interval" && optind+1 < len(cfg.Argv) {
+				val, _ := strconv.Atoi(cfg.Argv[optind+1])
+				if val > 0 {
+					watch.interval = time.Duration(val) * time.Millisecond
+				}
+				optind++
+			} else if strings.HasPrefix(arg, "--export-frames=") {
+				output.frames = arg[16:]
+			} else if arg == "--export-frames" && optind+1 < len(cfg.Argv) {
+				output.frames = cfg.Argv[optind+1]
+				optind++
 			} else if strings.HasPrefix(arg, "--export=") {
 				cfg.ExportFile = arg[9:]
 			} else if arg == "--export" && optind+1 < len(cfg.Argv) {
@@ -185,6 +488,8 @@ func getparams(cfg *figlet.Config) {
 				cfg.Justification = 1
 			case 'r':
 				cfg.Justification = 2
+			case 'j':
+				cfg.Justification = figlet.JustifyFull
 			case 'p':
 				cfg.Paragraphflag = true
 			case 'n':
@@ -276,6 +581,14 @@ func getparams(cfg *figlet.Config) {
 				} else if suffixcmp(cfg.Fontname, figlet.TOILETFILESUFFIX) {
 					cfg.Fontname = cfg.Fontname[:len(cfg.Fontname)-len(figlet.TOILETFILESUFFIX)]
 				}
+			case 'i':
+				if i+1 < len(arg) {
+					input.files = append(input.files, arg[i+1:])
+					i = len(arg)
+				} else if optind+1 < len(cfg.Argv) {
+					input.files = append(input.files, cfg.Argv[optind+1])
+					optind++
+				}
 			case 'C':
 				var name string
 				if i+1 < len(arg) {
@@ -331,6 +644,353 @@ func parseColorsArg(cfg *figlet.Config, colorsStr string) {
 	}
 }
 
+// parseThemeArg handles the --theme argument, a named palette used like --colors
+func parseThemeArg(cfg *figlet.Config, name string) {
+	colors, err := figlet.Theme(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", getmyname(cfg.Argv), err)
+		os.Exit(1)
+	}
+	figlet.WithColors(colors...)(cfg)
+}
+
+// parseStyleArg handles the --style argument and, like --colors, switches
+// the default parser to terminal-color
+func parseStyleArg(cfg *figlet.Config, names string) {
+	style, err := figlet.ParseStyle(names)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", getmyname(cfg.Argv), err)
+		os.Exit(1)
+	}
+	cfg.Style = style
+	if cfg.OutputParser != nil && cfg.OutputParser.Name == "terminal" {
+		cfg.OutputParser, _ = figlet.GetParser("terminal-color")
+	}
+}
+
+// parseColorDepthArg handles the --color-depth argument; auto uses the
+// depth the terminal reports
+func parseColorDepthArg(cfg *figlet.Config, value string) {
+	if value == "auto" {
+		cfg.ColorDepth = figlet.DetectColorDepth()
+		return
+	}
+	depth, err := figlet.ParseColorDepth(value)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", getmyname(cfg.Argv), err)
+		os.Exit(1)
+	}
+	cfg.ColorDepth = depth
+}
+
+// parseFillArg handles the --fill argument, a color strategy with an
+// optional parameter such as stripes:3
+func parseFillArg(cfg *figlet.Config, spec string) {
+	name, param, hasParam := strings.Cut(spec, ":")
+	n := 1
+	if hasParam {
+		var err error
+		if n, err = strconv.Atoi(param); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: invalid fill parameter %q\n", getmyname(cfg.Argv), param)
+			os.Exit(1)
+		}
+	}
+	switch name {
+	case "checkerboard":
+		cfg.ColorStrategy = figlet.Checkerboard()
+	case "stripes":
+		cfg.ColorStrategy = figlet.Stripes(n)
+	case "random":
+		seed := int64(n)
+		if !hasParam {
+			seed = time.Now().UnixNano()
+		}
+		cfg.ColorStrategy = figlet.RandomGlyphs(seed)
+	default:
+		fmt.Fprintf(os.Stderr, "%s: invalid fill %q (use checkerboard, stripes or random)\n", getmyname(cfg.Argv), name)
+		os.Exit(1)
+	}
+}
+
+// parseBubbleArg handles the --bubble argument, the speech bubble style
+func parseBubbleArg(cfg *figlet.Config, name string) {
+	style, err := figlet.ParseBubbleStyle(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", getmyname(cfg.Argv), err)
+		os.Exit(1)
+	}
+	cfg.Bubble.Style = style
+}
+
+// parseBorderArg handles the --border argument, the style of the box
+// drawn around the banner
+func parseBorderArg(cfg *figlet.Config, name string) {
+	style, err := figlet.ParseBorderStyle(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", getmyname(cfg.Argv), err)
+		os.Exit(1)
+	}
+	cfg.Border = style
+}
+
+// parsePresetArg handles the --preset argument, a named banner style;
+// the options after it override its settings
+func parsePresetArg(cfg *figlet.Config, name string) {
+	if _, err := figlet.GetPreset(name); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", getmyname(cfg.Argv), err)
+		os.Exit(1)
+	}
+	figlet.WithPreset(name)(cfg)
+}
+
+// parseBubbleTailArg handles the --bubble-tail argument, the number of
+// rows of the bubble's tail; 0 draws none
+func parseBubbleTailArg(cfg *figlet.Config, rows string) {
+	n, err := strconv.Atoi(rows)
+	if err != nil || n < 0 {
+		fmt.Fprintf(os.Stderr, "%s: invalid bubble tail %q\n", getmyname(cfg.Argv), rows)
+		os.Exit(1)
+	}
+	if n == 0 {
+		n = -1
+	}
+	cfg.Bubble.Tail = n
+}
+
+// parseQRPositionArg handles the --qr-position argument, where the QR
+// code goes relative to the banner
+func parseQRPositionArg(cfg *figlet.Config, position string) {
+	switch position {
+	case "below":
+		qrcode.placement = figlet.QRBelow
+	case "right":
+		qrcode.placement = figlet.QRRight
+	case "left":
+		qrcode.placement = figlet.QRLeft
+	default:
+		fmt.Fprintf(os.Stderr, "%s: invalid QR position %q (use below, right or left)\n", getmyname(cfg.Argv), position)
+		os.Exit(1)
+	}
+}
+
+// parseMaskWidthArg handles the --mask-width argument, the number of
+// columns the mask image is scaled to
+func parseMaskWidthArg(cfg *figlet.Config, width string) {
+	n, err := strconv.Atoi(width)
+	if err != nil || n <= 0 {
+		fmt.Fprintf(os.Stderr, "%s: invalid mask width %q\n", getmyname(cfg.Argv), width)
+		os.Exit(1)
+	}
+	mask.options.Width = n
+}
+
+// parseScaleArg handles the --scale argument, one factor for both
+// directions or two, across and down, separated by a comma
+func parseScaleArg(cfg *figlet.Config, arg string) {
+	factors := strings.Split(arg, ",")
+	scale := make([]float64, 0, 2)
+	for _, f := range factors {
+		n, err := strconv.ParseFloat(f, 64)
+		if err != nil || len(factors) > 2 || n <= 0 || n > figlet.MAXSCALE {
+			fmt.Fprintf(os.Stderr, "%s: invalid scale %q\n", getmyname(cfg.Argv), arg)
+			os.Exit(1)
+		}
+		scale = append(scale, n)
+	}
+	cfg.ScaleX, cfg.ScaleY = scale[0], scale[len(scale)-1]
+}
+
+// parseTransformArg handles the --transform argument, a comma separated
+// list of transforms applied in order
+func parseTransformArg(cfg *figlet.Config, arg string) {
+	for _, name := range strings.Split(arg, ",") {
+		t, err := figlet.ParseTransform(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", getmyname(cfg.Argv), err)
+			os.Exit(1)
+		}
+		cfg.Transforms = append(cfg.Transforms, t)
+	}
+}
+
+// parseIndentArg handles the --indent argument, the number of spaces
+// before every output line
+func parseIndentArg(cfg *figlet.Config, arg string) {
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 0 {
+		fmt.Fprintf(os.Stderr, "%s: invalid indent %q\n", getmyname(cfg.Argv), arg)
+		os.Exit(1)
+	}
+	cfg.Indent = n
+}
+
+// parseOverlapArg handles the --overlap argument
+func parseOverlapArg(cfg *figlet.Config, arg string) {
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 0 {
+		fmt.Fprintf(os.Stderr, "%s: invalid overlap %q\n", getmyname(cfg.Argv), arg)
+		os.Exit(1)
+	}
+	cfg.Overlap = n
+}
+
+// parseLineEndingArg handles the --line-ending argument
+func parseLineEndingArg(cfg *figlet.Config, ending string) {
+	switch ending {
+	case "lf":
+		cfg.LineEnding = "\n"
+	case "crlf":
+		cfg.LineEnding = "\r\n"
+	case "cr":
+		cfg.LineEnding = "\r"
+	default:
+		fmt.Fprintf(os.Stderr, "%s: invalid line ending %q (use lf, crlf or cr)\n", getmyname(cfg.Argv), ending)
+		os.Exit(1)
+	}
+}
+
+// parsePadArg handles the --pad argument
+func parsePadArg(cfg *figlet.Config, mode string) {
+	switch mode {
+	case "none":
+		cfg.Pad = figlet.PadNone
+	case "block":
+		cfg.Pad = figlet.PadBlock
+	case "width":
+		cfg.Pad = figlet.PadOutputWidth
+	default:
+		fmt.Fprintf(os.Stderr, "%s: invalid pad mode %q (use none, block or width)\n", getmyname(cfg.Argv), mode)
+		os.Exit(1)
+	}
+}
+
+// parseCaseArg handles the --case argument
+func parseCaseArg(cfg *figlet.Config, mode string) {
+	switch mode {
+	case "none":
+		cfg.Case = figlet.CaseNone
+	case "upper":
+		cfg.Case = figlet.CaseUpper
+	case "lower":
+		cfg.Case = figlet.CaseLower
+	case "title":
+		cfg.Case = figlet.CaseTitle
+	default:
+		fmt.Fprintf(os.Stderr, "%s: invalid case %q (use none, upper, lower or title)\n", getmyname(cfg.Argv), mode)
+		os.Exit(1)
+	}
+}
+
+// parseFormatArg handles the --format argument
+func parseFormatArg(cfg *figlet.Config, name string) {
+	format, err := figlet.ParseFormat(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", getmyname(cfg.Argv), err)
+		os.Exit(1)
+	}
+	output.format = format
+}
+
+// formatForFile guesses the output format from a file name
+func formatForFile(name string) figlet.Format {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".html", ".htm":
+		return figlet.FormatHTML
+	case ".svg":
+		return figlet.FormatSVG
+	case ".json":
+		return figlet.FormatJSON
+	case ".png":
+		return figlet.FormatPNG
+	case ".gif":
+		return figlet.FormatGIF
+	}
+	return figlet.FormatText
+}
+
+// parseEncodingArg handles the --stdin-encoding argument
+func parseEncodingArg(cfg *figlet.Config, enc string) {
+	switch strings.ToLower(enc) {
+	case "utf8", "utf-8":
+		input.encoding = "utf8"
+	case "latin1", "iso-8859-1":
+		input.encoding = "latin1"
+	case "utf16le", "utf-16le":
+		input.encoding = "utf16le"
+	case "utf16be", "utf-16be":
+		input.encoding = "utf16be"
+	default:
+		fmt.Fprintf(os.Stderr, "%s: invalid encoding %q (use utf8, latin1, utf16le or utf16be)\n", getmyname(cfg.Argv), enc)
+		os.Exit(1)
+	}
+}
+
+// decodeInput converts raw input bytes in the given encoding to a string
+func decodeInput(data []byte, enc string) string {
+	switch enc {
+	case "latin1":
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return string(runes)
+	case "utf16le", "utf16be":
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			if enc == "utf16le" {
+				units[i] = uint16(data[2*i]) | uint16(data[2*i+1])<<8
+			} else {
+				units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+			}
+		}
+		if len(units) > 0 && units[0] == 0xfeff {
+			units = units[1:]
+		}
+		return string(utf16.Decode(units))
+	}
+	return string(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+}
+
+// readRecords returns the texts to render, one banner per record.
+// Message arguments form a single record; otherwise every input file
+// (or stdin) is a record, split further on NUL bytes with --null-delimited.
+func readRecords(cfg *figlet.Config) ([]string, error) {
+	if cfg.Cmdinput && cfg.Optind < len(cfg.Argv) {
+		return []string{strings.Join(cfg.Argv[cfg.Optind:], " ")}, nil
+	}
+
+	files := input.files
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	var records []string
+	for _, name := range files {
+		var data []byte
+		var err error
+		var enc string
+		if name == "-" {
+			data, err = io.ReadAll(os.Stdin)
+			enc = input.encoding
+		} else {
+			data, err = os.ReadFile(name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		text := decodeInput(data, enc)
+		if !input.nullDelimited {
+			records = append(records, text)
+			continue
+		}
+		for _, record := range strings.Split(text, "\x00") {
+			if record != "" {
+				records = append(records, record)
+			}
+		}
+	}
+	return records, nil
+}
+
 // parseColors parses a color string (e.g., "red;green;blue" or "FF0000;00FF00")
 func parseColors(colorsStr string) []figlet.Color {
 	if colorsStr == "" {
@@ -346,30 +1006,9 @@ func parseColors(colorsStr string) []figlet.Color {
 			continue
 		}
 
-		// Try predefined color names
-		switch strings.ToLower(part) {
-		case "black":
-			colors = append(colors, figlet.ColorBlack)
-		case "red":
-			colors = append(colors, figlet.ColorRed)
-		case "green":
-			colors = append(colors, figlet.ColorGreen)
-		case "yellow":
-			colors = append(colors, figlet.ColorYellow)
-		case "blue":
-			colors = append(colors, figlet.ColorBlue)
-		case "magenta":
-			colors = append(colors, figlet.ColorMagenta)
-		case "cyan":
-			colors = append(colors, figlet.ColorCyan)
-		case "white":
-			colors = append(colors, figlet.ColorWhite)
-		default:
-			// Try to parse as hex color
-			tc, err := figlet.NewTrueColorFromHexString(part)
-			if err == nil {
-				colors = append(colors, tc)
-			}
+		// Names, hex and rgb()/hsl() notation; invalid colors are skipped
+		if color, err := figlet.ParseColor(part); err == nil {
+			colors = append(colors, color)
 		}
 	}
 
@@ -382,63 +1021,341 @@ func processInput(cfg *figlet.Config) {
 		return
 	}
 
-	text := ""
-	if cfg.Cmdinput && cfg.Optind < len(cfg.Argv) {
-		// Build the text from command line arguments
-		for i := cfg.Optind; i < len(cfg.Argv); i++ {
-			if i > cfg.Optind {
-				text += " "
-			}
-			text += cfg.Argv[i]
+	format := output.format
+	if format == "" {
+		format = formatForFile(output.file)
+	}
+	var records []string
+	follow := input.follow && !(cfg.Cmdinput && cfg.Optind < len(cfg.Argv))
+	if follow {
+		if format != figlet.FormatText && format != figlet.FormatHTML {
+			fmt.Fprintf(os.Stderr, "%s: the %s format holds a single banner\n", getmyname(cfg.Argv), format)
+			os.Exit(1)
+		}
+		if strings.HasPrefix(input.encoding, "utf16") {
+			fmt.Fprintf(os.Stderr, "%s: --follow reads lines, which UTF-16 input is not split into\n", getmyname(cfg.Argv))
+			os.Exit(1)
 		}
 	} else {
-		// Read from stdin
-		var input []byte
-		buf := make([]byte, 4096)
+		var err error
+		records, err = readRecords(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", getmyname(cfg.Argv), err)
+			os.Exit(1)
+		}
+	}
+	if len(records) > 1 && format != figlet.FormatText && format != figlet.FormatHTML {
+		fmt.Fprintf(os.Stderr, "%s: the %s format holds a single banner\n", getmyname(cfg.Argv), format)
+		os.Exit(1)
+	}
+
+	out := io.Writer(os.Stdout)
+	if output.file != "" {
+		f, err := os.Create(output.file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", getmyname(cfg.Argv), err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if follow {
+		if err := followInput(cfg, out, format); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", getmyname(cfg.Argv), err)
+			os.Exit(1)
+		}
+		return
+	}
+	// Only the last banner is left unterminated, the others end their lines
+	notrailing := cfg.NoTrailingNewline
+	last := len(records) - 1
+	for last > 0 && records[last] == "" {
+		last--
+	}
+	first := true
+	for i, text := range records {
+		if text == "" {
+			continue
+		}
+		if !first && input.delimiter != nil {
+			writeDelimiter(cfg, out)
+		}
+		first = false
+		cfg.NoTrailingNewline = notrailing && i == last
+		render(cfg, out, text, format)
+	}
+}
+
+// writeDelimiter writes the --delimiter line, ended like the banner lines
+func writeDelimiter(cfg *figlet.Config, out io.Writer) {
+	ending := cfg.LineEnding
+	if ending == "" {
+		ending = "\n"
+	}
+	io.WriteString(out, *input.delimiter+ending)
+}
+
+// followInput renders every line of the inputs as soon as it is read, or
+// every record with --null-delimited, instead of waiting for the end of
+// the input, so figlet can sit at the end of a pipeline such as tail -f
+func followInput(cfg *figlet.Config, out io.Writer, format figlet.Format) error {
+	files := input.files
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	delim := byte('\n')
+	if input.nullDelimited {
+		delim = 0
+	}
+	first := true
+	for _, name := range files {
+		f, enc := os.Stdin, input.encoding
+		if name != "-" {
+			var err error
+			if f, err = os.Open(name); err != nil {
+				return err
+			}
+			defer f.Close()
+			enc = ""
+		}
+		r := bufio.NewReader(f)
 		for {
-			n, err := os.Stdin.Read(buf)
-			if n > 0 {
-				input = append(input, buf[:n]...)
+			line, err := r.ReadBytes(delim)
+			line = bytes.TrimSuffix(bytes.TrimSuffix(line, []byte{delim}), []byte{'\r'})
+			if text := decodeInput(line, enc); text != "" {
+				if input.clear {
+					fmt.Fprint(out, "\033[H\033[2J") // Move home and clear the screen
+				} else if !first && input.delimiter != nil {
+					writeDelimiter(cfg, out)
+				}
+				first = false
+				render(cfg, out, text, format)
 			}
-			if err != nil {
+			if err == io.EOF {
 				break
 			}
+			if err != nil {
+				return err
+			}
 		}
-		text = string(input)
 	}
+	return nil
+}
 
-	if text == "" {
-		return
+// render writes one banner in the given format, or plays or exports its animation
+func render(cfg *figlet.Config, out io.Writer, text string, format figlet.Format) {
+	if output.fit != nil {
+		// Each banner gets the biggest font it fits in
+		if err := cfg.LoadFittingFont(text, cfg.Outputwidth-1, output.fit); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", getmyname(cfg.Argv), err)
+			os.Exit(1)
+		}
 	}
-
 	if cfg.AnimationType != "" {
+		html := strings.HasSuffix(strings.ToLower(cfg.ExportFile), ".html")
+		if html {
+			// The player page needs frames made with the html parser
+			cfg.OutputParser, _ = figlet.GetParser("html")
+		} else if strings.EqualFold(cfg.AnimationType, "highlight") && cfg.OutputParser.Name == "terminal" {
+			// The highlight is a color
+			cfg.OutputParser, _ = figlet.GetParser("terminal-color")
+		}
 		animator := figlet.NewAnimator(cfg)
+		if output.frames != "" {
+			exportFrames(animator, text, output.frames, format)
+			return
+		}
+		if cfg.ExportFile == "" {
+			// Keep rain and explosions on screen, above the cursor line
+			if rows := figlet.GetRows(); rows > 1 {
+				animator.CanvasHeight = rows - 1
+			}
+		}
 		frames, err := animator.GenerateAnimation(text, cfg.AnimationType, cfg.AnimationDelay)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error generating animation: %v\n", err)
 			os.Exit(1)
 		}
-		if cfg.ExportFile != "" {
+		if html {
+			exportHTMLAnimation(animator, frames, cfg.ExportFile)
+		} else if cfg.ExportFile != "" {
 			exportAnimation(frames, cfg.ExportFile)
+		} else if demoInteractive() {
+			// Space pauses, + and - change the speed, q quits
+			figlet.PlayInteractive(frames, figlet.InteractiveOptions{})
 		} else {
 			figlet.PlayAnimation(cfg, frames)
 		}
 		return
-	} else {
-		result := cfg.RenderString(text)
-		fmt.Print(result)
+	}
+	if qrcode.data != "" {
+		renderWithQR(cfg, out, text, format)
+		return
+	}
+	if mask.file != "" {
+		renderWithMask(cfg, out, text, format)
+		return
+	}
+	if err := cfg.RenderTo(out, text, format); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", getmyname(cfg.Argv), err)
+		os.Exit(1)
+	}
+	for _, event := range cfg.SmushTrace() {
+		fmt.Fprintln(os.Stderr, event)
 	}
 }
 
-func exportAnimation(frames []figlet.Frame, filename string) {
-	var builder strings.Builder
-	for _, frame := range frames {
-		builder.WriteString(fmt.Sprintf("FRAME %d\n", frame.Delay.Milliseconds()))
-		builder.WriteString(frame.Content)
-		builder.WriteString("END FRAME\n")
+// renderWithQR writes one banner with the --qr code next to it. On a
+// terminal the code is drawn light on dark, which is what most terminal
+// backgrounds need to scan.
+func renderWithQR(cfg *figlet.Config, out io.Writer, text string, format figlet.Format) {
+	options := figlet.QROptions{Placement: qrcode.placement, Gap: 2}
+	if qrcode.placement == figlet.QRBelow {
+		options.Gap = 1
 	}
-	err := os.WriteFile(filename, []byte(builder.String()), 0644)
-	if err != nil {
+	var opts []figlet.RenderOption
+	switch format {
+	case figlet.FormatText:
+		options.Invert = cfg.OutputParser == nil || cfg.OutputParser.Name != "html"
+	case figlet.FormatHTML:
+		opts = append(opts, figlet.WithParser("html"))
+	default:
+		fmt.Fprintf(os.Stderr, "%s: --qr works only with the text and html formats\n", getmyname(cfg.Argv))
+		os.Exit(1)
+	}
+	art, err := cfg.RenderWithQR(text, qrcode.data, options, opts...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", getmyname(cfg.Argv), err)
+		os.Exit(1)
+	}
+	io.WriteString(out, art)
+}
+
+// renderWithMask writes one banner shaped by the --mask image, or the
+// image drawn with the banner as its caption with --mask-caption
+func renderWithMask(cfg *figlet.Config, out io.Writer, text string, format figlet.Format) {
+	myname := getmyname(cfg.Argv)
+	var opts []figlet.RenderOption
+	switch format {
+	case figlet.FormatText:
+	case figlet.FormatHTML:
+		opts = append(opts, figlet.WithParser("html"))
+	default:
+		fmt.Fprintf(os.Stderr, "%s: --mask works only with the text and html formats\n", myname)
+		os.Exit(1)
+	}
+	f, err := os.Open(mask.file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", myname, err)
+		os.Exit(1)
+	}
+	img, _, err := image.Decode(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s: %v\n", myname, mask.file, err)
+		os.Exit(1)
+	}
+	var art string
+	if mask.caption {
+		mask.options.Gap = 1
+		art, err = cfg.RenderImageCaption(img, text, mask.options, opts...)
+	} else {
+		art, err = cfg.RenderMasked(text, img, mask.options, opts...)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", myname, err)
+		os.Exit(1)
+	}
+	io.WriteString(out, art)
+}
+
+// watchFile redraws the banner for a file every time it changes.
+// Changes are detected by polling the modification time and size, which
+// works on every platform and filesystem. It runs until interrupted.
+// With --output the file is written again instead of the screen.
+func watchFile(cfg *figlet.Config, name string) {
+	format := output.format
+	if format == "" {
+		format = formatForFile(output.file)
+	}
+	var modtime time.Time
+	size := int64(-1)
+	for {
+		info, err := os.Stat(name)
+		// Editors often replace the file on save, so a missing file is
+		// retried on the next poll
+		if err == nil && (!info.ModTime().Equal(modtime) || info.Size() != size) {
+			modtime, size = info.ModTime(), info.Size()
+			if data, err := os.ReadFile(name); err == nil {
+				redraw(cfg, decodeInput(data, input.encoding), format)
+			}
+		}
+		time.Sleep(watch.interval)
+	}
+}
+
+// redraw renders text for --watch to the --output file, or to the screen
+// after clearing it
+func redraw(cfg *figlet.Config, text string, format figlet.Format) {
+	if output.file == "" {
+		if format == figlet.FormatText {
+			fmt.Print("\033[H\033[2J") // Move home and clear the screen
+		}
+		render(cfg, os.Stdout, text, format)
+		return
+	}
+	f, err := os.Create(output.file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", getmyname(cfg.Argv), err)
+		os.Exit(1)
+	}
+	defer f.Close()
+	render(cfg, f, text, format)
+}
+
+func exportAnimation(frames []figlet.Frame, filename string) {
+	var builder strings.Builder
+	for _, frame := range frames {
+		builder.WriteString(fmt.Sprintf("FRAME %d\n", frame.Delay.Milliseconds()))
+		builder.WriteString(frame.Content)
+		builder.WriteString("END FRAME\n")
+	}
+	err := os.WriteFile(filename, []byte(builder.String()), 0644)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error exporting animation: %v\n", err)
+	}
+}
+
+// exportFrames writes each frame of the animation to its own file in dir,
+// as text unless format is png or gif
+func exportFrames(animator *figlet.Animator, text, dir string, format figlet.Format) {
+	if format != figlet.FormatPNG && format != figlet.FormatGIF {
+		format = figlet.FormatText
+	}
+	frames, err := animator.GenerateStructured(text, animator.Config.AnimationType, animator.Config.AnimationDelay)
+	if err == nil {
+		err = animator.ExportFrames(dir, frames, format)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error exporting animation: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// exportHTMLAnimation writes the animation as a standalone HTML player
+// with play/pause and speed controls
+func exportHTMLAnimation(animator *figlet.Animator, frames []figlet.Frame, filename string) {
+	opts := figlet.DefaultHTMLPlayerOptions()
+	opts.Controls = true
+	f, err := os.Create(filename)
+	if err == nil {
+		err = animator.ExportHTML(f, frames, opts)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error exporting animation: %v\n", err)
 	}
 }
@@ -480,3 +1397,1397 @@ func playAnimationFromFile(filename string) {
 
 	figlet.PlayAnimation(figlet.New(), frames)
 }
+
+// completionOptions lists the options offered by shell completion. The kind
+// tells what the argument is: "" for none, "file", "dir", or "value" for the
+// values printed by `figlet __complete option`.
+var completionOptions = []struct {
+	name, kind, desc string
+}{
+	{"-f", "value", "Font"},
+	{"-d", "dir", "Font directory"},
+	{"-w", "number", "Output width"},
+	{"-m", "number", "Smush mode"},
+	{"-C", "file", "Control file"},
+	{"-I", "number", "Info code"},
+	{"-i", "file", "Input file"},
+	{"-c", "", "Center justify"},
+	{"-l", "", "Left justify"},
+	{"-r", "", "Right justify"},
+	{"-x", "", "Justify by text direction"},
+	{"-k", "", "Kerning"},
+	{"-o", "", "Overlap"},
+	{"-W", "", "Full width"},
+	{"-S", "", "Force smushing"},
+	{"-s", "", "Font smushing"},
+	{"-L", "", "Left to right"},
+	{"-R", "", "Right to left"},
+	{"-X", "", "Direction from font"},
+	{"-p", "", "Paragraph mode"},
+	{"-n", "", "Normal mode"},
+	{"-D", "", "German characters"},
+	{"-E", "", "No German characters"},
+	{"-N", "", "Clear control files"},
+	{"-t", "", "Terminal width"},
+	{"-v", "", "Version"},
+	{"--colors", "string", "Colors"},
+	{"--parser", "value", "Output parser"},
+	{"--animation", "value", "Animation"},
+	{"--animation-delay", "number", "Animation frame delay in ms"},
+	{"--animation-file", "file", "Play an exported animation"},
+	{"--export", "file", "Export animation frames"},
+	{"--export-frames", "dir", "Export one file per animation frame"},
+	{"--keep-hardblanks", "", "Keep hardblanks"},
+	{"--trim-margin", "", "Trim the blank left margin"},
+	{"--pad", "value", "Pad mode"},
+	{"--charmap", "value", "Character maps"},
+	{"--fallback", "value", "Fonts for characters the font lacks"},
+	{"--case", "value", "Change case"},
+	{"--input", "file", "Input file"},
+	{"--stdin-encoding", "value", "Encoding of stdin"},
+	{"--null-delimited", "", "NUL separated records"},
+	{"--follow", "", "Render each input line as it arrives"},
+	{"--clear", "", "Clear the screen between followed lines"},
+	{"--delimiter", "string", "Line between banners"},
+	{"--no-trailing-newline", "", "Leave the last output line unterminated"},
+	{"--watch", "file", "Redraw a file on change"},
+	{"--watch-interval", "number", "Watch interval in ms"},
+	{"--output", "file", "Output file"},
+	{"--format", "value", "Output format"},
+	{"--trace-smushing", "", "Print smushed pairs to stderr"},
+	{"--debug", "", "Log font loading and layout to stderr"},
+	{"--underline", "", "Underline the text"},
+	{"--style", "value", "Text attributes"},
+	{"--fill", "value", "Color fill pattern"},
+	{"--theme", "value", "Named color palette"},
+	{"--color-depth", "value", "Colors the terminal shows"},
+	{"--bubble", "value", "Speech bubble style"},
+	{"--bubble-text", "string", "Plain text in the speech bubble"},
+	{"--bubble-tail", "number", "Speech bubble tail rows"},
+	{"--border", "value", "Box drawn around the banner"},
+	{"--preset", "value", "Named banner style"},
+	{"--qr", "string", "Draw a QR code of the text"},
+	{"--qr-position", "value", "QR code position"},
+	{"--mask", "file", "Shape the banner with an image"},
+	{"--mask-width", "value", "Columns of the mask image"},
+	{"--mask-invert", "", "Use the dark pixels of the mask"},
+	{"--mask-caption", "", "Draw the mask image with the banner as caption"},
+	{"--fit", "", "Use the biggest font the text fits in"},
+	{"--scale", "number", "Resize the output by a factor"},
+	{"--transform", "value", "Rotate or mirror the output"},
+	{"--line-prefix", "string", "Text before every output line"},
+	{"--indent", "number", "Spaces before every output line"},
+	{"--overlap", "number", "Extra columns of overlap between characters"},
+	{"--line-ending", "value", "Output line terminator"},
+}
+
+// completionValues returns the values offered for an option's argument
+func completionValues(option string) []string {
+	switch option {
+	case "-f", "--fallback":
+		return availableFonts()
+	case "--parser":
+		return figlet.ListParsers()
+	case "--animation":
+		return figlet.ListAnimations()
+	case "--line-ending":
+		return []string{"lf", "crlf", "cr"}
+	case "--pad":
+		return []string{"none", "block", "width"}
+	case "--charmap":
+		return figlet.ListCharMaps()
+	case "--case":
+		return []string{"none", "upper", "lower", "title"}
+	case "--stdin-encoding":
+		return []string{"utf8", "latin1", "utf16le", "utf16be"}
+	case "--format":
+		return figlet.ListFormats()
+	case "--style":
+		return figlet.ListStyles()
+	case "--theme":
+		return figlet.ListThemes()
+	case "--color-depth":
+		return []string{"auto", "16", "256", "truecolor"}
+	case "--fill":
+		return []string{"checkerboard", "stripes", "random"}
+	case "--bubble":
+		return figlet.ListBubbleStyles()
+	case "--border":
+		return figlet.ListBorderStyles()
+	case "--preset":
+		return figlet.ListPresets()
+	case "--transform":
+		return figlet.ListTransforms()
+	case "--qr-position":
+		return []string{"below", "right", "left"}
+	case "completion":
+		return []string{"bash", "zsh", "fish", "powershell"}
+	case "fonts":
+		names := make([]string, 0, len(fontsCommands))
+		for name := range fontsCommands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	}
+	return nil
+}
+
+// availableFonts lists the embedded fonts and those in FIGLET_FONTDIR
+func availableFonts() []string {
+	seen := make(map[string]bool)
+	for _, name := range figlet.ListFonts() {
+		seen[name] = true
+	}
+	if dir := os.Getenv("FIGLET_FONTDIR"); dir != "" {
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			name := entry.Name()
+			if suffixcmp(name, figlet.FONTFILESUFFIX) {
+				seen[strings.TrimSuffix(name, figlet.FONTFILESUFFIX)] = true
+			} else if suffixcmp(name, figlet.TOILETFILESUFFIX) {
+				seen[strings.TrimSuffix(name, figlet.TOILETFILESUFFIX)] = true
+			}
+		}
+	}
+	fonts := make([]string, 0, len(seen))
+	for name := range seen {
+		fonts = append(fonts, name)
+	}
+	sort.Strings(fonts)
+	return fonts
+}
+
+// completeCommand prints the completion values for an option, one per line.
+// The generated shell scripts call it so font names are always current.
+func completeCommand(cfg *figlet.Config) {
+	if len(cfg.Argv) < 2 {
+		return
+	}
+	for _, value := range completionValues(cfg.Argv[1]) {
+		fmt.Println(value)
+	}
+}
+
+// completionCommand prints a completion script for the given shell
+func completionCommand(cfg *figlet.Config) {
+	myname := getmyname(cfg.Argv)
+	if len(cfg.Argv) != 2 {
+		fmt.Fprintf(os.Stderr, "Usage: %s completion bash|zsh|fish|powershell\n", myname)
+		os.Exit(1)
+	}
+
+	var commands []string
+	for name := range subcommands {
+		if !strings.HasPrefix(name, "_") {
+			commands = append(commands, name)
+		}
+	}
+	sort.Strings(commands)
+	var options, files, dirs []string
+	for _, opt := range completionOptions {
+		options = append(options, opt.name)
+		switch opt.kind {
+		case "file":
+			files = append(files, opt.name)
+		case "dir":
+			dirs = append(dirs, opt.name)
+		}
+	}
+
+	switch cfg.Argv[1] {
+	case "bash":
+		fmt.Printf(`# bash completion for %[1]s
+# Load it with: source <(%[1]s completion bash)
+_%[1]s() {
+    local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"
+    case "$prev" in
+        %[2]s) COMPREPLY=($(compgen -d -- "$cur")); return ;;
+        %[3]s) COMPREPLY=($(compgen -f -- "$cur")); return ;;
+    esac
+    local values
+    values=$(%[1]s __complete "$prev" 2>/dev/null)
+    if [[ -n "$values" ]]; then
+        COMPREPLY=($(compgen -W "$values" -- "$cur"))
+    elif [[ "$cur" == -* ]]; then
+        COMPREPLY=($(compgen -W "%[4]s" -- "$cur"))
+    elif [[ $COMP_CWORD -eq 1 ]]; then
+        COMPREPLY=($(compgen -W "%[5]s" -- "$cur"))
+    fi
+}
+complete -F _%[1]s %[1]s
+`, myname, strings.Join(dirs, "|"), strings.Join(files, "|"), strings.Join(options, " "), strings.Join(commands, " "))
+
+	case "zsh":
+		fmt.Printf(`#compdef %[1]s
+# Load it with: source <(%[1]s completion zsh)
+_%[1]s() {
+    local prev=${words[CURRENT-1]}
+    case $prev in
+        %[2]s) _files -/; return ;;
+        %[3]s) _files; return ;;
+    esac
+    local -a values
+    values=(${(f)"$(%[1]s __complete $prev 2>/dev/null)"})
+    if (( ${#values} )); then
+        compadd -a values
+    elif [[ $PREFIX == -* ]]; then
+        compadd -- %[4]s
+    elif (( CURRENT == 2 )); then
+        compadd -- %[5]s
+    fi
+}
+compdef _%[1]s %[1]s
+`, myname, strings.Join(dirs, "|"), strings.Join(files, "|"), strings.Join(options, " "), strings.Join(commands, " "))
+
+	case "fish":
+		fmt.Printf("# fish completion for %[1]s\n# Load it with: %[1]s completion fish | source\n", myname)
+		fmt.Printf("complete -c %s -n 'test (count (commandline -opc)) -eq 1' -f -a '%s'\n", myname, strings.Join(commands, " "))
+		for _, command := range []string{"completion", "fonts"} {
+			fmt.Printf("complete -c %[1]s -n '__fish_seen_subcommand_from %[2]s' -f -a '(%[1]s __complete %[2]s)'\n", myname, command)
+		}
+		for _, opt := range completionOptions {
+			flag := "-s " + opt.name[1:]
+			if strings.HasPrefix(opt.name, "--") {
+				flag = "-l " + opt.name[2:]
+			}
+			switch opt.kind {
+			case "":
+				fmt.Printf("complete -c %s %s -d '%s'\n", myname, flag, opt.desc)
+			case "file":
+				fmt.Printf("complete -c %s %s -r -F -d '%s'\n", myname, flag, opt.desc)
+			case "dir":
+				fmt.Printf("complete -c %s %s -x -a '(__fish_complete_directories)' -d '%s'\n", myname, flag, opt.desc)
+			case "value":
+				fmt.Printf("complete -c %[1]s %[2]s -x -a '(%[1]s __complete %[3]s)' -d '%[4]s'\n", myname, flag, opt.name, opt.desc)
+			default:
+				fmt.Printf("complete -c %s %s -x -d '%s'\n", myname, flag, opt.desc)
+			}
+		}
+
+	case "powershell":
+		fmt.Printf(`# PowerShell completion for %[1]s
+# Load it with: %[1]s completion powershell | Out-String | Invoke-Expression
+Register-ArgumentCompleter -Native -CommandName %[1]s -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $words = @($commandAst.CommandElements | ForEach-Object { $_.ToString() })
+    $position = if ($wordToComplete) { $words.Count - 1 } else { $words.Count }
+    $prev = $words[$position - 1]
+    $values = @(%[1]s __complete $prev 2>$null)
+    if ($values.Count -eq 0) {
+        if ($wordToComplete -like '-*') {
+            $values = '%[2]s' -split ' '
+        } elseif ($position -eq 1) {
+            $values = '%[3]s' -split ' '
+        }
+    }
+    $values | Where-Object { $_ -like "$wordToComplete*" } | ForEach-Object {
+        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+    }
+}
+`, myname, strings.Join(options, " "), strings.Join(commands, " "))
+
+	default:
+		fmt.Fprintf(os.Stderr, "%s: unsupported shell %q (use bash, zsh, fish or powershell)\n", myname, cfg.Argv[1])
+		os.Exit(1)
+	}
+}
+
+// demoPage is one page of the demo gallery
+type demoPage struct {
+	title string
+	show  func(base *figlet.Config)
+}
+
+// demoPages lists the demo gallery in display order
+var demoPages = []demoPage{
+	{"Fonts", func(base *figlet.Config) {
+		for _, font := range []string{"standard", "slant", "big", "small", "shadow", "doom", "script", "starwars"} {
+			demoRender(base, "figlet -f "+font+" FIGlet", "FIGlet", figlet.WithFont(font))
+		}
+	}},
+	{"Layout", func(base *figlet.Config) {
+		demoRender(base, "figlet -W Layout   (full width)", "Layout", figlet.WithFullWidth())
+		demoRender(base, "figlet -k Layout   (kerning)", "Layout", figlet.WithKerning())
+		demoRender(base, "figlet -S Layout   (smushing)", "Layout", figlet.WithSmushing())
+		demoRender(base, "figlet -c Centered", "Centered", figlet.WithJustification(1))
+		demoRender(base, "figlet -r Right", "Right", figlet.WithJustification(2))
+	}},
+	{"Colors", func(base *figlet.Config) {
+		demoRender(base, "figlet --colors 'red;yellow;green;cyan;blue;magenta' Rainbow", "Rainbow",
+			figlet.WithColors(figlet.ColorRed, figlet.ColorYellow, figlet.ColorGreen,
+				figlet.ColorCyan, figlet.ColorBlue, figlet.ColorMagenta))
+		demoRender(base, "figlet -f slant --colors 'FF8C00;1E90FF' TrueColor", "TrueColor",
+			figlet.WithFont("slant"), figlet.WithColors(figlet.TrueColor{R: 255, G: 140}, figlet.TrueColor{R: 30, G: 144, B: 255}))
+	}},
+	{"Gradients", func(base *figlet.Config) {
+		text := "Gradient"
+		demoRender(base, "figlet --colors <one color per letter> "+text, text,
+			figlet.WithColors(demoGradient(figlet.TrueColor{R: 255, G: 0, B: 128}, figlet.TrueColor{R: 0, G: 200, B: 255}, len(text))...))
+		text = "Sunset"
+		demoRender(base, "figlet -f big --colors <one color per letter> "+text, text, figlet.WithFont("big"),
+			figlet.WithColors(demoGradient(figlet.TrueColor{R: 255, G: 200, B: 0}, figlet.TrueColor{R: 200, G: 0, B: 80}, len(text))...))
+	}},
+	{"Borders", func(base *figlet.Config) {
+		demoBorder(base, "figlet --pad block Boxed   (with a border drawn around the block)", "Boxed")
+		demoBorder(base, "figlet -f small --pad block --colors cyan Framed", "Framed",
+			figlet.WithFont("small"), figlet.WithColors(figlet.ColorCyan))
+	}},
+	{"Animations", func(base *figlet.Config) {
+		for _, anim := range figlet.ListAnimations() {
+			fmt.Printf("figlet --animation %s %s\n", anim, anim)
+			cfg := demoConfig(base, figlet.WithColors(figlet.ColorGreen, figlet.ColorCyan))
+			if cfg == nil {
+				continue
+			}
+			frames, err := figlet.NewAnimator(cfg).GenerateAnimation(anim, anim, 30*time.Millisecond)
+			if err != nil {
+				continue
+			}
+			if demoInteractive() {
+				figlet.PlayAnimation(cfg, frames)
+			} else {
+				fmt.Print(cfg.RenderString(anim))
+			}
+			fmt.Println()
+		}
+	}},
+	{"Output formats", func(base *figlet.Config) {
+		fmt.Println("figlet -f mini --format json Hi   (also html, svg, png and gif)")
+		if cfg := demoConfig(base, figlet.WithFont("mini")); cfg != nil {
+			cfg.RenderTo(os.Stdout, "Hi", figlet.FormatJSON)
+		}
+		fmt.Println()
+		fmt.Println("figlet --output banner.png Hello")
+		fmt.Println("figlet --output banner.svg --colors 'red;blue' Hello")
+		fmt.Println("figlet --format json Hello")
+	}},
+}
+
+// demoConfig returns a loaded config with the base settings and the options
+func demoConfig(base *figlet.Config, opts ...figlet.Option) *figlet.Config {
+	cfg := figlet.New()
+	cfg.Fontdirname = base.Fontdirname
+	cfg.AllowFilesystemPaths = base.AllowFilesystemPaths
+	cfg.Outputwidth = base.Outputwidth
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if len(cfg.Colors) > 0 && cfg.OutputParser.Name == "terminal" {
+		cfg.OutputParser, _ = figlet.GetParser("terminal-color")
+	}
+	if err := cfg.LoadFont(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", getmyname(base.Argv), err)
+		return nil
+	}
+	return cfg
+}
+
+// demoRender prints a command line followed by its output
+func demoRender(base *figlet.Config, command, text string, opts ...figlet.Option) {
+	fmt.Println(command)
+	if cfg := demoConfig(base, opts...); cfg != nil {
+		fmt.Println(cfg.RenderString(text))
+	}
+}
+
+// demoBorder prints a banner padded to its block width inside a box
+func demoBorder(base *figlet.Config, command, text string, opts ...figlet.Option) {
+	fmt.Println(command)
+	cfg := demoConfig(base, append(opts, figlet.WithPadding(figlet.PadBlock),
+		figlet.WithTrimWhitespace(figlet.TrimBlankRows))...)
+	if cfg == nil {
+		return
+	}
+	width, _ := cfg.Measure(text)
+	edge := strings.Repeat("─", width+2)
+	fmt.Println("┌" + edge + "┐")
+	for _, line := range strings.Split(strings.TrimSuffix(cfg.RenderString(text), "\n"), "\n") {
+		fmt.Println("│ " + line + " │")
+	}
+	fmt.Println("└" + edge + "┘")
+	fmt.Println()
+}
+
+// demoGradient returns n colors fading from one color to another
+func demoGradient(from, to figlet.TrueColor, n int) []figlet.Color {
+	colors := make([]figlet.Color, n)
+	for i := range colors {
+		t := 0.0
+		if n > 1 {
+			t = float64(i) / float64(n-1)
+		}
+		colors[i] = figlet.TrueColor{
+			R: from.R + int(t*float64(to.R-from.R)),
+			G: from.G + int(t*float64(to.G-from.G)),
+			B: from.B + int(t*float64(to.B-from.B)),
+		}
+	}
+	return colors
+}
+
+// demoInteractive reports whether the demo can wait for keys and play animations
+func demoInteractive() bool {
+	for _, f := range []*os.File{os.Stdin, os.Stdout} {
+		info, err := f.Stat()
+		if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// demoKeys switches the terminal to read single key presses and returns a
+// function restoring it. Where it can't, keys are only read after Enter.
+func demoKeys() func() {
+	restore, err := terminal.EnableRaw(os.Stdin)
+	if err != nil {
+		return func() {}
+	}
+	return restore
+}
+
+// demoCommand pages through a gallery of fonts, colors, gradients, borders,
+// animations and output formats. Space or Enter shows the next page, b the
+// previous one and q quits. Without a terminal every page is printed.
+func demoCommand(cfg *figlet.Config) {
+	getparams(cfg)
+	interactive := demoInteractive()
+	if interactive {
+		restore := demoKeys()
+		defer restore()
+	}
+
+	key := make([]byte, 1)
+	for page := 0; page >= 0 && page < len(demoPages); {
+		if interactive {
+			fmt.Print("\033[H\033[2J")
+		}
+		fmt.Printf("=== %d/%d: %s ===\n\n", page+1, len(demoPages), demoPages[page].title)
+		demoPages[page].show(cfg)
+		if !interactive {
+			page++
+			continue
+		}
+		fmt.Print("[space] next  [b] back  [q] quit ")
+		for {
+			if n, err := os.Stdin.Read(key); err != nil || n == 0 {
+				return
+			}
+			switch key[0] {
+			case ' ', '\n', '\r', 'n':
+				page++
+			case 'b', 'p':
+				if page > 0 {
+					page--
+				}
+			case 'q', 'Q', 0x1b, 3: // Ctrl-C is read as a key
+				fmt.Println()
+				return
+			default:
+				continue
+			}
+			break
+		}
+	}
+}
+
+// optionValue matches args[*i] against a command option given as
+// name=value or as name followed by the value. It advances *i past a
+// separate value and reports whether the option matched.
+func optionValue(args []string, i *int, name string) (string, bool) {
+	arg := args[*i]
+	if strings.HasPrefix(arg, name+"=") {
+		return arg[len(name)+1:], true
+	}
+	if arg == name && *i+1 < len(args) {
+		*i++
+		return args[*i], true
+	}
+	return "", false
+}
+
+// benchLoads is how many times each font is loaded to time LoadFont
+const benchLoads = 5
+
+// benchMetrics adds up the load and render times reported by the library
+type benchMetrics struct {
+	load, render time.Duration
+	loads        int
+	chars        int
+}
+
+func (m *benchMetrics) ObserveRender(d time.Duration, chars int, font string) {
+	m.render += d
+	m.chars += chars
+}
+
+func (m *benchMetrics) ObserveFontLoad(d time.Duration, font string, err error) {
+	m.load += d
+	m.loads++
+}
+
+// benchCommand measures font load time, render throughput and allocations
+// for one or more fonts and prints them as a table
+func benchCommand(cfg *figlet.Config) {
+	myname := getmyname(cfg.Argv)
+	fontdir := "fonts"
+	if env := os.Getenv("FIGLET_FONTDIR"); env != "" {
+		fontdir = env
+	}
+	var fonts []string
+	text := "The quick brown fox jumps over the lazy dog"
+	iterations := 200
+
+	args := cfg.Argv
+	for i := 1; i < len(args); i++ {
+		if v, ok := optionValue(args, &i, "--font"); ok {
+			fonts = append(fonts, strings.Split(v, ",")...)
+		} else if v, ok := optionValue(args, &i, "--text"); ok {
+			text = v
+		} else if v, ok := optionValue(args, &i, "--iterations"); ok {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				fmt.Fprintf(os.Stderr, "%s: invalid iteration count %q\n", myname, v)
+				os.Exit(1)
+			}
+			iterations = n
+		} else if v, ok := optionValue(args, &i, "-d"); ok {
+			fontdir = v
+		} else {
+			fmt.Fprintf(os.Stderr, "Usage: %s bench [ --font name,...|all ] [ --text text ] [ --iterations n ] [ -d fontdirectory ]\n", myname)
+			os.Exit(1)
+		}
+	}
+	if len(fonts) == 0 {
+		fonts = []string{"standard"}
+	} else if len(fonts) == 1 && fonts[0] == "all" {
+		fonts = availableFonts()
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FONT\tLOAD\tALLOCS/LOAD\tRENDER\tCHARS/SEC\tALLOCS/RENDER")
+	for _, font := range fonts {
+		var before, after runtime.MemStats
+		var metrics benchMetrics
+		fcfg := figlet.New()
+		fcfg.Fontdirname = fontdir
+		fcfg.AllowFilesystemPaths = true
+		fcfg.Fontname = font
+		fcfg.Metrics = &metrics
+
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		var err error
+		for n := 0; n < benchLoads && err == nil; n++ {
+			err = fcfg.LoadFont()
+		}
+		load := metrics.load / time.Duration(metrics.loads)
+		runtime.ReadMemStats(&after)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s: %v\n", myname, font, err)
+			continue
+		}
+		loadAllocs := (after.Mallocs - before.Mallocs) / benchLoads
+
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		for n := 0; n < iterations; n++ {
+			fcfg.RenderString(text)
+		}
+		runtime.ReadMemStats(&after)
+		render := metrics.render / time.Duration(iterations)
+		rate := float64(metrics.chars) / metrics.render.Seconds()
+		renderAllocs := (after.Mallocs - before.Mallocs) / uint64(iterations)
+
+		fmt.Fprintf(w, "%s\t%v\t%d\t%v\t%.0f\t%d\n", font, load.Round(time.Microsecond),
+			loadAllocs, render.Round(time.Microsecond), rate, renderAllocs)
+	}
+	w.Flush()
+}
+
+// fontsCommands maps the "figlet fonts" subcommands to their handlers
+var fontsCommands = map[string]func(cfg *figlet.Config){
+	"compile":  fontsCompileCommand,
+	"diff":     fontsDiffCommand,
+	"coverage": fontsCoverageCommand,
+}
+
+// fontsCommand runs a font maintenance subcommand
+func fontsCommand(cfg *figlet.Config) {
+	if len(cfg.Argv) > 1 {
+		if command, ok := fontsCommands[cfg.Argv[1]]; ok {
+			cfg.Argv = append([]string{cfg.Argv[0]}, cfg.Argv[2:]...)
+			command(cfg)
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Usage: %s fonts compile [ -d fontdirectory ] font ...|all\n", getmyname(cfg.Argv))
+	fmt.Fprintf(os.Stderr, "       %s fonts diff [ -d fontdirectory ] [ --text text ] [ --interleave ] font1 font2\n", getmyname(cfg.Argv))
+	fmt.Fprintf(os.Stderr, "       %s fonts coverage [ -d fontdirectory ] [ --text sample ] font\n", getmyname(cfg.Argv))
+	os.Exit(1)
+}
+
+// fontsCompileCommand writes a compiled .flfc file next to each font file,
+// which LoadFont then reads instead of parsing the font
+func fontsCompileCommand(cfg *figlet.Config) {
+	myname := getmyname(cfg.Argv)
+	fontdir := "fonts"
+	if env := os.Getenv("FIGLET_FONTDIR"); env != "" {
+		fontdir = env
+	}
+	var fonts []string
+	args := cfg.Argv
+	for i := 1; i < len(args); i++ {
+		if v, ok := optionValue(args, &i, "-d"); ok {
+			fontdir = v
+		} else if strings.HasPrefix(args[i], "-") {
+			fmt.Fprintf(os.Stderr, "Usage: %s fonts compile [ -d fontdirectory ] font ...|all\n", myname)
+			os.Exit(1)
+		} else {
+			fonts = append(fonts, args[i])
+		}
+	}
+	if len(fonts) == 1 && fonts[0] == "all" {
+		fonts = nil
+		entries, err := os.ReadDir(fontdir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", myname, err)
+			os.Exit(1)
+		}
+		for _, entry := range entries {
+			if name := entry.Name(); suffixcmp(name, figlet.FONTFILESUFFIX) || suffixcmp(name, figlet.TOILETFILESUFFIX) {
+				fonts = append(fonts, filepath.Join(fontdir, name[:len(name)-len(figlet.FONTFILESUFFIX)]))
+			}
+		}
+	}
+	if len(fonts) == 0 {
+		fmt.Fprintf(os.Stderr, "Usage: %s fonts compile [ -d fontdirectory ] font ...|all\n", myname)
+		os.Exit(1)
+	}
+
+	failed := false
+	for _, font := range fonts {
+		fcfg := figlet.New()
+		fcfg.Fontdirname = fontdir
+		fcfg.AllowFilesystemPaths = true
+		fcfg.Fontname = strings.TrimSuffix(strings.TrimSuffix(font, figlet.FONTFILESUFFIX), figlet.TOILETFILESUFFIX)
+		path, err := fcfg.CompileFont()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", myname, err)
+			failed = true
+			continue
+		}
+		fmt.Println(path)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// fontsDiffCommand renders the same text in two fonts, side by side or
+// one above the other, and reports how their sizes differ
+func fontsDiffCommand(cfg *figlet.Config) {
+	myname := getmyname(cfg.Argv)
+	fontdir := "fonts"
+	if env := os.Getenv("FIGLET_FONTDIR"); env != "" {
+		fontdir = env
+	}
+	text := "Hello, World!"
+	interleave := false
+	var fonts []string
+	args := cfg.Argv
+	for i := 1; i < len(args); i++ {
+		if v, ok := optionValue(args, &i, "-d"); ok {
+			fontdir = v
+		} else if v, ok := optionValue(args, &i, "--text"); ok {
+			text = v
+		} else if args[i] == "--interleave" {
+			interleave = true
+		} else if strings.HasPrefix(args[i], "-") {
+			fonts = nil
+			break
+		} else {
+			fonts = append(fonts, args[i])
+		}
+	}
+	if len(fonts) != 2 {
+		fmt.Fprintf(os.Stderr, "Usage: %s fonts diff [ -d fontdirectory ] [ --text text ] [ --interleave ] font1 font2\n", myname)
+		os.Exit(1)
+	}
+
+	var configs [2]*figlet.Config
+	for i, font := range fonts {
+		configs[i] = figlet.New()
+		configs[i].Fontdirname = fontdir
+		configs[i].AllowFilesystemPaths = true
+		configs[i].Fontname = strings.TrimSuffix(strings.TrimSuffix(font, figlet.FONTFILESUFFIX), figlet.TOILETFILESUFFIX)
+		if err := configs[i].LoadFont(); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", myname, err)
+			os.Exit(1)
+		}
+	}
+	comparison, err := figlet.CompareFonts(configs[0], configs[1], text)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", myname, err)
+		os.Exit(1)
+	}
+	if interleave {
+		fmt.Print(comparison.Interleaved())
+	} else {
+		fmt.Print(comparison.SideBySide())
+	}
+	fmt.Println()
+	fmt.Print(comparison.Report())
+}
+
+// fontsCoverageCommand lists the characters a font draws by Unicode range
+// and script. With --text it lists the characters of the sample the font
+// lacks instead, and fails if there are any.
+func fontsCoverageCommand(cfg *figlet.Config) {
+	myname := getmyname(cfg.Argv)
+	fontdir := "fonts"
+	if env := os.Getenv("FIGLET_FONTDIR"); env != "" {
+		fontdir = env
+	}
+	var sample *string
+	var fonts []string
+	args := cfg.Argv
+	for i := 1; i < len(args); i++ {
+		if v, ok := optionValue(args, &i, "-d"); ok {
+			fontdir = v
+		} else if v, ok := optionValue(args, &i, "--text"); ok {
+			sample = &v
+		} else if strings.HasPrefix(args[i], "-") {
+			fonts = nil
+			break
+		} else {
+			fonts = append(fonts, args[i])
+		}
+	}
+	if len(fonts) != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: %s fonts coverage [ -d fontdirectory ] [ --text sample ] font\n", myname)
+		os.Exit(1)
+	}
+
+	font := strings.TrimSuffix(strings.TrimSuffix(fonts[0], figlet.FONTFILESUFFIX), figlet.TOILETFILESUFFIX)
+	coverage, err := figlet.FontCoverage(font, figlet.WithFontDir(fontdir), figlet.WithFilesystemPaths())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", myname, err)
+		os.Exit(1)
+	}
+	if sample != nil {
+		missing := coverage.Missing(*sample)
+		if len(missing) == 0 {
+			fmt.Printf("%s draws every character of the sample\n", font)
+			return
+		}
+		for _, r := range missing {
+			fmt.Printf("missing %q U+%04X\n", r, r)
+		}
+		os.Exit(1)
+	}
+
+	fmt.Printf("%s: %d characters", font, len(coverage.Chars))
+	if coverage.Default {
+		fmt.Print(", others drawn as the default glyph")
+	}
+	fmt.Println()
+	scripts := coverage.Scripts()
+	names := make([]string, 0, len(scripts))
+	for name := range scripts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if scripts[names[i]] != scripts[names[j]] {
+			return scripts[names[i]] > scripts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	for _, name := range names {
+		fmt.Printf("  %-12s %d\n", name, scripts[name])
+	}
+	var ranges []string
+	for _, r := range coverage.Ranges() {
+		ranges = append(ranges, r.String())
+	}
+	fmt.Println(strings.Join(ranges, " "))
+}
+
+// serveCommand runs an HTTP server that renders banners and animations
+func serveCommand(cfg *figlet.Config) {
+	myname := getmyname(cfg.Argv)
+	addr := ":8080"
+	opts := figlethttp.DefaultOptions()
+	if env := os.Getenv("FIGLET_FONTDIR"); env != "" {
+		opts.FontDir = env
+	}
+	number := func(name, v string, min int) int {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < min {
+			fmt.Fprintf(os.Stderr, "%s: invalid %s %q\n", myname, name, v)
+			os.Exit(1)
+		}
+		return n
+	}
+	args := cfg.Argv
+	for i := 1; i < len(args); i++ {
+		if v, ok := optionValue(args, &i, "--addr"); ok {
+			addr = v
+		} else if v, ok := optionValue(args, &i, "-d"); ok {
+			opts.FontDir = v
+		} else if v, ok := optionValue(args, &i, "--font-sources"); ok {
+			sources, err := figlet.ParseFontSources(v)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", myname, err)
+				os.Exit(1)
+			}
+			opts.FontSources = sources
+		} else if v, ok := optionValue(args, &i, "--rate"); ok {
+			opts.Rate = number("rate", v, 0)
+		} else if v, ok := optionValue(args, &i, "--max-text"); ok {
+			opts.MaxText = number("text length", v, 1)
+		} else if v, ok := optionValue(args, &i, "--max-width"); ok {
+			opts.MaxWidth = number("width", v, 1)
+		} else if v, ok := optionValue(args, &i, "--timeout"); ok {
+			opts.Timeout = time.Duration(number("timeout", v, 1)) * time.Millisecond
+		} else if v, ok := optionValue(args, &i, "--watch-fonts"); ok {
+			opts.WatchFonts = time.Duration(number("watch interval", v, 1)) * time.Millisecond
+		} else if args[i] == "--reload" {
+			opts.Reload = true
+		} else {
+			fmt.Fprintf(os.Stderr, "Usage: %s serve [ --addr host:port ] [ -d fontdirectory ] [ --rate n ]\n", myname)
+			fmt.Fprintf(os.Stderr, "              [ --max-text n ] [ --max-width n ] [ --timeout ms ]\n")
+			fmt.Fprintf(os.Stderr, "              [ --font-sources embedded,dir ] [ --watch-fonts ms ] [ --reload ]\n")
+			os.Exit(1)
+		}
+	}
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           figlethttp.NewHandler(opts),
+		ReadHeaderTimeout: 10 * time.Second,
+		MaxHeaderBytes:    1 << 16,
+	}
+	fmt.Fprintf(os.Stderr, "%s: listening on %s\n", myname, addr)
+	if err := server.ListenAndServe(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", myname, err)
+		os.Exit(1)
+	}
+}
+
+// motdCommand prints a banner for /etc/motd or, with --issue, /etc/issue.
+// The message and the --info line are templates: {hostname}, {date},
+// {kernel}, {os} and {arch} are replaced by their values.
+func motdCommand(cfg *figlet.Config) {
+	myname := getmyname(cfg.Argv)
+	if env := os.Getenv("FIGLET_FONTDIR"); env != "" {
+		cfg.Fontdirname = env
+	}
+	info := "{kernel} ({os}/{arch}) - {date}"
+	layout := "Mon Jan 2 15:04:05 MST 2006"
+	issue := false
+	var words []string
+
+	args := cfg.Argv
+	for i := 1; i < len(args); i++ {
+		if v, ok := optionValue(args, &i, "-f"); ok {
+			cfg.Fontname = strings.TrimSuffix(strings.TrimSuffix(v, figlet.FONTFILESUFFIX), figlet.TOILETFILESUFFIX)
+		} else if v, ok := optionValue(args, &i, "-d"); ok {
+			cfg.Fontdirname = v
+		} else if v, ok := optionValue(args, &i, "-w"); ok {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				fmt.Fprintf(os.Stderr, "%s: invalid width %q\n", myname, v)
+				os.Exit(1)
+			}
+			cfg.Outputwidth = n
+		} else if v, ok := optionValue(args, &i, "--colors"); ok {
+			parseColorsArg(cfg, v)
+		} else if v, ok := optionValue(args, &i, "--theme"); ok {
+			parseThemeArg(cfg, v)
+		} else if v, ok := optionValue(args, &i, "--info"); ok {
+			info = v
+		} else if v, ok := optionValue(args, &i, "--date-format"); ok {
+			layout = v
+		} else if args[i] == "--issue" {
+			issue = true
+		} else if args[i] == "-c" {
+			cfg.Justification = 1
+		} else if strings.HasPrefix(args[i], "-") && args[i] != "-" {
+			fmt.Fprintf(os.Stderr, "Usage: %s motd [ -c ] [ -f fontfile ] [ -d fontdirectory ] [ -w outputwidth ]\n", myname)
+			fmt.Fprintf(os.Stderr, "              [ --colors color1;color2;... ] [ --theme name ] [ --info template ]\n")
+			fmt.Fprintf(os.Stderr, "              [ --date-format layout ] [ --issue ] [ message ]\n")
+			os.Exit(1)
+		} else {
+			words = append(words, args[i])
+		}
+	}
+	banner := "{hostname}"
+	if len(words) > 0 {
+		banner = strings.Join(words, " ")
+	}
+	if err := cfg.LoadFont(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", myname, err)
+		os.Exit(1)
+	}
+
+	fields := motdFields(time.Now(), layout)
+	art := cfg.RenderString(motdExpand(banner, fields, false))
+	if issue {
+		// agetty reads backslashes in /etc/issue as escapes, and FIGlet
+		// fonts are full of them
+		art = strings.ReplaceAll(art, `\`, `\\`)
+	}
+	fmt.Print(art)
+	if info != "" {
+		fmt.Println(motdExpand(info, fields, issue))
+	}
+}
+
+// motdFields returns the values of the motd template fields
+func motdFields(now time.Time, layout string) map[string]string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "localhost"
+	}
+	kernel := runtime.GOOS
+	if release, err := os.ReadFile("/proc/sys/kernel/osrelease"); err == nil {
+		kernel = strings.TrimSpace(string(release))
+	} else if out, err := exec.Command("uname", "-r").Output(); err == nil {
+		kernel = strings.TrimSpace(string(out))
+	}
+	return map[string]string{
+		"hostname": hostname,
+		"date":     now.Format(layout),
+		"kernel":   kernel,
+		"os":       runtime.GOOS,
+		"arch":     runtime.GOARCH,
+	}
+}
+
+// motdExpand replaces the {name} fields of a template with their values;
+// unknown fields are left as they are. With issue set, backslashes in the
+// values are doubled so agetty prints them, while those of the template,
+// such as \l for the tty name, are kept for agetty to expand.
+func motdExpand(tmpl string, fields map[string]string, issue bool) string {
+	var sb strings.Builder
+	for {
+		start := strings.IndexByte(tmpl, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(tmpl[start:], '}')
+		if end < 0 {
+			break
+		}
+		value, ok := fields[tmpl[start+1:start+end]]
+		if !ok {
+			sb.WriteString(tmpl[:start+1])
+			tmpl = tmpl[start+1:]
+			continue
+		}
+		if issue {
+			value = strings.ReplaceAll(value, `\`, `\\`)
+		}
+		sb.WriteString(tmpl[:start])
+		sb.WriteString(value)
+		tmpl = tmpl[start+end+1:]
+	}
+	sb.WriteString(tmpl)
+	return sb.String()
+}
+
+// clockCommand shows the time, or what is left of a countdown, as a banner
+// redrawn in place every second until interrupted or the countdown ends
+func clockCommand(cfg *figlet.Config) {
+	myname := getmyname(cfg.Argv)
+	if env := os.Getenv("FIGLET_FONTDIR"); env != "" {
+		cfg.Fontdirname = env
+	}
+	layout := "15:04:05"
+	var countdown time.Duration
+
+	args := cfg.Argv
+	for i := 1; i < len(args); i++ {
+		if v, ok := optionValue(args, &i, "-f"); ok {
+			cfg.Fontname = strings.TrimSuffix(strings.TrimSuffix(v, figlet.FONTFILESUFFIX), figlet.TOILETFILESUFFIX)
+		} else if v, ok := optionValue(args, &i, "-d"); ok {
+			cfg.Fontdirname = v
+		} else if v, ok := optionValue(args, &i, "-w"); ok {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				fmt.Fprintf(os.Stderr, "%s: invalid width %q\n", myname, v)
+				os.Exit(1)
+			}
+			cfg.Outputwidth = n
+		} else if v, ok := optionValue(args, &i, "--colors"); ok {
+			parseColorsArg(cfg, v)
+		} else if v, ok := optionValue(args, &i, "--theme"); ok {
+			parseThemeArg(cfg, v)
+		} else if v, ok := optionValue(args, &i, "--format"); ok {
+			layout = v
+		} else if v, ok := optionValue(args, &i, "--countdown"); ok {
+			d, err := time.ParseDuration(v)
+			// The remaining time is formatted as a time of day
+			if err != nil || d <= 0 || d >= 24*time.Hour {
+				fmt.Fprintf(os.Stderr, "%s: invalid countdown %q (use a duration under 24h, such as 10m)\n", myname, v)
+				os.Exit(1)
+			}
+			countdown = d
+		} else if args[i] == "-c" {
+			cfg.Justification = 1
+		} else {
+			fmt.Fprintf(os.Stderr, "Usage: %s clock [ -c ] [ -f fontfile ] [ -d fontdirectory ] [ -w outputwidth ]\n", myname)
+			fmt.Fprintf(os.Stderr, "              [ --colors color1;color2;... ] [ --theme name ]\n")
+			fmt.Fprintf(os.Stderr, "              [ --countdown duration ] [ --format layout ]\n")
+			os.Exit(1)
+		}
+	}
+	if err := cfg.LoadFont(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", myname, err)
+		os.Exit(1)
+	}
+
+	// Stop on Ctrl-C so the player can show the cursor again
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	frames := make(chan figlet.Frame)
+	go func() {
+		defer close(frames)
+		deadline := time.Now().Add(countdown)
+		for {
+			now := time.Now()
+			text := now.Format(layout)
+			// Wake up on the next second of the clock
+			wait := now.Truncate(time.Second).Add(time.Second).Sub(now)
+			if countdown > 0 {
+				left := deadline.Sub(now)
+				// Round up, so the countdown shows 00:00:00 only when it is over
+				shown := max(0, (left + time.Second - 1).Truncate(time.Second))
+				text = time.Time{}.Add(shown).Format(layout)
+				wait = left - (shown - time.Second)
+				if shown == 0 {
+					frames <- figlet.Frame{Content: cfg.RenderString(text)}
+					return
+				}
+			}
+			frames <- figlet.Frame{Content: cfg.RenderString(text)}
+			select {
+			case <-interrupt:
+				return
+			case <-time.After(wait):
+			}
+		}
+	}()
+	figlet.PlayStream(cfg, frames)
+}
+
+// badgeCommand writes a README badge whose label and message are rendered
+// in a FIGlet font, as SVG or PNG depending on the --out file name
+func badgeCommand(cfg *figlet.Config) {
+	myname := getmyname(cfg.Argv)
+	if env := os.Getenv("FIGLET_FONTDIR"); env != "" {
+		cfg.Fontdirname = env
+	}
+	var badge figlet.BadgeOptions
+	var text, out string
+	format := figlet.FormatSVG
+	var words []string
+
+	args := cfg.Argv
+	for i := 1; i < len(args); i++ {
+		if v, ok := optionValue(args, &i, "-f"); ok {
+			cfg.Fontname = strings.TrimSuffix(strings.TrimSuffix(v, figlet.FONTFILESUFFIX), figlet.TOILETFILESUFFIX)
+		} else if v, ok := optionValue(args, &i, "-d"); ok {
+			cfg.Fontdirname = v
+		} else if v, ok := optionValue(args, &i, "--colors"); ok {
+			parseColorsArg(cfg, v)
+		} else if v, ok := optionValue(args, &i, "--theme"); ok {
+			parseThemeArg(cfg, v)
+		} else if v, ok := optionValue(args, &i, "--text"); ok {
+			text = v
+		} else if v, ok := optionValue(args, &i, "--label"); ok {
+			badge.Label = v
+		} else if v, ok := optionValue(args, &i, "--style"); ok {
+			style, err := figlet.ParseBadgeStyle(v)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", myname, err)
+				os.Exit(1)
+			}
+			badge.Style = style
+		} else if v, ok := optionValue(args, &i, "--color"); ok {
+			badge.Color = parseBadgeColor(myname, v)
+		} else if v, ok := optionValue(args, &i, "--label-color"); ok {
+			badge.LabelColor = parseBadgeColor(myname, v)
+		} else if v, ok := optionValue(args, &i, "--height"); ok {
+			badge.Height = parseBadgeSize(myname, "height", v)
+		} else if v, ok := optionValue(args, &i, "--max-width"); ok {
+			badge.MaxWidth = parseBadgeSize(myname, "width", v)
+		} else if v, ok := optionValue(args, &i, "--out"); ok {
+			out = v
+		} else if strings.HasPrefix(args[i], "-") && args[i] != "-" {
+			fmt.Fprintf(os.Stderr, "Usage: %s badge [ -f fontfile ] [ -d fontdirectory ] [ --colors color1;color2;... ]\n", myname)
+			fmt.Fprintf(os.Stderr, "              [ --theme name ] [ --label text ] [ --style flat|flat-square|plastic ]\n")
+			fmt.Fprintf(os.Stderr, "              [ --color color ] [ --label-color color ] [ --height pixels ]\n")
+			fmt.Fprintf(os.Stderr, "              [ --max-width pixels ] [ --out file.svg|file.png ] [ --text message | message ]\n")
+			os.Exit(1)
+		} else {
+			words = append(words, args[i])
+		}
+	}
+	if text == "" {
+		text = strings.Join(words, " ")
+	}
+	if text == "" {
+		fmt.Fprintf(os.Stderr, "%s: badge needs a message\n", myname)
+		os.Exit(1)
+	}
+	if strings.EqualFold(filepath.Ext(out), ".png") {
+		format = figlet.FormatPNG
+	}
+	if err := cfg.LoadFont(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", myname, err)
+		os.Exit(1)
+	}
+
+	var buf bytes.Buffer
+	if err := cfg.RenderBadge(&buf, text, badge, format); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", myname, err)
+		os.Exit(1)
+	}
+	if out == "" {
+		os.Stdout.Write(buf.Bytes())
+		return
+	}
+	if err := os.WriteFile(out, buf.Bytes(), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", myname, err)
+		os.Exit(1)
+	}
+}
+
+// commentCommand prints a banner inside a comment block of a programming
+// language, ready to paste into a source file
+func commentCommand(cfg *figlet.Config) {
+	myname := getmyname(cfg.Argv)
+	if env := os.Getenv("FIGLET_FONTDIR"); env != "" {
+		cfg.Fontdirname = env
+	}
+	lang := "go"
+	box := false
+	var words []string
+
+	args := cfg.Argv
+	for i := 1; i < len(args); i++ {
+		if v, ok := optionValue(args, &i, "--lang"); ok {
+			lang = v
+		} else if v, ok := optionValue(args, &i, "-f"); ok {
+			cfg.Fontname = strings.TrimSuffix(strings.TrimSuffix(v, figlet.FONTFILESUFFIX), figlet.TOILETFILESUFFIX)
+		} else if v, ok := optionValue(args, &i, "-d"); ok {
+			cfg.Fontdirname = v
+		} else if v, ok := optionValue(args, &i, "-w"); ok {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				fmt.Fprintf(os.Stderr, "%s: invalid width %q\n", myname, v)
+				os.Exit(1)
+			}
+			cfg.Outputwidth = n
+		} else if args[i] == "--box" {
+			box = true
+		} else if args[i] == "-c" {
+			cfg.Justification = 1
+		} else if strings.HasPrefix(args[i], "-") && args[i] != "-" {
+			fmt.Fprintf(os.Stderr, "Usage: %s comment [ --lang %s ]\n", myname, strings.Join(figlet.ListCommentLanguages(), "|"))
+			fmt.Fprintf(os.Stderr, "              [ --box ] [ -c ] [ -f fontfile ] [ -d fontdirectory ] [ -w outputwidth ] message\n")
+			os.Exit(1)
+		} else {
+			words = append(words, args[i])
+		}
+	}
+	if len(words) == 0 {
+		fmt.Fprintf(os.Stderr, "%s: comment needs a message\n", myname)
+		os.Exit(1)
+	}
+	if err := cfg.LoadFont(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", myname, err)
+		os.Exit(1)
+	}
+	comment, err := figlet.CommentBlock(cfg.RenderString(strings.Join(words, " ")), lang, box)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", myname, err)
+		os.Exit(1)
+	}
+	fmt.Print(comment)
+}
+
+// mdExpandCommand renders the figlet blocks of a Markdown file or stdin
+func mdExpandCommand(cfg *figlet.Config) {
+	myname := getmyname(cfg.Argv)
+	if env := os.Getenv("FIGLET_FONTDIR"); env != "" {
+		cfg.Fontdirname = env
+	}
+	opts := []figlet.Option{figlet.WithFilesystemPaths()}
+	input, output := "-", ""
+
+	args := cfg.Argv
+	for i := 1; i < len(args); i++ {
+		if v, ok := optionValue(args, &i, "-f"); ok {
+			opts = append(opts, figlet.WithFont(strings.TrimSuffix(strings.TrimSuffix(v, figlet.FONTFILESUFFIX), figlet.TOILETFILESUFFIX)))
+		} else if v, ok := optionValue(args, &i, "-d"); ok {
+			cfg.Fontdirname = v
+		} else if v, ok := optionValue(args, &i, "-w"); ok {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				fmt.Fprintf(os.Stderr, "%s: invalid width %q\n", myname, v)
+				os.Exit(1)
+			}
+			opts = append(opts, figlet.WithWidth(n))
+		} else if v, ok := optionValue(args, &i, "-o"); ok {
+			output = v
+		} else if strings.HasPrefix(args[i], "-") && args[i] != "-" || input != "-" {
+			fmt.Fprintf(os.Stderr, "Usage: %s md-expand [ -f fontfile ] [ -d fontdirectory ] [ -w outputwidth ]\n", myname)
+			fmt.Fprintf(os.Stderr, "                [ -o output.md ] [ input.md ]\n")
+			os.Exit(1)
+		} else {
+			input = args[i]
+		}
+	}
+	opts = append([]figlet.Option{figlet.WithFontDir(cfg.Fontdirname)}, opts...)
+
+	var src []byte
+	var err error
+	if input == "-" {
+		src, err = io.ReadAll(os.Stdin)
+	} else {
+		src, err = os.ReadFile(input)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", myname, err)
+		os.Exit(1)
+	}
+	doc, err := figlet.ExpandMarkdown(string(src), opts...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s: %v\n", myname, input, err)
+		os.Exit(1)
+	}
+	if output == "" {
+		fmt.Print(doc)
+		return
+	}
+	if err := os.WriteFile(output, []byte(doc), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", myname, err)
+		os.Exit(1)
+	}
+}
+
+// gitBannerCommand renders the tag or branch being built, taken from the
+// CI environment or else from git
+func gitBannerCommand(cfg *figlet.Config) {
+	myname := getmyname(cfg.Argv)
+	if env := os.Getenv("FIGLET_FONTDIR"); env != "" {
+		cfg.Fontdirname = env
+	}
+	prefix := ""
+
+	args := cfg.Argv
+	for i := 1; i < len(args); i++ {
+		if v, ok := optionValue(args, &i, "-f"); ok {
+			cfg.Fontname = strings.TrimSuffix(strings.TrimSuffix(v, figlet.FONTFILESUFFIX), figlet.TOILETFILESUFFIX)
+		} else if v, ok := optionValue(args, &i, "-d"); ok {
+			cfg.Fontdirname = v
+		} else if v, ok := optionValue(args, &i, "-w"); ok {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				fmt.Fprintf(os.Stderr, "%s: invalid width %q\n", myname, v)
+				os.Exit(1)
+			}
+			cfg.Outputwidth = n
+		} else if v, ok := optionValue(args, &i, "--colors"); ok {
+			parseColorsArg(cfg, v)
+		} else if v, ok := optionValue(args, &i, "--theme"); ok {
+			parseThemeArg(cfg, v)
+		} else if v, ok := optionValue(args, &i, "--style"); ok {
+			parseStyleArg(cfg, v)
+		} else if v, ok := optionValue(args, &i, "--prefix"); ok {
+			prefix = v
+		} else if args[i] == "-c" {
+			cfg.Justification = 1
+		} else {
+			fmt.Fprintf(os.Stderr, "Usage: %s git-banner [ -c ] [ -f fontfile ] [ -d fontdirectory ] [ -w outputwidth ]\n", myname)
+			fmt.Fprintf(os.Stderr, "              [ --colors color1;color2;... ] [ --theme name ] [ --style names ] [ --prefix text ]\n")
+			os.Exit(1)
+		}
+	}
+	ref := figlet.CIRef(os.Getenv)
+	if ref == "" {
+		ref = gitRef()
+	}
+	if ref == "" {
+		fmt.Fprintf(os.Stderr, "%s: no branch or tag found in the CI environment or with git\n", myname)
+		os.Exit(1)
+	}
+	if err := cfg.LoadFont(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", myname, err)
+		os.Exit(1)
+	}
+	fmt.Print(cfg.RenderString(prefix + ref))
+}
+
+// gitRef returns the tag at HEAD, or else the branch checked out, or else
+// the abbreviated commit of a detached HEAD, or "" outside a repository
+func gitRef() string {
+	for _, args := range [][]string{
+		{"describe", "--tags", "--exact-match"},
+		{"symbolic-ref", "--short", "-q", "HEAD"},
+		{"rev-parse", "--short", "HEAD"},
+	} {
+		if out, err := exec.Command("git", args...).Output(); err == nil {
+			if ref := strings.TrimSpace(string(out)); ref != "" {
+				return ref
+			}
+		}
+	}
+	return ""
+}
+
+// replayCommand replays a session recorded with figlet.Recorder
+func replayCommand(cfg *figlet.Config) {
+	myname := getmyname(cfg.Argv)
+	if len(cfg.Argv) != 2 || strings.HasPrefix(cfg.Argv[1], "-") {
+		fmt.Fprintf(os.Stderr, "Usage: %s replay session.json\n", myname)
+		os.Exit(1)
+	}
+	f, err := os.Open(cfg.Argv[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", myname, err)
+		os.Exit(1)
+	}
+	session, err := figlet.ReadSession(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", myname, err)
+		os.Exit(1)
+	}
+	figlet.ReplaySession(cfg, session)
+}
+
+// parseBadgeColor reads a badge background color
+func parseBadgeColor(myname, v string) figlet.Color {
+	c, err := figlet.ParseColor(v)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", myname, err)
+		os.Exit(1)
+	}
+	return c
+}
+
+// parseBadgeSize reads a badge size in pixels
+func parseBadgeSize(myname, what, v string) int {
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		fmt.Fprintf(os.Stderr, "%s: invalid badge %s %q\n", myname, what, v)
+		os.Exit(1)
+	}
+	return n
+}
@@ -1,9 +1,12 @@
 package figlet
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"math"
 	"math/rand"
+	"os"
 	"strings"
 	"time"
 )
@@ -13,13 +16,42 @@ type Frame struct {
 	Content        string
 	Delay          time.Duration
 	BaselineOffset int // Number of lines before the FIGlet row 0 in this frame
+	Baseline       int // Line of the font baseline of the first FIGlet line in this frame
 }
 
 // Animator handles the generation and playback of FIGlet animations
 type Animator struct {
 	Config *Config
+	// MaxFrames caps the number of frames of an animation, 0 for no
+	// limit. Frames are dropped evenly, keeping the first and the last,
+	// and the delays stretched so the animation lasts as long.
+	MaxFrames int
+	// MaxCells caps the width times height of the padded grids of the
+	// scroll and explosion animations, 0 for no limit. The grid is
+	// narrowed, but never below the width of the text.
+	MaxCells int
+	// CanvasHeight is the number of lines the rain and explosion
+	// animations may draw on, usually the terminal height from GetRows, 0
+	// for no limit. Particles spread less to stay on a short canvas, and
+	// rain only falls on the text lines that fit on it.
+	CanvasHeight int
+	// HighlightColor paints the character lit by the highlight animation,
+	// ColorYellow if nil
+	HighlightColor Color
+	filters        []FrameFilter
+	// timing is how long GenerateHighlight lights each character
+	timing *highlighttiming
+	// ctx is the context of the GenerateAnimationContext call running
+	ctx context.Context
+	// grids collects the cells of every frame while GenerateStructured runs
+	grids      [][][]Cell
+	structured bool
 }
 
+// FrameFilter transforms frame i of a generated animation. Content is
+// passed without the parser prefix and suffix, which are added afterwards.
+type FrameFilter func(i int, f Frame) Frame
+
 // NewAnimator creates a new Animator
 func NewAnimator(cfg *Config) *Animator {
 	return &Animator{Config: cfg}
@@ -27,75 +59,181 @@ func NewAnimator(cfg *Config) *Animator {
 
 // ListAnimations returns a list of available animation types
 func ListAnimations() []string {
-	return []string{"reveal", "scroll", "rain", "wave", "explosion"}
+	return []string{"reveal", "scroll", "rain", "wave", "explosion", "drop", "slide", "spin", "highlight"}
 }
 
 // GenerateAnimation generates frames for the specified animation type
 func (a *Animator) GenerateAnimation(text string, animType string, delay time.Duration) ([]Frame, error) {
+	return a.GenerateAnimationContext(context.Background(), text, animType, delay)
+}
+
+// GenerateAnimationContext generates an animation like GenerateAnimation,
+// stopping with the context's error when it is done before all the frames
+// are generated
+func (a *Animator) GenerateAnimationContext(ctx context.Context, text string, animType string, delay time.Duration) ([]Frame, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	a.ctx = ctx
+	defer func() {
+		a.ctx = nil
+	}()
 	// First, get the final rendered string to know the dimensions and content
 	// We use the terminal parser to get raw geometry.
-	rows, maps := a.renderToRowsAndMaps(text)
+	rows, maps, letters, baseline := a.renderToRowsAndMaps(text)
 	if len(rows) == 0 {
 		return nil, nil
 	}
 
+	var frames []Frame
+	var err error
 	switch strings.ToLower(animType) {
 	case "reveal":
-		return a.generateReveal(rows, maps, delay), nil
+		frames = a.generateReveal(rows, maps, delay)
 	case "scroll":
-		return a.generateScroll(rows, maps, delay), nil
+		frames = a.generateScroll(rows, maps, delay)
 	case "rain":
-		return a.generateRain(rows, maps, delay), nil
+		frames = a.generateRain(rows, maps, delay)
 	case "wave":
-		return a.generateWave(rows, maps, delay), nil
+		frames = a.generateWave(rows, maps, delay)
 	case "explosion":
-		return a.generateExplosion(rows, maps, delay), nil
+		frames = a.generateExplosion(rows, maps, delay)
+	case "drop", "slide", "spin":
+		frames = a.generateLetters(strings.ToLower(animType), rows, maps, letters, delay)
+	case "highlight":
+		frames, err = a.generateHighlight(rows, maps, letters, delay)
 	default:
 		return nil, fmt.Errorf("unknown animation type: %s", animType)
 	}
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for i := range frames {
+		frames[i].Baseline = frames[i].BaselineOffset + baseline
+		for _, filter := range a.filters {
+			frames[i] = filter(i, frames[i])
+		}
+		if a.Config.OutputParser != nil {
+			frames[i].Content = a.Config.OutputParser.Prefix + frames[i].Content + a.Config.OutputParser.Suffix
+		}
+	}
+	return frames, nil
 }
 
-// renderToRowsAndMaps renders the text and returns it as a slice of strings (one per line)
-// and a corresponding character position map.
-func (a *Animator) renderToRowsAndMaps(text string) ([]string, [][]int) {
-	// Remember original parser
-	origParser := a.Config.OutputParser
-	parser, _ := GetParser("terminal")
-	a.Config.OutputParser = parser
+// AddFrameFilter adds a filter run on every frame generated from now on,
+// after the filters added before it. Filters can add timestamps, overlay
+// watermarks or clamp the width without changing the generators.
+func (a *Animator) AddFrameFilter(filter FrameFilter) {
+	a.filters = append(a.filters, filter)
+}
 
-	a.Config.PreserveMap = true
-	defer func() { a.Config.PreserveMap = false }()
+// framesteps returns the numbers of the frames to generate out of n, no more
+// than max of them when max is positive, and the delay stretched over the
+// frames dropped
+func framesteps(n, max int, delay time.Duration) ([]int, time.Duration) {
+	k := n
+	if max > 0 && max < n {
+		k = max
+	}
+	steps := make([]int, k)
+	for i := range steps {
+		if k == 1 {
+			// The last frame shows the finished text
+			steps[i] = n - 1
+		} else {
+			steps[i] = i * (n - 1) / (k - 1)
+		}
+	}
+	return steps, delay * time.Duration(n) / time.Duration(k)
+}
 
-	rendered := a.Config.RenderString(text)
+// done reports whether the context of the running generation is done
+func (a *Animator) done() bool {
+	return a.ctx != nil && a.ctx.Err() != nil
+}
 
-	// Capture character maps
-	maps := make([][]int, len(a.Config.charPositionMap))
-	for i, row := range a.Config.charPositionMap {
-		maps[i] = make([]int, len(row))
-		copy(maps[i], row)
+// gridwidth returns the width of a padded frame grid of the given height,
+// narrowed to keep it within MaxCells but no narrower than the text
+func (a *Animator) gridwidth(width, height, textwidth int) int {
+	if a.MaxCells <= 0 || height <= 0 || width*height <= a.MaxCells {
+		return width
+	}
+	width = a.MaxCells / height
+	if width < textwidth {
+		width = textwidth
 	}
+	return width
+}
 
-	// Restore original parser
-	a.Config.OutputParser = origParser
+// renderToRowsAndMaps renders the text and returns it as a slice of strings (one per line)
+// and a corresponding character position map. Both come from the laid out glyph
+// grid, so justification spaces line up with the map and are never colored.
+// It also returns the input character each cell was drawn from, -1 for none,
+// and the line of the first baseline.
+func (a *Animator) renderToRowsAndMaps(text string) ([]string, [][]int, [][]int, int) {
+	rows := a.Config.layoutrows(a.Config.compose(text))
+
+	lines := make([]string, len(rows))
+	maps := make([][]int, len(rows))
+	letters := make([][]int, len(rows))
+	baseline := -1
+	for n, row := range rows {
+		if row.baseline && baseline < 0 {
+			baseline = n
+		}
+		lines[n] = a.Config.text(row)
+		width := row.pad + len(row.cells) + row.fill
+		maps[n] = make([]int, width)
+		letters[n] = make([]int, width)
+		for i := range maps[n] {
+			maps[n][i] = a.Config.cellindex(row, n, i)
+			letters[n][i] = -1
+			if j := i - row.pad; j >= 0 && j < len(row.index) && row.index[j] >= 0 {
+				letters[n][i] = row.index[j]
+			}
+		}
+	}
+	if baseline < 0 {
+		// The baseline row was trimmed away
+		baseline = len(rows) - 1
+	}
+	return lines, maps, letters, baseline
+}
 
-	// Split by newline and remove empty trailing line if present
-	lines := strings.Split(rendered, "\n")
-	if len(lines) > 0 && lines[len(lines)-1] == "" {
-		lines = lines[:len(lines)-1]
+// framebuilder collects the text of a frame and, for GenerateStructured,
+// its cells
+type framebuilder struct {
+	sb    strings.Builder
+	run   colorrun
+	cells [][]Cell
+	line  []Cell
+}
+
+// createFrame returns a Frame. The parser prefix and suffix are added by
+// GenerateAnimation, after the frame filters.
+func (a *Animator) createFrame(fb *framebuilder, delay time.Duration, baselineOffset int) Frame {
+	if a.structured {
+		a.grids = append(a.grids, fb.cells)
 	}
-	return lines, maps
+	fb.run.end(&fb.sb)
+	return Frame{Content: fb.sb.String(), Delay: delay, BaselineOffset: baselineOffset}
 }
 
-// createFrame wraps the content with parser prefix/suffix and returns a Frame
-func (a *Animator) createFrame(content string, delay time.Duration, baselineOffset int) Frame {
-	if a.Config.OutputParser != nil {
-		content = a.Config.OutputParser.Prefix + content + a.Config.OutputParser.Suffix
+// endline ends the current line of a frame
+func (a *Animator) endline(fb *framebuilder) {
+	fb.run.end(&fb.sb)
+	fb.sb.WriteString("\n")
+	if a.structured {
+		fb.cells = append(fb.cells, fb.line)
+		fb.line = nil
 	}
-	return Frame{Content: content, Delay: delay, BaselineOffset: baselineOffset}
 }
 
 // appendStyledRange appends a range of characters from a row using character mapping for colors
-func (a *Animator) appendStyledRange(sb *strings.Builder, row string, rowMap []int, start, end int) {
+func (a *Animator) appendStyledRange(fb *framebuilder, row string, rowMap []int, start, end int) {
 	runes := []rune(row)
 	if start < 0 {
 		start = 0
@@ -107,21 +245,42 @@ func (a *Animator) appendStyledRange(sb *strings.Builder, row string, rowMap []i
 		return
 	}
 
-	hasColors := len(a.Config.Colors) > 0 && a.Config.OutputParser != nil && a.Config.OutputParser.Name != "terminal"
-
 	for i := start; i < end; i++ {
-		charStr := string(runes[i])
-		if hasColors {
-			charIndex := -1
-			if i < len(rowMap) {
-				charIndex = rowMap[i]
-			}
-			charStr = a.Config.applyColorWithIndex(charStr, charIndex)
-		} else if a.Config.OutputParser != nil {
-			charStr = handleReplaces(charStr, a.Config.OutputParser)
+		charIndex := -1
+		if i < len(rowMap) {
+			charIndex = rowMap[i]
+		}
+		if a.structured {
+			fb.line = append(fb.line, a.cell(runes[i], charIndex))
 		}
-		sb.WriteString(charStr)
+		prefix, text, suffix := a.colorparts(string(runes[i]), charIndex)
+		fb.run.write(&fb.sb, prefix, text, suffix)
+	}
+}
+
+// colorparts returns the text of a cell with the output parser's
+// replacements, and the markup put around it for the color of the given
+// index, or the highlight color for highlighted
+func (a *Animator) colorparts(s string, index int) (string, string, string) {
+	cfg := a.Config
+	parser := cfg.OutputParser
+	if parser == nil {
+		return "", s, ""
 	}
+	s = handleReplaces(s, parser)
+	if parser.Name == "terminal" {
+		return "", s, ""
+	}
+	if index == highlighted {
+		c := cfg.quantize(a.highlightcolor())
+		prefix, suffix := cfg.Style.wrap(c.getPrefix(parser), c.getSuffix(parser), parser)
+		return prefix, s, suffix
+	}
+	if len(cfg.Colors) > 0 || cfg.Style != 0 {
+		prefix, suffix := cfg.colorcodes(index)
+		return prefix, s, suffix
+	}
+	return "", s, ""
 }
 
 func (a *Animator) generateReveal(rows []string, maps [][]int, delay time.Duration) []Frame {
@@ -132,13 +291,16 @@ func (a *Animator) generateReveal(rows []string, maps [][]int, delay time.Durati
 		}
 	}
 
-	frames := make([]Frame, 0, width+1)
+	steps, delay := framesteps(width+1, a.MaxFrames, delay)
+	frames := make([]Frame, 0, len(steps))
 
-	for i := 0; i <= width; i++ {
-		var sb strings.Builder
+	for _, i := range steps {
+		if a.done() {
+			break
+		}
+		var sb framebuilder
 		for r, row := range rows {
 			rowMap := maps[r]
-			a.Config.currentLineIndex = r
 			runes := []rune(row)
 			if i < len(runes) {
 				a.appendStyledRange(&sb, row, rowMap, 0, i)
@@ -147,9 +309,9 @@ func (a *Animator) generateReveal(rows []string, maps [][]int, delay time.Durati
 			} else {
 				a.appendStyledRange(&sb, row, rowMap, 0, len(runes))
 			}
-			sb.WriteString("\n")
+			a.endline(&sb)
 		}
-		frames = append(frames, a.createFrame(sb.String(), delay, 0))
+		frames = append(frames, a.createFrame(&sb, delay, 0))
 	}
 
 	return frames
@@ -167,14 +329,19 @@ func (a *Animator) generateScroll(rows []string, maps [][]int, delay time.Durati
 	if termWidth <= 0 {
 		termWidth = 80
 	}
+	termWidth = a.gridwidth(termWidth, len(rows), width)
 
-	frames := make([]Frame, 0, termWidth+1)
+	steps, delay := framesteps(termWidth+1, a.MaxFrames, delay)
+	frames := make([]Frame, 0, len(steps))
 
-	for i := termWidth; i >= 0; i-- {
-		var sb strings.Builder
+	for _, step := range steps {
+		if a.done() {
+			break
+		}
+		i := termWidth - step
+		var sb framebuilder
 		for r, row := range rows {
 			rowMap := maps[r]
-			a.Config.currentLineIndex = r
 			// Leading spaces (no mapping)
 			a.appendStyledRange(&sb, strings.Repeat(" ", i), nil, 0, i)
 
@@ -188,9 +355,9 @@ func (a *Animator) generateScroll(rows []string, maps [][]int, delay time.Durati
 				}
 				a.appendStyledRange(&sb, row, rowMap, 0, end)
 			}
-			sb.WriteString("\n")
+			a.endline(&sb)
 		}
-		frames = append(frames, a.createFrame(sb.String(), delay, 0))
+		frames = append(frames, a.createFrame(&sb, delay, 0))
 	}
 
 	return frames
@@ -205,10 +372,21 @@ func (a *Animator) generateRain(rows []string, maps [][]int, delay time.Duration
 		}
 	}
 
-	numFrames := height + 15
-	frames := make([]Frame, 0, numFrames)
+	// Lines that scroll off the top of the canvas are shown settled, so no
+	// frames are spent on rain nobody sees
+	hidden := 0
+	if a.CanvasHeight > 0 && height > a.CanvasHeight {
+		hidden = height - a.CanvasHeight
+	}
 
-	for f := 0; f < numFrames; f++ {
+	numFrames := height - hidden + 15
+	steps, delay := framesteps(numFrames, a.MaxFrames, delay)
+	frames := make([]Frame, 0, len(steps))
+
+	for _, f := range steps {
+		if a.done() {
+			break
+		}
 		grid := make([][]rune, height)
 		gridMap := make([][]int, height)
 		for i := range grid {
@@ -228,16 +406,16 @@ func (a *Animator) generateRain(rows []string, maps [][]int, delay time.Duration
 					continue
 				}
 				delayColumn := (c / 2) % 10
-				reachFrame := r + delayColumn
+				reachFrame := r - hidden + delayColumn
 
-				if f >= reachFrame {
+				if r < hidden || f >= reachFrame {
 					grid[r][c] = rowRunes[c]
 					if c < len(rowMap) {
 						gridMap[r][c] = rowMap[c]
 					}
 				} else {
-					currR := f - delayColumn
-					if currR >= 0 && currR < height {
+					currR := hidden + f - delayColumn
+					if currR >= hidden && currR < height {
 						grid[currR][c] = rowRunes[c]
 						if c < len(rowMap) {
 							gridMap[currR][c] = rowMap[c]
@@ -247,16 +425,15 @@ func (a *Animator) generateRain(rows []string, maps [][]int, delay time.Duration
 			}
 		}
 
-		var sb strings.Builder
+		var sb framebuilder
 		for r, gridRow := range grid {
-			a.Config.currentLineIndex = r
 			rowStr := string(gridRow)
 			trimmedRow := strings.TrimRight(rowStr, " ")
 			runes := []rune(trimmedRow)
 			a.appendStyledRange(&sb, trimmedRow, gridMap[r][:len(runes)], 0, len(runes))
-			sb.WriteString("\n")
+			a.endline(&sb)
 		}
-		frames = append(frames, a.createFrame(sb.String(), delay, 0))
+		frames = append(frames, a.createFrame(&sb, delay, 0))
 	}
 
 	return frames
@@ -264,17 +441,20 @@ func (a *Animator) generateRain(rows []string, maps [][]int, delay time.Duration
 
 func (a *Animator) generateWave(rows []string, maps [][]int, delay time.Duration) []Frame {
 	numFrames := 40
-	frames := make([]Frame, 0, numFrames)
+	steps, delay := framesteps(numFrames, a.MaxFrames, delay)
+	frames := make([]Frame, 0, len(steps))
 
-	for f := 0; f < numFrames; f++ {
-		var sb strings.Builder
+	for _, f := range steps {
+		if a.done() {
+			break
+		}
+		var sb framebuilder
 		phase := float64(f) * 0.5
 		dampening := 1.0 - float64(f)/float64(numFrames-1)
 
 		for r := 0; r < len(rows); r++ {
 			row := rows[r]
 			rowMap := maps[r]
-			a.Config.currentLineIndex = r
 			runes := []rune(row)
 			shift := int(5.0 * dampening * math.Sin(phase+float64(r)*0.5))
 
@@ -289,9 +469,9 @@ func (a *Animator) generateWave(rows []string, maps [][]int, delay time.Duration
 			} else {
 				a.appendStyledRange(&sb, row, rowMap, 0, len(runes))
 			}
-			sb.WriteString("\n")
+			a.endline(&sb)
 		}
-		frames = append(frames, a.createFrame(sb.String(), delay, 0))
+		frames = append(frames, a.createFrame(&sb, delay, 0))
 	}
 
 	return frames
@@ -301,21 +481,31 @@ func (a *Animator) generateExplosion(rows []string, maps [][]int, delay time.Dur
 	height := len(rows)
 
 	// Capture the initial static content and mappings for pauses
-	var staticSb strings.Builder
+	var staticSb framebuilder
 	for r, row := range rows {
-		a.Config.currentLineIndex = r
 		a.appendStyledRange(&staticSb, row, maps[r], 0, len([]rune(row)))
-		staticSb.WriteString("\n")
+		a.endline(&staticSb)
 	}
-	staticContent := staticSb.String()
 
 	numStaticStart := 8
-	frames := make([]Frame, 0, 70)
-	for i := 0; i < numStaticStart; i++ {
-		frames = append(frames, a.createFrame(staticContent, delay, 0))
+	numFrames := 40
+	// Under a frame budget the pause before the explosion is one longer
+	// frame, and the explosion gets the frames left besides the last one
+	pause, pauseDelay, budget := numStaticStart, delay, 0
+	if a.MaxFrames > 0 && a.MaxFrames < numStaticStart+numFrames+1 {
+		if a.MaxFrames < 3 {
+			total := delay * time.Duration(numStaticStart+numFrames+1)
+			return []Frame{a.createFrame(&staticSb, total, 0)}
+		}
+		pause, pauseDelay, budget = 1, delay*time.Duration(numStaticStart), a.MaxFrames-2
+	}
+	steps, stepDelay := framesteps(numFrames, budget, delay)
+
+	frames := make([]Frame, 0, pause+len(steps)+1)
+	for i := 0; i < pause; i++ {
+		frames = append(frames, a.createFrame(&staticSb, pauseDelay, 0))
 	}
 
-	numFrames := 40
 	type particle struct {
 		char      rune
 		charIndex int
@@ -323,6 +513,17 @@ func (a *Animator) generateExplosion(rows []string, maps [][]int, delay time.Dur
 		vx, vy    float64
 	}
 
+	// Particles fly up to margin lines above and below the text, fewer
+	// when the canvas is too short for the full margin
+	margin := 5
+	if a.CanvasHeight > 0 && height+2*margin > a.CanvasHeight {
+		margin = (a.CanvasHeight - height) / 2
+		if margin < 0 {
+			margin = 0
+		}
+	}
+	spread := 0.4 * float64(margin) / 5
+
 	var particles []particle
 	for r, row := range rows {
 		runes := []rune(row)
@@ -341,7 +542,7 @@ func (a *Animator) generateExplosion(rows []string, maps [][]int, delay time.Dur
 					row:       r,
 					col:       c,
 					vx:        math.Cos(angle) * speed * 2.0,
-					vy:        math.Sin(angle) * speed * 0.4,
+					vy:        math.Sin(angle) * speed * spread,
 				})
 			}
 		}
@@ -361,13 +562,23 @@ func (a *Animator) generateExplosion(rows []string, maps [][]int, delay time.Dur
 		explosionPositions[i] = struct{ x, y float64 }{x, y}
 	}
 
-	for f := 0; f < numFrames; f++ {
-		gridHeight := height + 10
-		targetWidth := a.Config.Outputwidth
-		if targetWidth <= 0 {
-			targetWidth = 80
+	width := 0
+	for _, row := range rows {
+		if len([]rune(row)) > width {
+			width = len([]rune(row))
 		}
+	}
+	gridHeight := height + 2*margin
+	targetWidth := a.Config.Outputwidth
+	if targetWidth <= 0 {
+		targetWidth = 80
+	}
+	targetWidth = a.gridwidth(targetWidth, gridHeight, width)
 
+	for _, f := range steps {
+		if a.done() {
+			break
+		}
 		grid := make([][]rune, gridHeight)
 		gridMap := make([][]int, gridHeight)
 		for i := range grid {
@@ -379,7 +590,7 @@ func (a *Animator) generateExplosion(rows []string, maps [][]int, delay time.Dur
 			}
 		}
 
-		offsetY := 5
+		offsetY := margin
 		for i := range particles {
 			p := &particles[i]
 			var x, y float64
@@ -408,112 +619,166 @@ func (a *Animator) generateExplosion(rows []string, maps [][]int, delay time.Dur
 			}
 		}
 
-		var sb strings.Builder
+		var sb framebuilder
 		for r, gridRow := range grid {
 			rowStr := string(gridRow)
 			trimmedRow := strings.TrimRight(rowStr, " ")
 			runes := []rune(trimmedRow)
 			a.appendStyledRange(&sb, trimmedRow, gridMap[r][:len(runes)], 0, len(runes))
-			sb.WriteString("\n")
+			a.endline(&sb)
 		}
-		frames = append(frames, a.createFrame(sb.String(), delay, offsetY))
+		frames = append(frames, a.createFrame(&sb, stepDelay, offsetY))
 	}
 
-	frames = append(frames, a.createFrame(staticContent, delay, 0))
+	frames = append(frames, a.createFrame(&staticSb, delay, 0))
 
 	return frames
 }
 
 // PlayAnimation plays the animation with terminal control codes OR as a standalone HTML player.
 func PlayAnimation(cfg *Config, frames []Frame) {
-	if len(frames) == 0 {
+	PlayDeltas(cfg, EncodeFrames(frames))
+}
+
+// PlayDeltas plays an animation stored as deltas like PlayAnimation. On a
+// terminal only the lines that changed are redrawn. Frames are drawn at
+// the times of their Schedule, however long drawing takes.
+func PlayDeltas(cfg *Config, deltas []FrameDelta) {
+	if len(deltas) == 0 {
 		return
 	}
 
 	// For HTML output, we generate a standalone player
 	if cfg.OutputParser != nil && cfg.OutputParser.Name == "html" {
-		playHTMLAnimation(frames)
+		writehtmlplayer(os.Stdout, deltas, DefaultHTMLPlayerOptions())
 		return
 	}
 
-	// Default: Terminal playback with ANSI codes
-	fmt.Print("\033[?25l")       // Hide cursor
-	defer fmt.Print("\033[?25h") // Show cursor
+	delays := make([]time.Duration, len(deltas))
+	for i, delta := range deltas {
+		delays[i] = delta.Delay
+	}
+	schedule := NewSchedule(delays)
+	p := terminalplayer{w: os.Stdout}
+	defer p.close()
+	start := time.Now()
+	for i, delta := range deltas {
+		if cfg.SkipLateFrames && i+1 < len(deltas) && time.Since(start) >= schedule[i+1] {
+			p.skip(delta)
+			continue
+		}
+		p.draw(delta)
+		if wait := schedule[i+1] - time.Since(start); wait > 0 {
+			time.Sleep(wait)
+		}
+	}
+}
 
-	lastTotalLines := 0
-	lastBaselineOffset := 0
+// PlayStream plays frames on the terminal as they arrive, redrawing them
+// in place like PlayAnimation, until the channel is closed. Frames are
+// drawn as soon as they are received, so their Delay is not used; the
+// sender sets the pace. It suits frames that depend on when they are
+// shown, such as a clock.
+func PlayStream(cfg *Config, frames <-chan Frame) {
+	var enc deltaencoder
+	p := terminalplayer{w: os.Stdout}
+	defer p.close()
+	for frame := range frames {
+		p.draw(enc.encode(frame))
+	}
+}
 
-	for i, frame := range frames {
-		contentLines := strings.Split(strings.TrimSuffix(frame.Content, "\n"), "\n")
+// terminalplayer draws deltas in place on a terminal with ANSI codes
+type terminalplayer struct {
+	w          io.Writer
+	started    bool
+	lines      []string
+	lastlines  int // lines drawn by the previous frame
+	lastoffset int
+	dirty      map[int]bool // lines changed by skipped frames
+}
 
-		if i > 0 {
-			if lastTotalLines > 0 {
-				fmt.Printf("\033[%dA", lastTotalLines)
-			}
-			diff := frame.BaselineOffset - lastBaselineOffset
-			if diff > 0 {
-				fmt.Printf("\033[%dA", diff)
-			} else if diff < 0 {
-				fmt.Printf("\033[%dB", -diff)
-			}
-		} else {
-			if frame.BaselineOffset > 0 {
-				fmt.Printf("\033[%dA", frame.BaselineOffset)
-			}
+// draw draws the next frame over the previous one. Only changed lines are
+// written; unchanged ones are skipped by moving to the next line.
+func (p *terminalplayer) draw(delta FrameDelta) {
+	// Decode the frame, remembering which lines must be redrawn
+	next := make([]string, delta.Lines)
+	copy(next, p.lines)
+	changed := make([]bool, delta.Lines)
+	for _, change := range delta.Changes {
+		if change.Line >= 0 && change.Line < len(next) {
+			next[change.Line] = change.apply(next[change.Line])
+			changed[change.Line] = true
 		}
+	}
+	for line := range p.dirty {
+		if line < len(changed) {
+			changed[line] = true
+		}
+	}
+	p.dirty = nil
+	p.lines = next
+	contentLines := p.lines
+	if n := len(contentLines); n > 0 && contentLines[n-1] == "" {
+		contentLines = contentLines[:n-1]
+	}
 
-		for _, line := range contentLines {
-			fmt.Print(line)
-			fmt.Print("\033[K\n")
+	var sb strings.Builder
+	if p.started {
+		if p.lastlines > 0 {
+			fmt.Fprintf(&sb, "\033[%dA", p.lastlines)
+		}
+		diff := delta.BaselineOffset - p.lastoffset
+		if diff > 0 {
+			fmt.Fprintf(&sb, "\033[%dA", diff)
+		} else if diff < 0 {
+			fmt.Fprintf(&sb, "\033[%dB", -diff)
 		}
+	} else {
+		sb.WriteString("\033[?25l") // Hide cursor
+		if delta.BaselineOffset > 0 {
+			fmt.Fprintf(&sb, "\033[%dA", delta.BaselineOffset)
+		}
+	}
 
-		lastTotalLines = len(contentLines)
-		lastBaselineOffset = frame.BaselineOffset
-		time.Sleep(frame.Delay)
+	for n, line := range contentLines {
+		if changed[n] || n >= p.lastlines {
+			sb.WriteString(line)
+			sb.WriteString("\033[K")
+		}
+		sb.WriteString("\n")
 	}
+	// Clear what is left of a taller previous frame
+	if len(contentLines) < p.lastlines {
+		sb.WriteString("\033[J")
+	}
+	io.WriteString(p.w, sb.String())
+
+	p.started = true
+	p.lastlines = len(contentLines)
+	p.lastoffset = delta.BaselineOffset
 }
 
-// playHTMLAnimation generates a standalone HTML player for the animation.
-func playHTMLAnimation(frames []Frame) {
-	var sb strings.Builder
+// skip applies the changes of a frame that is not drawn, so the next
+// frame drawn redraws them
+func (p *terminalplayer) skip(delta FrameDelta) {
+	next := make([]string, delta.Lines)
+	copy(next, p.lines)
+	if p.dirty == nil {
+		p.dirty = make(map[int]bool)
+	}
+	for _, change := range delta.Changes {
+		if change.Line >= 0 && change.Line < len(next) {
+			next[change.Line] = change.apply(next[change.Line])
+			p.dirty[change.Line] = true
+		}
+	}
+	p.lines = next
+}
 
-	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n")
-	sb.WriteString("<title>FIGlet Animation</title>\n")
-	sb.WriteString("<style>\n")
-	sb.WriteString("  body { background: #0c0c0c; color: #cccccc; font-family: 'Cascadia Code', 'Ubuntu Mono', 'Roboto Mono', 'DejaVu Sans Mono', monospace; margin: 0; padding: 20px; overflow: auto; }\n")
-	sb.WriteString("  #terminal { white-space: pre; line-height: 1.25; font-size: 14px; position: relative; }\n")
-	sb.WriteString("</style>\n")
-	sb.WriteString("</head>\n<body>\n")
-	sb.WriteString("<div id='terminal'></div>\n")
-	sb.WriteString("<script>\n")
-	sb.WriteString("  const frames = [\n")
-
-	for _, frame := range frames {
-		// Escape backticks and backslashes for JS template literal
-		content := strings.ReplaceAll(frame.Content, "\\", "\\\\")
-		content = strings.ReplaceAll(content, "`", "\\`")
-		content = strings.ReplaceAll(content, "${", "\\${")
-
-		sb.WriteString(fmt.Sprintf("    { c: `%s`, d: %d, o: %d },\n",
-			content, frame.Delay.Milliseconds(), frame.BaselineOffset))
-	}
-
-	sb.WriteString("  ];\n")
-	sb.WriteString("  const term = document.getElementById('terminal');\n")
-	sb.WriteString("  let idx = 0;\n")
-	sb.WriteString("  const LINE_HEIGHT = 17.5;\n")
-	sb.WriteString("\n")
-	sb.WriteString("  function update() {\n")
-	sb.WriteString("    const frame = frames[idx];\n")
-	sb.WriteString("    term.innerHTML = frame.c;\n")
-	sb.WriteString("    term.style.marginTop = (frame.o * LINE_HEIGHT) + 'px';\n")
-	sb.WriteString("    const delay = frame.d || 50;\n")
-	sb.WriteString("    idx = (idx + 1) % frames.length;\n")
-	sb.WriteString("    setTimeout(update, delay);\n")
-	sb.WriteString("  }\n")
-	sb.WriteString("  if (frames.length > 0) update();\n")
-	sb.WriteString("</script>\n")
-	sb.WriteString("</body>\n</html>\n")
-
-	fmt.Print(sb.String())
+// close shows the cursor again
+func (p *terminalplayer) close() {
+	if p.started {
+		io.WriteString(p.w, "\033[?25h")
+	}
 }
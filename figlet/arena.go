@@ -0,0 +1,104 @@
+package figlet
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Arena blocks start small, so small fonts stay small, and double up to a limit
+const (
+	glypharenamin = 256
+	glypharenamax = 16 << 10
+)
+
+// glypharena stores the glyph rows of a font in large shared blocks instead
+// of allocating every row separately. Identical rows, common in big Unicode
+// fonts, are interned and stored once. Rows are handed out with their
+// capacity capped, so appending to one never overwrites its neighbours.
+type glypharena struct {
+	runes []rune            // free space in the current rune block
+	rows  [][]rune          // free space in the current row block
+	nodes []FCharNode       // free space in the current character block
+	seen  map[string][]rune // interned rows keyed by their text in the font file
+	line  []byte            // buffer for reading font file lines
+	buf   []rune            // buffer for decoding a line
+	size  int               // size of the next rune block
+}
+
+func newglypharena() *glypharena {
+	return &glypharena{
+		seen: make(map[string][]rune),
+		line: make([]byte, MAXLEN+1),
+	}
+}
+
+// char returns a new character node with empty row slots, linked before next
+func (a *glypharena) char(ord rune, height int, next *FCharNode) *FCharNode {
+	if len(a.nodes) == 0 {
+		a.nodes = make([]FCharNode, 128)
+	}
+	node := &a.nodes[0]
+	a.nodes = a.nodes[1:]
+	node.ord = ord
+	node.thechar = a.glyph(height)
+	node.next = next
+	return node
+}
+
+// glyph returns the row slots for a character of the given height
+func (a *glypharena) glyph(height int) [][]rune {
+	if len(a.rows) < height {
+		a.rows = make([][]rune, max(height, 512))
+	}
+	g := a.rows[:height:height]
+	a.rows = a.rows[height:]
+	return g
+}
+
+// row returns the glyph row for a line of the font file, with trailing
+// whitespace and endmarks removed
+func (a *glypharena) row(line []byte) []rune {
+	a.buf = a.buf[:0]
+	for i := 0; i < len(line); {
+		r, size := utf8.DecodeRune(line[i:])
+		a.buf = append(a.buf, r)
+		i += size
+	}
+
+	// Remove trailing spaces, then the endmarks
+	k := len(a.buf) - 1
+	for k >= 0 && unicode.IsSpace(a.buf[k]) {
+		k--
+	}
+	if k >= 0 {
+		endchar := a.buf[k]
+		for k >= 0 && a.buf[k] == endchar {
+			k--
+		}
+	}
+	n := k + 1
+
+	end := 0
+	for i := 0; i < n; i++ {
+		_, size := utf8.DecodeRune(line[end:])
+		end += size
+	}
+	if r, ok := a.seen[string(line[:end])]; ok {
+		return r
+	}
+	r := a.alloc(n)
+	copy(r, a.buf)
+	a.seen[string(line[:end])] = r
+	return r
+}
+
+// alloc returns space for a row of n runes
+func (a *glypharena) alloc(n int) []rune {
+	if len(a.runes) < n {
+		a.size = min(max(2*a.size, glypharenamin), glypharenamax)
+		a.runes = make([]rune, max(n, a.size))
+	}
+	r := a.runes[:n:n]
+	a.runes = a.runes[n:]
+	return r
+}
@@ -0,0 +1,335 @@
+package figlet
+
+import (
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// BadgeStyle selects the look of a badge drawn by RenderBadge
+type BadgeStyle int
+
+const (
+	// BadgeFlat draws round corners with a soft gradient, like most README
+	// badges
+	BadgeFlat BadgeStyle = iota
+	// BadgeFlatSquare draws square corners without a gradient
+	BadgeFlatSquare
+	// BadgePlastic draws round corners with a glossy gradient
+	BadgePlastic
+)
+
+// badgestyles lists every style with its name, the radius of its corners
+// in pixels, and the stops of the gradient drawn over it in SVG
+var badgestyles = []struct {
+	style  BadgeStyle
+	name   string
+	radius int
+	stops  string
+}{
+	{BadgeFlat, "flat", 3, `<stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/>`},
+	{BadgeFlatSquare, "flat-square", 0, ""},
+	{BadgePlastic, "plastic", 4, `<stop offset="0" stop-color="#fff" stop-opacity=".7"/><stop offset=".1" stop-color="#aaa" stop-opacity=".1"/><stop offset=".9" stop-opacity=".3"/><stop offset="1" stop-opacity=".5"/>`},
+}
+
+// Badge colors used when none are set
+var (
+	badgelabelcolor   = TrueColor{0x55, 0x55, 0x55}
+	badgemessagecolor = TrueColor{0x44, 0xcc, 0x11}
+)
+
+// badgelineheight is the height of a row of art in a badge, in pixels,
+// when neither Height nor MaxWidth scale it
+const badgelineheight = 8
+
+// BadgeOptions describes a badge drawn by RenderBadge
+type BadgeOptions struct {
+	Style BadgeStyle
+	// Label is rendered on the left part of the badge, such as "build";
+	// empty draws the message alone
+	Label string
+	// Color is the background of the message; nil means green
+	Color Color
+	// LabelColor is the background of the label; nil means dark gray
+	LabelColor Color
+	// Height is the height of the badge in pixels, which the art is scaled
+	// to fit. 0 draws every row of art 8 pixels tall in SVG, or at the size
+	// of the bitmap font in PNG, which is never drawn smaller.
+	Height int
+	// MaxWidth is the widest the badge may be in pixels; wider art is
+	// scaled down, in PNG down to the size of the bitmap font. 0 means no
+	// limit.
+	MaxWidth int
+}
+
+// ListBadgeStyles returns the badge style names accepted by ParseBadgeStyle
+func ListBadgeStyles() []string {
+	names := make([]string, len(badgestyles))
+	for i, b := range badgestyles {
+		names[i] = b.name
+	}
+	return names
+}
+
+// ParseBadgeStyle returns the badge style with the given name
+func ParseBadgeStyle(name string) (BadgeStyle, error) {
+	for _, b := range badgestyles {
+		if strings.EqualFold(name, b.name) {
+			return b.style, nil
+		}
+	}
+	return BadgeFlat, fmt.Errorf("invalid badge style: %s (valid: %s)", name, strings.Join(ListBadgeStyles(), ", "))
+}
+
+// String returns the name of the badge style
+func (s BadgeStyle) String() string {
+	for _, b := range badgestyles {
+		if b.style == s {
+			return b.name
+		}
+	}
+	return "flat"
+}
+
+// RenderBadge writes a README badge, a label and a message rendered in the
+// current font on colored backgrounds, as an SVG or PNG image. The badge is
+// as wide as the art, scaled to fit Height and MaxWidth. Text is white
+// unless colors are set. Options apply to this call only, as with
+// RenderString.
+func (cfg *Config) RenderBadge(w io.Writer, message string, badge BadgeOptions, format Format, opts ...RenderOption) error {
+	if format != FormatSVG && format != FormatPNG {
+		return fmt.Errorf("badges can only be written as svg or png, not %s", format)
+	}
+	return cfg.apply(opts, func() error {
+		var label []outputrow
+		if badge.Label != "" {
+			label = badgerows(cfg.layoutrows(cfg.compose(badge.Label)))
+		}
+		rows := badgerows(cfg.layoutrows(cfg.compose(message)))
+		if format == FormatPNG {
+			return png.Encode(w, cfg.badgeimage(label, rows, badge))
+		}
+		title := message
+		if badge.Label != "" {
+			title = badge.Label + ": " + message
+		}
+		_, err := io.WriteString(w, cfg.badgesvg(title, label, rows, badge))
+		return err
+	})
+}
+
+// badgerows drops the margin that justification puts before laid out rows,
+// so a badge is only as wide as its art
+func badgerows(rows []outputrow) []outputrow {
+	margin := -1
+	for _, row := range rows {
+		if margin < 0 || row.pad < margin {
+			margin = row.pad
+		}
+	}
+	out := make([]outputrow, len(rows))
+	for n, row := range rows {
+		out[n] = row.flatten(margin)
+	}
+	return out
+}
+
+// badgecolors returns the background of the label and of the message
+func badgecolors(badge BadgeOptions) (label, message TrueColor) {
+	label, message = badgelabelcolor, badgemessagecolor
+	if tc, ok := truecolor(badge.LabelColor); ok {
+		label = tc
+	}
+	if tc, ok := truecolor(badge.Color); ok {
+		message = tc
+	}
+	return label, message
+}
+
+// badgesize returns the number of rows of a badge, the width of its label
+// and of its message in cells, counting a blank cell on either side
+func badgesize(label, message []outputrow) (lines, labelcols, messagecols int) {
+	lines = max(len(label), len(message))
+	if len(label) > 0 {
+		labelcols = blockwidth(label) + 2
+	}
+	return lines, labelcols, blockwidth(message) + 2
+}
+
+// badgesvg draws a badge as an SVG document. Rows are drawn in SVG cells
+// scaled to the height of a row of the badge; half a row of padding goes
+// above and below the art. The title is read by screen readers.
+func (cfg *Config) badgesvg(title string, label, message []outputrow, badge BadgeOptions) string {
+	lines, labelcols, messagecols := badgesize(label, message)
+	lineheight := float64(badgelineheight)
+	if badge.Height > 0 {
+		lineheight = float64(badge.Height) / float64(lines+1)
+	}
+	if width := float64(labelcols+messagecols) * lineheight / 2; badge.MaxWidth > 0 && width > float64(badge.MaxWidth) {
+		lineheight *= float64(badge.MaxWidth) / width
+	}
+	cell := lineheight / 2
+	width := math.Ceil(float64(labelcols+messagecols) * cell)
+	height := math.Ceil(float64(lines+1) * lineheight)
+	if badge.Height > 0 {
+		height = float64(badge.Height)
+	}
+	style := badgestyles[0]
+	for _, b := range badgestyles {
+		if b.style == badge.Style {
+			style = b
+		}
+	}
+	labelbg, messagebg := badgecolors(badge)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%s\" height=\"%s\" viewBox=\"0 0 %s %s\">\n",
+		svgnumber(width), svgnumber(height), svgnumber(width), svgnumber(height))
+	sb.WriteString("<title>")
+	xml.EscapeText(&sb, []byte(title))
+	sb.WriteString("</title>\n")
+	if style.stops != "" {
+		fmt.Fprintf(&sb, "<linearGradient id=\"s\" x2=\"0\" y2=\"100%%\">%s</linearGradient>\n", style.stops)
+	}
+	fmt.Fprintf(&sb, "<clipPath id=\"r\"><rect width=\"%s\" height=\"%s\" rx=\"%d\" fill=\"#fff\"/></clipPath>\n",
+		svgnumber(width), svgnumber(height), style.radius)
+	sb.WriteString("<g clip-path=\"url(#r)\">\n")
+	labelwidth := math.Round(float64(labelcols) * cell)
+	if labelcols > 0 {
+		fmt.Fprintf(&sb, "<rect width=\"%s\" height=\"%s\" fill=\"rgb(%d,%d,%d)\"/>\n",
+			svgnumber(labelwidth), svgnumber(height), labelbg.R, labelbg.G, labelbg.B)
+	}
+	fmt.Fprintf(&sb, "<rect x=\"%s\" width=\"%s\" height=\"%s\" fill=\"rgb(%d,%d,%d)\"/>\n",
+		svgnumber(labelwidth), svgnumber(width-labelwidth), svgnumber(height), messagebg.R, messagebg.G, messagebg.B)
+	if style.stops != "" {
+		fmt.Fprintf(&sb, "<rect width=\"%s\" height=\"%s\" fill=\"url(#s)\"/>\n", svgnumber(width), svgnumber(height))
+	}
+	sb.WriteString("</g>\n")
+
+	scale := lineheight / svglineheight
+	part := func(rows []outputrow, left float64) {
+		top := (height - float64(len(rows))*lineheight) / 2
+		fmt.Fprintf(&sb, "<g fill=\"#fff\" font-family=\"monospace\" font-size=\"%d\" xml:space=\"preserve\" transform=\"translate(%s %s) scale(%s)\">\n",
+			svgfontsize, svgnumber(left+cell), svgnumber(top), svgnumber(scale))
+		cfg.svgtext(&sb, rows)
+		sb.WriteString("</g>\n")
+	}
+	if labelcols > 0 {
+		part(label, 0)
+	}
+	part(message, labelwidth)
+	sb.WriteString("</svg>\n")
+	return sb.String()
+}
+
+// svgnumber formats a length with at most three decimals
+func svgnumber(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1000)/1000, 'f', -1, 64)
+}
+
+// badgeimage draws a badge with the built-in bitmap font, in whole
+// multiples of its cell size. PNG badges have no gradient.
+func (cfg *Config) badgeimage(label, message []outputrow, badge BadgeOptions) *image.Paletted {
+	lines, labelcols, messagecols := badgesize(label, message)
+	scale := 1
+	if badge.Height > 0 {
+		scale = max(1, badge.Height/((lines+1)*cellheight))
+	}
+	for scale > 1 && badge.MaxWidth > 0 && (labelcols+messagecols)*cellwidth*scale > badge.MaxWidth {
+		scale--
+	}
+	width := (labelcols + messagecols) * cellwidth * scale
+	height := max(badge.Height, (lines+1)*cellheight*scale)
+	labelbg, messagebg := badgecolors(badge)
+	palette := color.Palette{color.Transparent,
+		color.RGBA{uint8(labelbg.R), uint8(labelbg.G), uint8(labelbg.B), 0xff},
+		color.RGBA{uint8(messagebg.R), uint8(messagebg.G), uint8(messagebg.B), 0xff},
+		color.White}
+	img := image.NewPaletted(image.Rect(0, 0, width, height), palette)
+
+	radius := 0
+	for _, b := range badgestyles {
+		if b.style == badge.Style {
+			radius = b.radius * scale
+		}
+	}
+	labelwidth := labelcols * cellwidth * scale
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if badgecorner(x, y, width, height, radius) {
+				continue
+			}
+			bg := uint8(2)
+			if x < labelwidth {
+				bg = 1
+			}
+			img.SetColorIndex(x, y, bg)
+		}
+	}
+
+	part := func(rows []outputrow, left int) {
+		top := (height - len(rows)*cellheight*scale) / 2
+		for y, row := range rows {
+			for x, r := range row.cells {
+				if cfg.isblank(r) {
+					continue
+				}
+				ink := uint8(3)
+				if tc, ok := cfg.cellcolor(row, y, x); ok {
+					ink = inkindex(img, tc)
+				}
+				glyph := bitmapglyph(cfg.cellrune(r))
+				for gy, bits := range glyph {
+					for gx := 0; gx < 8; gx++ {
+						if bits>>gx&1 == 0 {
+							continue
+						}
+						px, py := left+(x+1)*cellwidth*scale+gx*scale, top+y*cellheight*scale+2*gy*scale
+						for dy := 0; dy < 2*scale; dy++ {
+							for dx := 0; dx < scale; dx++ {
+								img.SetColorIndex(px+dx, py+dy, ink)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	if labelcols > 0 {
+		part(label, 0)
+	}
+	part(message, labelwidth)
+	return img
+}
+
+// badgecorner reports whether a pixel lies outside the round corners of a
+// badge
+func badgecorner(x, y, width, height, radius int) bool {
+	if radius == 0 {
+		return false
+	}
+	cx, cy := -1, -1
+	switch {
+	case x < radius:
+		cx = radius
+	case x >= width-radius:
+		cx = width - radius - 1
+	}
+	switch {
+	case y < radius:
+		cy = radius
+	case y >= height-radius:
+		cy = height - radius - 1
+	}
+	if cx < 0 || cy < 0 {
+		return false
+	}
+	dx, dy := x-cx, y-cy
+	return dx*dx+dy*dy > radius*radius
+}
@@ -0,0 +1,141 @@
+package figlet
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BorderStyle selects the box drawn around the output
+type BorderStyle int
+
+const (
+	// BorderNone draws no box
+	BorderNone BorderStyle = iota
+	// BorderSingle draws a box with single lines
+	BorderSingle
+	// BorderDouble draws a box with double lines
+	BorderDouble
+	// BorderRound draws a box with single lines and round corners
+	BorderRound
+	// BorderHeavy draws a box with heavy lines
+	BorderHeavy
+	// BorderASCII draws a box with + corners, - edges and | sides, for
+	// terminals and files without box drawing characters
+	BorderASCII
+)
+
+// borders lists every style with its name and the characters drawing it:
+// the top left corner, edge and top right corner, the bottom ones, and the
+// left and right sides
+var borders = []struct {
+	style       BorderStyle
+	name        string
+	top, bottom string
+	sides       [2]rune
+}{
+	{BorderSingle, "single", "┌─┐", "└─┘", [2]rune{'│', '│'}},
+	{BorderDouble, "double", "╔═╗", "╚═╝", [2]rune{'║', '║'}},
+	{BorderRound, "round", "╭─╮", "╰─╯", [2]rune{'│', '│'}},
+	{BorderHeavy, "heavy", "┏━┓", "┗━┛", [2]rune{'┃', '┃'}},
+	{BorderASCII, "ascii", "+-+", "+-+", [2]rune{'|', '|'}},
+}
+
+// WithBorder draws a box around the output, one space away from the art.
+// Colors and every output format are kept, and the box stays where
+// justification put the art.
+func WithBorder(style BorderStyle) Option {
+	return func(cfg *Config) {
+		cfg.Border = style
+	}
+}
+
+// ListBorderStyles returns the border style names accepted by
+// ParseBorderStyle
+func ListBorderStyles() []string {
+	names := make([]string, len(borders))
+	for i, b := range borders {
+		names[i] = b.name
+	}
+	return names
+}
+
+// ParseBorderStyle returns the border style with the given name
+func ParseBorderStyle(name string) (BorderStyle, error) {
+	for _, b := range borders {
+		if strings.EqualFold(name, b.name) {
+			return b.style, nil
+		}
+	}
+	return BorderNone, fmt.Errorf("invalid border style: %s (valid: %s)", name, strings.Join(ListBorderStyles(), ", "))
+}
+
+// String returns the name of the border style, or "none"
+func (s BorderStyle) String() string {
+	for _, b := range borders {
+		if b.style == s {
+			return b.name
+		}
+	}
+	return "none"
+}
+
+// border returns laid out rows drawn inside the configured box. Art cells
+// keep their color index; the box and the spaces in it are never colored.
+func (cfg *Config) border(rows []outputrow) []outputrow {
+	style := -1
+	for i, b := range borders {
+		if b.style == cfg.Border {
+			style = i
+		}
+	}
+	if style < 0 || len(rows) == 0 {
+		return rows
+	}
+	b := borders[style]
+
+	// Keep the alignment of the rows, without the margin before them all
+	margin := -1
+	for _, row := range rows {
+		if margin < 0 || row.pad < margin {
+			margin = row.pad
+		}
+	}
+	width := 0
+	for _, row := range rows {
+		width = max(width, row.pad-margin+cellswidth(row.cells))
+	}
+
+	edge := func(s string) outputrow {
+		r := []rune(s)
+		cells := append([]rune{r[0]}, []rune(strings.Repeat(string(r[1]), width+2))...)
+		return plainrow(append(cells, r[2]))
+	}
+	out := []outputrow{edge(b.top)}
+	for _, row := range rows {
+		row = row.flatten(margin)
+		cells := append([]rune{b.sides[0], ' '}, row.cells...)
+		index := append([]int{nocolor, nocolor}, row.index...)
+		for n := cellswidth(cells); n < width+2; n++ {
+			cells, index = append(cells, ' '), append(index, nocolor)
+		}
+		cells, index = append(cells, ' ', b.sides[1]), append(index, nocolor, nocolor)
+		out = append(out, outputrow{cells: cells, index: index, baseline: row.baseline})
+	}
+	out = append(out, edge(b.bottom))
+
+	// The box is two columns wider than the art on each side
+	pad := max(0, margin-2)
+	for n := range out {
+		out[n].pad = pad
+	}
+	if cfg.Pad != PadNone {
+		boxwidth := pad + width + 4
+		if cfg.Pad == PadOutputWidth {
+			boxwidth = max(boxwidth, cfg.Outputwidth-1)
+		}
+		for n := range out {
+			out[n].fill = max(0, boxwidth-pad-cellswidth(out[n].cells))
+		}
+	}
+	return out
+}
@@ -0,0 +1,244 @@
+package figlet
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BubbleStyle selects the shape of the speech bubble drawn around the output
+type BubbleStyle int
+
+const (
+	// BubbleNone draws no bubble
+	BubbleNone BubbleStyle = iota
+	// BubbleSay draws a cowsay bubble: < > around a single row, / \ and |
+	// around more, with a \ tail
+	BubbleSay
+	// BubbleThink draws a cowthink bubble, with ( ) sides and an o tail
+	BubbleThink
+	// BubbleRound draws a box with round corners and a ╲ tail
+	BubbleRound
+)
+
+// bubbles lists every style with its name and the characters drawing it:
+// the top and bottom edges, the left and right sides of a single row, of
+// the first, middle and last rows of a taller bubble, and the tail
+var bubbles = []struct {
+	style                   BubbleStyle
+	name                    string
+	top, bottom             string
+	single, first, mid, end [2]rune
+	tail                    rune
+}{
+	{BubbleSay, "say", " _ ", " - ", [2]rune{'<', '>'}, [2]rune{'/', '\\'}, [2]rune{'|', '|'}, [2]rune{'\\', '/'}, '\\'},
+	{BubbleThink, "think", " _ ", " - ", [2]rune{'(', ')'}, [2]rune{'(', ')'}, [2]rune{'(', ')'}, [2]rune{'(', ')'}, 'o'},
+	{BubbleRound, "round", "╭─╮", "╰─╯", [2]rune{'│', '│'}, [2]rune{'│', '│'}, [2]rune{'│', '│'}, [2]rune{'│', '│'}, '╲'},
+}
+
+// SpeechBubble describes the bubble drawn around the output
+type SpeechBubble struct {
+	Style BubbleStyle
+	// Text is plain text shown under the art, wrapped to the width of the
+	// bubble. It is never colored. The font's hardblank character prints
+	// as a space, as in the art.
+	Text string
+	// Tail is the number of rows the tail hangs below the bubble. Zero
+	// means 2; a negative value draws no tail.
+	Tail int
+}
+
+// nocolor marks a cell of a composed row that is never colored, unlike -1,
+// which falls back to positional colors
+const nocolor = -2
+
+// bubbletailcolumn is the column of the first row of the tail
+const bubbletailcolumn = 4
+
+// WithSpeechBubble draws the output inside a cowsay-like speech bubble.
+// Unlike piping figlet into cowsay, colors and every output format are
+// kept. The bubble is drawn at the left margin; justification only aligns
+// the rows inside it.
+func WithSpeechBubble(style BubbleStyle) Option {
+	return func(cfg *Config) {
+		cfg.Bubble.Style = style
+	}
+}
+
+// WithBubbleText sets plain text shown under the art inside the bubble
+func WithBubbleText(text string) Option {
+	return func(cfg *Config) {
+		cfg.Bubble.Text = text
+	}
+}
+
+// WithBubbleTail sets how many rows the bubble's tail hangs; a negative
+// value draws no tail
+func WithBubbleTail(rows int) Option {
+	return func(cfg *Config) {
+		cfg.Bubble.Tail = rows
+	}
+}
+
+// ListBubbleStyles returns the bubble style names accepted by ParseBubbleStyle
+func ListBubbleStyles() []string {
+	names := make([]string, len(bubbles))
+	for i, b := range bubbles {
+		names[i] = b.name
+	}
+	return names
+}
+
+// ParseBubbleStyle returns the bubble style with the given name
+func ParseBubbleStyle(name string) (BubbleStyle, error) {
+	for _, b := range bubbles {
+		if strings.EqualFold(name, b.name) {
+			return b.style, nil
+		}
+	}
+	return BubbleNone, fmt.Errorf("invalid bubble style: %s (valid: %s)", name, strings.Join(ListBubbleStyles(), ", "))
+}
+
+// String returns the name of the bubble style, or "none"
+func (s BubbleStyle) String() string {
+	for _, b := range bubbles {
+		if b.style == s {
+			return b.name
+		}
+	}
+	return "none"
+}
+
+// bubble returns laid out rows drawn inside the configured speech bubble.
+// Art cells keep their color index; the bubble, the text and the spaces
+// around them are never colored.
+func (cfg *Config) bubble(rows []outputrow) []outputrow {
+	style := -1
+	for i, b := range bubbles {
+		if b.style == cfg.Bubble.Style {
+			style = i
+		}
+	}
+	if style < 0 {
+		return rows
+	}
+	b := bubbles[style]
+
+	// Keep the alignment of the rows, without the margin before them all
+	margin := -1
+	for _, row := range rows {
+		if margin < 0 || row.pad < margin {
+			margin = row.pad
+		}
+	}
+	width := 0
+	for _, row := range rows {
+		width = max(width, row.pad-margin+cellswidth(row.cells))
+	}
+	textwidth := width
+	if textwidth == 0 {
+		textwidth = 40
+	}
+	text := wrapbubbletext(cfg.Bubble.Text, textwidth)
+	for _, line := range text {
+		width = max(width, cellswidth(line))
+	}
+
+	var inner []outputrow
+	for _, row := range rows {
+		inner = append(inner, row.flatten(margin))
+	}
+	for _, line := range text {
+		index := make([]int, len(line))
+		for i := range index {
+			index[i] = nocolor
+		}
+		inner = append(inner, outputrow{cells: line, index: index})
+	}
+
+	edge := func(s string) outputrow {
+		r := []rune(s)
+		cells := append([]rune{r[0]}, []rune(strings.Repeat(string(r[1]), width+2))...)
+		if r[2] != ' ' {
+			cells = append(cells, r[2])
+		}
+		return plainrow(cells)
+	}
+	out := []outputrow{edge(b.top)}
+	for n, row := range inner {
+		sides := b.mid
+		switch {
+		case len(inner) == 1:
+			sides = b.single
+		case n == 0:
+			sides = b.first
+		case n == len(inner)-1:
+			sides = b.end
+		}
+		cells := append([]rune{sides[0], ' '}, row.cells...)
+		index := append([]int{nocolor, nocolor}, row.index...)
+		for n := cellswidth(cells); n < width+2; n++ {
+			cells, index = append(cells, ' '), append(index, nocolor)
+		}
+		cells, index = append(cells, ' ', sides[1]), append(index, nocolor, nocolor)
+		out = append(out, outputrow{cells: cells, index: index, baseline: row.baseline})
+	}
+	out = append(out, edge(b.bottom))
+
+	tail := cfg.Bubble.Tail
+	if tail == 0 {
+		tail = 2
+	}
+	for n := 0; n < tail; n++ {
+		out = append(out, plainrow([]rune(strings.Repeat(" ", bubbletailcolumn+n)+string(b.tail))))
+	}
+
+	if cfg.Pad != PadNone {
+		bubblewidth := width + 4
+		if cfg.Pad == PadOutputWidth {
+			bubblewidth = max(bubblewidth, cfg.Outputwidth-1)
+		}
+		for n := range out {
+			out[n].fill = max(0, bubblewidth-cellswidth(out[n].cells))
+		}
+	}
+	return out
+}
+
+// plainrow returns a row of cells that are never colored
+func plainrow(cells []rune) outputrow {
+	index := make([]int, len(cells))
+	for i := range index {
+		index[i] = nocolor
+	}
+	return outputrow{cells: cells, index: index}
+}
+
+// wrapbubbletext splits text into lines of at most width runes, breaking
+// at spaces when it can. Newlines in the text start new lines.
+func wrapbubbletext(text string, width int) [][]rune {
+	if text == "" {
+		return nil
+	}
+	var lines [][]rune
+	for _, paragraph := range strings.Split(text, "\n") {
+		var line []rune
+		for _, word := range strings.Fields(paragraph) {
+			w := []rune(word)
+			if len(line) > 0 && cellswidth(line)+1+cellswidth(w) > width {
+				lines = append(lines, line)
+				line = nil
+			}
+			if len(line) > 0 {
+				line = append(line, ' ')
+			}
+			// A word longer than a line is broken, always on a line of its own
+			for len(w) > width {
+				lines = append(lines, w[:width])
+				w = w[width:]
+			}
+			line = append(line, w...)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,170 @@
+package figlet
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// CaseMode selects a case transformation applied to the input
+type CaseMode int
+
+const (
+	// CaseNone leaves the input unchanged (default)
+	CaseNone CaseMode = iota
+	// CaseUpper converts every letter to uppercase
+	CaseUpper
+	// CaseLower converts every letter to lowercase
+	CaseLower
+	// CaseTitle uppercases the first letter of each word and lowercases the rest
+	CaseTitle
+)
+
+// WithCase transforms the case of each input character before it is
+// translated and looked up in the font
+func WithCase(mode CaseMode) Option {
+	return func(cfg *Config) {
+		cfg.Case = mode
+	}
+}
+
+// applycase transforms c according to cfg.Case. inword reports whether the
+// previous character was part of a word, for CaseTitle.
+func (cfg *Config) applycase(c rune, inword bool) rune {
+	switch cfg.Case {
+	case CaseUpper:
+		return unicode.ToUpper(c)
+	case CaseLower:
+		return unicode.ToLower(c)
+	case CaseTitle:
+		if inword {
+			return unicode.ToLower(c)
+		}
+		return unicode.ToTitle(c)
+	}
+	return c
+}
+
+// iswordchar reports whether c continues a word for CaseTitle
+func iswordchar(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '\''
+}
+
+// Built-in character maps selectable with WithCharMap. They are programmatic
+// equivalents of the common control files, so no .flc file is needed.
+const (
+	// CharMapLatin1 reads the input as ISO 8859-1, FIGlet's default encoding
+	CharMapLatin1 = "latin1"
+	// CharMapUTF8 reads the input as UTF-8 and replaces characters missing
+	// from the font with their closest Latin-1 or ASCII equivalent
+	CharMapUTF8 = "utf8"
+	// CharMapUppercase maps lowercase ASCII and Latin-1 letters to uppercase,
+	// like upper.flc
+	CharMapUppercase = "uppercase"
+	// CharMapQuotes replaces typographic quotes with straight ones
+	CharMapQuotes = "quotes"
+)
+
+// ListCharMaps returns the names of the built-in character maps
+func ListCharMaps() []string {
+	return []string{CharMapLatin1, CharMapUTF8, CharMapUppercase, CharMapQuotes}
+}
+
+// WithCharMap applies built-in character maps, in order, after any control files
+func WithCharMap(names ...string) Option {
+	return func(cfg *Config) {
+		cfg.CharMaps = append(cfg.CharMaps, names...)
+	}
+}
+
+// fallbacks maps characters to a simpler equivalent. Letters map to
+// Latin-1 or ASCII, punctuation to ASCII.
+var fallbacks = map[rune]rune{
+	'À': 'A', 'Á': 'A', 'Â': 'A', 'Ã': 'A', 'Ä': 'A', 'Å': 'A', 'Æ': 'A', 'Ç': 'C',
+	'È': 'E', 'É': 'E', 'Ê': 'E', 'Ë': 'E', 'Ì': 'I', 'Í': 'I', 'Î': 'I', 'Ï': 'I',
+	'Ð': 'D', 'Ñ': 'N', 'Ò': 'O', 'Ó': 'O', 'Ô': 'O', 'Õ': 'O', 'Ö': 'O', 'Ø': 'O',
+	'Ù': 'U', 'Ú': 'U', 'Û': 'U', 'Ü': 'U', 'Ý': 'Y', 'Þ': 'T', 'ß': 's', 'à': 'a',
+	'á': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a', 'å': 'a', 'æ': 'a', 'ç': 'c', 'è': 'e',
+	'é': 'e', 'ê': 'e', 'ë': 'e', 'ì': 'i', 'í': 'i', 'î': 'i', 'ï': 'i', 'ð': 'd',
+	'ñ': 'n', 'ò': 'o', 'ó': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o', 'ø': 'o', 'ù': 'u',
+	'ú': 'u', 'û': 'u', 'ü': 'u', 'ý': 'y', 'þ': 't', 'ÿ': 'y', 'Ā': 'A', 'ā': 'a',
+	'Ă': 'A', 'ă': 'a', 'Ą': 'A', 'ą': 'a', 'Ć': 'C', 'ć': 'c', 'Ĉ': 'C', 'ĉ': 'c',
+	'Ċ': 'C', 'ċ': 'c', 'Č': 'C', 'č': 'c', 'Ď': 'D', 'ď': 'd', 'Đ': 'D', 'đ': 'd',
+	'Ē': 'E', 'ē': 'e', 'Ĕ': 'E', 'ĕ': 'e', 'Ė': 'E', 'ė': 'e', 'Ę': 'E', 'ę': 'e',
+	'Ě': 'E', 'ě': 'e', 'Ĝ': 'G', 'ĝ': 'g', 'Ğ': 'G', 'ğ': 'g', 'Ġ': 'G', 'ġ': 'g',
+	'Ģ': 'G', 'ģ': 'g', 'Ĥ': 'H', 'ĥ': 'h', 'Ħ': 'H', 'ħ': 'h', 'Ĩ': 'I', 'ĩ': 'i',
+	'Ī': 'I', 'ī': 'i', 'Ĭ': 'I', 'ĭ': 'i', 'Į': 'I', 'į': 'i', 'İ': 'I', 'ı': 'i',
+	'Ĳ': 'I', 'ĳ': 'i', 'Ĵ': 'J', 'ĵ': 'j', 'Ķ': 'K', 'ķ': 'k', 'ĸ': 'k', 'Ĺ': 'L',
+	'ĺ': 'l', 'Ļ': 'L', 'ļ': 'l', 'Ľ': 'L', 'ľ': 'l', 'Ŀ': 'L', 'ŀ': 'l', 'Ł': 'L',
+	'ł': 'l', 'Ń': 'N', 'ń': 'n', 'Ņ': 'N', 'ņ': 'n', 'Ň': 'N', 'ň': 'n', 'ŉ': 'n',
+	'Ŋ': 'N', 'ŋ': 'n', 'Ō': 'O', 'ō': 'o', 'Ŏ': 'O', 'ŏ': 'o', 'Ő': 'Ö', 'ő': 'ö',
+	'Œ': 'O', 'œ': 'o', 'Ŕ': 'R', 'ŕ': 'r', 'Ŗ': 'R', 'ŗ': 'r', 'Ř': 'R', 'ř': 'r',
+	'Ś': 'S', 'ś': 's', 'Ŝ': 'S', 'ŝ': 's', 'Ş': 'S', 'ş': 's', 'Š': 'S', 'š': 's',
+	'Ţ': 'T', 'ţ': 't', 'Ť': 'T', 'ť': 't', 'Ŧ': 'T', 'ŧ': 't', 'Ũ': 'U', 'ũ': 'u',
+	'Ū': 'U', 'ū': 'u', 'Ŭ': 'U', 'ŭ': 'u', 'Ů': 'U', 'ů': 'u', 'Ű': 'Ü', 'ű': 'ü',
+	'Ų': 'U', 'ų': 'u', 'Ŵ': 'W', 'ŵ': 'w', 'Ŷ': 'Y', 'ŷ': 'y', 'Ÿ': 'Y', 'Ź': 'Z',
+	'ź': 'z', 'Ż': 'Z', 'ż': 'z', 'Ž': 'Z', 'ž': 'z', 'ſ': 's',
+	'\u00a0': ' ', '\u2010': '-', '\u2011': '-', '\u2012': '-', '\u2013': '-', '\u2014': '-', '\u2015': '-',
+	'\u2018': '\'', '\u2019': '\'', '\u201a': '\'', '\u201b': '\'', '\u2032': '\'',
+	'\u201c': '"', '\u201d': '"', '\u201e': '"', '\u201f': '"', '\u2033': '"',
+	'\u2026': '.', '\u2022': '*', '\u00d7': 'x', '\u00f7': '/',
+}
+
+// quotes maps typographic quotes to straight quotes
+var quotes = map[rune]rune{
+	'\u2018': '\'', '\u2019': '\'', '\u201a': '\'', '\u201b': '\'', '\u2039': '\'', '\u203a': '\'',
+	'\u201c': '"', '\u201d': '"', '\u201e': '"', '\u201f': '"', '\u00ab': '"', '\u00bb': '"',
+}
+
+// loadcharmaps checks the configured character maps and applies the ones
+// that select an input encoding. It runs after the control files are read.
+func (cfg *Config) loadcharmaps() error {
+	for _, name := range cfg.CharMaps {
+		switch name {
+		case CharMapLatin1:
+			cfg.Multibyte = 0
+			cfg.gn = [4]rune{0, 0x80, 0, 0}
+			cfg.gndbl = [4]bool{}
+			cfg.gl = 0
+			cfg.gr = 1
+		case CharMapUTF8:
+			cfg.Multibyte = 2
+		case CharMapUppercase, CharMapQuotes:
+		default:
+			return fmt.Errorf("unknown character map: %s", name)
+		}
+	}
+	return nil
+}
+
+// charmap translates c with the configured character maps
+func (cfg *Config) charmap(c rune) rune {
+	for _, name := range cfg.CharMaps {
+		switch name {
+		case CharMapUTF8:
+			// Follow the fallbacks until the font has the character
+			for i := 0; i < 2 && !cfg.hasglyph(c); i++ {
+				r, ok := fallbacks[c]
+				if !ok {
+					break
+				}
+				c = r
+			}
+		case CharMapUppercase:
+			if (c >= 'a' && c <= 'z') || (c >= 0xe0 && c <= 0xfe && c != 0xf7) {
+				c -= 'a' - 'A'
+			} else if c == 0xff {
+				c = 'Y'
+			}
+		case CharMapQuotes:
+			if r, ok := quotes[c]; ok {
+				c = r
+			}
+		}
+	}
+	return c
+}
+
+// hasglyph reports whether the loaded font defines character c
+func (cfg *Config) hasglyph(c rune) bool {
+	return cfg.font != nil && cfg.font.hasglyph(c)
+}
@@ -0,0 +1,54 @@
+package figlet
+
+import "strings"
+
+// cirefvars lists, in the order they are tried, the environment variables
+// in which CI services give the tag or branch being built. Pull request
+// source branches come before the branch a pull request is merged into.
+var cirefvars = []string{
+	// GitHub Actions
+	"GITHUB_HEAD_REF", "GITHUB_REF_NAME", "GITHUB_REF",
+	// GitLab CI
+	"CI_COMMIT_TAG", "CI_MERGE_REQUEST_SOURCE_BRANCH_NAME", "CI_COMMIT_REF_NAME",
+	// CircleCI
+	"CIRCLE_TAG", "CIRCLE_BRANCH",
+	// Travis CI
+	"TRAVIS_TAG", "TRAVIS_PULL_REQUEST_BRANCH", "TRAVIS_BRANCH",
+	// Buildkite
+	"BUILDKITE_TAG", "BUILDKITE_BRANCH",
+	// Bitbucket Pipelines
+	"BITBUCKET_TAG", "BITBUCKET_BRANCH",
+	// Azure Pipelines
+	"SYSTEM_PULLREQUEST_SOURCEBRANCH", "BUILD_SOURCEBRANCH",
+	// Jenkins
+	"TAG_NAME", "CHANGE_BRANCH", "BRANCH_NAME", "GIT_BRANCH",
+}
+
+// CIRef returns the tag or branch a CI pipeline is building, read from
+// the environment variables of common CI services, such as GITHUB_REF on
+// GitHub Actions or CI_COMMIT_REF_NAME on GitLab, or "" when none is set.
+// Full refs lose their refs/heads/ or refs/tags/ prefix and Jenkins
+// branches their origin/ prefix. getenv is usually os.Getenv. The
+// `figlet git-banner` command renders it, so build logs get the same
+// header on every service.
+func CIRef(getenv func(string) string) string {
+	for _, name := range cirefvars {
+		ref := strings.TrimSpace(getenv(name))
+		if ref == "" {
+			continue
+		}
+		// GitHub sets GITHUB_REF to refs/pull/N/merge for pull requests,
+		// which names no branch
+		if strings.HasPrefix(ref, "refs/pull/") {
+			continue
+		}
+		for _, prefix := range []string{"refs/heads/", "refs/tags/"} {
+			ref = strings.TrimPrefix(ref, prefix)
+		}
+		if name == "GIT_BRANCH" {
+			ref = strings.TrimPrefix(ref, "origin/")
+		}
+		return ref
+	}
+	return ""
+}
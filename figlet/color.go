@@ -5,6 +5,9 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 )
 
 // Escape character for ANSI codes
@@ -33,6 +36,18 @@ var (
 	ColorWhite   = AnsiColor{37}
 )
 
+// ansinames maps the names of the eight ANSI colors to them
+var ansinames = map[string]AnsiColor{
+	"black":   ColorBlack,
+	"red":     ColorRed,
+	"green":   ColorGreen,
+	"yellow":  ColorYellow,
+	"blue":    ColorBlue,
+	"magenta": ColorMagenta,
+	"cyan":    ColorCyan,
+	"white":   ColorWhite,
+}
+
 // TrueColor represents a 24-bit RGB color
 type TrueColor struct {
 	R int
@@ -51,6 +66,15 @@ var tcfac = map[AnsiColor]TrueColor{
 	ColorMagenta: {177, 13, 201},
 	ColorCyan:    {105, 206, 245},
 	ColorWhite:   {255, 255, 255},
+	// The bright colors, which Quantize picks for Depth16
+	{90}: {170, 170, 170},
+	{91}: {255, 120, 110},
+	{92}: {190, 230, 120},
+	{93}: {255, 240, 110},
+	{94}: {90, 170, 255},
+	{95}: {225, 110, 240},
+	{96}: {170, 235, 255},
+	{97}: {255, 255, 255},
 }
 
 // getPrefix returns the prefix for TrueColor based on parser type
@@ -75,16 +99,21 @@ func (tc TrueColor) getSuffix(parser *OutputParser) string {
 	return ""
 }
 
-// NewTrueColorFromHexString creates a TrueColor from a hexadecimal string (e.g., "FF0000" or "#FF0000")
+// NewTrueColorFromHexString creates a TrueColor from a hexadecimal string (e.g., "FF0000", "#FF0000" or "#F00")
 func NewTrueColorFromHexString(hexStr string) (*TrueColor, error) {
 	// Remove # if present
 	if len(hexStr) > 0 && hexStr[0] == '#' {
 		hexStr = hexStr[1:]
 	}
 
+	// Expand the 3 digit shorthand, so F00 is FF0000
+	if len(hexStr) == 3 {
+		hexStr = string([]byte{hexStr[0], hexStr[0], hexStr[1], hexStr[1], hexStr[2], hexStr[2]})
+	}
+
 	// Must be 6 characters for RGB
 	if len(hexStr) != 6 {
-		return nil, errors.New("hex color must be 6 characters (e.g., 'FF0000' or '#FF0000')")
+		return nil, errors.New("hex color must be 3 or 6 characters (e.g., 'FF0000', '#FF0000' or '#F00')")
 	}
 
 	rgb, err := hex.DecodeString(hexStr)
@@ -103,6 +132,179 @@ func NewTrueColorFromHexString(hexStr string) (*TrueColor, error) {
 	}, nil
 }
 
+// ParseColor parses a color the way CSS writes it: hex ("#ff0000" or
+// "#f00", the # is optional), functional notation ("rgb(255, 0, 0)",
+// "rgb(100% 0% 0%)", "hsl(0, 100%, 50%)") or a CSS color name. Alpha
+// values are accepted and ignored. The eight ANSI names (black, red,
+// green, yellow, blue, magenta, cyan and white) return AnsiColor, so they
+// keep using the terminal's own palette; every other color is a TrueColor.
+func ParseColor(s string) (Color, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if ac, ok := ansinames[name]; ok {
+		return ac, nil
+	}
+	if tc, ok := cssnames[name]; ok {
+		return tc, nil
+	}
+	if open := strings.IndexByte(name, '('); open > 0 && strings.HasSuffix(name, ")") {
+		tc, err := parsefunctional(name[:open], name[open+1:len(name)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid color: %s: %v", s, err)
+		}
+		return tc, nil
+	}
+	tc, err := NewTrueColorFromHexString(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid color: %s", s)
+	}
+	return *tc, nil
+}
+
+// parsefunctional parses the arguments of rgb() or hsl(), separated by
+// commas or spaces, with an optional alpha after a comma or a slash
+func parsefunctional(fn, args string) (TrueColor, error) {
+	fields := strings.FieldsFunc(args, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '/' || r == '\t'
+	})
+	if len(fields) != 3 && len(fields) != 4 {
+		return TrueColor{}, fmt.Errorf("%s() needs 3 values", fn)
+	}
+	var v [3]float64
+	for i, field := range fields[:3] {
+		number := strings.TrimSuffix(field, "%")
+		if fn == "hsl" || fn == "hsla" {
+			number = strings.TrimSuffix(number, "deg")
+		}
+		f, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			return TrueColor{}, fmt.Errorf("bad value %q", field)
+		}
+		percent := strings.HasSuffix(field, "%")
+		switch fn {
+		case "rgb", "rgba":
+			if percent {
+				f = f * 255 / 100
+			}
+			v[i] = math.Max(0, math.Min(255, f))
+		case "hsl", "hsla":
+			if i > 0 {
+				// Saturation and lightness are percentages, with or without %
+				f = math.Max(0, math.Min(100, f)) / 100
+			}
+			v[i] = f
+		default:
+			return TrueColor{}, fmt.Errorf("unknown function %s()", fn)
+		}
+	}
+	if fn == "hsl" || fn == "hsla" {
+		return hsltorgb(v[0], v[1], v[2]), nil
+	}
+	return TrueColor{int(math.Round(v[0])), int(math.Round(v[1])), int(math.Round(v[2]))}, nil
+}
+
+// hsltorgb converts a hue in degrees and a saturation and lightness
+// between 0 and 1 to RGB
+func hsltorgb(h, s, l float64) TrueColor {
+	h = math.Mod(h, 360)
+	if h < 0 {
+		h += 360
+	}
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - c/2
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	channel := func(v float64) int {
+		return int(math.Round((v + m) * 255))
+	}
+	return TrueColor{channel(r), channel(g), channel(b)}
+}
+
+// NewTrueColorFromHSL creates a TrueColor from a hue in degrees and a
+// saturation and lightness between 0 and 1. Hues wrap around and the
+// other values are clamped.
+func NewTrueColorFromHSL(h, s, l float64) TrueColor {
+	return hsltorgb(h, clamp01(s), clamp01(l))
+}
+
+// NewTrueColorFromHSV creates a TrueColor from a hue in degrees and a
+// saturation and value between 0 and 1
+func NewTrueColorFromHSV(h, s, v float64) TrueColor {
+	s, v = clamp01(s), clamp01(v)
+	// Convert to HSL, which shares the hue
+	l := v * (1 - s/2)
+	if l > 0 && l < 1 {
+		s = (v - l) / math.Min(l, 1-l)
+	} else {
+		s = 0
+	}
+	return hsltorgb(h, s, l)
+}
+
+// HSL returns the hue of the color in degrees, from 0 up to 360, and its
+// saturation and lightness between 0 and 1
+func (tc TrueColor) HSL() (h, s, l float64) {
+	r, g, b := float64(tc.R)/255, float64(tc.G)/255, float64(tc.B)/255
+	hi, lo := math.Max(r, math.Max(g, b)), math.Min(r, math.Min(g, b))
+	l = (hi + lo) / 2
+	d := hi - lo
+	if d == 0 {
+		return 0, 0, l
+	}
+	s = d / (1 - math.Abs(2*l-1))
+	switch hi {
+	case r:
+		h = math.Mod((g-b)/d, 6)
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	h *= 60
+	if h < 0 {
+		h += 360
+	}
+	return h, s, l
+}
+
+// Lighten returns the color with its lightness raised by amount, from 0
+// to 1. Lighten(1) is always white.
+func (tc TrueColor) Lighten(amount float64) TrueColor {
+	h, s, l := tc.HSL()
+	return NewTrueColorFromHSL(h, s, l+amount)
+}
+
+// Darken returns the color with its lightness lowered by amount, from 0
+// to 1. Darken(1) is always black.
+func (tc TrueColor) Darken(amount float64) TrueColor {
+	return tc.Lighten(-amount)
+}
+
+// Rotate returns the color with its hue turned by degrees around the
+// color wheel. Rotate(180) gives the complementary color.
+func (tc TrueColor) Rotate(degrees float64) TrueColor {
+	h, s, l := tc.HSL()
+	return NewTrueColorFromHSL(h+degrees, s, l)
+}
+
+// clamp01 limits v to the range 0 to 1
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
 // getPrefix returns the prefix for AnsiColor based on parser type
 func (ac AnsiColor) getPrefix(parser *OutputParser) string {
 	switch parser.Name {
@@ -128,3 +330,62 @@ func (ac AnsiColor) getSuffix(parser *OutputParser) string {
 	}
 	return ""
 }
+
+// RGB returns the RGB value of a color, if it has one. ANSI colors give
+// the values the html parser uses for them.
+func RGB(c Color) (TrueColor, bool) {
+	return truecolor(c)
+}
+
+// truecolor returns the RGB value of a color, if it has one
+func truecolor(c Color) (TrueColor, bool) {
+	switch c := c.(type) {
+	case AnsiColor:
+		tc, ok := tcfac[c]
+		return tc, ok
+	case TrueColor:
+		return c, true
+	case Ansi256Color:
+		return c.RGB(), true
+	case *TrueColor:
+		if c != nil {
+			return *c, true
+		}
+	}
+	return TrueColor{}, false
+}
+
+// colorrun writes colored cells, letting neighboring cells with the same
+// markup share it: a run of red cells gets one <span> or one escape
+// sequence instead of one per cell. On a terminal a color replaces the
+// one before it, so colored runs follow each other without a reset and a
+// line only needs one, at its end.
+type colorrun struct {
+	prefix, suffix string
+}
+
+// write writes the text of a cell with the markup put around it
+func (r *colorrun) write(sb *strings.Builder, prefix, text, suffix string) {
+	if prefix != r.prefix || suffix != r.suffix {
+		if !(r.suffix == escape+"[0m" && setsforeground(prefix)) {
+			sb.WriteString(r.suffix)
+		}
+		sb.WriteString(prefix)
+		r.prefix, r.suffix = prefix, suffix
+	}
+	sb.WriteString(text)
+}
+
+// setsforeground reports whether the escape sequences of a colored cell
+// start by setting the foreground color, as those of AnsiColor and
+// TrueColor do. Text attributes come after it and are the same for every
+// cell of a render.
+func setsforeground(prefix string) bool {
+	return strings.HasPrefix(prefix, escape+"[0;") || strings.HasPrefix(prefix, escape+"[38;")
+}
+
+// end closes the open run, at the end of a line
+func (r *colorrun) end(sb *strings.Builder) {
+	sb.WriteString(r.suffix)
+	*r = colorrun{}
+}
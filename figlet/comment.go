@@ -0,0 +1,97 @@
+package figlet
+
+import (
+	"fmt"
+	"strings"
+)
+
+// commentsyntax is how a language writes a comment block: an optional
+// opening and closing line around lines that all start with line. Boxes
+// end each line with end and fill their top and bottom with fill.
+type commentsyntax struct {
+	names       []string
+	open, close string
+	line, end   string
+	fill        string
+}
+
+// commentsyntaxes lists the languages CommentBlock knows, first name first
+var commentsyntaxes = []commentsyntax{
+	{names: []string{"go", "js", "javascript", "ts", "typescript", "java", "rust", "swift", "kotlin"}, line: "// ", end: " //", fill: "/"},
+	{names: []string{"c", "cpp", "c++", "css"}, open: "/*", close: " */", line: " * ", end: " *", fill: "*"},
+	{names: []string{"python", "ruby", "perl", "r", "yaml", "toml"}, line: "# ", end: " #", fill: "#"},
+	{names: []string{"shell", "sh", "bash", "zsh", "make", "dockerfile"}, line: "# ", end: " #", fill: "#"},
+	{names: []string{"sql", "lua", "haskell", "ada"}, line: "-- ", end: " --", fill: "-"},
+	{names: []string{"html", "xml", "markdown"}, open: "<!--", close: "-->", line: "  ", end: "  ", fill: "-"},
+	{names: []string{"lisp", "clojure", "scheme", "asm"}, line: ";; ", end: " ;;", fill: ";"},
+	{names: []string{"tex", "latex", "erlang", "matlab"}, line: "% ", end: " %", fill: "%"},
+	{names: []string{"vim"}, line: `" `, end: ` "`, fill: `"`},
+}
+
+// ListCommentLanguages returns the main name of every language
+// CommentBlock can write a comment for
+func ListCommentLanguages() []string {
+	names := make([]string, len(commentsyntaxes))
+	for i, s := range commentsyntaxes {
+		names[i] = s.names[0]
+	}
+	return names
+}
+
+// CommentBlock wraps rendered art in a comment of the given language, such
+// as "go", "python", "c", "shell" or "sql", see ListCommentLanguages. Art
+// lines lose their trailing spaces, or, with box, are padded and framed by
+// the comment characters. Art that would end a block comment early is
+// refused.
+func CommentBlock(art, lang string, box bool) (string, error) {
+	var syntax *commentsyntax
+	for i, s := range commentsyntaxes {
+		for _, name := range s.names {
+			if strings.EqualFold(lang, name) {
+				syntax = &commentsyntaxes[i]
+			}
+		}
+	}
+	if syntax == nil {
+		return "", fmt.Errorf("invalid comment language: %s (valid: %s)", lang, strings.Join(ListCommentLanguages(), ", "))
+	}
+	if closing := strings.TrimSpace(syntax.close); closing != "" && strings.Contains(art, closing) {
+		return "", fmt.Errorf("the art contains %q, which ends a %s comment", closing, lang)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(art, "\n"), "\n")
+	width := 0
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+		width = max(width, DisplayWidth(lines[i]))
+	}
+	total := len(syntax.line) + width + len(syntax.end)
+
+	var sb strings.Builder
+	// edge writes the opening or closing line, which a box fills up to its
+	// width, keeping the comment token at its outer end
+	edge := func(s string, closing bool) {
+		if box {
+			token := strings.TrimSpace(s)
+			lead := s[:len(s)-len(strings.TrimLeft(s, " "))]
+			fill := strings.Repeat(syntax.fill, max(0, total-len(s)))
+			if closing {
+				s = lead + fill + token
+			} else {
+				s = token + fill
+			}
+		}
+		if s != "" {
+			sb.WriteString(s + "\n")
+		}
+	}
+	edge(syntax.open, false)
+	for _, line := range lines {
+		if box {
+			line += strings.Repeat(" ", width-DisplayWidth(line)) + syntax.end
+		}
+		sb.WriteString(strings.TrimRight(syntax.line+line, " ") + "\n")
+	}
+	edge(syntax.close, true)
+	return sb.String(), nil
+}
@@ -0,0 +1,120 @@
+package figlet
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FontComparison holds the same text rendered with two configs, usually
+// two fonts or two revisions of a font, as returned by CompareFonts
+type FontComparison struct {
+	// Fonts holds the font names
+	Fonts [2]string
+	// Grids holds the plain rendering of the whole text in each font
+	Grids [2][][]rune
+	// Lines holds, for every line of the text, its rendering in each font
+	Lines [][2][][]rune
+	// Width and Height hold the size of each rendering
+	Width, Height [2]int
+	// Glyphs lists the characters of the text drawn at different widths
+	// in the two fonts, in the order they first appear
+	Glyphs []GlyphWidths
+}
+
+// GlyphWidths gives the width of a character in each of two fonts
+type GlyphWidths struct {
+	Char  rune
+	Width [2]int
+}
+
+// CompareFonts renders text with a and b, whose fonts must be loaded, and
+// measures the difference. Both configs keep their own layout settings.
+func CompareFonts(a, b *Config, text string) (FontComparison, error) {
+	var c FontComparison
+	for i, cfg := range [2]*Config{a, b} {
+		grid, meta, err := cfg.RenderGrid(text)
+		if err != nil {
+			return c, err
+		}
+		c.Fonts[i], c.Grids[i] = meta.Font, grid
+		c.Width[i], c.Height[i] = meta.Width, meta.Height
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		var pair [2][][]rune
+		for i, cfg := range [2]*Config{a, b} {
+			pair[i], _, _ = cfg.RenderGrid(line)
+		}
+		c.Lines = append(c.Lines, pair)
+	}
+	seen := make(map[rune]bool)
+	for _, r := range text {
+		if seen[r] || r == '\n' {
+			continue
+		}
+		seen[r] = true
+		aw, _ := a.Measure(string(r))
+		bw, _ := b.Measure(string(r))
+		if aw != bw {
+			c.Glyphs = append(c.Glyphs, GlyphWidths{Char: r, Width: [2]int{aw, bw}})
+		}
+	}
+	return c, nil
+}
+
+// SideBySide returns the two renderings next to each other under their
+// font names, separated by a column of '|'
+func (c FontComparison) SideBySide() string {
+	left := max(c.Width[0], len([]rune(c.Fonts[0])))
+	var sb strings.Builder
+	writerow := func(a, b string) {
+		sb.WriteString(strings.TrimRight(fmt.Sprintf("%-*s | %s", left, a, b), " "))
+		sb.WriteByte('\n')
+	}
+	writerow(c.Fonts[0], c.Fonts[1])
+	for y := 0; y < max(len(c.Grids[0]), len(c.Grids[1])); y++ {
+		var a, b string
+		if y < len(c.Grids[0]) {
+			a = string(c.Grids[0][y])
+		}
+		if y < len(c.Grids[1]) {
+			b = string(c.Grids[1][y])
+		}
+		writerow(a, b)
+	}
+	return sb.String()
+}
+
+// Interleaved returns every line of the text rendered in the first font
+// then in the second, each under a rule with its font name, so lines too
+// wide to show side by side can still be compared
+func (c FontComparison) Interleaved() string {
+	width := max(c.Width[0], c.Width[1])
+	var sb strings.Builder
+	for _, pair := range c.Lines {
+		for i, grid := range pair {
+			label := "-- " + c.Fonts[i] + " "
+			sb.WriteString(label)
+			sb.WriteString(strings.Repeat("-", max(3, width-len([]rune(label)))))
+			sb.WriteByte('\n')
+			for _, row := range grid {
+				sb.WriteString(strings.TrimRight(string(row), " "))
+				sb.WriteByte('\n')
+			}
+		}
+	}
+	return sb.String()
+}
+
+// Report describes the size of each rendering and how the second differs
+// from the first, followed by the characters drawn at different widths
+func (c FontComparison) Report() string {
+	name := max(len([]rune(c.Fonts[0])), len([]rune(c.Fonts[1])))
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%-*s  %d columns, %d rows\n", name, c.Fonts[0], c.Width[0], c.Height[0])
+	fmt.Fprintf(&sb, "%-*s  %d columns, %d rows (%+d columns, %+d rows)\n", name, c.Fonts[1],
+		c.Width[1], c.Height[1], c.Width[1]-c.Width[0], c.Height[1]-c.Height[0])
+	for _, g := range c.Glyphs {
+		fmt.Fprintf(&sb, "%q: %d -> %d columns\n", g.Char, g.Width[0], g.Width[1])
+	}
+	return sb.String()
+}
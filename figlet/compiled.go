@@ -0,0 +1,295 @@
+package figlet
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// compiledversion is bumped whenever the compiled font layout changes.
+// Sidecars of another version are ignored and the font is parsed instead.
+const compiledversion = 2
+
+// A compiled font holds a parsed font so it can be loaded without parsing.
+// All numbers are varints:
+//
+//	"flfc" version
+//	hardblank charheight baseline smushmode right2left toilet
+//	rowcount { length rune... }
+//	charcount { code rowindex... }
+//	warningcount { kind char line length message }
+//
+// Rows are stored once and shared by every character that uses them.
+// Characters are stored in reverse lookup order, so reading them back
+// builds the same list and redefinitions keep winning.
+
+// WriteCompiledFont writes the loaded font in the compiled format. With
+// LazyGlyphs every deferred character is parsed first.
+func (cfg *Config) WriteCompiledFont(w io.Writer) error {
+	f := cfg.font
+	if f == nil {
+		return errors.New("no font loaded")
+	}
+	f.loadglyphs()
+
+	var chars []*FCharNode
+	if f.lazy != nil {
+		for _, node := range f.lazy.loaded {
+			chars = append(chars, node)
+		}
+		sort.Slice(chars, func(i, j int) bool { return chars[i].ord < chars[j].ord })
+	}
+	for node := f.glyphs; node != nil; node = node.next {
+		chars = append(chars, node)
+	}
+
+	var buf []byte
+	putuint := func(n int) { buf = binary.AppendUvarint(buf, uint64(n)) }
+	putint := func(n int) { buf = binary.AppendVarint(buf, int64(n)) }
+
+	buf = append(buf, COMPILEDFILEMAGICNUMBER...)
+	putuint(compiledversion)
+	putint(int(f.hardblank))
+	putuint(f.height)
+	putuint(f.baseline)
+	putint(f.smush)
+	putuint(f.right2left)
+	toilet := 0
+	if f.toilet {
+		toilet = 1
+	}
+	putuint(toilet)
+
+	rows := make(map[string]int)
+	var table [][]rune
+	for _, node := range chars {
+		for _, row := range node.thechar {
+			if _, ok := rows[string(row)]; !ok {
+				rows[string(row)] = len(table)
+				table = append(table, row)
+			}
+		}
+	}
+	putuint(len(table))
+	for _, row := range table {
+		putuint(len(row))
+		for _, r := range row {
+			putint(int(r))
+		}
+	}
+
+	putuint(len(chars))
+	for i := len(chars) - 1; i >= 0; i-- {
+		node := chars[i]
+		putint(int(node.ord))
+		for row := 0; row < f.height; row++ {
+			putuint(rows[string(node.thechar[row])])
+		}
+	}
+
+	warnings := f.Warnings()
+	putuint(len(warnings))
+	for _, w := range warnings {
+		putuint(int(w.Kind))
+		putint(int(w.Char))
+		putuint(w.Line)
+		putuint(len(w.Message))
+		buf = append(buf, w.Message...)
+	}
+
+	_, err := w.Write(buf)
+	return err
+}
+
+// CompileFont parses the font file named by cfg.Fontname and writes it in
+// the compiled format next to it, replacing the .flf (or .tlf) suffix by
+// .flfc (or .tlfc). LoadFont uses the compiled font instead of parsing the
+// original as long as it is newer. Only font files on disk can be compiled.
+// CompileFont returns the path of the compiled font.
+func (cfg *Config) CompileFont() (string, error) {
+	if err := cfg.Validate(); err != nil {
+		return "", err
+	}
+	fontfile, err := openfont(cfg)
+	if err != nil {
+		return "", err
+	}
+	defer Zclose(fontfile)
+	if fontfile.file == nil {
+		return "", fmt.Errorf("font %s is embedded; only font files on disk can be compiled", cfg.Fontname)
+	}
+	path := sidecarpath(fontfile.file.Name())
+
+	lazy := cfg.LazyGlyphs
+	cfg.LazyGlyphs = false
+	err = parsefont(cfg, fontfile)
+	cfg.LazyGlyphs = lazy
+	if err != nil {
+		return "", err
+	}
+	out, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	if err := cfg.WriteCompiledFont(out); err != nil {
+		out.Close()
+		os.Remove(path)
+		return "", err
+	}
+	return path, out.Close()
+}
+
+// sidecarpath returns the path of the compiled font for a font file
+func sidecarpath(path string) string {
+	return path + COMPILEDFILESUFFIX[len(FONTFILESUFFIX):]
+}
+
+// readsidecar loads the compiled font next to an opened font file if there
+// is one newer than the font file. It reports whether the font was loaded;
+// a missing, stale or unreadable compiled font is silently ignored.
+func (cfg *Config) readsidecar(fontfile *ZFILE) bool {
+	if fontfile.file == nil {
+		return false
+	}
+	source, err := fontfile.file.Stat()
+	if err != nil {
+		return false
+	}
+	path := sidecarpath(fontfile.file.Name())
+	info, err := os.Stat(path)
+	if err != nil || !info.ModTime().After(source.ModTime()) {
+		return false
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	if err := cfg.ReadCompiledFont(f); err != nil {
+		cfg.debugf("compiled font %s: %v", path, err)
+		return false
+	}
+	cfg.debugf("compiled font %s: loaded", path)
+	return true
+}
+
+// ReadCompiledFont loads a font written by WriteCompiledFont in place of
+// the font file. Like LoadFont, it applies the font's default layout.
+// Control files and character maps are not loaded.
+func (cfg *Config) ReadCompiledFont(r io.Reader) error {
+	br := bufio.NewReader(r)
+	var err error
+	getuint := func() int {
+		if err != nil {
+			return 0
+		}
+		var n uint64
+		n, err = binary.ReadUvarint(br)
+		if n > 1<<31 {
+			err = errors.New("value out of range")
+		}
+		return int(n)
+	}
+	getint := func() int {
+		if err != nil {
+			return 0
+		}
+		var n int64
+		n, err = binary.ReadVarint(br)
+		if n > 1<<31 || n < -1<<31 {
+			err = errors.New("value out of range")
+		}
+		return int(n)
+	}
+
+	magic := make([]byte, len(COMPILEDFILEMAGICNUMBER))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != COMPILEDFILEMAGICNUMBER {
+		return errors.New("not a compiled font")
+	}
+	if version := getuint(); err == nil && version != compiledversion {
+		return fmt.Errorf("unsupported compiled font version %d", version)
+	}
+	hardblank := rune(getint())
+	charheight := getuint()
+	baseline := getuint()
+	fontsmush := getint()
+	fontright2left := getuint()
+	toilet := getuint()
+	if err == nil && (charheight < 1 || charheight > MAXHEIGHT) {
+		err = fmt.Errorf("invalid character height %d", charheight)
+	}
+	if err == nil && (baseline < 1 || baseline > charheight) {
+		err = fmt.Errorf("invalid baseline %d", baseline)
+	}
+
+	arena := newglypharena()
+	var table [][]rune
+	for i, nrows := 0, getuint(); i < nrows && err == nil; i++ {
+		n := getuint()
+		if err == nil && n > 1<<16 {
+			err = fmt.Errorf("invalid row length %d", n)
+		}
+		if err != nil {
+			break
+		}
+		row := arena.alloc(n)
+		for j := range row {
+			row[j] = rune(getint())
+		}
+		table = append(table, row)
+	}
+
+	// Characters are stored last first, as they are linked
+	var fcharlist *FCharNode
+	for i, nchars := 0, getuint(); i < nchars && err == nil; i++ {
+		fcharlist = arena.char(rune(getint()), charheight, fcharlist)
+		for row := range fcharlist.thechar {
+			n := getuint()
+			if err == nil && n >= len(table) {
+				err = fmt.Errorf("invalid row %d", n)
+			}
+			if err == nil {
+				fcharlist.thechar[row] = table[n]
+			}
+		}
+	}
+
+	var warnings []Warning
+	nwarnings := getuint()
+	for i := 0; i < nwarnings && err == nil; i++ {
+		w := Warning{Kind: WarningKind(getuint()), Char: rune(getint()), Line: getuint()}
+		var sb strings.Builder
+		n := getuint()
+		if err == nil {
+			_, err = io.CopyN(&sb, br, int64(n))
+		}
+		w.Message = sb.String()
+		warnings = append(warnings, w)
+	}
+	if err != nil {
+		return fmt.Errorf("corrupt compiled font: %v", err)
+	}
+	if fcharlist == nil {
+		return errors.New("corrupt compiled font: no characters")
+	}
+
+	cfg.setfont(&Font{
+		name:       cfg.Fontname,
+		hardblank:  hardblank,
+		height:     charheight,
+		baseline:   baseline,
+		smush:      fontsmush,
+		right2left: fontright2left,
+		toilet:     toilet != 0,
+		glyphs:     fcharlist,
+		warnings:   warnings,
+	})
+	cfg.outlinelenlimit = cfg.linelimit()
+	linealloc(cfg)
+	return nil
+}
@@ -0,0 +1,89 @@
+package figlet
+
+import "strings"
+
+// ContactSheetCells lays frames out on one grid, cols to a row, so an
+// animation can be previewed without playing it. Every frame gets a tile
+// as big as the biggest frame, with frames moved up by their
+// BaselineOffset like on a terminal, and tiles are two columns and one
+// line apart. Generate with MaxFrames set to pick a few frames evenly.
+func ContactSheetCells(frames []StructuredFrame, cols int) [][]Cell {
+	if len(frames) == 0 {
+		return nil
+	}
+	if cols <= 0 || cols > len(frames) {
+		cols = len(frames)
+	}
+	maxoffset, width := 0, 0
+	for _, f := range frames {
+		maxoffset = max(maxoffset, f.BaselineOffset)
+		for _, line := range f.Cells {
+			width = max(width, len(line))
+		}
+	}
+	height := 0
+	for _, f := range frames {
+		height = max(height, maxoffset-f.BaselineOffset+len(f.Cells))
+	}
+
+	const gapx, gapy = 2, 1
+	tiles := (len(frames) + cols - 1) / cols
+	sheet := make([][]Cell, tiles*(height+gapy)-gapy)
+	for y := range sheet {
+		sheet[y] = make([]Cell, cols*(width+gapx)-gapx)
+		for x := range sheet[y] {
+			sheet[y][x] = Cell{' ', -1}
+		}
+	}
+	for i, f := range frames {
+		left := i % cols * (width + gapx)
+		top := i/cols*(height+gapy) + maxoffset - f.BaselineOffset
+		for y, line := range f.Cells {
+			copy(sheet[top+y][left:], line)
+		}
+	}
+	for y, line := range sheet {
+		end := len(line)
+		for end > 0 && line[end-1] == (Cell{' ', -1}) {
+			end--
+		}
+		sheet[y] = line[:end]
+	}
+	for len(sheet) > 0 && len(sheet[len(sheet)-1]) == 0 {
+		sheet = sheet[:len(sheet)-1]
+	}
+	return sheet
+}
+
+// ContactSheet returns the frames laid out like ContactSheetCells as text,
+// with the colors and output parser of the Animator's Config
+func (a *Animator) ContactSheet(frames []StructuredFrame, cols int) string {
+	return a.celltext(ContactSheetCells(frames, cols))
+}
+
+// celltext returns cells as text with the colors and output parser of the
+// Animator's Config
+func (a *Animator) celltext(cells [][]Cell) string {
+	cfg := a.Config
+	var sb strings.Builder
+	if cfg.OutputParser != nil {
+		sb.WriteString(cfg.OutputParser.Prefix)
+	}
+	for _, line := range cells {
+		var run colorrun
+		for _, cell := range line {
+			index := cell.Color
+			if index == len(cfg.Colors) {
+				index = highlighted
+			}
+			prefix, text, suffix := a.colorparts(string(cell.Char), index)
+			run.write(&sb, prefix, text, suffix)
+		}
+		run.end(&sb)
+		sb.WriteString("\n")
+	}
+	if cfg.OutputParser != nil {
+		sb.WriteString(cfg.OutputParser.Suffix)
+	}
+	return sb.String()
+}
@@ -0,0 +1,94 @@
+package figlet
+
+import "math"
+
+// WCAG 2 contrast targets, as ratios between the lighter and the darker color
+const (
+	// ContrastAALarge is the minimum for large text, which FIGlet art always is
+	ContrastAALarge = 3.0
+	// ContrastAA is the minimum for normal text
+	ContrastAA = 4.5
+	// ContrastAAA is the enhanced target for normal text
+	ContrastAAA = 7.0
+)
+
+// Luminance returns the WCAG relative luminance of the color, from 0 for
+// black to 1 for white
+func (tc TrueColor) Luminance() float64 {
+	channel := func(c int) float64 {
+		v := float64(c) / 255
+		if v <= 0.04045 {
+			return v / 12.92
+		}
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(tc.R) + 0.7152*channel(tc.G) + 0.0722*channel(tc.B)
+}
+
+// ContrastRatio returns the WCAG contrast ratio between two colors, from 1
+// for identical colors to 21 for black on white. ANSI colors are measured
+// with the RGB values the html parser uses for them. Colors without an RGB
+// value count as black.
+func ContrastRatio(foreground, background Color) float64 {
+	fg, _ := truecolor(foreground)
+	bg, _ := truecolor(background)
+	return contrast(fg, bg)
+}
+
+func contrast(a, b TrueColor) float64 {
+	la, lb := a.Luminance(), b.Luminance()
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// ContrastRatios returns the contrast ratio of each color against the
+// background, in order
+func ContrastRatios(colors []Color, background Color) []float64 {
+	ratios := make([]float64, len(colors))
+	for i, c := range colors {
+		ratios[i] = ContrastRatio(c, background)
+	}
+	return ratios
+}
+
+// EnsureContrast returns the colors with the lightness of those below the
+// target contrast against the background changed just enough to reach it,
+// keeping their hue. Each color is darkened or lightened, whichever needs
+// the smaller change; when neither reaches the target, as with a mid gray
+// background and ContrastAAA, it becomes black or white, whichever has
+// more contrast. Colors that already meet the target are returned as is.
+func EnsureContrast(colors []Color, background Color, target float64) []Color {
+	bg, _ := truecolor(background)
+	out := make([]Color, len(colors))
+	for i, c := range colors {
+		out[i] = c
+		fg, _ := truecolor(c)
+		if contrast(fg, bg) >= target {
+			continue
+		}
+		out[i] = adjustcontrast(fg, bg, target)
+	}
+	return out
+}
+
+// adjustcontrast finds the smallest lightness change that gives fg the
+// target contrast against bg
+func adjustcontrast(fg, bg TrueColor, target float64) TrueColor {
+	const steps = 100
+	for n := 1; n <= steps; n++ {
+		amount := float64(n) / steps
+		if c := fg.Darken(amount); contrast(c, bg) >= target {
+			return c
+		}
+		if c := fg.Lighten(amount); contrast(c, bg) >= target {
+			return c
+		}
+	}
+	black, white := TrueColor{0, 0, 0}, TrueColor{255, 255, 255}
+	if contrast(black, bg) >= contrast(white, bg) {
+		return black
+	}
+	return white
+}
@@ -0,0 +1,178 @@
+package figlet
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Coverage lists the characters a font draws, as returned by FontCoverage
+type Coverage struct {
+	Font string
+	// Chars lists the characters the font has a glyph for, in code point
+	// order. Required characters the font leaves empty are not included.
+	Chars []rune
+	// Default reports whether the font has a glyph for code 0, which is
+	// drawn for characters the font lacks
+	Default bool
+}
+
+// CoverageRange is a run of consecutive code points, First to Last
+type CoverageRange struct {
+	First, Last rune
+}
+
+// FontCoverage reports the characters the font with the given name draws,
+// reading only its code tags and skipping over the rows of each glyph, so
+// it is much faster than LoadFont for big Unicode fonts. Options such as
+// WithFontDir select where the font is looked up. Control files and
+// character maps are not taken into account.
+func FontCoverage(name string, options ...Option) (Coverage, error) {
+	cfg := New()
+	for _, opt := range options {
+		opt(cfg)
+	}
+	cfg.Fontname = name
+	fontfile, err := openfont(cfg)
+	if err != nil {
+		return Coverage{}, err
+	}
+	defer Zclose(fontfile)
+
+	magicnum := readmagic(fontfile)
+	fileline := make([]byte, MAXLEN+1)
+	header := myfgets(fileline, MAXLEN+1, fontfile)
+	if len(header) > 0 && header[len(header)-1] != '\n' {
+		skiptoeol(fontfile)
+	}
+	var dummy, hardblank byte
+	var charheight, upheight, maxlen, smush, cmtlines int
+	numsread, _ := fmt.Sscanf(strings.TrimSpace(string(header)), "%c%c %d %d %d %d %d",
+		&dummy, &hardblank, &charheight, &upheight, &maxlen, &smush, &cmtlines)
+	if (magicnum != FONTFILEMAGICNUMBER && magicnum != TOILETFILEMAGICNUMBER) || numsread < 7 {
+		return Coverage{}, fmt.Errorf("font %s: not a FIGlet 2 font file", name)
+	}
+	for i := 1; i <= cmtlines && !fontfile.eof; i++ {
+		skiptoeol(fontfile)
+	}
+	charheight = max(charheight, 1)
+
+	cov := Coverage{Font: name}
+	seen := make(map[rune]bool)
+	// glyph skips over the rows of a character, noting it if any row is
+	// drawn, and reports whether all its rows were there
+	glyph := func(theord rune) bool {
+		drawn := false
+		for row := 0; row < charheight; row++ {
+			line := myfgets(fileline, MAXLEN+1, fontfile)
+			if line == nil {
+				return false
+			}
+			drawn = drawn || rowdrawn(string(line))
+		}
+		if drawn && !seen[theord] {
+			seen[theord] = true
+			if theord == 0 {
+				cov.Default = true
+			} else if theord > 0 {
+				cov.Chars = append(cov.Chars, theord)
+			}
+		}
+		return true
+	}
+	for theord := ' '; theord <= '~'; theord++ {
+		if !glyph(theord) {
+			break
+		}
+	}
+	for _, theord := range Deutsch {
+		if fontfile.eof || !glyph(theord) {
+			break
+		}
+	}
+	for {
+		line := myfgets(fileline, MAXLEN+1, fontfile)
+		if line == nil {
+			break
+		}
+		theord, ok := parsecodetag(line)
+		if !ok || !glyph(theord) {
+			break
+		}
+	}
+	sort.Slice(cov.Chars, func(i, j int) bool { return cov.Chars[i] < cov.Chars[j] })
+	return cov, nil
+}
+
+// rowdrawn reports whether a glyph row has anything left once its trailing
+// spaces and endmarks are removed
+func rowdrawn(line string) bool {
+	line = strings.TrimRightFunc(line, unicode.IsSpace)
+	if line == "" {
+		return false
+	}
+	r := []rune(line)
+	end := r[len(r)-1]
+	return strings.TrimRight(line, string(end)) != ""
+}
+
+// Has reports whether the font draws r
+func (c Coverage) Has(r rune) bool {
+	i := sort.Search(len(c.Chars), func(i int) bool { return c.Chars[i] >= r })
+	return i < len(c.Chars) && c.Chars[i] == r
+}
+
+// Missing returns the characters of text the font lacks, each once, in
+// the order they appear. Line breaks and tabs are never missing.
+func (c Coverage) Missing(text string) []rune {
+	var missing []rune
+	seen := make(map[rune]bool)
+	for _, r := range text {
+		if r == '\n' || r == '\r' || r == '\t' || seen[r] || c.Has(r) {
+			continue
+		}
+		seen[r] = true
+		missing = append(missing, r)
+	}
+	return missing
+}
+
+// Ranges returns the characters the font draws as runs of consecutive
+// code points
+func (c Coverage) Ranges() []CoverageRange {
+	var ranges []CoverageRange
+	for _, r := range c.Chars {
+		if n := len(ranges); n > 0 && ranges[n-1].Last == r-1 {
+			ranges[n-1].Last = r
+		} else {
+			ranges = append(ranges, CoverageRange{r, r})
+		}
+	}
+	return ranges
+}
+
+// Scripts counts the characters the font draws in each Unicode script,
+// such as "Latin" or "Greek"; punctuation, digits and symbols shared by
+// scripts count as "Common"
+func (c Coverage) Scripts() map[string]int {
+	counts := make(map[string]int)
+	for _, r := range c.Chars {
+		for name, table := range unicode.Scripts {
+			if unicode.Is(table, r) {
+				counts[name]++
+				break
+			}
+		}
+	}
+	return counts
+}
+
+// String formats the range as U+0020-U+007E, or U+00C4 for a single code
+// point
+func (r CoverageRange) String() string {
+	if r.First == r.Last {
+		return fmt.Sprintf("U+%04X", r.First)
+	}
+	return fmt.Sprintf("U+%04X-U+%04X", r.First, r.Last)
+}
@@ -0,0 +1,130 @@
+package figlet
+
+import (
+	"strings"
+	"time"
+)
+
+// LineChange replaces the end of one line of a frame: the line becomes
+// its first Column runes in the previous frame followed by Text
+type LineChange struct {
+	Line   int
+	Column int
+	Text   string
+}
+
+// FrameDelta is a frame stored as the lines that differ from the previous
+// frame. Lines are the Content of a Frame split at newlines, parser prefix
+// and suffix included, so decoding gives back the exact Content.
+type FrameDelta struct {
+	Changes []LineChange
+	// Lines is the number of lines in the frame. Lines past it are dropped.
+	Lines          int
+	Delay          time.Duration
+	BaselineOffset int
+	Baseline       int
+}
+
+// EncodeFrames converts frames to deltas. The first frame, and any frame
+// whose BaselineOffset differs from the previous one, has every line in
+// its changes, since the whole picture moves on screen.
+func EncodeFrames(frames []Frame) []FrameDelta {
+	deltas := make([]FrameDelta, len(frames))
+	var enc deltaencoder
+	for i, frame := range frames {
+		deltas[i] = enc.encode(frame)
+	}
+	return deltas
+}
+
+// deltaencoder converts frames to deltas one at a time, for frames that
+// are not all known in advance
+type deltaencoder struct {
+	prev   []string
+	offset int
+	count  int
+}
+
+// encode returns the delta from the previous frame given to encode
+func (enc *deltaencoder) encode(frame Frame) FrameDelta {
+	lines := strings.Split(frame.Content, "\n")
+	full := enc.count == 0 || frame.BaselineOffset != enc.offset
+	delta := FrameDelta{
+		Lines:          len(lines),
+		Delay:          frame.Delay,
+		BaselineOffset: frame.BaselineOffset,
+		Baseline:       frame.Baseline,
+	}
+	for n, line := range lines {
+		switch {
+		case full || n >= len(enc.prev):
+			delta.Changes = append(delta.Changes, LineChange{n, 0, line})
+		case enc.prev[n] != line:
+			column, start := commonprefix(enc.prev[n], line)
+			delta.Changes = append(delta.Changes, LineChange{n, column, line[start:]})
+		}
+	}
+	enc.prev, enc.offset = lines, frame.BaselineOffset
+	enc.count++
+	return delta
+}
+
+// DecodeFrames converts deltas made by EncodeFrames back to frames
+func DecodeFrames(deltas []FrameDelta) []Frame {
+	frames := make([]Frame, len(deltas))
+	var lines []string
+	for i, delta := range deltas {
+		next := make([]string, delta.Lines)
+		copy(next, lines)
+		for _, change := range delta.Changes {
+			if change.Line >= 0 && change.Line < len(next) {
+				next[change.Line] = change.apply(next[change.Line])
+			}
+		}
+		lines = next
+		frames[i] = Frame{
+			Content:        strings.Join(lines, "\n"),
+			Delay:          delta.Delay,
+			BaselineOffset: delta.BaselineOffset,
+			Baseline:       delta.Baseline,
+		}
+	}
+	return frames
+}
+
+// apply returns the line after the change
+func (c LineChange) apply(line string) string {
+	if c.Column <= 0 {
+		return c.Text
+	}
+	n := 0
+	for i := range line {
+		if n == c.Column {
+			return line[:i] + c.Text
+		}
+		n++
+	}
+	return line + c.Text
+}
+
+// commonprefix returns the number of leading runes a and b share, and
+// their length in bytes
+func commonprefix(a, b string) (runes, bytes int) {
+	for i, r := range b {
+		if i >= len(a) || !strings.HasPrefix(a[i:], string(r)) {
+			return runes, i
+		}
+		runes++
+	}
+	return runes, len(b)
+}
+
+// GenerateDeltas generates an animation like GenerateAnimation and returns
+// it as deltas, which are much smaller for long animations of wide text
+func (a *Animator) GenerateDeltas(text string, animType string, delay time.Duration) ([]FrameDelta, error) {
+	frames, err := a.GenerateAnimation(text, animType, delay)
+	if err != nil {
+		return nil, err
+	}
+	return EncodeFrames(frames), nil
+}
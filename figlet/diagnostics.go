@@ -0,0 +1,230 @@
+package figlet
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"unicode"
+)
+
+// WarningKind identifies the type of problem reported by a Warning
+type WarningKind int
+
+const (
+	// WarnRaggedGlyph means the rows of a character have different widths
+	WarnRaggedGlyph WarningKind = iota
+	// WarnUnusualHardblank means the header declares an unusual hardblank character
+	WarnUnusualHardblank
+	// WarnMissingGlyph means one of the 102 required characters is missing
+	WarnMissingGlyph
+	// WarnUnsafeCell means a character draws with a sub-character that
+	// breaks the alignment of the output format, see CheckMonospace
+	WarnUnsafeCell
+)
+
+// String returns a short name for the warning kind
+func (k WarningKind) String() string {
+	switch k {
+	case WarnRaggedGlyph:
+		return "ragged-glyph"
+	case WarnUnusualHardblank:
+		return "unusual-hardblank"
+	case WarnMissingGlyph:
+		return "missing-glyph"
+	case WarnUnsafeCell:
+		return "unsafe-cell"
+	}
+	return "unknown"
+}
+
+// Warning describes a non-fatal problem found while loading a font.
+// The font is still usable, but the affected characters may render incorrectly.
+type Warning struct {
+	Kind WarningKind
+	// Char is the character code the warning refers to (0 for header warnings)
+	Char rune
+	// Line is the 1-based line number in the font file, or 0 if unknown
+	Line    int
+	Message string
+}
+
+// String formats the warning for display
+func (w Warning) String() string {
+	if w.Line > 0 {
+		return fmt.Sprintf("line %d: %s: %s", w.Line, w.Kind, w.Message)
+	}
+	return fmt.Sprintf("%s: %s", w.Kind, w.Message)
+}
+
+// possHardblanks lists the hardblank characters chkfont considers usual
+var possHardblanks = []rune{'!', '@', '#', '$', '%', '&', '*', 0x7f}
+
+// LoadFontWithDiagnostics loads the font like LoadFont and also returns the
+// warnings found while parsing it. Warnings never prevent the font from loading;
+// a non-nil error is returned only when LoadFont would fail. With LazyGlyphs
+// every character is parsed so all of them are checked. The warnings of
+// CheckMonospace for the output parser follow those of the font.
+func (cfg *Config) LoadFontWithDiagnostics() ([]Warning, error) {
+	if err := cfg.LoadFont(); err != nil {
+		return cfg.Warnings(), err
+	}
+	cfg.font.loadglyphs()
+	return append(cfg.Warnings(), cfg.CheckMonospace()...), nil
+}
+
+// CheckMonospace warns about every character of the loaded font that
+// draws with a sub-character breaking the column alignment of the output
+// parser: tabs and other control characters, and zero-width characters
+// such as combining marks, everywhere, and wide characters, such as CJK
+// ideographs, outside the terminal parsers, since browsers draw them at
+// whatever width the page's font gives them. The warnings depend on the
+// parser, so they are not kept with the font's. Set UnsafeCellReplacement
+// to draw another character in their place.
+func (cfg *Config) CheckMonospace() []Warning {
+	if cfg.font == nil {
+		return nil
+	}
+	var warnings []Warning
+	for _, node := range cfg.font.allglyphs() {
+		if r, reason := cfg.unsafeglyph(node.thechar); r != 0 {
+			warnings = append(warnings, Warning{
+				Kind: WarnUnsafeCell,
+				Char: node.ord,
+				Message: fmt.Sprintf("character %d draws %q, %s that breaks alignment in %s output",
+					node.ord, r, reason, cfg.OutputParser.Name),
+			})
+		}
+	}
+	return warnings
+}
+
+// WithUnsafeCellReplacement draws r in place of the sub-characters that
+// CheckMonospace warns about, such as '?' or ' ', so fonts using tabs or
+// wide characters still line up. r must take one column.
+func WithUnsafeCellReplacement(r rune) Option {
+	return func(cfg *Config) {
+		cfg.UnsafeCellReplacement = r
+	}
+}
+
+// allglyphs returns every parsed character of the font by character code
+func (f *Font) allglyphs() []*FCharNode {
+	var nodes []*FCharNode
+	for node := f.glyphs; node != nil; node = node.next {
+		nodes = append(nodes, node)
+	}
+	if f.lazy != nil {
+		f.lazy.mu.Lock()
+		for _, node := range f.lazy.loaded {
+			nodes = append(nodes, node)
+		}
+		f.lazy.mu.Unlock()
+	}
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].ord < nodes[j].ord })
+	return nodes
+}
+
+// unsafecell describes why sub-character r breaks the alignment of the
+// output, or returns "" if it does not
+func (cfg *Config) unsafecell(r rune) string {
+	if r == cfg.hardblank {
+		return ""
+	}
+	switch runewidth(r) {
+	case 0:
+		if unicode.IsControl(r) {
+			return "a control character"
+		}
+		return "a zero-width character"
+	case 2:
+		if name := cfg.OutputParser.Name; name != "terminal" && name != "terminal-color" {
+			return "a wide character"
+		}
+	}
+	return ""
+}
+
+// unsafeglyph returns the first sub-character of rows that breaks the
+// alignment of the output and why, or 0
+func (cfg *Config) unsafeglyph(rows [][]rune) (rune, string) {
+	for _, row := range rows {
+		for _, r := range row {
+			if reason := cfg.unsafecell(r); reason != "" {
+				return r, reason
+			}
+		}
+	}
+	return 0, ""
+}
+
+// safeglyph returns rows with every sub-character that breaks the
+// alignment of the output replaced by UnsafeCellReplacement. The font's
+// rows are copied, never changed.
+func (cfg *Config) safeglyph(rows [][]rune) [][]rune {
+	if r, _ := cfg.unsafeglyph(rows); r == 0 {
+		return rows
+	}
+	safe := make([][]rune, len(rows))
+	for i, row := range rows {
+		safe[i] = make([]rune, len(row))
+		for j, r := range row {
+			if cfg.unsafecell(r) != "" {
+				r = cfg.UnsafeCellReplacement
+			}
+			safe[i][j] = r
+		}
+	}
+	return safe
+}
+
+// Debug logs how the font is found and parsed and how text is laid out to
+// w: the font paths tried, the header values, the overlap chosen for each
+// character and every line break. A nil w turns logging off.
+func (cfg *Config) Debug(w io.Writer) {
+	cfg.debug = w
+}
+
+// debugf writes a line to the debug log, if there is one
+func (cfg *Config) debugf(format string, args ...interface{}) {
+	if cfg.debug != nil {
+		fmt.Fprintf(cfg.debug, format+"\n", args...)
+	}
+}
+
+// Warnings returns the warnings collected by the last font load
+func (cfg *Config) Warnings() []Warning {
+	if cfg.font == nil {
+		return nil
+	}
+	return cfg.font.Warnings()
+}
+
+func (cfg *Config) warn(kind WarningKind, char rune, line int, format string, args ...interface{}) {
+	cfg.font.warn(kind, char, line, format, args...)
+}
+
+// checkhardblank warns when the font uses a hardblank outside the usual set
+func (cfg *Config) checkhardblank() {
+	for _, hb := range possHardblanks {
+		if cfg.hardblank == hb {
+			return
+		}
+	}
+	cfg.warn(WarnUnusualHardblank, 0, 1, "unusual hardblank %q", cfg.hardblank)
+}
+
+// checkglyph warns when the rows of a freshly read character differ in width
+func (f *Font) checkglyph(theord rune, line int, rows [][]rune) {
+	if len(rows) == 0 {
+		return
+	}
+	width := len(rows[0])
+	for row := 1; row < len(rows); row++ {
+		if len(rows[row]) != width {
+			f.warn(WarnRaggedGlyph, theord, line+row,
+				"character %d has inconsistent width (row 1 is %d, row %d is %d)",
+				theord, width, row+1, len(rows[row]))
+			return
+		}
+	}
+}
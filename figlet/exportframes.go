@@ -0,0 +1,78 @@
+package figlet
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+)
+
+// FrameManifest describes frames written by ExportFrames
+type FrameManifest struct {
+	Format Format          `json:"format"`
+	Frames []ManifestFrame `json:"frames"`
+}
+
+// ManifestFrame is a frame file written by ExportFrames with its timing
+type ManifestFrame struct {
+	File           string `json:"file"`
+	DelayMs        int64  `json:"delayMs"`
+	BaselineOffset int    `json:"baselineOffset"`
+	Baseline       int    `json:"baseline"`
+}
+
+// ExportFrames writes every frame to its own file in dir, frame-0001.txt,
+// frame-0002.txt and so on, and their delays and offsets to manifest.json,
+// for stitching animations in tools like ffmpeg. FormatText writes the
+// frames with the colors and output parser of the Animator's Config;
+// FormatPNG and FormatGIF draw them on images of the same size, each moved
+// up by its BaselineOffset. dir is created if needed.
+func (a *Animator) ExportFrames(dir string, frames []StructuredFrame, format Format) error {
+	ext := map[Format]string{FormatText: "txt", FormatPNG: "png", FormatGIF: "gif"}[format]
+	if ext == "" {
+		return fmt.Errorf("cannot export frames as %s", format)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	manifest := FrameManifest{Format: format, Frames: make([]ManifestFrame, len(frames))}
+	var images []*image.Paletted
+	if format != FormatText {
+		images = a.frameimages(frames)
+	}
+	for i, frame := range frames {
+		name := fmt.Sprintf("frame-%04d.%s", i+1, ext)
+		manifest.Frames[i] = ManifestFrame{
+			File:           name,
+			DelayMs:        frame.Delay.Milliseconds(),
+			BaselineOffset: frame.BaselineOffset,
+			Baseline:       frame.Baseline,
+		}
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		switch format {
+		case FormatText:
+			_, err = f.WriteString(a.celltext(frame.Cells))
+		case FormatPNG:
+			err = png.Encode(f, images[i])
+		case FormatGIF:
+			err = gif.Encode(f, images[i], nil)
+		}
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "manifest.json"), append(data, '\n'), 0644)
+}
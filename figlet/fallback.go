@@ -0,0 +1,112 @@
+package figlet
+
+import (
+	"fmt"
+	"strings"
+)
+
+// fallbackfont is a font loaded for WithFontFallback
+type fallbackfont struct {
+	cfg    *Config
+	top    int               // blank rows above its glyphs
+	glyphs map[rune][][]rune // glyphs used so far, padded and converted
+}
+
+// WithFontFallback draws the characters the font lacks, or leaves empty,
+// with the first of the given fonts that has them, like fontconfig does for
+// system fonts. Fonts of different heights are padded to the tallest, with
+// their baselines lined up. Glyphs from different fonts are kerned, never
+// smushed together. The fallback fonts are loaded by LoadFont, from the
+// same font directory.
+func WithFontFallback(names ...string) Option {
+	return func(cfg *Config) {
+		cfg.FontFallback = append([]string(nil), names...)
+	}
+}
+
+// loadfallbacks loads the fallback fonts, then pads the glyphs of the font
+// so every font has the height of the tallest
+func (cfg *Config) loadfallbacks() error {
+	cfg.fallbacks = nil
+	if len(cfg.FontFallback) == 0 {
+		return nil
+	}
+	above, below := cfg.baseline, cfg.charheight-cfg.baseline
+	for _, name := range cfg.FontFallback {
+		fb := New()
+		fb.Fontdirname, fb.FontSources = cfg.Fontdirname, cfg.FontSources
+		fb.AllowFilesystemPaths = cfg.AllowFilesystemPaths
+		fb.Fontname = strings.TrimSuffix(strings.TrimSuffix(name, FONTFILESUFFIX), TOILETFILESUFFIX)
+		fb.LazyGlyphs = cfg.LazyGlyphs
+		if err := fb.LoadFont(); err != nil {
+			return fmt.Errorf("fallback font %s: %w", name, err)
+		}
+		above, below = max(above, fb.baseline), max(below, fb.charheight-fb.baseline)
+		cfg.fallbacks = append(cfg.fallbacks, &fallbackfont{cfg: fb, glyphs: make(map[rune][][]rune)})
+	}
+	for _, fb := range cfg.fallbacks {
+		fb.top = above - fb.cfg.baseline
+	}
+	cfg.debugf("fallback: %s, height %d", strings.Join(cfg.FontFallback, ", "), above+below)
+
+	if height := above + below; height != cfg.charheight {
+		// The font may be shared, so pad a copy of it
+		cfg.setfont(cfg.font.padded(above-cfg.baseline, height))
+	}
+	return nil
+}
+
+// fallbackglyph returns the glyph of c from the first fallback font that
+// draws it, with the font's hardblanks, and the number of that font from 1
+func (cfg *Config) fallbackglyph(c rune) ([][]rune, int) {
+	for n, fb := range cfg.fallbacks {
+		if rows, ok := fb.glyphs[c]; ok {
+			return rows, n + 1
+		}
+		node := fb.cfg.findglyph(c)
+		if node == nil || glyphwidth(node.thechar) == 0 {
+			continue
+		}
+		rows := padglyph(node.thechar, fb.top, cfg.charheight)
+		for _, row := range rows {
+			for i, r := range row {
+				if r == fb.cfg.hardblank {
+					row[i] = cfg.hardblank
+				}
+			}
+		}
+		fb.glyphs[c] = rows
+		return rows, n + 1
+	}
+	return nil, 0
+}
+
+// findglyph returns the glyph of c in the font, or nil if it has none
+func (cfg *Config) findglyph(c rune) *FCharNode {
+	return cfg.font.glyph(c)
+}
+
+// glyphwidth returns the width of a glyph, that of its first row
+func glyphwidth(rows [][]rune) int {
+	if len(rows) == 0 {
+		return 0
+	}
+	return len(rows[0])
+}
+
+// padglyph returns a copy of a glyph with top blank rows above it and as
+// many below as make it height rows tall
+func padglyph(rows [][]rune, top, height int) [][]rune {
+	blank := []rune(strings.Repeat(" ", glyphwidth(rows)))
+	out := make([][]rune, 0, height)
+	for len(out) < top {
+		out = append(out, append([]rune(nil), blank...))
+	}
+	for _, row := range rows {
+		out = append(out, append([]rune(nil), row...))
+	}
+	for len(out) < height {
+		out = append(out, append([]rune(nil), blank...))
+	}
+	return out[:height]
+}
@@ -6,14 +6,17 @@ import (
 	"archive/zip"
 	"bytes"
 	"embed"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 	"unicode"
+	"unicode/utf8"
 )
 
 //go:embed fonts/*.flf fonts/*.flc
@@ -24,14 +27,17 @@ const (
 	VERSION     = "2.2.5"
 	VERSION_INT = 20205
 
-	FONTFILESUFFIX         = ".flf"
-	FONTFILEMAGICNUMBER    = "flf2"
-	CONTROLFILESUFFIX      = ".flc"
-	CONTROLFILEMAGICNUMBER = "flc2"
-	TOILETFILESUFFIX       = ".tlf"
-	TOILETFILEMAGICNUMBER  = "tlf2"
-	DEFAULTCOLUMNS         = 80
-	MAXLEN                 = 255
+	FONTFILESUFFIX          = ".flf"
+	FONTFILEMAGICNUMBER     = "flf2"
+	CONTROLFILESUFFIX       = ".flc"
+	CONTROLFILEMAGICNUMBER  = "flc2"
+	TOILETFILESUFFIX        = ".tlf"
+	TOILETFILEMAGICNUMBER   = "tlf2"
+	COMPILEDFILESUFFIX      = ".flfc"
+	COMPILEDFILEMAGICNUMBER = "flfc"
+	DEFAULTCOLUMNS          = 80
+	MAXLEN                  = 255
+	MAXHEIGHT               = 1024
 
 	SM_SMUSH     = 128
 	SM_KERN      = 64
@@ -76,27 +82,35 @@ type ComNode struct {
 // Config holds the FIGlet configuration and state
 type Config struct {
 	Deutschflag       bool
-	Justification     int // -1 = auto, 0 = left, 1 = center, 2 = right
+	Justification     int // -1 = auto, 0 = left, 1 = center, 2 = right, 3 = full
 	Paragraphflag     bool
-	Right2left        int // -1 = auto, 0 = left, 1 = right
-	Multibyte         int // 0 = ISO 2022, 1 = DBCS, 2 = UTF-8, 3 = HZ, 4 = Shift-JIS
+	Right2left        int  // -1 = auto, 0 = left, 1 = right
+	NoRTLRightJustify bool // JustifyAuto stays flush-left for right-to-left text
+	Multibyte         int  // 0 = ISO 2022, 1 = DBCS, 2 = UTF-8, 3 = HZ, 4 = Shift-JIS
 	Cmdinput          bool
-	Smushmode         int
+	Smushmode         int // SM_* bitmask, see SmushRules for a symbolic form
 	Smushoverride     int
 	Outputwidth       int
 	Fontdirname       string
 	Fontname          string
 	cfilelist         *CFNameNode
 	cfilelistend      **CFNameNode
+	controlfiles      []string // control files applied by the last load, includes expanded
+	controlstack      []string // control files being read, for include cycle detection
 	commandlist       *ComNode
 	commandlistend    **ComNode
 	hardblank         rune
 	charheight        int
-	fcharlist         *FCharNode
+	baseline          int   // rows from the top of a character to its baseline
+	font              *Font // the loaded font, see Font
+	loadedfont        *Font // font given with WithLoadedFont
 	outputline        [][]rune
+	outputmap         [][]int // input character index for each cell of outputline
 	outlinelen        int
 	outlinelenlimit   int
 	inchrline         []rune
+	inchrindex        []int // input character index for each entry of inchrline
+	indexbuf          []int // scratch index map for the character being added
 	inchrlinelen      int
 	inchrlinelenlimit int
 	currchar          [][]rune
@@ -107,40 +121,125 @@ type Config struct {
 	gn                [4]rune
 	gl                int
 	gr                int
-	toiletfont        bool
+	wrapping          bool // the line being printed is broken by wrapping
 	getinchr_buffer   rune
 	getinchr_flag     bool
 	Optind            int
 	Argv              []string
 	agetmode          int // >= 0 for displacement into argv[n], <0 EOF
-	output            *strings.Builder
+	rows              []outputrow
+	// CharMaps lists the built-in character maps applied after the control files
+	CharMaps []string
+	// Case transforms the case of the input before translation
+	Case CaseMode
 	// Color support
 	Colors       []Color
 	OutputParser *OutputParser
 	// Track current character index for color cycling
 	currentCharIndex int
-	// Track which input character is at each output position for each line
-	// Maps line index -> column index -> input character index
-	charPositionMap [][]int
-	// Current line being built (for charPositionMap)
-	currentLineIndex int
 	// Animation support
 	AnimationType  string
 	AnimationFile  string
 	AnimationDelay time.Duration
 	ExportFile     string
+	// SkipLateFrames skips the frames whose time is over when playback
+	// falls behind, see WithSkipLateFrames
+	SkipLateFrames bool
 	// DisableMappedColors disables character-based color mapping,
 	// using purely positional coloring instead. Useful for stable animations.
 	DisableMappedColors bool
-	PreserveMap         bool
-	// baseRowIndex tracks the starting row index of the current FIGlet line being rendered.
-	baseRowIndex int
+	// Deprecated: character maps are now kept with every composed row,
+	// so PreserveMap has no effect.
+	PreserveMap bool
+	// Trim selects which whitespace is removed from the output
+	Trim TrimMode
+	// Pad selects how lines are padded on the right
+	Pad PadMode
+	// KeepHardblanks emits hardblanks as-is (or as HardblankSubstitute when
+	// non-zero) instead of converting them to spaces
+	KeepHardblanks      bool
+	HardblankSubstitute rune
+	// LazyGlyphs defers parsing code-tagged characters until they are used
+	LazyGlyphs bool
+	// UnsafeCellReplacement, when non-zero, is drawn in place of the
+	// sub-characters that break the alignment of the output, see
+	// CheckMonospace
+	UnsafeCellReplacement rune
+	// Overlap forces up to that many more columns of overlap between
+	// characters than the layout allows, see WithOverlap
+	Overlap int
+	// TraceSmushing records every smushed pair of sub-characters
+	TraceSmushing bool
+	smushtrace    []SmushEvent
+	// debug log, see Debug
+	debug io.Writer
+	// Underline draws a line under the text, just below the font's baseline
+	Underline bool
+	// ColorDepth is the number of colors the terminal-color parser may
+	// use, see WithColorDepth
+	ColorDepth ColorDepth
+	// Style holds text attributes such as bold, applied like colors
+	Style Style
+	// ColorStrategy picks the color of each cell; nil cycles colors per
+	// input character
+	ColorStrategy ColorStrategy
+	// names given to WithTheme and WithPreset that are not a theme or
+	// preset, for Validate
+	unknowntheme, unknownpreset string
+	// Bubble draws the output inside a speech bubble
+	Bubble SpeechBubble
+	// Border draws a box around the output, see WithBorder
+	Border BorderStyle
+	// GlyphOverrides replaces the glyphs of some characters, see
+	// WithGlyphOverride
+	GlyphOverrides map[rune][]string
+	// LineHook, when set, is given every composed row before it is laid
+	// out, with the font's hardblanks still in it, and returns its cells
+	LineHook func(lineIndex int, cells []rune) []rune
+	// FontFallback lists the fonts that draw the characters the font
+	// lacks, see WithFontFallback
+	FontFallback []string
+	fallbacks    []*fallbackfont
+	// font of the current and previous glyph: 0 for the font, n for the
+	// nth fallback font
+	currfont, previousfont int
+	// ScaleX and ScaleY resize the composed output across and down, see
+	// WithScale; 0 leaves it as is
+	ScaleX, ScaleY float64
+	// Transforms rotate or mirror the output, see WithTransform
+	Transforms []Transform
+	// FontSources restricts where fonts and control files are loaded
+	// from, see WithFontSources; 0 allows every source
+	FontSources FontSource
+	// AllowFilesystemPaths allows font and control file names that are
+	// absolute or contain "..", see WithFilesystemPaths
+	AllowFilesystemPaths bool
+	// LinePrefix is put before every output line, after Indent spaces
+	LinePrefix string
+	Indent     int
+	// LineEnding ends every output line in place of "\n", see
+	// WithLineEnding; empty keeps the parser's
+	LineEnding string
+	// NoTrailingNewline leaves the last output line unterminated
+	NoTrailingNewline bool
+	// Separator is the line put between the blocks of RenderLines, if
+	// Separate is set
+	Separate  bool
+	Separator string
+	// Metrics, when set, is told how long renders and font loads take,
+	// see WithMetrics
+	Metrics Metrics
+	// input characters composed by the current render, for Metrics
+	renderchars int
+	// Tracer, when set, starts spans around font loading and the stages
+	// of every render, see WithTracer
+	Tracer Tracer
 }
 
 // New creates a new Config with default values
 func New() *Config {
 	cfg := &Config{
-		Justification: -1,
+		Justification: JustifyAuto,
 		Right2left:    -1,
 		Outputwidth:   DEFAULTCOLUMNS,
 		gr:            1,
@@ -188,13 +287,23 @@ func WithWidth(width int) Option {
 	}
 }
 
-// WithJustification sets the text justification (-1=auto, 0=left, 1=center, 2=right)
+// WithJustification sets the text justification (-1=auto, 0=left, 1=center,
+// 2=right, 3=full, see JustifyAuto and JustifyFull)
 func WithJustification(j int) Option {
 	return func(cfg *Config) {
 		cfg.Justification = j
 	}
 }
 
+// WithRTLRightJustify sets whether JustifyAuto makes right-to-left text
+// flush-right, as it does by default. Turning it off keeps right-to-left
+// fonts flush-left without setting the justification after LoadFont.
+func WithRTLRightJustify(on bool) Option {
+	return func(cfg *Config) {
+		cfg.NoRTLRightJustify = !on
+	}
+}
+
 // WithRightToLeft sets the right-to-left mode (-1=auto, 0=left, 1=right)
 func WithRightToLeft(r int) Option {
 	return func(cfg *Config) {
@@ -209,13 +318,7 @@ func WithSmushMode(mode int) Option {
 			cfg.Smushoverride = SMO_NO
 			return
 		}
-		if mode == 0 {
-			cfg.Smushmode = SM_KERN
-		} else if mode == -1 {
-			cfg.Smushmode = 0
-		} else {
-			cfg.Smushmode = (mode & 63) | SM_SMUSH
-		}
+		cfg.Smushmode = SmushRulesFromMode(mode).Bits()
 		cfg.Smushoverride = SMO_YES
 	}
 }
@@ -293,7 +396,7 @@ func Render(text string, options ...Option) (string, error) {
 		return "", err
 	}
 
-	return cfg.RenderString(text), nil
+	return cfg.Render(text)
 }
 
 // RenderWithFont is a convenience function to render text with a specific font
@@ -303,34 +406,100 @@ func RenderWithFont(text, fontName string) (string, error) {
 
 // LoadFont loads the font specified in the config
 func (cfg *Config) LoadFont() error {
-	cfg.outlinelenlimit = cfg.Outputwidth - 1
-	readcontrolfiles(cfg)
-	if err := readfont(cfg); err != nil {
+	return cfg.load(readfont)
+}
+
+// load loads the control files and character maps, then the font with read
+func (cfg *Config) load(read func(cfg *Config) error) (err error) {
+	end := cfg.span("figlet.LoadFont")
+	defer func() { end(err) }()
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	cfg.outlinelenlimit = cfg.linelimit()
+	if err := readcontrolfiles(cfg); err != nil {
+		return err
+	}
+	if err := cfg.loadcharmaps(); err != nil {
+		return err
+	}
+	start := time.Now()
+	err = read(cfg)
+	if err == nil {
+		err = cfg.loadfallbacks()
+	}
+	observeload(cfg.Metrics, start, cfg.Fontname, err)
+	if err != nil {
 		return err
 	}
 	linealloc(cfg)
 	return nil
 }
 
-// RenderString renders the given text and returns the result as a string
-func (cfg *Config) RenderString(text string) string {
-	cfg.output = &strings.Builder{}
+// RenderOption overrides a setting for a single RenderString call
+type RenderOption = Option
+
+// RenderString renders the given text and returns the result as a string.
+// Options override settings such as width, justification, smush mode, colors
+// and parser for this call only; the Config is left unchanged. Options that
+// need the font to be reloaded (font, font directory, control files) have no
+// effect here. An invalid configuration renders as ""; use Render to get the
+// error.
+func (cfg *Config) RenderString(text string, opts ...RenderOption) string {
+	result, _ := cfg.Render(text, opts...)
+	return result
+}
+
+// fontdefaults resolves the settings left to the font: direction,
+// justification and, if smush is set, the smush mode
+func (cfg *Config) fontdefaults(smush bool) {
+	var fontsmush, fontright2left int
+	if cfg.font != nil {
+		fontsmush, fontright2left = cfg.font.smush, cfg.font.right2left
+	}
+	if smush {
+		if cfg.Smushoverride == SMO_NO {
+			cfg.Smushmode = fontsmush
+		} else if cfg.Smushoverride == SMO_FORCE {
+			cfg.Smushmode |= fontsmush
+		}
+	}
+
+	if cfg.Right2left < 0 {
+		cfg.Right2left = fontright2left
+	}
+
+	if cfg.Justification < 0 {
+		cfg.Justification = 0
+		if !cfg.NoRTLRightJustify {
+			cfg.Justification = 2 * cfg.Right2left
+		}
+	}
+}
+
+// compose runs the FIGlet engine over text and returns the composed rows:
+// the raw glyph grid with the input character index of every cell. It does
+// no justification, coloring or serialization.
+func (cfg *Config) compose(text string) []outputrow {
+	defer cfg.span("figlet.compose")(nil)
+	// The width may have changed since the font was loaded
+	if cfg.outlinelenlimit != cfg.linelimit() && cfg.charheight > 0 {
+		cfg.outlinelenlimit = cfg.linelimit()
+		linealloc(cfg)
+	}
+	cfg.rows = cfg.rows[:0]
+	cfg.renderchars += utf8.RuneCountInString(text)
 	cfg.Cmdinput = true
 	cfg.Argv = []string{"figlet", text}
 	cfg.Optind = 1
 	cfg.agetmode = 0
 	cfg.currentCharIndex = 0
-	cfg.currentLineIndex = 0
-	cfg.baseRowIndex = 0
-	cfg.charPositionMap = make([][]int, 0)
-
-	// Write parser prefix if any
-	if cfg.OutputParser != nil && cfg.OutputParser.Prefix != "" {
-		cfg.output.WriteString(cfg.OutputParser.Prefix)
-	}
+	cfg.smushtrace = nil
+	cfg.clearline()
 
 	wordbreakmode := 0
 	last_was_eol_flag := false
+	wasword := false // previous input character was part of a word, for CaseTitle
 
 	for {
 		c := getinchr(cfg)
@@ -349,6 +518,12 @@ func (cfg *Config) RenderString(text string) string {
 		}
 		last_was_eol_flag = isASCII(c) && unicode.IsSpace(c) && c != '\t' && c != ' '
 
+		if cfg.Case != CaseNone {
+			inword := wasword
+			wasword = iswordchar(c)
+			c = cfg.applycase(c, inword)
+		}
+
 		if cfg.Deutschflag {
 			if c >= '[' && c <= ']' {
 				c = Deutsch[c-'[']
@@ -358,6 +533,9 @@ func (cfg *Config) RenderString(text string) string {
 		}
 
 		c = handlemapping(cfg, c)
+		if len(cfg.CharMaps) > 0 {
+			c = cfg.charmap(c)
+		}
 
 		if isASCII(c) && unicode.IsSpace(c) {
 			if c == '\t' || c == ' ' {
@@ -402,29 +580,42 @@ func (cfg *Config) RenderString(text string) string {
 					}
 				}
 			} else if cfg.outlinelen == 0 {
+				// The character is wider than the line: output it alone
+				cfg.debugf("wrap: %q is wider than the line, output alone", c)
 				for i := 0; i < cfg.charheight; i++ {
+					str := cfg.currchar[i]
 					if cfg.Right2left == 1 && cfg.Outputwidth > 1 {
-						start := len(cfg.currchar[i]) - cfg.outlinelenlimit
+						start := len(str) - cfg.outlinelenlimit
 						if start < 0 {
 							start = 0
 						}
-						cfg.putstring(cfg.currchar[i][start:])
-					} else {
-						cfg.putstring(cfg.currchar[i])
+						str = str[start:]
+					}
+					index := make([]int, len(str))
+					for k := range index {
+						index[k] = cfg.currentCharIndex
 					}
+					cfg.putstring(str, index)
 				}
+				cfg.currentCharIndex++
 				wordbreakmode = -1
 			} else if c == ' ' {
 				if wordbreakmode == 2 {
+					cfg.debugf("wrap: line full at a space, splitting at the last word break")
 					cfg.splitline()
 				} else {
+					cfg.debugf("wrap: line full at a space, breaking the line")
+					cfg.wrapping = true
 					cfg.printline()
 				}
 				wordbreakmode = -1
 			} else {
 				if wordbreakmode >= 2 {
+					cfg.debugf("wrap: line full at %q, moving the word to the next line", c)
 					cfg.splitline()
 				} else {
+					cfg.debugf("wrap: line full at %q, breaking the word", c)
+					cfg.wrapping = true
 					cfg.printline()
 				}
 				if wordbreakmode == 3 {
@@ -444,13 +635,7 @@ func (cfg *Config) RenderString(text string) string {
 	if cfg.outlinelen != 0 {
 		cfg.printline()
 	}
-
-	// Write parser suffix if any
-	if cfg.OutputParser != nil && cfg.OutputParser.Suffix != "" {
-		cfg.output.WriteString(cfg.OutputParser.Suffix)
-	}
-
-	return cfg.output.String()
+	return cfg.rows
 }
 
 // ListFonts returns a list of available fonts from the embedded fonts
@@ -468,7 +653,7 @@ func ListFonts() []string {
 			fonts = append(fonts, strings.TrimSuffix(name, TOILETFILESUFFIX))
 		}
 	}
-	return fonts
+	return append(fonts, blockfonts...)
 }
 
 // GetVersion returns the FIGlet version string
@@ -509,42 +694,55 @@ type ZFILE struct {
 	zipFile   *zip.File
 	zipReader io.ReadCloser
 	file      *os.File // For filesystem files that need to be closed
+	line      int      // Number of newlines consumed so far
+	eof       bool
 }
 
 func Zopen(path string, mode string) (*ZFILE, error) {
 	// Try embedded fonts first
 	if strings.HasPrefix(path, "fonts/") || !strings.Contains(path, "/") {
-		// Try embedded
-		data, err := embeddedFonts.ReadFile(path)
-		if err == nil {
-			// Check if it's a zip file
-			if len(data) >= 4 && string(data[0:4]) == "PK\x03\x04" {
-				// It's a zip file
-				zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
-				if err != nil {
-					return nil, err
-				}
-				if len(zipReader.File) > 0 {
-					zf := zipReader.File[0]
-					rc, err := zf.Open()
-					if err != nil {
-						return nil, err
-					}
-					return &ZFILE{
-						reader:    rc,
-						isZip:     true,
-						zipFile:   zf,
-						zipReader: rc,
-					}, nil
-				}
+		if zf, err := zopenembedded(path); !errors.Is(err, fs.ErrNotExist) {
+			return zf, err
+		}
+	}
+	return zopenfile(path)
+}
+
+// zopenembedded opens one of the embedded fonts, such as fonts/big.flf
+func zopenembedded(path string) (*ZFILE, error) {
+	data, err := embeddedFonts.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// Check if it's a zip file
+	if len(data) >= 4 && string(data[0:4]) == "PK\x03\x04" {
+		// It's a zip file
+		zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, err
+		}
+		if len(zipReader.File) > 0 {
+			zf := zipReader.File[0]
+			rc, err := zf.Open()
+			if err != nil {
+				return nil, err
 			}
 			return &ZFILE{
-				reader: bytes.NewReader(data),
+				reader:    rc,
+				isZip:     true,
+				zipFile:   zf,
+				zipReader: rc,
 			}, nil
 		}
 	}
+	return &ZFILE{
+		reader: bytes.NewReader(data),
+	}, nil
+}
 
-	// Try filesystem
+// zopenfile opens a file from the filesystem, reading the first file of
+// a zip archive
+func zopenfile(path string) (*ZFILE, error) {
 	file, err := os.Open(path)
 	if err != nil {
 		return nil, err
@@ -589,13 +787,20 @@ func Zopen(path string, mode string) (*ZFILE, error) {
 }
 
 func Zgetc(zf *ZFILE) int {
+	if zf.eof {
+		return -1
+	}
 	if zf.buffer == nil || zf.pos >= len(zf.buffer) {
-		buf := make([]byte, 4096)
-		n, err := zf.reader.Read(buf)
+		if zf.buffer == nil {
+			zf.buffer = make([]byte, 4096)
+		}
+		n, err := zf.reader.Read(zf.buffer[:cap(zf.buffer)])
 		if err != nil && n == 0 {
+			// EOF is sticky, so callers looping at the end of a short file stay cheap
+			zf.eof = true
 			return -1
 		}
-		zf.buffer = buf[:n]
+		zf.buffer = zf.buffer[:n]
 		zf.pos = 0
 	}
 	if zf.pos >= len(zf.buffer) {
@@ -603,12 +808,21 @@ func Zgetc(zf *ZFILE) int {
 	}
 	b := zf.buffer[zf.pos]
 	zf.pos++
+	if b == '\n' {
+		zf.line++
+	}
 	return int(b)
 }
 
 func Zungetc(c int, zf *ZFILE) {
+	if c == -1 {
+		return
+	}
 	if zf.pos > 0 {
 		zf.pos--
+		if zf.buffer[zf.pos] == '\n' {
+			zf.line--
+		}
 	}
 }
 
@@ -655,6 +869,17 @@ func myfgets(line []byte, maxlen int, zf *ZFILE) []byte {
 	return nil
 }
 
+// skipblanks skips spaces and tabs but, unlike skipws, stops at the end of the line
+func skipblanks(zf *ZFILE) {
+	for {
+		c := Zgetc(zf)
+		if c != ' ' && c != '\t' {
+			Zungetc(c, zf)
+			return
+		}
+	}
+}
+
 func skiptoeol(zf *ZFILE) {
 	for {
 		c := Zgetc(zf)
@@ -791,29 +1016,56 @@ func readTchar(zf *ZFILE) rune {
 }
 
 func FIGopen(cfg *Config, name string, suffix string) (*ZFILE, error) {
+	if err := cfg.checkname(name); err != nil {
+		return nil, err
+	}
+	if sources := cfg.fontsources(); sources != FontSourceAny {
+		return cfg.figopenfrom(sources, name, suffix)
+	}
 	// Try with fontdirname
 	if !hasdirsep(name) {
 		path := filepath.Join(cfg.Fontdirname, name+suffix)
-		zf, err := Zopen(path, "rb")
+		zf, err := cfg.zopen(path)
 		if err == nil {
 			return zf, nil
 		}
 		// Try embedded
 		embeddedPath := filepath.Join("fonts", name+suffix)
-		zf, err = Zopen(embeddedPath, "rb")
+		zf, err = cfg.zopen(embeddedPath)
 		if err == nil {
 			return zf, nil
 		}
 	}
 	// Try as full path
 	path := name + suffix
-	zf, err := Zopen(path, "rb")
+	zf, err := cfg.zopen(path)
 	if err == nil {
 		return zf, nil
 	}
 	// Try embedded
 	embeddedPath := filepath.Join("fonts", filepath.Base(name)+suffix)
-	return Zopen(embeddedPath, "rb")
+	return cfg.zopen(embeddedPath)
+}
+
+// zopen opens a file for FIGopen, logging the attempt
+func (cfg *Config) zopen(path string) (*ZFILE, error) {
+	zf, err := Zopen(path, "rb")
+	return cfg.logopen(path, zf, err)
+}
+
+// logopen logs an attempt to open a font or control file
+func (cfg *Config) logopen(path string, zf *ZFILE, err error) (*ZFILE, error) {
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		cfg.debugf("open %s: not found", path)
+	case err != nil:
+		cfg.debugf("open %s: %v", path, err)
+	case zf.file == nil:
+		cfg.debugf("open %s: embedded", path)
+	default:
+		cfg.debugf("open %s: found", path)
+	}
+	return zf, err
 }
 
 func charsetname(zf *ZFILE) rune {
@@ -833,7 +1085,12 @@ func charset(cfg *Config, n int, controlfile *ZFILE) {
 	}
 	ch := Zgetc(controlfile)
 	if ch == '6' {
-		cfg.gn[n] = rune(65536)*charsetname(controlfile) + 0x80
+		skipblanks(controlfile)
+		name := charsetname(controlfile)
+		if name == 'A' { // Latin-1 right half, as in ESC - A
+			name = 0
+		}
+		cfg.gn[n] = rune(65536)*name + 0x80
 		cfg.gndbl[n] = false
 		skiptoeol(controlfile)
 		return
@@ -852,25 +1109,67 @@ func charset(cfg *Config, n int, controlfile *ZFILE) {
 			skiptoeol(controlfile)
 			return
 		}
-		skipws(controlfile)
+		skipblanks(controlfile)
 		cfg.gn[n] = rune(65536) * charsetname(controlfile)
 		cfg.gndbl[n] = true
 		skiptoeol(controlfile)
 		return
 	}
 	Zungetc(ch, controlfile)
-	skipws(controlfile)
-	cfg.gn[n] = rune(65536) * charsetname(controlfile)
+	skipblanks(controlfile)
+	name := charsetname(controlfile)
+	if name == 'B' { // ASCII, as in ESC ( B
+		name = 0
+	}
+	cfg.gn[n] = rune(65536) * name
 	cfg.gndbl[n] = false
+	skiptoeol(controlfile)
 }
 
 func readcontrol(cfg *Config, controlname string) error {
+	// Refuse include cycles: a file may only appear once on the stack
+	for i, name := range cfg.controlstack {
+		if name == controlname {
+			chain := append(append([]string(nil), cfg.controlstack[i:]...), controlname)
+			return fmt.Errorf("control file include cycle: %s", strings.Join(chain, " -> "))
+		}
+	}
+
 	controlfile, err := FIGopen(cfg, controlname, CONTROLFILESUFFIX)
-	if err != nil {
+	var nameerr *FontNameError
+	if errors.As(err, &nameerr) {
+		return err
+	} else if err != nil {
 		return fmt.Errorf("unable to open control file: %s", controlname)
 	}
 	defer Zclose(controlfile)
 
+	cfg.controlfiles = append(cfg.controlfiles, controlname)
+	cfg.controlstack = append(cfg.controlstack, controlname)
+	defer func() { cfg.controlstack = cfg.controlstack[:len(cfg.controlstack)-1] }()
+	return parsecontrol(cfg, controlfile)
+}
+
+// includename resolves the name used by an include command. Names without a
+// directory are looked up next to the including file first.
+func (cfg *Config) includename(name string) string {
+	name = strings.TrimSuffix(name, CONTROLFILESUFFIX)
+	if len(cfg.controlstack) == 0 || hasdirsep(name) {
+		return name
+	}
+	parent := cfg.controlstack[len(cfg.controlstack)-1]
+	if !hasdirsep(parent) {
+		return name
+	}
+	sibling := filepath.Join(filepath.Dir(parent), name)
+	if _, err := os.Stat(sibling + CONTROLFILESUFFIX); err == nil {
+		return sibling
+	}
+	return name
+}
+
+// parsecontrol reads the commands of an already opened control file
+func parsecontrol(cfg *Config, controlfile *ZFILE) error {
 	// Begin with a freeze command
 	node := &ComNode{thecommand: 0}
 	*cfg.commandlistend = node
@@ -905,12 +1204,19 @@ func readcontrol(cfg *Config, controlname string) error {
 			*cfg.commandlistend = node
 			cfg.commandlistend = &node.next
 		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9', '-':
+			// "in out" maps one code; "lo-hi out" maps a range starting at out.
+			// A target range ("lo-hi outlo-outhi") is accepted like for 't'.
 			Zungetc(command, controlfile)
 			firstch, _ := readnum(controlfile)
+			lastch := firstch
+			if dashcheck := Zgetc(controlfile); dashcheck == '-' {
+				lastch, _ = readnum(controlfile)
+			} else {
+				Zungetc(dashcheck, controlfile)
+			}
 			skipws(controlfile)
-			lastch, _ := readnum(controlfile)
-			offset := lastch - firstch
-			lastch = firstch
+			target, _ := readnum(controlfile)
+			offset := target - firstch
 			skiptoeol(controlfile)
 			node := &ComNode{
 				thecommand: 1,
@@ -927,12 +1233,16 @@ func readcontrol(cfg *Config, controlname string) error {
 			cfg.commandlistend = &node.next
 		case 'b':
 			cfg.Multibyte = 1
+			skiptoeol(controlfile)
 		case 'u':
 			cfg.Multibyte = 2
+			skiptoeol(controlfile)
 		case 'h':
 			cfg.Multibyte = 3
+			skiptoeol(controlfile)
 		case 'j':
 			cfg.Multibyte = 4
+			skiptoeol(controlfile)
 		case 'g':
 			cfg.Multibyte = 0
 			skipws(controlfile)
@@ -948,15 +1258,35 @@ func readcontrol(cfg *Config, controlname string) error {
 				charset(cfg, 3, controlfile)
 			case 'l', 'L':
 				skipws(controlfile)
-				cfg.gl = Zgetc(controlfile) - '0'
+				if n := Zgetc(controlfile) - '0'; n >= 0 && n <= 3 {
+					cfg.gl = n
+				}
 				skiptoeol(controlfile)
 			case 'r', 'R':
 				skipws(controlfile)
-				cfg.gr = Zgetc(controlfile) - '0'
+				if n := Zgetc(controlfile) - '0'; n >= 0 && n <= 3 {
+					cfg.gr = n
+				}
 				skiptoeol(controlfile)
 			default:
 				skiptoeol(controlfile)
 			}
+		case 'i':
+			// Include another control file at this point
+			skipblanks(controlfile)
+			var name []rune
+			for {
+				c := Zgetc(controlfile)
+				if c == -1 || c == '\n' || c == '\r' {
+					Zungetc(c, controlfile)
+					break
+				}
+				name = append(name, rune(c))
+			}
+			skiptoeol(controlfile)
+			if err := readcontrol(cfg, cfg.includename(strings.TrimSpace(string(name)))); err != nil {
+				return err
+			}
 		case '\r', '\n':
 			// blank line
 		default:
@@ -966,36 +1296,43 @@ func readcontrol(cfg *Config, controlname string) error {
 	return nil
 }
 
-func readcontrolfiles(cfg *Config) {
+// readcontrolfiles applies the control files in the order they were added.
+// The command list is rebuilt from scratch, so loading again is idempotent.
+func readcontrolfiles(cfg *Config) error {
+	cfg.commandlist = nil
+	cfg.commandlistend = &cfg.commandlist
+	cfg.controlfiles = cfg.controlfiles[:0]
+	cfg.controlstack = cfg.controlstack[:0]
 	for cfnptr := cfg.cfilelist; cfnptr != nil; cfnptr = cfnptr.next {
-		readcontrol(cfg, cfnptr.thename)
+		if err := readcontrol(cfg, cfnptr.thename); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 func (cfg *Config) clearline() {
 	for i := 0; i < cfg.charheight; i++ {
 		cfg.outputline[i] = cfg.outputline[i][:0]
-		if !cfg.PreserveMap && cfg.charPositionMap != nil && i < len(cfg.charPositionMap) {
-			cfg.charPositionMap[i] = cfg.charPositionMap[i][:0]
-		}
+		cfg.outputmap[i] = cfg.outputmap[i][:0]
 	}
 	cfg.outlinelen = 0
 	cfg.inchrlinelen = 0
 }
 
-func readfontchar(cfg *Config, file *ZFILE, theord rune) {
-	fclsave := cfg.fcharlist
-	cfg.fcharlist = &FCharNode{
-		ord:     theord,
-		thechar: make([][]rune, cfg.charheight),
-		next:    fclsave,
-	}
+// readfontchar reads one character of height rows of font f from the font
+// file, links it in front of next, and reports whether all of its rows were
+// present. Rows are stored in the arena.
+func readfontchar(f *Font, arena *glypharena, file *ZFILE, theord rune, height int, next *FCharNode) (*FCharNode, bool) {
+	startline := file.line + 1
+	complete := true
+	node := arena.char(theord, height, next)
 
-	templine := make([]byte, MAXLEN+1)
-	for row := 0; row < cfg.charheight; row++ {
-		line := myfgets(templine, MAXLEN+1, file)
+	for row := 0; row < height; row++ {
+		line := myfgets(arena.line, MAXLEN+1, file)
 		if line == nil {
-			cfg.fcharlist.thechar[row] = []rune{}
+			node.thechar[row] = []rune{}
+			complete = false
 			continue
 		}
 		// Remove newline if present
@@ -1006,50 +1343,64 @@ func readfontchar(cfg *Config, file *ZFILE, theord rune) {
 		if len(line) > 0 && line[len(line)-1] == '\r' {
 			line = line[:len(line)-1]
 		}
-		var outline []rune
-		if cfg.toiletfont {
-			outline = []rune(string(line))
-		} else {
-			outline = []rune(string(line))
-		}
-		// Remove trailing spaces
-		k := len(outline) - 1
-		for k >= 0 && k < len(outline) && unicode.IsSpace(outline[k]) {
-			k--
-		}
-		// Remove endmarks
-		if k >= 0 && k < len(outline) {
-			endchar := outline[k]
-			for k >= 0 && k < len(outline) && outline[k] == endchar {
-				k--
-			}
-		}
-		// k+1 is the new length (like outline[k+1] = '\0' in C)
-		if k+1 >= 0 {
-			if k+1 <= len(outline) {
-				outline = outline[:k+1]
-			} else {
-				outline = []rune{}
-			}
-		} else {
-			outline = []rune{}
-		}
-		cfg.fcharlist.thechar[row] = outline
+		node.thechar[row] = arena.row(line)
 	}
+	if complete {
+		f.checkglyph(theord, startline, node.thechar)
+	}
+	return node, complete
 }
 
 func readfont(cfg *Config) error {
+	if cfg.loadedfont != nil {
+		cfg.setfont(cfg.loadedfont)
+		return nil
+	}
+	fontfile, err := openfont(cfg)
+	if err != nil {
+		return err
+	}
+	defer Zclose(fontfile)
+	if cfg.readsidecar(fontfile) {
+		return nil
+	}
+	return parsefont(cfg, fontfile)
+}
+
+// openfont opens the FIGlet or TOIlet font named by cfg.Fontname, and
+// starts a new font for it to be read into
+func openfont(cfg *Config) (*ZFILE, error) {
+	if err := cfg.checkname(cfg.Fontname); err != nil {
+		return nil, err
+	}
+	toilet := false
 	fontfile, err := FIGopen(cfg, cfg.Fontname, FONTFILESUFFIX)
 	if err != nil {
 		fontfile, err = FIGopen(cfg, cfg.Fontname, TOILETFILESUFFIX)
-		if err == nil {
-			cfg.toiletfont = true
-		}
+		toilet = err == nil
+	}
+	if err == nil {
+		cfg.font = &Font{name: cfg.Fontname, toilet: toilet}
 	}
 	if err != nil {
-		return fmt.Errorf("unable to open font file: %s", cfg.Fontname)
+		// A font file of the same name takes precedence over a generated font
+		if b, ok := generatedfont(cfg.Fontname); ok {
+			var buf bytes.Buffer
+			b.WriteTo(&buf)
+			cfg.debugf("open %s: generated", cfg.Fontname)
+			cfg.font = &Font{name: cfg.Fontname}
+			return &ZFILE{reader: &buf}, nil
+		}
+		return nil, fmt.Errorf("unable to open font file: %s", cfg.Fontname)
 	}
-	defer Zclose(fontfile)
+	return fontfile, nil
+}
+
+// parsefont reads the header and characters of an already opened font file
+// into the font started for it
+func parsefont(cfg *Config, fontfile *ZFILE) error {
+	f := cfg.font
+	f.lazy = nil
 
 	magicnum := readmagic(fontfile)
 	fileline := make([]byte, MAXLEN+1)
@@ -1069,20 +1420,25 @@ func readfont(cfg *Config) error {
 		&dummy, &hardblank, &charheight, &upheight, &maxlen, &smush, &cmtlines,
 		&ffright2left, &smush2)
 
+	cfg.debugf("header: magic %s, hardblank %q, height %d, baseline %d, max length %d, old layout %d, comment lines %d, print direction %d, full layout %d",
+		magicnum, hardblank, charheight, upheight, maxlen, smush, cmtlines, ffright2left, smush2)
 	if maxlen > MAXLEN {
 		return fmt.Errorf("font %s: character is too wide", cfg.Fontname)
 	}
+	if charheight > MAXHEIGHT {
+		return fmt.Errorf("font %s: character is too tall", cfg.Fontname)
+	}
 
 	// Check magic number
-	if (!cfg.toiletfont && magicnum != FONTFILEMAGICNUMBER) ||
-		(cfg.toiletfont && magicnum != TOILETFILEMAGICNUMBER) {
+	if (!f.toilet && magicnum != FONTFILEMAGICNUMBER) ||
+		(f.toilet && magicnum != TOILETFILEMAGICNUMBER) {
 		return fmt.Errorf("font %s: not a FIGlet 2 font file (magic: %s, expected: %s)", cfg.Fontname, magicnum, FONTFILEMAGICNUMBER)
 	}
 	if numsread < 7 {
 		return fmt.Errorf("font %s: not a FIGlet 2 font file (numsread: %d)", cfg.Fontname, numsread)
 	}
 
-	for i := 1; i <= cmtlines; i++ {
+	for i := 1; i <= cmtlines && !fontfile.eof; i++ {
 		skiptoeol(fontfile)
 	}
 
@@ -1104,48 +1460,61 @@ func readfont(cfg *Config) error {
 		charheight = 1
 	}
 
+	// A baseline outside the character puts it at the bottom
+	if upheight < 1 || upheight > charheight {
+		upheight = charheight
+	}
+
 	if maxlen < 1 {
 		maxlen = 1
 	}
 
 	maxlen += 100
 
-	if cfg.Smushoverride == SMO_NO {
-		cfg.Smushmode = smush2
-	} else if cfg.Smushoverride == SMO_FORCE {
-		cfg.Smushmode |= smush2
+	f.smush = smush2
+	f.right2left = 0
+	if ffright2left != 0 {
+		f.right2left = 1
 	}
-
-	if cfg.Right2left < 0 {
-		if ffright2left != 0 {
-			cfg.Right2left = 1
-		} else {
-			cfg.Right2left = 0
-		}
-	}
-
-	if cfg.Justification < 0 {
-		cfg.Justification = 2 * cfg.Right2left
-	}
-
-	cfg.hardblank = rune(hardblank)
-	cfg.charheight = charheight
+	f.hardblank, f.height, f.baseline = rune(hardblank), charheight, upheight
+	cfg.setfont(f)
+	cfg.checkhardblank()
 
 	// Allocate "missing" character
-	cfg.fcharlist = &FCharNode{
-		ord:     0,
-		thechar: make([][]rune, charheight),
-		next:    nil,
-	}
+	arena := newglypharena()
+	f.glyphs = arena.char(0, charheight, nil)
 	for row := 0; row < charheight; row++ {
-		cfg.fcharlist.thechar[row] = []rune{}
+		f.glyphs.thechar[row] = []rune{}
+	}
+	// read reads the next character into the font
+	read := func(theord rune) bool {
+		var complete bool
+		f.glyphs, complete = readfontchar(f, arena, fontfile, theord, charheight, f.glyphs)
+		return complete
 	}
 
+	missing := 0
+	// Characters past the end of a truncated file are left out; lookups
+	// fall back to the empty "missing" character just the same.
 	for theord := ' '; theord <= '~'; theord++ {
-		readfontchar(cfg, fontfile, theord)
+		if fontfile.eof || !read(theord) {
+			missing++
+		}
 	}
 	for i := 0; i <= 6; i++ {
-		readfontchar(cfg, fontfile, Deutsch[i])
+		if fontfile.eof || !read(Deutsch[i]) {
+			missing++
+		}
+	}
+	if missing > 0 {
+		cfg.warn(WarnMissingGlyph, 0, fontfile.line,
+			"font ends early: %d of the 102 required characters are missing", missing)
+	}
+
+	if cfg.LazyGlyphs {
+		f.indexglyphs(fontfile, arena)
+		cfg.debugf("lazy: %d code-tagged characters deferred", len(f.lazy.offsets))
+		return nil
 	}
 
 	fileline = make([]byte, maxlen+1)
@@ -1154,56 +1523,76 @@ func readfont(cfg *Config) error {
 		if line == nil {
 			break
 		}
-		lineStr := strings.TrimSpace(string(line))
-		var theord int64
-		var err error
-		// Try to parse as hex (0x...) or octal (0...) or decimal
-		if strings.HasPrefix(lineStr, "0x") || strings.HasPrefix(lineStr, "0X") {
-			_, err = fmt.Sscanf(lineStr, "0x%x", &theord)
-			if err != nil {
-				_, err = fmt.Sscanf(lineStr, "0X%x", &theord)
-			}
-		} else if strings.HasPrefix(lineStr, "-0x") || strings.HasPrefix(lineStr, "-0X") {
-			_, err = fmt.Sscanf(lineStr, "-0x%x", &theord)
-			if err != nil {
-				_, err = fmt.Sscanf(lineStr, "-0X%x", &theord)
-			}
-			theord = -theord
-		} else {
-			theord, err = strconv.ParseInt(lineStr, 0, 64)
-			if err != nil {
-				// Try just reading first number
-				_, err = fmt.Sscanf(lineStr, "%d", &theord)
-			}
-		}
-		if err != nil {
+		theord, ok := parsecodetag(line)
+		if !ok {
 			break
 		}
-		readfontchar(cfg, fontfile, rune(theord))
+		if !read(theord) {
+			cfg.warn(WarnMissingGlyph, theord, fontfile.line,
+				"character %d is truncated by the end of the file", theord)
+		}
 	}
 	return nil
 }
 
+// parsecodetag parses the character code at the start of a code tag line.
+// Codes may be decimal, octal (0...) or hexadecimal (0x...), and negative.
+func parsecodetag(line []byte) (rune, bool) {
+	lineStr := strings.TrimSpace(string(line))
+	var theord int64
+	var err error
+	// Try to parse as hex (0x...) or octal (0...) or decimal
+	if strings.HasPrefix(lineStr, "0x") || strings.HasPrefix(lineStr, "0X") {
+		_, err = fmt.Sscanf(lineStr, "0x%x", &theord)
+		if err != nil {
+			_, err = fmt.Sscanf(lineStr, "0X%x", &theord)
+		}
+	} else if strings.HasPrefix(lineStr, "-0x") || strings.HasPrefix(lineStr, "-0X") {
+		_, err = fmt.Sscanf(lineStr, "-0x%x", &theord)
+		if err != nil {
+			_, err = fmt.Sscanf(lineStr, "-0X%x", &theord)
+		}
+		theord = -theord
+	} else {
+		theord, err = strconv.ParseInt(lineStr, 0, 64)
+		if err != nil {
+			// Try just reading first number
+			_, err = fmt.Sscanf(lineStr, "%d", &theord)
+		}
+	}
+	return rune(theord), err == nil
+}
+
 func linealloc(cfg *Config) {
 	cfg.outputline = make([][]rune, cfg.charheight)
+	cfg.outputmap = make([][]int, cfg.charheight)
 	for row := 0; row < cfg.charheight; row++ {
 		cfg.outputline[row] = make([]rune, cfg.outlinelenlimit+1)
+		cfg.outputmap[row] = make([]int, 0, cfg.outlinelenlimit+1)
 	}
 	cfg.inchrlinelenlimit = cfg.Outputwidth*4 + 100
 	cfg.inchrline = make([]rune, cfg.inchrlinelenlimit+1)
+	cfg.inchrindex = make([]int, cfg.inchrlinelenlimit+1)
 	cfg.clearline()
 }
 
 func (cfg *Config) getletter(c rune) {
+	cfg.previousfont, cfg.currfont = cfg.currfont, 0
 	var charptr *FCharNode
-	for charptr = cfg.fcharlist; charptr != nil && charptr.ord != c; charptr = charptr.next {
-	}
-	if charptr != nil {
-		cfg.currchar = charptr.thechar
-	} else {
-		for charptr = cfg.fcharlist; charptr != nil && charptr.ord != 0; charptr = charptr.next {
+	if rows := cfg.overrideglyph(c); rows != nil {
+		charptr = &FCharNode{ord: c, thechar: rows}
+	} else if charptr = cfg.findglyph(c); len(cfg.fallbacks) > 0 && (charptr == nil || glyphwidth(charptr.thechar) == 0) {
+		if rows, font := cfg.fallbackglyph(c); rows != nil {
+			charptr = &FCharNode{ord: c, thechar: rows}
+			cfg.currfont = font
 		}
-		cfg.currchar = charptr.thechar
+	}
+	if charptr == nil {
+		charptr = cfg.findglyph(0)
+	}
+	cfg.currchar = charptr.thechar
+	if cfg.UnsafeCellReplacement != 0 {
+		cfg.currchar = cfg.safeglyph(cfg.currchar)
 	}
 	cfg.previouscharwidth = cfg.currcharwidth
 	if len(cfg.currchar) > 0 && len(cfg.currchar[0]) > 0 {
@@ -1213,133 +1602,19 @@ func (cfg *Config) getletter(c rune) {
 	}
 }
 
+// smushem returns the sub-character that results from smushing lch and rch
+// with the configured rules, or 0 if they cannot be smushed
 func (cfg *Config) smushem(lch, rch rune) rune {
-	if lch == ' ' {
-		return rch
-	}
-	if rch == ' ' {
-		return lch
-	}
-
-	if cfg.previouscharwidth < 2 || cfg.currcharwidth < 2 {
-		return 0
-	}
-
-	if (cfg.Smushmode & SM_SMUSH) == 0 {
-		return 0
-	}
-
-	if (cfg.Smushmode & 63) == 0 {
-		if lch == ' ' {
-			return rch
-		}
-		if rch == ' ' {
-			return lch
-		}
-		if lch == cfg.hardblank {
-			return rch
-		}
-		if rch == cfg.hardblank {
-			return lch
-		}
-		if cfg.Right2left == 1 {
-			return lch
-		}
-		return rch
-	}
-
-	if (cfg.Smushmode & SM_HARDBLANK) != 0 {
-		if lch == cfg.hardblank && rch == cfg.hardblank {
-			return lch
-		}
-	}
-
-	if lch == cfg.hardblank || rch == cfg.hardblank {
-		return 0
-	}
-
-	if (cfg.Smushmode & SM_EQUAL) != 0 {
-		if lch == rch {
-			return lch
-		}
-	}
-
-	if (cfg.Smushmode & SM_LOWLINE) != 0 {
-		if lch == '_' && strings.ContainsRune("|/\\[]{}()<>", rch) {
-			return rch
-		}
-		if rch == '_' && strings.ContainsRune("|/\\[]{}()<>", lch) {
-			return lch
-		}
-	}
-
-	if (cfg.Smushmode & SM_HIERARCHY) != 0 {
-		if lch == '|' && strings.ContainsRune("/\\[]{}()<>", rch) {
-			return rch
-		}
-		if rch == '|' && strings.ContainsRune("/\\[]{}()<>", lch) {
-			return lch
-		}
-		if strings.ContainsRune("/\\", lch) && strings.ContainsRune("[]{}()<>", rch) {
-			return rch
-		}
-		if strings.ContainsRune("/\\", rch) && strings.ContainsRune("[]{}()<>", lch) {
-			return lch
-		}
-		if strings.ContainsRune("[]", lch) && strings.ContainsRune("{}()<>", rch) {
-			return rch
-		}
-		if strings.ContainsRune("[]", rch) && strings.ContainsRune("{}()<>", lch) {
-			return lch
-		}
-		if strings.ContainsRune("{}", lch) && strings.ContainsRune("()<>", rch) {
-			return rch
-		}
-		if strings.ContainsRune("{}", rch) && strings.ContainsRune("()<>", lch) {
-			return lch
-		}
-		if strings.ContainsRune("()", lch) && strings.ContainsRune("<>", rch) {
-			return rch
-		}
-		if strings.ContainsRune("()", rch) && strings.ContainsRune("<>", lch) {
-			return lch
-		}
-	}
-
-	if (cfg.Smushmode & SM_PAIR) != 0 {
-		if lch == '[' && rch == ']' {
-			return '|'
-		}
-		if rch == '[' && lch == ']' {
-			return '|'
-		}
-		if lch == '{' && rch == '}' {
-			return '|'
-		}
-		if rch == '{' && lch == '}' {
-			return '|'
-		}
-		if lch == '(' && rch == ')' {
-			return '|'
-		}
-		if rch == '(' && lch == ')' {
-			return '|'
-		}
-	}
+	r, _ := cfg.smushrule(lch, rch)
+	return r
+}
 
-	if (cfg.Smushmode & SM_BIGX) != 0 {
-		if lch == '/' && rch == '\\' {
-			return '|'
-		}
-		if rch == '/' && lch == '\\' {
-			return 'Y'
-		}
-		if lch == '>' && rch == '<' {
-			return 'X'
-		}
+// smushrule is smushem, also returning the rule that merged the sub-characters
+func (cfg *Config) smushrule(lch, rch rune) (rune, RuleApplied) {
+	if lch != ' ' && rch != ' ' && (cfg.previouscharwidth < 2 || cfg.currcharwidth < 2) {
+		return 0, RuleNone
 	}
-
-	return 0
+	return smush(lch, rch, cfg.Smushmode, cfg.hardblank, cfg.Right2left == 1)
 }
 
 func (cfg *Config) smushamt() int {
@@ -1347,18 +1622,19 @@ func (cfg *Config) smushamt() int {
 		return 0
 	}
 	maxsmush := cfg.currcharwidth
-	for row := 0; row < cfg.charheight; row++ {
+	for row := 0; row < cfg.linerows(); row++ {
 		var linebd, charbd int
 		var ch1, ch2 rune
+		currchar := cfg.currrow(row)
 
 		if cfg.Right2left == 1 {
 			// C: for (charbd=STRLEN(currchar[row]);
 			//      ch1=currchar[row][charbd],(charbd>0&&(!ch1||ch1==' '));charbd--) ;
-			charbd = len(cfg.currchar[row])
+			charbd = len(currchar)
 			for {
 				// Get ch1 at current position (null terminator if out of bounds)
-				if charbd < len(cfg.currchar[row]) {
-					ch1 = cfg.currchar[row][charbd]
+				if charbd < len(currchar) {
+					ch1 = currchar[charbd]
 				} else {
 					ch1 = 0
 				}
@@ -1417,8 +1693,8 @@ func (cfg *Config) smushamt() int {
 			// C: for (charbd=0;ch2=currchar[row][charbd],ch2==' ';charbd++) ;
 			charbd = 0
 			for {
-				if charbd < len(cfg.currchar[row]) {
-					ch2 = cfg.currchar[row][charbd]
+				if charbd < len(currchar) {
+					ch2 = currchar[charbd]
 				} else {
 					ch2 = 0
 				}
@@ -1446,232 +1722,197 @@ func (cfg *Config) smushamt() int {
 	return maxsmush
 }
 
+// linerows returns the number of rows of the output line, which is the
+// font height once the line is allocated
+func (cfg *Config) linerows() int {
+	return min(cfg.charheight, len(cfg.outputline))
+}
+
+// currrow returns a row of the current character. Glyphs with fewer rows
+// than the font, from malformed fonts, get blank rows of their width.
+func (cfg *Config) currrow(row int) []rune {
+	if row < len(cfg.currchar) {
+		return cfg.currchar[row]
+	}
+	return []rune(strings.Repeat(" ", cfg.currcharwidth))
+}
+
+// addchar adds the next input character to the output line, recording it
+// as the next character for color mapping.
 func (cfg *Config) addchar(c rune) bool {
+	if !cfg.addcharindex(c, cfg.currentCharIndex) {
+		return false
+	}
+	cfg.currentCharIndex++
+	return true
+}
+
+// addcharindex adds c to the output line and records index as the input
+// character that produced its cells.
+func (cfg *Config) addcharindex(c rune, index int) bool {
 	cfg.getletter(c)
+	if cfg.currfont != cfg.previousfont && cfg.Smushmode&SM_SMUSH != 0 {
+		// The smushing rules of one font make no sense for the
+		// sub-characters of another
+		defer func(mode int) { cfg.Smushmode = mode }(cfg.Smushmode)
+		cfg.Smushmode = cfg.Smushmode&^SM_SMUSH | SM_KERN
+	}
 	smushamount := cfg.smushamt()
 	if smushamount < 0 {
 		smushamount = 0
 	}
+	forced := 0
+	if cfg.Overlap > 0 && cfg.outlinelen > 0 {
+		forced = min(cfg.Overlap, cfg.outlinelen-smushamount)
+		smushamount += max(forced, 0)
+	}
 	if smushamount > cfg.currcharwidth {
 		smushamount = cfg.currcharwidth
 	}
 	if cfg.outlinelen+cfg.currcharwidth-smushamount > cfg.outlinelenlimit ||
 		cfg.inchrlinelen+1 > cfg.inchrlinelenlimit {
+		cfg.debugf("char %q: width %d, overlap %d: does not fit in %d columns (line is %d)",
+			c, cfg.currcharwidth, smushamount, cfg.outlinelenlimit, cfg.outlinelen)
 		return false
 	}
+	cfg.debugf("char %q: width %d, overlap %d (%s)", c, cfg.currcharwidth, smushamount, cfg.SmushRules().Mode)
 
-	// Track character position for color mapping
-	cfg.currentCharIndex++
-
-	for row := 0; row < cfg.charheight; row++ {
+	// Both directions join two pieces the same way: the left one, whose
+	// last smushamount columns overlap the right one. Right to left, the
+	// new character is the left piece and must be copied, as it belongs
+	// to the font.
+	for row := 0; row < cfg.linerows(); row++ {
+		char := cfg.currrow(row)
 		if cfg.Right2left == 1 {
-			templine := make([]rune, len(cfg.currchar[row]))
-			copy(templine, cfg.currchar[row])
-			for k := 0; k < smushamount && k < len(cfg.outputline[row]); k++ {
-				idx := cfg.currcharwidth - smushamount + k
-				if idx >= 0 && idx < len(templine) {
-					smushed := cfg.smushem(templine[idx], cfg.outputline[row][k])
-					if smushed != 0 {
-						templine[idx] = smushed
-					}
-				}
-			}
-			remaining := len(cfg.outputline[row])
-			if smushamount < remaining {
-				cfg.outputline[row] = append(templine, cfg.outputline[row][smushamount:]...)
-				// Track character positions for Right2left
-				if row < len(cfg.charPositionMap) {
-					charWidth := len(templine)
-					// Insert at the beginning for Right2left
-					newMap := make([]int, charWidth)
-					charIdx := cfg.currentCharIndex - 1
-					for i := range newMap {
-						newMap[i] = charIdx
-					}
-					// Only slice if we have enough elements
-					if smushamount < len(cfg.charPositionMap[row]) {
-						cfg.charPositionMap[row] = append(newMap, cfg.charPositionMap[row][smushamount:]...)
-					} else {
-						cfg.charPositionMap[row] = newMap
-					}
-				}
-			} else {
-				cfg.outputline[row] = templine
-				// Track character positions for Right2left
-				if cfg.baseRowIndex+row < len(cfg.charPositionMap) {
-					charWidth := len(templine)
-					newMap := make([]int, charWidth)
-					charIdx := cfg.currentCharIndex - 1
-					for i := range newMap {
-						newMap[i] = charIdx
-					}
-					cfg.charPositionMap[cfg.baseRowIndex+row] = newMap
-				}
+			charmap := make([]int, len(char))
+			for i := range charmap {
+				charmap[i] = index
 			}
+			cfg.outputline[row], cfg.outputmap[row] = cfg.joinrow(c, row, smushamount, cfg.currcharwidth, forced > 0,
+				append([]rune(nil), char...), charmap, cfg.outputline[row], cfg.outputmap[row])
 		} else {
-			// Track character positions for color mapping
-			startCol := cfg.outlinelen - smushamount
-			if startCol < 0 {
-				startCol = 0
+			for len(cfg.indexbuf) < len(char) {
+				cfg.indexbuf = append(cfg.indexbuf, 0)
 			}
-
-			// Ensure charPositionMap has enough rows
-			for len(cfg.charPositionMap) < cfg.baseRowIndex+cfg.charheight {
-				cfg.charPositionMap = append(cfg.charPositionMap, make([]int, 0, 100))
-			}
-
-			for k := 0; k < smushamount; k++ {
-				column := cfg.outlinelen - smushamount + k
-				if column < 0 {
-					column = 0
-				}
-				if column < len(cfg.outputline[row]) && k < len(cfg.currchar[row]) {
-					cfg.outputline[row][column] = cfg.smushem(cfg.outputline[row][column], cfg.currchar[row][k])
-					// Update character position map for smushed positions
-					if cfg.baseRowIndex+row < len(cfg.charPositionMap) && column < len(cfg.charPositionMap[cfg.baseRowIndex+row]) {
-						// Keep the existing character index for smushed positions
-					}
-				}
-			}
-			if smushamount < len(cfg.currchar[row]) {
-				cfg.outputline[row] = append(cfg.outputline[row], cfg.currchar[row][smushamount:]...)
-				// Track character positions for new columns
-				if cfg.baseRowIndex+row < len(cfg.charPositionMap) {
-					charWidth := len(cfg.currchar[row]) - smushamount
-					for i := 0; i < charWidth; i++ {
-						cfg.charPositionMap[cfg.baseRowIndex+row] = append(cfg.charPositionMap[cfg.baseRowIndex+row], cfg.currentCharIndex-1)
-					}
-				}
+			charmap := cfg.indexbuf[:len(char)]
+			for i := range charmap {
+				charmap[i] = index
 			}
+			cfg.outputline[row], cfg.outputmap[row] = cfg.joinrow(c, row, smushamount, cfg.outlinelen, forced > 0,
+				cfg.outputline[row], cfg.outputmap[row], char, charmap)
 		}
 	}
-	if len(cfg.outputline[0]) > 0 {
+	if len(cfg.outputline) > 0 && len(cfg.outputline[0]) > 0 {
 		cfg.outlinelen = len(cfg.outputline[0])
 	}
 	cfg.inchrline[cfg.inchrlinelen] = c
+	cfg.inchrindex[cfg.inchrlinelen] = index
 	cfg.inchrlinelen++
 	return true
 }
 
-func (cfg *Config) putstring(str []rune) {
-	length := len(str)
-	if cfg.Outputwidth > 1 {
-		if length > cfg.Outputwidth-1 {
-			length = cfg.Outputwidth - 1
+// joinrow appends right to left, smushing the overlap columns: the last
+// overlap of the width columns of left with the first of right. left is
+// modified. Smushed cells keep the input character index of the left cell.
+// When the overlap is forced by Overlap, cells no rule smushes go to the
+// visible one, or to the new character when both are.
+func (cfg *Config) joinrow(c rune, row, overlap, width int, forced bool, left []rune, leftmap []int, right []rune, rightmap []int) ([]rune, []int) {
+	for k := 0; k < overlap && k < len(right); k++ {
+		column := width - overlap + k
+		if column < 0 || column >= len(left) {
+			continue
 		}
-		if cfg.Justification > 0 {
-			for i := 1; (3-cfg.Justification)*i+length+cfg.Justification-2 < cfg.Outputwidth; i++ {
-				cfg.output.WriteString(" ")
+		smushed := cfg.tracesmush(c, row, column, left[column], right[k])
+		if smushed == 0 && forced {
+			newleft := cfg.Right2left == 1
+			switch {
+			case cfg.isblank(right[k]):
+				smushed = left[column]
+			case cfg.isblank(left[column]) || !newleft:
+				smushed = right[k]
+			default:
+				smushed = left[column]
 			}
 		}
-	}
-
-	// Apply colors if enabled
-	hasColors := len(cfg.Colors) > 0 && cfg.OutputParser != nil && cfg.OutputParser.Name != "terminal"
-
-	for i := 0; i < length; i++ {
-		if i < len(str) {
-			var charStr string
-			if str[i] == cfg.hardblank {
-				charStr = " "
-			} else {
-				charStr = string(str[i])
-			}
-
-			// Apply color if enabled
-			if hasColors {
-				charStr = cfg.applyColorToChar(charStr, i)
-			} else {
-				// Apply parser replacements even without colors
-				if cfg.OutputParser != nil {
-					charStr = handleReplaces(charStr, cfg.OutputParser)
-				}
-			}
-
-			cfg.output.WriteString(charStr)
+		if smushed != 0 {
+			left[column] = smushed
 		}
 	}
-
-	// Use parser's newline representation
-	newline := "\n"
-	if cfg.OutputParser != nil && cfg.OutputParser.NewLine != "" {
-		newline = cfg.OutputParser.NewLine
-	}
-	cfg.output.WriteString(newline)
-
-	// Move to next line for character position tracking
-	cfg.currentLineIndex++
-	if cfg.currentLineIndex >= cfg.charheight {
-		cfg.currentLineIndex = 0
+	if overlap < len(right) {
+		left = append(left, right[overlap:]...)
+		leftmap = append(leftmap, rightmap[overlap:]...)
 	}
+	return left, leftmap
 }
 
-// applyColorToChar applies color to a character based on its position in the line
-func (cfg *Config) applyColorToChar(charStr string, position int) string {
-	if len(cfg.Colors) == 0 {
-		return handleReplaces(charStr, cfg.OutputParser)
+// putstring buffers one composed output row together with the input
+// character index of each of its cells
+func (cfg *Config) putstring(str []rune, index []int) {
+	row := outputrow{cells: append([]rune(nil), str...)}
+	// Rows come in groups of charheight, one group per FIGlet line
+	row.baseline = cfg.charheight > 0 && len(cfg.rows)%cfg.charheight == cfg.baseline-1
+	if !cfg.DisableMappedColors {
+		row.index = append([]int(nil), index...)
 	}
-
-	// Get the input character index for this position
-	charIndex := -1
-	if !cfg.DisableMappedColors && cfg.charPositionMap != nil && cfg.currentLineIndex < len(cfg.charPositionMap) {
-		if position < len(cfg.charPositionMap[cfg.currentLineIndex]) {
-			charIndex = cfg.charPositionMap[cfg.currentLineIndex][position]
+	if cfg.LineHook != nil {
+		row.cells = cfg.LineHook(len(cfg.rows), row.cells)
+		// Cells the hook added are never colored
+		for len(row.index) > 0 && len(row.index) < len(row.cells) {
+			row.index = append(row.index, nocolor)
 		}
+		row.index = row.index[:min(len(row.index), len(row.cells))]
 	}
-
-	// If we couldn't map to an input character, use position-based cycling
-	if charIndex < 0 {
-		charIndex = position
-	}
-
-	// Cycle through colors based on character index
-	colorIndex := charIndex % len(cfg.Colors)
-	if colorIndex < 0 {
-		colorIndex = 0
-	}
-	color := cfg.Colors[colorIndex]
-
-	prefix := color.getPrefix(cfg.OutputParser)
-	suffix := color.getSuffix(cfg.OutputParser)
-
-	// Apply parser replacements
-	replaced := handleReplaces(charStr, cfg.OutputParser)
-
-	return prefix + replaced + suffix
+	cfg.rows = append(cfg.rows, row)
 }
 
 // applyColorWithIndex applies color based on a specific character index
 func (cfg *Config) applyColorWithIndex(charStr string, charIndex int) string {
-	if len(cfg.Colors) == 0 {
-		return handleReplaces(charStr, cfg.OutputParser)
-	}
+	prefix, suffix := cfg.colorcodes(charIndex)
+	return prefix + handleReplaces(charStr, cfg.OutputParser) + suffix
+}
+
+// colorcodes returns the markup put around a cell of the given character
+// index for its color and style, or "" and "" for an uncolored cell
+func (cfg *Config) colorcodes(charIndex int) (string, string) {
 	if charIndex < 0 {
-		return handleReplaces(charStr, cfg.OutputParser)
+		return "", ""
 	}
-	colorIndex := charIndex % len(cfg.Colors)
-	if colorIndex < 0 {
-		colorIndex = 0
+	var prefix, suffix string
+	if len(cfg.Colors) > 0 {
+		color := cfg.quantize(cfg.Colors[charIndex%len(cfg.Colors)])
+		prefix = color.getPrefix(cfg.OutputParser)
+		suffix = color.getSuffix(cfg.OutputParser)
 	}
-	color := cfg.Colors[colorIndex]
-	prefix := color.getPrefix(cfg.OutputParser)
-	suffix := color.getSuffix(cfg.OutputParser)
-	replaced := handleReplaces(charStr, cfg.OutputParser)
-	return prefix + replaced + suffix
+	return cfg.Style.wrap(prefix, suffix, cfg.OutputParser)
 }
 
 func (cfg *Config) printline() {
-	cfg.currentLineIndex = cfg.baseRowIndex
+	if cfg.Underline {
+		cfg.underline()
+	}
+	var gaps []int
+	if cfg.wrapping && cfg.Justification == JustifyFull {
+		gaps = cfg.wordgaps()
+	}
 	for i := 0; i < cfg.charheight; i++ {
-		cfg.putstring(cfg.outputline[i])
+		cfg.putstring(cfg.outputline[i], cfg.outputmap[i])
+		// Only the gaps within the row, which a LineHook may have shortened
+		row := &cfg.rows[len(cfg.rows)-1]
+		n := 0
+		for n < len(gaps) && gaps[n] < len(row.cells) {
+			n++
+		}
+		row.gaps = gaps[:n]
 	}
-	cfg.baseRowIndex += cfg.charheight
+	cfg.wrapping = false
 	cfg.clearline()
 }
 
 func (cfg *Config) splitline() {
 	part1 := make([]rune, cfg.inchrlinelen+1)
 	part2 := make([]rune, cfg.inchrlinelen+1)
+	index1 := make([]int, cfg.inchrlinelen+1)
+	index2 := make([]int, cfg.inchrlinelen+1)
 	gotspace := false
 	lastspace := cfg.inchrlinelen - 1
 	i := cfg.inchrlinelen - 1
@@ -1689,17 +1930,20 @@ func (cfg *Config) splitline() {
 	len2 := cfg.inchrlinelen - lastspace - 1
 	for i := 0; i < len1; i++ {
 		part1[i] = cfg.inchrline[i]
+		index1[i] = cfg.inchrindex[i]
 	}
 	for i := 0; i < len2; i++ {
 		part2[i] = cfg.inchrline[lastspace+1+i]
+		index2[i] = cfg.inchrindex[lastspace+1+i]
 	}
 	cfg.clearline()
 	for i := 0; i < len1; i++ {
-		cfg.addchar(part1[i])
+		cfg.addcharindex(part1[i], index1[i])
 	}
+	cfg.wrapping = true
 	cfg.printline()
 	for i := 0; i < len2; i++ {
-		cfg.addchar(part2[i])
+		cfg.addcharindex(part2[i], index2[i])
 	}
 }
 
@@ -2007,11 +2251,27 @@ func (cfg *Config) AddControlFile(name string) {
 	node := &CFNameNode{thename: controlname}
 	*cfg.cfilelistend = node
 	cfg.cfilelistend = &node.next
+	cfg.controlfiles = nil
+}
+
+// ControlFiles returns the control files in the order they are applied.
+// After LoadFont the list includes the files pulled in by include ("i")
+// commands, right after the file that includes them.
+func (cfg *Config) ControlFiles() []string {
+	if len(cfg.controlfiles) > 0 {
+		return append([]string(nil), cfg.controlfiles...)
+	}
+	var names []string
+	for cfnptr := cfg.cfilelist; cfnptr != nil; cfnptr = cfnptr.next {
+		names = append(names, cfnptr.thename)
+	}
+	return names
 }
 
 // ClearControlFiles clears all control files
 func (cfg *Config) ClearControlFiles() {
 	cfg.clearcfilelist()
+	cfg.controlfiles = nil
 	cfg.Multibyte = 0
 	cfg.gn[0] = 0
 	cfg.gn[1] = 0x80
@@ -1,10 +1,46 @@
 package figlet
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+	"unicode/utf8"
 )
 
+// writeTestFont writes a minimal one-row font to a temp dir and returns the dir.
+// glyphs lists the rows for the required characters starting at ' '.
+func writeTestFont(t *testing.T, name, header string, glyphs []string) string {
+	t.Helper()
+	dir := t.TempDir()
+	var sb strings.Builder
+	sb.WriteString(header + "\n")
+	for _, g := range glyphs {
+		sb.WriteString(g + "\n")
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".flf"), []byte(sb.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
 // TestRender tests the basic Render function
 func TestRender(t *testing.T) {
 	result, err := Render("Hi")
@@ -146,6 +182,48 @@ func TestWithOverlapping(t *testing.T) {
 	}
 }
 
+// TestWithOverlap tests forcing overlap beyond the smushing rules
+func TestWithOverlap(t *testing.T) {
+	plain := New()
+	if err := plain.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	tight := New()
+	WithOverlap(1)(tight)
+	if err := tight.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	// Each of the four joins moves one more column
+	pw, _ := plain.Measure("Hello")
+	tw, _ := tight.Measure("Hello")
+	if tw != pw-4 {
+		t.Errorf("Expected %d columns, got %d", pw-4, tw)
+	}
+
+	// Two visible sub-characters no rule smushes: the later one wins
+	for _, tc := range []struct {
+		r2l  int
+		want string
+	}{{0, "b\n"}, {1, "b\n"}} {
+		out, err := Render("ab", WithFont("term"), WithOverlap(1), WithRightToLeft(tc.r2l), WithJustification(0))
+		if err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		if out != tc.want {
+			t.Errorf("Right2left %d: got %q, want %q", tc.r2l, out, tc.want)
+		}
+	}
+
+	if err := New().Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	bad := New()
+	bad.Overlap = -1
+	if err := bad.Validate(); err == nil {
+		t.Error("Expected a negative Overlap to be invalid")
+	}
+}
+
 // TestListFonts tests that ListFonts returns fonts
 func TestListFonts(t *testing.T) {
 	fonts := ListFonts()
@@ -360,26 +438,3970 @@ func TestConstants(t *testing.T) {
 	}
 }
 
-// BenchmarkRender benchmarks the Render function
-func BenchmarkRender(b *testing.B) {
-	for i := 0; i < b.N; i++ {
-		_, _ = Render("Hello")
+// TestLoadFontWithDiagnosticsClean tests that a well-formed font has no warnings
+func TestLoadFontWithDiagnosticsClean(t *testing.T) {
+	cfg := New()
+	warnings, err := cfg.LoadFontWithDiagnostics()
+	if err != nil {
+		t.Fatalf("LoadFontWithDiagnostics failed: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("Expected no warnings for standard font, got %v", warnings)
 	}
 }
 
-// BenchmarkRenderWithFont benchmarks rendering with specific font
-func BenchmarkRenderWithFont(b *testing.B) {
-	for i := 0; i < b.N; i++ {
-		_, _ = RenderWithFont("Hello", "standard")
+// TestLoadFontWithDiagnosticsProblems tests ragged, hardblank and missing glyph warnings
+func TestLoadFontWithDiagnosticsProblems(t *testing.T) {
+	glyphs := []string{"a@", "bb@", "cc@@", "dd@@"}
+	dir := writeTestFont(t, "broken", "flf2aX 2 2 4 0 0", glyphs)
+
+	cfg := New()
+	cfg.Fontdirname = dir
+	cfg.Fontname = "broken"
+	warnings, err := cfg.LoadFontWithDiagnostics()
+	if err != nil {
+		t.Fatalf("LoadFontWithDiagnostics failed: %v", err)
+	}
+
+	kinds := make(map[WarningKind]Warning)
+	for _, w := range warnings {
+		kinds[w.Kind] = w
+	}
+	if _, ok := kinds[WarnUnusualHardblank]; !ok {
+		t.Error("Expected an unusual hardblank warning")
+	}
+	if w, ok := kinds[WarnRaggedGlyph]; !ok {
+		t.Error("Expected a ragged glyph warning")
+	} else if w.Char != ' ' || w.Line != 3 {
+		t.Errorf("Ragged warning should point at ' ' line 3, got %q line %d", w.Char, w.Line)
+	}
+	if _, ok := kinds[WarnMissingGlyph]; !ok {
+		t.Error("Expected a missing glyph warning")
+	}
+	if len(cfg.Warnings()) != len(warnings) {
+		t.Error("Warnings() should return the warnings from the last load")
 	}
 }
 
-// BenchmarkConfigReuse benchmarks reusing Config for multiple renders
-func BenchmarkConfigReuse(b *testing.B) {
+// TestCheckMonospace tests the unsafe cell warnings and replacement
+func TestCheckMonospace(t *testing.T) {
+	glyphs := []string{" @@", "!\t@@", "\"中@@"}
+	dir := writeTestFont(t, "unsafe", "flf2a$ 1 1 3 0 0", glyphs)
+
+	unsafe := func(parser string) (*Config, map[rune]Warning) {
+		cfg := New()
+		cfg.Fontdirname = dir
+		cfg.Fontname = "unsafe"
+		cfg.OutputParser, _ = GetParser(parser)
+		warnings, err := cfg.LoadFontWithDiagnostics()
+		if err != nil {
+			t.Fatalf("LoadFontWithDiagnostics failed: %v", err)
+		}
+		found := make(map[rune]Warning)
+		for _, w := range warnings {
+			if w.Kind == WarnUnsafeCell {
+				found[w.Char] = w
+			}
+		}
+		return cfg, found
+	}
+	cfg, found := unsafe("terminal")
+	if w, ok := found['!']; !ok || !strings.Contains(w.Message, "control character") {
+		t.Errorf("Expected a control character warning for '!', got %v", found)
+	}
+	if _, ok := found['"']; ok {
+		t.Error("Wide characters should not be reported for the terminal parser")
+	}
+
+	if _, html := unsafe("html"); !strings.Contains(html['"'].Message, "html") {
+		t.Errorf("Expected a wide character warning for '\"' in html output, got %v", html)
+	}
+	if got := cfg.RenderString("!", WithUnsafeCellReplacement('?')); got != "!?\n" {
+		t.Errorf("Replaced output = %q, want %q", got, "!?\n")
+	}
+	if got := cfg.RenderString("!"); got != "!\t\n" {
+		t.Errorf("Replacement should not change the font, got %q", got)
+	}
+	cfg.UnsafeCellReplacement = '\t'
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected a control character replacement to be rejected")
+	}
+}
+
+// TestWithTrimWhitespace tests the trailing, blank row and margin trim modes
+func TestWithTrimWhitespace(t *testing.T) {
+	plain, err := Render("Hi")
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+
+	trailing, err := Render("Hi", WithTrimWhitespace(TrimTrailing))
+	if err != nil {
+		t.Fatalf("Render with TrimTrailing failed: %v", err)
+	}
+	for _, line := range strings.Split(trailing, "\n") {
+		if strings.HasSuffix(line, " ") {
+			t.Errorf("Line has trailing spaces: %q", line)
+		}
+	}
+	if len(trailing) >= len(plain) {
+		t.Error("TrimTrailing should shrink the output")
+	}
+
+	// The standard font has a blank last row
+	rows, err := Render("Hi", WithTrimWhitespace(TrimBlankRows))
+	if err != nil {
+		t.Fatalf("Render with TrimBlankRows failed: %v", err)
+	}
+	if got, want := strings.Count(rows, "\n"), strings.Count(plain, "\n")-1; got != want {
+		t.Errorf("TrimBlankRows: expected %d lines, got %d", want, got)
+	}
+
+	// Leading spaces in the input produce a blank left margin
+	margin, err := Render("   Hi", WithTrimWhitespace(TrimLeftMargin))
+	if err != nil {
+		t.Fatalf("Render with TrimLeftMargin failed: %v", err)
+	}
+	hasEdge := false
+	for _, line := range strings.Split(strings.TrimSuffix(margin, "\n"), "\n") {
+		if line != "" && line[0] != ' ' {
+			hasEdge = true
+		}
+	}
+	if !hasEdge {
+		t.Errorf("TrimLeftMargin should leave no common left margin:\n%s", margin)
+	}
+
+	// TrimBlockMargin trims the margin of the whole block, so the second
+	// line stays indented
+	text := "  Hi\n      Ho"
+	block, err := Render(text, WithTrimWhitespace(TrimBlockMargin))
+	if err != nil {
+		t.Fatalf("Render with TrimBlockMargin failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(block, "\n"), "\n")
+	height := len(lines) / 2
+	indent := func(lines []string) int {
+		n := -1
+		for _, line := range lines {
+			if trimmed := strings.TrimLeft(line, " "); trimmed != "" && (n < 0 || len(line)-len(trimmed) < n) {
+				n = len(line) - len(trimmed)
+			}
+		}
+		return n
+	}
+	if first, second := indent(lines[:height]), indent(lines[height:]); first != 0 || second <= 0 {
+		t.Errorf("TrimBlockMargin: expected indents 0 and more, got %d and %d:\n%s", first, second, block)
+	}
+	perline, _ := Render(text, WithTrimWhitespace(TrimLeftMargin))
+	if perline == block {
+		t.Errorf("TrimLeftMargin should also trim the second line:\n%s", perline)
+	}
+}
+
+// TestWithKeepHardblanks tests emitting raw or substituted hardblanks
+func TestWithKeepHardblanks(t *testing.T) {
+	// The standard font uses '$' as hardblank, e.g. in the space character
+	plain, err := Render("a b")
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if strings.Contains(plain, "$") {
+		t.Error("Hardblanks should be converted to spaces by default")
+	}
+
+	raw, err := Render("a b", WithKeepHardblanks(0))
+	if err != nil {
+		t.Fatalf("Render with WithKeepHardblanks failed: %v", err)
+	}
+	if !strings.Contains(raw, "$") {
+		t.Error("Expected raw '$' hardblanks in output")
+	}
+
+	sub, err := Render("a b", WithKeepHardblanks('~'))
+	if err != nil {
+		t.Fatalf("Render with substitute failed: %v", err)
+	}
+	if strings.Contains(sub, "$") || !strings.Contains(sub, "~") {
+		t.Error("Expected hardblanks replaced by '~'")
+	}
+}
+
+// TestWithPadding tests padding lines to the block width and the output width
+func TestWithPadding(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  PadMode
+		width int
+	}{
+		{"block", PadBlock, -1},
+		{"output-width", PadOutputWidth, 59},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Render("Pad me\nok", WithWidth(60), WithPadding(tt.mode), WithTrimWhitespace(TrimTrailing))
+			if err != nil {
+				t.Fatalf("Render with WithPadding failed: %v", err)
+			}
+			lines := strings.Split(strings.TrimSuffix(result, "\n"), "\n")
+			want := tt.width
+			if want < 0 {
+				want = len(lines[0])
+			}
+			for _, line := range lines {
+				if len(line) != want {
+					t.Errorf("Expected line width %d, got %d: %q", want, len(line), line)
+				}
+			}
+		})
+	}
+}
+
+// TestJustificationWithColors tests that colors do not change the layout
+func TestJustificationWithColors(t *testing.T) {
+	ansi := regexp.MustCompile("\x1b\\[[0-9;]*m")
+	parser, _ := GetParser("terminal-color")
+	for _, j := range []int{1, 2} {
+		plain, err := Render("Hi\nthere", WithWidth(60), WithJustification(j))
+		if err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		colored, err := Render("Hi\nthere", WithWidth(60), WithJustification(j),
+			WithColors(ColorRed, ColorBlue), WithOutputParser(parser))
+		if err != nil {
+			t.Fatalf("Render with colors failed: %v", err)
+		}
+		if stripped := ansi.ReplaceAllString(colored, ""); stripped != plain {
+			t.Errorf("justification %d: colored layout differs\nplain:\n%s\nstripped:\n%s", j, plain, stripped)
+		}
+		if strings.Contains(colored, "\x1b[31m \x1b[0m\x1b[31m ") {
+			t.Errorf("justification %d: padding should not be colored", j)
+		}
+
+		cfg := New()
+		cfg.Outputwidth = 60
+		cfg.Justification = j
+		cfg.Colors = []Color{ColorRed, ColorBlue}
+		cfg.OutputParser = parser
+		if err := cfg.LoadFont(); err != nil {
+			t.Fatalf("LoadFont failed: %v", err)
+		}
+		frames, err := NewAnimator(cfg).GenerateAnimation("Hi\nthere", "reveal", 0)
+		if err != nil || len(frames) == 0 {
+			t.Fatalf("GenerateAnimation failed: %v", err)
+		}
+		if last := frames[len(frames)-1].Content; last != colored {
+			t.Errorf("justification %d: last reveal frame differs from rendered output\nframe:\n%q\nrender:\n%q", j, last, colored)
+		}
+	}
+}
+
+// TestMeasure tests that Measure matches the rendered output
+func TestMeasure(t *testing.T) {
 	cfg := New()
-	_ = cfg.LoadFont()
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		_ = cfg.RenderString("Hello")
+	cfg.Outputwidth = 40
+	cfg.Justification = 1
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	width, height := cfg.Measure("Measure me")
+	lines := strings.Split(strings.TrimSuffix(cfg.RenderString("Measure me"), "\n"), "\n")
+	if height != len(lines) {
+		t.Errorf("Expected height %d, got %d", len(lines), height)
+	}
+	widest := 0
+	for _, line := range lines {
+		if len(line) > widest {
+			widest = len(line)
+		}
+	}
+	if width != widest {
+		t.Errorf("Expected width %d, got %d", widest, width)
+	}
+}
+
+// TestComposeCharacterMap tests that every composed row maps its cells to
+// the input characters of its own FIGlet line, also when lines wrap
+func TestComposeCharacterMap(t *testing.T) {
+	for _, rtl := range []int{0, 1} {
+		cfg := New()
+		cfg.Outputwidth = 30
+		cfg.Right2left = rtl
+		if err := cfg.LoadFont(); err != nil {
+			t.Fatalf("LoadFont failed: %v", err)
+		}
+		rows := cfg.compose("abcdef ghi")
+		if len(rows) != 2*cfg.charheight {
+			t.Fatalf("rtl=%d: expected %d rows, got %d", rtl, 2*cfg.charheight, len(rows))
+		}
+		// The second line must only use characters after those of the first
+		lastFirst, firstSecond := -1, len("abcdef ghi")
+		for n, row := range rows {
+			if len(row.index) != len(row.cells) {
+				t.Errorf("rtl=%d row %d: %d cells but %d map entries", rtl, n, len(row.cells), len(row.index))
+			}
+			for _, index := range row.index {
+				if n < cfg.charheight && index > lastFirst {
+					lastFirst = index
+				}
+				if n >= cfg.charheight && index < firstSecond {
+					firstSecond = index
+				}
+			}
+		}
+		if lastFirst >= firstSecond {
+			t.Errorf("rtl=%d: lines share characters (first line ends at %d, second starts at %d)", rtl, lastFirst, firstSecond)
+		}
+	}
+}
+
+// TestRightToLeftColors tests that colors follow the characters of a
+// right-to-left font, the first character being the rightmost
+func TestRightToLeftColors(t *testing.T) {
+	cfg := New()
+	cfg.Fontname = "ivrit"
+	cfg.Outputwidth = 40
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	if cfg.Right2left != 1 {
+		t.Fatalf("Expected ivrit to be right-to-left, got %d", cfg.Right2left)
+	}
+	text := "abc def ghij"
+	for n, row := range cfg.layoutrows(cfg.compose(text)) {
+		if len(row.index) != len(row.cells) {
+			t.Errorf("row %d: %d cells but %d map entries", n, len(row.cells), len(row.index))
+		}
+		// Indexes fall from left to right
+		prev := len(text)
+		for i := range row.cells {
+			index := row.colorindex(row.pad + i)
+			if index > prev {
+				t.Errorf("row %d: character %d is left of character %d", n, prev, index)
+			}
+			prev = index
+		}
+	}
+
+	plain := cfg.RenderString(text)
+	cfg.Colors = []Color{ColorRed, ColorGreen}
+	cfg.OutputParser, _ = GetParser("terminal-color")
+	colored := cfg.RenderString(text)
+	if got := regexp.MustCompile("\x1b\\[[0-9;]*m").ReplaceAllString(colored, ""); got != plain {
+		t.Errorf("Colors changed the text:\n%s\nexpected:\n%s", got, plain)
+	}
+	// The top row ends with 'a' in the first color, right of 'b' in the second
+	first := strings.SplitN(colored, "\n", 2)[0]
+	red := strings.LastIndex(first, ColorRed.getPrefix(cfg.OutputParser))
+	green := strings.LastIndex(first, ColorGreen.getPrefix(cfg.OutputParser))
+	if red < 0 || green < 0 || red < green {
+		t.Errorf("Expected the first color right of the second in %q", first)
+	}
+}
+
+// TestBaseline tests the font baseline and the underline drawn below it
+func TestBaseline(t *testing.T) {
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	if got := cfg.Baseline(); got != 5 {
+		t.Errorf("Expected standard to have its baseline at row 5, got %d", got)
+	}
+
+	plain := strings.Split(cfg.RenderString("Hi"), "\n")
+	lines := strings.Split(cfg.RenderString("Hi", WithUnderline()), "\n")
+	if len(lines) != len(plain) {
+		t.Fatalf("Underline changed the height from %d to %d", len(plain), len(lines))
+	}
+	for i := range lines {
+		want := plain[i]
+		if i == cfg.Baseline() {
+			want = strings.Repeat("_", len(plain[i]))
+		}
+		if lines[i] != want {
+			t.Errorf("line %d: expected %q, got %q", i, want, lines[i])
+		}
+	}
+
+	rows := cfg.layoutrows(cfg.compose("Hi there"))
+	for n, row := range rows {
+		if row.baseline != (n%6 == 4) {
+			t.Errorf("row %d: baseline mark is %v", n, row.baseline)
+		}
+	}
+
+	frames, err := NewAnimator(cfg).GenerateAnimation("Hi", "explosion", 0)
+	if err != nil {
+		t.Fatalf("GenerateAnimation failed: %v", err)
+	}
+	for _, frame := range frames {
+		if frame.Baseline != frame.BaselineOffset+4 {
+			t.Errorf("Expected the frame baseline at line %d, got %d", frame.BaselineOffset+4, frame.Baseline)
+		}
+	}
+}
+
+// TestStyle tests text attributes in the terminal-color and html parsers
+func TestStyle(t *testing.T) {
+	style, err := ParseStyle("bold, Underline")
+	if err != nil || style != StyleBold|StyleUnderline {
+		t.Fatalf("ParseStyle gave %v, %v", style, err)
+	}
+	if style.String() != "bold,underline" {
+		t.Errorf("Expected \"bold,underline\", got %q", style.String())
+	}
+	if _, err := ParseStyle("bold,shiny"); err == nil {
+		t.Error("Expected an error for an unknown style")
+	}
+
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	plain := cfg.RenderString("Hi")
+	if got := cfg.RenderString("Hi", WithStyle(StyleBold)); got != plain {
+		t.Error("Expected the terminal parser to ignore styles")
+	}
+
+	terminal := WithParser("terminal-color")
+	if got := cfg.RenderString("Hi", terminal, WithStyle(StyleBold, StyleUnderline)); !strings.Contains(got, "\x1b[1;4m| | | (_)\x1b[0m") {
+		t.Errorf("Expected bold underlined cells, got %q", got)
+	}
+	got := cfg.RenderString("Hi", terminal, WithStyle(StyleItalic), WithColors(ColorRed))
+	if !strings.Contains(got, "\x1b[0;31m\x1b[3m| | | (_)\x1b[0m") {
+		t.Errorf("Expected the style after the color, got %q", got)
+	}
+
+	got = cfg.RenderString("Hi", WithParser("html"), WithStyle(StyleBold|StyleUnderline|StyleStrikethrough))
+	if !strings.Contains(got, "<span style='font-weight: bold; text-decoration: underline line-through;'>|_|&nbsp;|_|_|</span>") {
+		t.Errorf("Expected one merged text-decoration, got %q", got)
+	}
+}
+
+// TestColorStrategy tests the fill patterns over the laid out grid
+func TestColorStrategy(t *testing.T) {
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	text := "Hello"
+	rows := cfg.layoutrows(cfg.compose(text))
+
+	cfg.ColorStrategy = Checkerboard()
+	for y, row := range rows {
+		for i := range row.cells {
+			if got := cfg.cellindex(row, y, i); got != (y+i)%2 {
+				t.Fatalf("checkerboard: cell %d,%d is %d", y, i, got)
+			}
+		}
+	}
+
+	cfg.ColorStrategy = Stripes(3)
+	if got := cfg.cellindex(rows[0], 0, 7); got != 2 {
+		t.Errorf("stripes: expected column 7 in stripe 2, got %d", got)
+	}
+
+	// Every cell of a glyph gets the same color, the same on every render
+	cfg.ColorStrategy = RandomGlyphs(42)
+	colors := map[int]int{}
+	for y, row := range rows {
+		for i := range row.cells {
+			char := row.colorindex(i)
+			got := cfg.cellindex(row, y, i)
+			if got < 0 {
+				t.Fatalf("random: negative index for cell %d,%d", y, i)
+			}
+			if prev, ok := colors[char]; ok && prev != got {
+				t.Fatalf("random: character %d has colors %d and %d", char, prev, got)
+			}
+			colors[char] = got
+		}
+	}
+	if RandomGlyphs(42)(0, 0, 3) != colors[3] {
+		t.Error("random: expected the same color for the same seed")
+	}
+
+	cfg.Colors = []Color{ColorRed, ColorBlue}
+	got := cfg.RenderString(text, WithParser("terminal-color"), WithColorStrategy(Checkerboard()))
+	if !strings.HasPrefix(got, "\x1b[0;31m \x1b[0;34m_\x1b[0;31m ") {
+		t.Errorf("Expected alternating colors, got %q", got)
+	}
+}
+
+// TestThemes tests the built-in and registered palettes
+func TestThemes(t *testing.T) {
+	for _, name := range []string{"fire", "ocean", "pride", "matrix", "dracula"} {
+		colors, err := Theme(name)
+		if err != nil || len(colors) == 0 {
+			t.Errorf("Theme(%q) gave %d colors, %v", name, len(colors), err)
+		}
+	}
+	if _, err := Theme("nope"); err == nil {
+		t.Error("Expected an error for an unknown theme")
+	}
+	if err := RegisterTheme("mono"); err == nil {
+		t.Error("Expected an error for a theme without colors")
+	}
+	if err := RegisterTheme("Mono", ColorWhite, ColorBlack); err != nil {
+		t.Fatalf("RegisterTheme failed: %v", err)
+	}
+
+	cfg := New()
+	WithTheme("mono")(cfg)
+	if len(cfg.Colors) != 2 || cfg.Colors[0] != ColorWhite || cfg.OutputParser.Name != "terminal-color" {
+		t.Errorf("Expected the mono palette and terminal-color, got %v with %s", cfg.Colors, cfg.OutputParser.Name)
+	}
+	WithTheme("nope")(cfg)
+	if len(cfg.Colors) != 2 {
+		t.Error("Expected an unknown theme to leave the colors alone")
+	}
+	var verr *ValidationError
+	if err := cfg.Validate(); !errors.As(err, &verr) || verr.Field != "Theme" || verr.Value != "nope" {
+		t.Errorf("Expected the unknown theme to be reported, got %v", err)
+	}
+	if _, err := Render("Hi", WithTheme("nope")); !errors.As(err, &verr) {
+		t.Errorf("Expected Render to fail for an unknown theme, got %v", err)
+	}
+	WithTheme("mono")(cfg)
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected a known theme to clear the error, got %v", err)
+	}
+
+	// The returned palette is a copy
+	colors, _ := Theme("mono")
+	colors[0] = ColorRed
+	if again, _ := Theme("mono"); again[0] != ColorWhite {
+		t.Error("Expected Theme to return a copy of the palette")
+	}
+}
+
+// TestParseColor tests hex, functional and named color notation
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Color
+	}{
+		{"red", ColorRed},
+		{"Cyan", ColorCyan},
+		{"#f00", TrueColor{255, 0, 0}},
+		{"0a0B0c", TrueColor{10, 11, 12}},
+		{"rgb(255, 128, 0)", TrueColor{255, 128, 0}},
+		{"rgba(1,2,3,0.5)", TrueColor{1, 2, 3}},
+		{"rgb(100% 0% 50% / 0.2)", TrueColor{255, 0, 128}},
+		{"rgb(300, -5, 0)", TrueColor{255, 0, 0}},
+		{"hsl(120, 100%, 50%)", TrueColor{0, 255, 0}},
+		{"hsl(240deg 100% 25%)", TrueColor{0, 0, 128}},
+		{"hsl(-60, 100%, 50%)", TrueColor{255, 0, 255}},
+		{"rebeccapurple", TrueColor{102, 51, 153}},
+		{" DarkSlateGrey ", TrueColor{47, 79, 79}},
+	}
+	for _, tt := range tests {
+		got, err := ParseColor(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseColor(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "#ff", "notacolor", "rgb(1,2)", "hsl(a,b,c)", "cmyk(0,0,0,0)"} {
+		if _, err := ParseColor(in); err == nil {
+			t.Errorf("ParseColor(%q): expected an error", in)
+		}
+	}
+}
+
+// TestHSL tests the HSL and HSV helpers on TrueColor
+func TestHSL(t *testing.T) {
+	tests := []struct {
+		got, want TrueColor
+	}{
+		{NewTrueColorFromHSL(0, 1, 0.5), TrueColor{255, 0, 0}},
+		{NewTrueColorFromHSL(480, 1, 0.5), TrueColor{0, 255, 0}},
+		{NewTrueColorFromHSL(0, 0, 2), TrueColor{255, 255, 255}},
+		{NewTrueColorFromHSV(240, 1, 1), TrueColor{0, 0, 255}},
+		{NewTrueColorFromHSV(0, 0, 0.5), TrueColor{128, 128, 128}},
+		{NewTrueColorFromHSV(60, 0.5, 0.8), TrueColor{204, 204, 102}},
+		{TrueColor{255, 0, 0}.Rotate(120), TrueColor{0, 255, 0}},
+		{TrueColor{255, 0, 0}.Rotate(-120), TrueColor{0, 0, 255}},
+		{TrueColor{255, 0, 0}.Lighten(0.25), TrueColor{255, 128, 128}},
+		{TrueColor{255, 0, 0}.Darken(0.25), TrueColor{128, 0, 0}},
+		{TrueColor{10, 20, 30}.Darken(1), TrueColor{0, 0, 0}},
+	}
+	for i, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("case %d: got %v, want %v", i, tt.got, tt.want)
+		}
+	}
+
+	// Converting to HSL and back keeps the color
+	for _, tc := range []TrueColor{{12, 34, 56}, {255, 200, 0}, {77, 77, 77}, {0, 255, 65}} {
+		if got := NewTrueColorFromHSL(tc.HSL()); got != tc {
+			t.Errorf("%v went through HSL as %v", tc, got)
+		}
+	}
+}
+
+// TestContrast tests the WCAG contrast helpers
+func TestContrast(t *testing.T) {
+	white := TrueColor{255, 255, 255}
+	if r := ContrastRatio(TrueColor{0, 0, 0}, white); math.Abs(r-21) > 1e-9 {
+		t.Errorf("Expected 21 for black on white, got %v", r)
+	}
+	if r := ContrastRatio(white, white); r != 1 {
+		t.Errorf("Expected 1 for white on white, got %v", r)
+	}
+	if r := ContrastRatio(ColorYellow, white); r >= ContrastAALarge {
+		t.Errorf("Expected yellow on white to fail, got %v", r)
+	}
+
+	colors := []Color{ColorYellow, TrueColor{0, 0, 128}, TrueColor{255, 240, 120}}
+	fixed := EnsureContrast(colors, white, ContrastAA)
+	for i, ratio := range ContrastRatios(fixed, white) {
+		if ratio < ContrastAA {
+			t.Errorf("color %d: ratio %v is below the target", i, ratio)
+		}
+	}
+	if fixed[1] != colors[1] {
+		t.Errorf("Expected navy to be kept, got %v", fixed[1])
+	}
+	// Only the lightness changes
+	h, _, _ := fixed[0].(TrueColor).HSL()
+	if want, _, _ := tcfac[ColorYellow].HSL(); math.Abs(h-want) > 2 {
+		t.Errorf("Expected the hue of yellow, got %v", h)
+	}
+
+	// Nothing reaches 7:1 against mid gray, so the best extreme is used
+	gray := TrueColor{119, 119, 119}
+	if got := EnsureContrast([]Color{gray}, gray, ContrastAAA)[0]; got != (TrueColor{0, 0, 0}) {
+		t.Errorf("Expected black, got %v", got)
+	}
+}
+
+// TestRenderGrid tests that the grid matches the text output
+func TestRenderGrid(t *testing.T) {
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	for _, opts := range [][]RenderOption{
+		nil,
+		{WithJustification(1), WithWidth(40)},
+		{WithKeepHardblanks('.'), WithPadding(PadBlock)},
+	} {
+		grid, meta, err := cfg.RenderGrid("Hi you", opts...)
+		if err != nil {
+			t.Fatalf("RenderGrid failed: %v", err)
+		}
+		lines := strings.Split(strings.TrimSuffix(cfg.RenderString("Hi you", opts...), "\n"), "\n")
+		if len(grid) != len(lines) || meta.Height != len(lines) {
+			t.Fatalf("Expected %d rows, got %d (height %d)", len(lines), len(grid), meta.Height)
+		}
+		for y, row := range grid {
+			if string(row) != lines[y] {
+				t.Errorf("row %d: got %q, want %q", y, string(row), lines[y])
+			}
+			if len(meta.Index[y]) != len(row) {
+				t.Errorf("row %d: %d cells but %d indexes", y, len(row), len(meta.Index[y]))
+			}
+			if len(row) > meta.Width {
+				t.Errorf("row %d: %d cells is wider than %d", y, len(row), meta.Width)
+			}
+		}
+		if meta.Font != "standard" || meta.CharHeight != 6 || meta.Hardblank != '$' || len(meta.Baselines) != 1 {
+			t.Errorf("Unexpected metadata %+v", meta)
+		}
+	}
+
+	grid, meta, _ := cfg.RenderGrid("Hi", WithJustification(2), WithWidth(30))
+	for x, r := range grid[1] {
+		if r != ' ' {
+			if meta.Index[1][x] != 0 {
+				t.Errorf("Expected the first visible cell to come from character 0, got %d", meta.Index[1][x])
+			}
+			break
+		}
+		if meta.Index[1][x] != -1 {
+			t.Errorf("Expected -1 for justification at column %d", x)
+		}
+	}
+
+	if _, _, err := cfg.RenderGrid("x", WithJustification(5)); err == nil {
+		t.Error("Expected an invalid option to fail")
+	}
+}
+
+// TestAnimationBudget tests that MaxFrames and MaxCells shrink animations
+// and that a done context stops the generation
+func TestAnimationBudget(t *testing.T) {
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	for _, anim := range ListAnimations() {
+		full, err := NewAnimator(cfg).GenerateAnimation("Hi", anim, 10*time.Millisecond)
+		if err != nil {
+			t.Fatalf("GenerateAnimation failed: %v", err)
+		}
+		for _, max := range []int{1, 5} {
+			animator := NewAnimator(cfg)
+			animator.MaxFrames = max
+			frames, err := animator.GenerateAnimation("Hi", anim, 10*time.Millisecond)
+			if err != nil {
+				t.Fatalf("GenerateAnimation failed: %v", err)
+			}
+			if len(frames) == 0 || len(frames) > max {
+				t.Errorf("%s: %d frames with MaxFrames %d", anim, len(frames), max)
+			}
+			if frames[len(frames)-1].Content != full[len(full)-1].Content {
+				t.Errorf("%s: last frame with MaxFrames %d is not the finished text", anim, max)
+			}
+		}
+	}
+
+	full, _ := NewAnimator(cfg).GenerateAnimation("Hi", "reveal", 10*time.Millisecond)
+	animator := NewAnimator(cfg)
+	animator.MaxFrames = len(full) / 2
+	frames, _ := animator.GenerateAnimation("Hi", "reveal", 10*time.Millisecond)
+	var total time.Duration
+	for _, f := range frames {
+		total += f.Delay
+	}
+	if want := time.Duration(len(full)) * 10 * time.Millisecond; total != want {
+		t.Errorf("Budgeted reveal lasts %v, want %v", total, want)
+	}
+
+	animator = NewAnimator(cfg)
+	animator.MaxCells = 200
+	frames, _ = animator.GenerateAnimation("Hi", "scroll", 0)
+	for _, line := range strings.Split(frames[0].Content, "\n") {
+		if len(line) > 200/cfg.charheight {
+			t.Fatalf("Scroll line %q is wider than the cell budget", line)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewAnimator(cfg).GenerateAnimationContext(ctx, "Hi", "wave", 0); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+// TestAnimationCanvasHeight tests that rain and explosions stay within
+// CanvasHeight lines
+func TestAnimationCanvasHeight(t *testing.T) {
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	for _, canvas := range []int{3, 8, 12} {
+		animator := NewAnimator(cfg)
+		animator.CanvasHeight = canvas
+		frames, err := animator.GenerateAnimation("Hi", "explosion", 0)
+		if err != nil {
+			t.Fatalf("GenerateAnimation failed: %v", err)
+		}
+		for i, f := range frames {
+			if lines := strings.Count(f.Content, "\n"); lines > canvas && lines > cfg.charheight {
+				t.Fatalf("Canvas %d: explosion frame %d has %d lines", canvas, i, lines)
+			}
+		}
+	}
+
+	full, _ := NewAnimator(cfg).GenerateAnimation("Hi", "rain", 0)
+	animator := NewAnimator(cfg)
+	animator.CanvasHeight = 2
+	frames, _ := animator.GenerateAnimation("Hi", "rain", 0)
+	if len(frames) != len(full)-(cfg.charheight-2) {
+		t.Errorf("Rain on a 2 line canvas has %d frames, %d without", len(frames), len(full))
+	}
+	if frames[len(frames)-1].Content != full[len(full)-1].Content {
+		t.Error("Rain on a short canvas does not end with the text")
+	}
+	top := strings.SplitN(full[len(full)-1].Content, "\n", 2)[0]
+	if got := strings.SplitN(frames[0].Content, "\n", 2)[0]; got != top {
+		t.Errorf("Hidden rain line %q is not settled, want %q", got, top)
+	}
+}
+
+// TestLetterAnimations tests that the per-letter animations bring in one
+// letter after the other and end with the text
+func TestLetterAnimations(t *testing.T) {
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	rain, _ := NewAnimator(cfg).GenerateAnimation("Hi", "rain", 0)
+	final := rain[len(rain)-1].Content
+	h, meta, err := cfg.RenderGrid("H")
+	if err != nil || len(h) == 0 {
+		t.Fatalf("RenderGrid failed: %v", err)
+	}
+	for _, anim := range []string{"drop", "slide", "spin"} {
+		frames, err := NewAnimator(cfg).GenerateAnimation("Hi", anim, 0)
+		if err != nil {
+			t.Fatalf("GenerateAnimation failed: %v", err)
+		}
+		if want := letterstagger + letterframes + 1; len(frames) != want {
+			t.Errorf("%s: expected %d frames, got %d", anim, want, len(frames))
+		}
+		if frames[len(frames)-1].Content != final {
+			t.Errorf("%s: expected the text last, got:\n%s", anim, frames[len(frames)-1].Content)
+		}
+		// Only H has started moving when i starts
+		for _, line := range strings.Split(frames[letterstagger].Content, "\n") {
+			if len(line) > meta.Width {
+				t.Errorf("%s: i is drawn too early in %q", anim, line)
+			}
+		}
+	}
+}
+
+// TestHighlight tests that the highlight lights one character per frame
+// for its time
+func TestHighlight(t *testing.T) {
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	cfg.OutputParser, _ = GetParser("terminal-color")
+	animator := NewAnimator(cfg)
+	animator.HighlightColor = ColorRed
+	lit := ColorRed.getPrefix(cfg.OutputParser)
+
+	frames, err := animator.GenerateHighlight("Hi", time.Second, nil)
+	if err != nil {
+		t.Fatalf("GenerateHighlight failed: %v", err)
+	}
+	if len(frames) != 3 {
+		t.Fatalf("Expected 3 frames, got %d", len(frames))
+	}
+	if frames[0].Delay != 500*time.Millisecond || frames[2].Delay != 0 {
+		t.Errorf("Unexpected delays %v, %v", frames[0].Delay, frames[2].Delay)
+	}
+	if !strings.Contains(frames[0].Content, lit) || strings.Contains(frames[2].Content, lit) {
+		t.Error("Expected the highlight on the first frame only of the first and last")
+	}
+	if frames[0].Content == frames[1].Content {
+		t.Error("Expected a different character lit in the second frame")
+	}
+
+	frames, err = animator.GenerateHighlight("Hi", 0, []time.Duration{time.Second, 2 * time.Second})
+	if err != nil || frames[1].Delay != 2*time.Second {
+		t.Errorf("Expected the second character lit for 2s, got %v", err)
+	}
+	if _, err := animator.GenerateHighlight("Hi", 0, []time.Duration{time.Second}); err == nil {
+		t.Error("Expected an error for a timing missing")
+	}
+
+	structured, _ := animator.GenerateStructured("Hi", "highlight", time.Millisecond)
+	found := false
+	for _, line := range structured[0].Cells {
+		for _, c := range line {
+			found = found || c.Color == len(cfg.Colors)
+		}
+	}
+	if !found || animator.Palette()[len(cfg.Colors)] != ColorRed {
+		t.Error("Expected highlighted cells to use the last palette color")
+	}
+}
+
+// TestFrameDeltas tests that deltas decode to the frames they came from
+func TestFrameDeltas(t *testing.T) {
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	cfg.Colors = []Color{ColorRed, ColorBlue}
+	for _, parser := range []string{"terminal", "terminal-color", "html"} {
+		cfg.OutputParser, _ = GetParser(parser)
+		for _, anim := range []string{"reveal", "scroll", "rain", "wave"} {
+			frames, err := NewAnimator(cfg).GenerateAnimation("Hi there", anim, time.Millisecond)
+			if err != nil {
+				t.Fatalf("GenerateAnimation failed: %v", err)
+			}
+			deltas := EncodeFrames(frames)
+			decoded := DecodeFrames(deltas)
+			if len(decoded) != len(frames) {
+				t.Fatalf("%s/%s: %d frames decoded from %d", parser, anim, len(decoded), len(frames))
+			}
+			full, kept := 0, 0
+			for i := range frames {
+				if decoded[i] != frames[i] {
+					t.Fatalf("%s/%s: frame %d differs after decoding", parser, anim, i)
+				}
+				full += len(frames[i].Content)
+				for _, change := range deltas[i].Changes {
+					kept += len(change.Text)
+				}
+			}
+			if (anim == "reveal" || anim == "rain") && kept*2 > full {
+				t.Errorf("%s/%s: deltas keep %d of %d bytes", parser, anim, kept, full)
+			}
+		}
+	}
+
+	// A frame that moves on screen is stored whole
+	frames := []Frame{{Content: "a\nb\n"}, {Content: "a\nc\n", BaselineOffset: 1}, {Content: "a\nd"}}
+	deltas := EncodeFrames(frames)
+	if len(deltas[1].Changes) != 3 || len(deltas[2].Changes) != 2 || deltas[2].Lines != 2 {
+		t.Errorf("Unexpected deltas %+v", deltas)
+	}
+	// Only the end of a changed line is stored, counted in runes
+	deltas = EncodeFrames([]Frame{{Content: "ééa"}, {Content: "ééb"}})
+	if c := deltas[1].Changes[0]; c.Column != 2 || c.Text != "b" {
+		t.Errorf("Unexpected change %+v", c)
+	}
+	if got := DecodeFrames(deltas)[1].Content; got != "ééb" {
+		t.Errorf("Expected \"ééb\", got %q", got)
+	}
+}
+
+// TestTerminalPlayer tests the in-place redraw used by PlayDeltas and PlayStream
+func TestTerminalPlayer(t *testing.T) {
+	var sb strings.Builder
+	var enc deltaencoder
+	p := terminalplayer{w: &sb}
+	for _, content := range []string{"12\n34\n", "12\n35\n", "6\n"} {
+		p.draw(enc.encode(Frame{Content: content}))
+	}
+	p.close()
+	want := "\033[?25l12\033[K\n34\033[K\n" +
+		"\033[2A\n35\033[K\n" + // the unchanged line is skipped
+		"\033[2A6\033[K\n\033[J" + // a shorter frame clears the rest
+		"\033[?25h"
+	if got := sb.String(); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+// TestSchedule tests frame start times, lookup and rounded delays
+func TestSchedule(t *testing.T) {
+	frames := []Frame{{Delay: 15 * time.Millisecond}, {Delay: 15 * time.Millisecond}, {Delay: 15 * time.Millisecond}}
+	s := NewSchedule(FrameDelays(frames))
+	if s.Duration() != 45*time.Millisecond || s[2] != 30*time.Millisecond {
+		t.Errorf("Unexpected schedule %v", s)
+	}
+	for elapsed, want := range map[time.Duration]int{0: 0, 14 * time.Millisecond: 0, 15 * time.Millisecond: 1, time.Second: 2} {
+		if got := s.FrameAt(elapsed); got != want {
+			t.Errorf("FrameAt(%v) = %d, want %d", elapsed, got, want)
+		}
+	}
+	if got := NewSchedule(nil).FrameAt(0); got != -1 {
+		t.Errorf("FrameAt with no frames = %d, want -1", got)
+	}
+	// Rounding every delay would give 20ms each, 60ms in all
+	var total time.Duration
+	for _, d := range s.Delays(10 * time.Millisecond) {
+		total += d
+	}
+	if total != 50*time.Millisecond {
+		t.Errorf("Rounded delays add up to %v, want 50ms", total)
+	}
+}
+
+// TestTerminalPlayerSkip tests that lines changed by skipped frames are redrawn
+func TestTerminalPlayerSkip(t *testing.T) {
+	var sb strings.Builder
+	p := terminalplayer{w: &sb}
+	deltas := EncodeFrames([]Frame{{Content: "1\n2\n"}, {Content: "3\n2\n"}, {Content: "3\n4\n"}})
+	p.draw(deltas[0])
+	p.skip(deltas[1])
+	sb.Reset()
+	p.draw(deltas[2])
+	if want := "\033[2A3\033[K\n4\033[K\n"; sb.String() != want {
+		t.Errorf("Expected %q, got %q", want, sb.String())
+	}
+}
+
+// TestPlayer tests playback, pausing, seeking and the end of a Player
+func TestPlayer(t *testing.T) {
+	frames := []Frame{{Content: "A", Delay: time.Millisecond}, {Content: "B", Delay: time.Hour}, {Content: "C"}}
+	p := NewPlayer(frames)
+	shown := make(chan int, 10)
+	ended := make(chan struct{})
+	p.OnFrame = func(index int, frame Frame) {
+		if frame.Content != frames[index].Content {
+			t.Errorf("Frame %d is %q", index, frame.Content)
+		}
+		shown <- index
+	}
+	p.OnEnd = func() { close(ended) }
+
+	if err := p.Seek(3); err == nil {
+		t.Error("Expected an error seeking past the last frame")
+	}
+	p.Play()
+	for _, want := range []int{0, 1} {
+		if got := <-shown; got != want {
+			t.Fatalf("Expected frame %d, got %d", want, got)
+		}
+	}
+	p.Pause()
+	if p.Playing() {
+		t.Error("Playing after Pause")
+	}
+	if err := p.Seek(2); err != nil {
+		t.Fatalf("Seek failed: %v", err)
+	}
+	if got := <-shown; got != 2 {
+		t.Fatalf("Expected frame 2 after Seek, got %d", got)
+	}
+	if index, _ := p.Frame(); index != 2 || p.Playing() {
+		t.Errorf("Seek should stay paused on frame 2, got frame %d, playing %v", index, p.Playing())
+	}
+
+	p.SetSpeed(4)
+	p.Play()
+	select {
+	case <-ended:
+	case <-time.After(time.Second):
+		t.Fatal("OnEnd was not called")
+	}
+	if p.Playing() || p.Speed() != 4 {
+		t.Errorf("Expected a stopped Player at speed 4, got playing %v, speed %v", p.Playing(), p.Speed())
+	}
+}
+
+// TestPlayInteractive tests the quit, pause and end of input controls
+func TestPlayInteractive(t *testing.T) {
+	frames := []Frame{{Content: "A\n", Delay: time.Hour}, {Content: "B\n"}}
+	var sb strings.Builder
+	PlayInteractive(frames, InteractiveOptions{Input: strings.NewReader("q"), Output: &sb})
+	if got := sb.String(); !strings.Contains(got, "A") || strings.Contains(got, "B") {
+		t.Errorf("q should quit on the first frame, got %q", got)
+	}
+
+	// Without input the animation plays to the end
+	sb.Reset()
+	frames[0].Delay = time.Millisecond
+	PlayInteractive(frames, InteractiveOptions{Input: strings.NewReader(""), Output: &sb})
+	if got := sb.String(); !strings.Contains(got, "B") || !strings.HasSuffix(got, "\033[?25h") {
+		t.Errorf("Expected every frame and the cursor shown, got %q", got)
+	}
+
+	// Space pauses before the first frame ends
+	frames[0].Delay = 100 * time.Millisecond
+	r, w := io.Pipe()
+	played := make(chan string)
+	go func() {
+		var out strings.Builder
+		PlayInteractive(frames, InteractiveOptions{Input: r, Output: &out})
+		played <- out.String()
+	}()
+	w.Write([]byte(" "))
+	time.Sleep(200 * time.Millisecond)
+	w.Write([]byte("q"))
+	if got := <-played; strings.Contains(got, "B") {
+		t.Errorf("The paused animation went on, got %q", got)
+	}
+}
+
+// TestProgressBanner tests in-place updates mixed with log output
+func TestProgressBanner(t *testing.T) {
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	var sb strings.Builder
+	p := NewProgressBanner(cfg, &sb)
+	p.Label = "Build "
+	p.Update(42.9)
+	_, height := cfg.Measure("Build 42%")
+	first := sb.Len()
+	if !strings.HasPrefix(sb.String(), "\033[?25l") || strings.Count(sb.String(), "\n") != height {
+		t.Fatalf("Unexpected first banner %q", sb.String())
+	}
+
+	log := p.Writer()
+	fmt.Fprint(log, "compiling")
+	if sb.Len() != first {
+		t.Error("A partial log line was written")
+	}
+	fmt.Fprint(log, " done\nlinking")
+	erase := fmt.Sprintf("\033[%dA\033[J", height)
+	out := sb.String()[first:]
+	if !strings.HasPrefix(out, erase+"compiling done\n") || !strings.Contains(out, cfg.RenderString("Build 42%")[:10]) {
+		t.Errorf("Log line not written above the banner: %q", out)
+	}
+
+	p.Update(250)
+	if !strings.Contains(sb.String(), "\033[?25l") || strings.Contains(sb.String(), "\033[?25h") {
+		t.Error("Cursor shown before Done")
+	}
+	p.Done()
+	out = sb.String()
+	if !strings.HasSuffix(out, "\033[?25h") || !strings.Contains(out, erase+"linking\n") {
+		t.Errorf("Unexpected end of output %q", out[len(out)-40:])
+	}
+	for _, line := range strings.Split(cfg.RenderString("Build 100%"), "\n") {
+		if line != "" && !strings.Contains(out, line) {
+			t.Errorf("Banner line %q not drawn", line)
+		}
+	}
+
+	sb.Reset()
+	p = NewProgressBanner(cfg, &sb)
+	p.Step(3, 10)
+	p.Clear()
+	if !strings.HasSuffix(sb.String(), erase+"\033[?25h") {
+		t.Errorf("Banner not erased: %q", sb.String())
+	}
+}
+
+// TestExportHTML tests the standalone HTML player options
+func TestExportHTML(t *testing.T) {
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	cfg.OutputParser, _ = GetParser("html")
+	animator := NewAnimator(cfg)
+	frames, err := animator.GenerateAnimation("Hi", "reveal", 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("GenerateAnimation failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := animator.ExportHTML(&buf, frames, DefaultHTMLPlayerOptions()); err != nil {
+		t.Fatalf("ExportHTML failed: %v", err)
+	}
+	page := buf.String()
+	if strings.Contains(page, "id='controls'") || !strings.Contains(page, "const LOOP = true;") || !strings.Contains(page, "    play();") {
+		t.Error("Expected the default player to autoplay in a loop without controls")
+	}
+
+	buf.Reset()
+	opts := HTMLPlayerOptions{Controls: true, Speed: 2, Theme: "light", Title: "<Hi>"}
+	if err := animator.ExportHTML(&buf, frames, opts); err != nil {
+		t.Fatalf("ExportHTML failed: %v", err)
+	}
+	page = buf.String()
+	for _, want := range []string{"id='toggle'", "value='2'", "let speed = 2;", "const LOOP = false;", "background: #ffffff", "<title>&lt;Hi&gt;</title>"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("Expected the page to contain %q", want)
+		}
+	}
+	if strings.Contains(page, "    play();") {
+		t.Error("Expected the player to wait without Autoplay")
+	}
+
+	opts.Theme = "neon"
+	if err := animator.ExportHTML(&buf, frames, opts); err == nil {
+		t.Error("Expected an error for an unknown theme")
+	}
+}
+
+// TestFrameFilter tests that filters run in order, before the parser suffix
+func TestFrameFilter(t *testing.T) {
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	cfg.OutputParser, _ = GetParser("html")
+	animator := NewAnimator(cfg)
+	animator.AddFrameFilter(func(i int, f Frame) Frame {
+		f.Content += fmt.Sprintf("frame %d\n", i)
+		return f
+	})
+	animator.AddFrameFilter(func(i int, f Frame) Frame {
+		f.Content = strings.ToUpper(f.Content)
+		f.Delay *= 2
+		return f
+	})
+	frames, err := animator.GenerateAnimation("Hi", "reveal", 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("GenerateAnimation failed: %v", err)
+	}
+	for i, f := range frames {
+		if !strings.HasPrefix(f.Content, "<code>") || !strings.HasSuffix(f.Content, fmt.Sprintf("FRAME %d\n</code>", i)) {
+			t.Fatalf("frame %d: unexpected content %q", i, f.Content)
+		}
+		if f.Delay != 20*time.Millisecond {
+			t.Errorf("frame %d: expected a 20ms delay, got %v", i, f.Delay)
+		}
+	}
+}
+
+// TestGenerateStructured tests that cell grids match the text frames
+func TestGenerateStructured(t *testing.T) {
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	cfg.Colors = []Color{ColorRed, ColorGreen, ColorBlue}
+	animator := NewAnimator(cfg)
+	for _, anim := range ListAnimations() {
+		plain := *cfg
+		plain.Colors = nil
+		frames, err := NewAnimator(&plain).GenerateAnimation("Hi", anim, time.Millisecond)
+		if err != nil {
+			t.Fatalf("GenerateAnimation failed: %v", err)
+		}
+		structured, err := animator.GenerateStructured("Hi", anim, time.Millisecond)
+		if err != nil {
+			t.Fatalf("GenerateStructured failed: %v", err)
+		}
+		if len(structured) != len(frames) {
+			t.Fatalf("%s: %d structured frames, %d frames", anim, len(structured), len(frames))
+		}
+		var prev [][]Cell
+		for i, f := range structured {
+			// Explosion scatters characters at random, so only compare shapes
+			if anim != "explosion" {
+				var sb strings.Builder
+				for _, line := range f.Cells {
+					for _, c := range line {
+						sb.WriteRune(c.Char)
+					}
+					sb.WriteString("\n")
+				}
+				if sb.String() != frames[i].Content {
+					t.Fatalf("%s: frame %d differs:\n%s\n%s", anim, i, sb.String(), frames[i].Content)
+				}
+			}
+			for _, line := range f.Cells {
+				for _, c := range line {
+					if c.Color >= len(animator.Palette()) || (c.Char != ' ' && c.Color < 0) {
+						t.Fatalf("%s: frame %d: bad color %d for %q", anim, i, c.Color, c.Char)
+					}
+				}
+			}
+			// Applying the diff to the previous grid gives this one
+			screen := map[[2]int]Cell{}
+			for y, line := range prev {
+				for x, c := range line {
+					screen[[2]int{x, y}] = c
+				}
+			}
+			for _, change := range DiffCells(prev, f.Cells) {
+				screen[[2]int{change.X, change.Y}] = change.Cell
+			}
+			for y, line := range f.Cells {
+				for x, c := range line {
+					got, ok := screen[[2]int{x, y}]
+					if !ok {
+						got = Cell{' ', -1}
+					}
+					if got != c {
+						t.Fatalf("%s: frame %d: diff misses cell %d,%d", anim, i, x, y)
+					}
+				}
+			}
+			prev = f.Cells
+		}
+	}
+	if animator.structured || animator.grids != nil {
+		t.Error("Expected GenerateStructured to reset the animator")
+	}
+}
+
+// TestExportFrames tests that frames are written to numbered files with a
+// manifest
+func TestExportFrames(t *testing.T) {
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	animator := NewAnimator(cfg)
+	frames, err := animator.GenerateStructured("Hi", "reveal", 20*time.Millisecond)
+	if err != nil {
+		t.Fatalf("GenerateStructured failed: %v", err)
+	}
+	plain, _ := animator.GenerateAnimation("Hi", "reveal", 20*time.Millisecond)
+	for _, format := range []Format{FormatText, FormatPNG} {
+		dir := filepath.Join(t.TempDir(), "frames")
+		if err := animator.ExportFrames(dir, frames, format); err != nil {
+			t.Fatalf("ExportFrames failed: %v", err)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+		if err != nil {
+			t.Fatalf("Reading the manifest failed: %v", err)
+		}
+		var manifest FrameManifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			t.Fatalf("Bad manifest: %v", err)
+		}
+		if len(manifest.Frames) != len(frames) || manifest.Frames[0].DelayMs != 20 {
+			t.Fatalf("Unexpected manifest %+v", manifest)
+		}
+		last := filepath.Join(dir, manifest.Frames[len(frames)-1].File)
+		if format == FormatText {
+			content, _ := os.ReadFile(last)
+			if string(content) != plain[len(plain)-1].Content {
+				t.Errorf("Expected %q, got %q", plain[len(plain)-1].Content, content)
+			}
+		} else if f, err := os.Open(last); err != nil {
+			t.Errorf("Opening %s failed: %v", last, err)
+		} else {
+			if _, err := png.Decode(f); err != nil {
+				t.Errorf("Bad PNG frame: %v", err)
+			}
+			f.Close()
+		}
+	}
+	if err := animator.ExportFrames(t.TempDir(), frames, FormatSVG); err == nil {
+		t.Error("Expected an error for the svg format")
+	}
+}
+
+// TestFrameGrids tests that grids emit the frames they came from and can
+// be recolored
+func TestFrameGrids(t *testing.T) {
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	cfg.Colors = []Color{ColorRed, TrueColor{0, 128, 255}}
+	for _, parser := range []string{"terminal", "terminal-color", "html"} {
+		cfg.OutputParser, _ = GetParser(parser)
+		animator := NewAnimator(cfg)
+		frames, _ := animator.GenerateAnimation("Hi", "rain", time.Millisecond)
+		grids, err := animator.GenerateGrids("Hi", "rain", time.Millisecond)
+		if err != nil {
+			t.Fatalf("GenerateGrids failed: %v", err)
+		}
+		if len(grids) != len(frames) {
+			t.Fatalf("%s: %d grids, %d frames", parser, len(grids), len(frames))
+		}
+		for i := range grids {
+			if f := grids[i].Frame(cfg.OutputParser); f != frames[i] {
+				t.Fatalf("%s: frame %d differs:\n%q\n%q", parser, i, f.Content, frames[i].Content)
+			}
+		}
+	}
+
+	// A fade darkens every colored cell
+	grids, _ := NewAnimator(cfg).GenerateGrids("Hi", "rain", 0)
+	last := grids[len(grids)-1]
+	faded := last.Recolor(func(x, y int, c Color) Color {
+		if c == nil {
+			return nil
+		}
+		tc, _ := RGB(c)
+		return tc.Darken(0.5)
+	})
+	if faded.Emit(cfg.OutputParser) == last.Emit(cfg.OutputParser) {
+		t.Error("Expected the faded grid to differ")
+	}
+	if last.Colors[0][1] == faded.Colors[0][1] && last.Colors[0][1] != nil {
+		t.Error("Recolor changed the original grid")
+	}
+}
+
+// TestRecorder tests that a recorded session survives a round trip
+func TestRecorder(t *testing.T) {
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	rec := NewRecorder(cfg)
+	out := rec.RenderString("Hi")
+	if out != cfg.RenderString("Hi") {
+		t.Errorf("Expected the banner, got %q", out)
+	}
+	rec.record(SessionEvent{TimeMs: 20, Frames: []SessionFrame{{"a\n", 5, 0, 0}}})
+
+	var buf bytes.Buffer
+	if err := rec.WriteSession(&buf); err != nil {
+		t.Fatalf("WriteSession failed: %v", err)
+	}
+	session, err := ReadSession(&buf)
+	if err != nil {
+		t.Fatalf("ReadSession failed: %v", err)
+	}
+	if len(session.Events) != 2 || session.Events[0].Output != out || session.Events[1].Frames[0].DelayMs != 5 {
+		t.Errorf("Unexpected session %+v", session)
+	}
+	if _, err := ReadSession(strings.NewReader("{")); err == nil {
+		t.Error("Expected an error for a broken session")
+	}
+}
+
+// TestContactSheet tests that frames are tiled cols to a row
+func TestContactSheet(t *testing.T) {
+	frames := []StructuredFrame{
+		{Cells: [][]Cell{{{'a', 0}}, {{'b', -1}}}},
+		{Cells: [][]Cell{{{'c', -1}}}, BaselineOffset: 1},
+		{Cells: [][]Cell{{{'d', -1}, {'e', -1}}}},
+	}
+	cfg := New()
+	sheet := NewAnimator(cfg).ContactSheet(frames, 2)
+	// The second frame starts a line higher, like on a terminal
+	want := "    c\na\nb\n\n\nde\n"
+	if sheet != want {
+		t.Errorf("Expected %q, got %q", want, sheet)
+	}
+	if cells := ContactSheetCells(frames, 0); len(cells) != 3 || len(cells[1]) != 10 {
+		t.Errorf("Expected one row of three tiles, got %v", cells)
+	}
+
+	cfg.Colors = []Color{ColorRed}
+	cfg.OutputParser, _ = GetParser("html")
+	sheet = NewAnimator(cfg).ContactSheet(frames, 3)
+	if !strings.Contains(sheet, "color:") || !strings.HasPrefix(sheet, cfg.OutputParser.Prefix) {
+		t.Errorf("Expected a colored html sheet, got %q", sheet)
+	}
+}
+
+// renderWithControl renders text with the standard font and the given control files
+func renderWithControl(t *testing.T, text string, controls ...string) (*Config, string) {
+	t.Helper()
+	cfg := New()
+	// Control files written by the tests are named by absolute path
+	cfg.AllowFilesystemPaths = true
+	for _, name := range controls {
+		cfg.AddControlFile(name)
+	}
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	return cfg, cfg.RenderString(text)
+}
+
+// TestShippedControlFiles tests the control files in fonts/
+func TestShippedControlFiles(t *testing.T) {
+	_, upper := renderWithControl(t, "HELLO")
+	if _, got := renderWithControl(t, "hello", "upper"); got != upper {
+		t.Error("upper.flc should map lowercase to uppercase")
+	}
+
+	deutsch := New()
+	deutsch.Deutschflag = true
+	if err := deutsch.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	if _, got := renderWithControl(t, "[", "646-de"); got != deutsch.RenderString("[") {
+		t.Error("646-de.flc should map [ to A with diaeresis")
+	}
+
+	if cfg, _ := renderWithControl(t, "x", "utf8"); cfg.Multibyte != 2 {
+		t.Errorf("utf8.flc: expected Multibyte 2, got %d", cfg.Multibyte)
+	}
+	if cfg, _ := renderWithControl(t, "x", "hz"); cfg.Multibyte != 3 {
+		t.Errorf("hz.flc: expected Multibyte 3, got %d", cfg.Multibyte)
+	}
+	cfg, _ := renderWithControl(t, "x", "jis0201")
+	if cfg.gn[0] != 'J'<<16 || cfg.gn[1] != 'I'<<16 || cfg.gl != 0 || cfg.gr != 1 {
+		t.Errorf("jis0201.flc: unexpected charsets gn=%x gl=%d gr=%d", cfg.gn, cfg.gl, cfg.gr)
+	}
+
+	// Every shipped control file must load and render
+	files, err := filepath.Glob("fonts/*.flc")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no control files found: %v", err)
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".flc")
+		if _, got := renderWithControl(t, "42", name); got == "" {
+			t.Errorf("%s: empty output", name)
+		}
+	}
+}
+
+// TestControlFileCommands tests numeric ranges and charset corner cases
+func TestControlFileCommands(t *testing.T) {
+	dir := t.TempDir()
+	control := "flc2a\n" +
+		"0x61-0x7A 0x41\t# a-z to A-Z\n" +
+		"u # trailing text is ignored\n" +
+		"g 0 94 B\n" +
+		"g 1 96  A\n" +
+		"g 2 94\n" +
+		"g 3 94x94 C\n" +
+		"g L 9\n"
+	path := filepath.Join(dir, "test")
+	if err := os.WriteFile(path+".flc", []byte(control), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, upper := renderWithControl(t, "ABZ")
+	cfg, got := renderWithControl(t, "abz", path)
+	if got != upper {
+		t.Error("numeric range should map a-z to A-Z")
+	}
+	if cfg.Multibyte != 0 {
+		t.Errorf("g should reset Multibyte, got %d", cfg.Multibyte)
+	}
+	if cfg.gn[0] != 0 || cfg.gn[1] != 0x80 || cfg.gn[2] != 0 {
+		t.Errorf("unexpected single-byte charsets %x", cfg.gn)
+	}
+	if cfg.gn[3] != 'C'<<16 || !cfg.gndbl[3] {
+		t.Errorf("expected double-byte charset C in G3, got %x (double=%v)", cfg.gn[3], cfg.gndbl[3])
+	}
+	if cfg.gl != 0 {
+		t.Errorf("out of range g L should be ignored, got gl=%d", cfg.gl)
+	}
+}
+
+// TestControlFileInclude tests chaining and the include command
+func TestControlFileInclude(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"swap":  "flc2a\nt a b\nt b a\n",
+		"main":  "flc2a\ni upper.flc\ni swap\n",
+		"loop1": "flc2a\ni loop2\n",
+		"loop2": "flc2a\ni loop1\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name+".flc"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Commands apply in order: upper turns "ab" into "AB" before swap sees it
+	_, want := renderWithControl(t, "AB")
+	cfg, got := renderWithControl(t, "ab", filepath.Join(dir, "main"))
+	if got != want {
+		t.Error("included files should be applied in order")
+	}
+	expected := []string{filepath.Join(dir, "main"), "upper", filepath.Join(dir, "swap")}
+	if names := cfg.ControlFiles(); strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected control files %v, got %v", expected, names)
+	}
+
+	// Reloading must not apply the commands twice
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	if cfg.RenderString("ab") != want {
+		t.Error("reloading the font should not change the translation")
+	}
+
+	cycle := New()
+	cycle.AllowFilesystemPaths = true
+	cycle.AddControlFile(filepath.Join(dir, "loop1"))
+	if err := cycle.LoadFont(); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("Expected include cycle error, got %v", err)
+	}
+
+	missing := New()
+	missing.AddControlFile("no-such-control-file")
+	if err := missing.LoadFont(); err == nil {
+		t.Error("Expected error for missing control file")
+	}
+}
+
+// TestWithCharMap tests the built-in character maps
+func TestWithCharMap(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+		maps  []string
+	}{
+		{"utf8 fallback", "café", "cafe", []string{CharMapUTF8}},
+		{"utf8 keeps font glyphs", "Ö", "\xd6", []string{CharMapUTF8}},
+		{"utf8 double acute", "Ő", "\xd6", []string{CharMapUTF8}},
+		{"utf8 extended latin", "ŵ", "w", []string{CharMapUTF8}},
+		{"uppercase", "hello", "HELLO", []string{CharMapUppercase}},
+		{"quotes", "“hi” ‘x’", "\"hi\" 'x'", []string{CharMapUTF8, CharMapQuotes}},
+		{"latin1", "\xe4", "\xe4", []string{CharMapLatin1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// doom only has the ASCII characters and the Deutsch letters
+			got, err := Render(tt.input, WithFont("doom"), WithCharMap(tt.maps...))
+			if err != nil {
+				t.Fatalf("Render with WithCharMap failed: %v", err)
+			}
+			want, _ := Render(tt.want, WithFont("doom"))
+			if got != want {
+				t.Errorf("Expected %q to render like %q\ngot:\n%s\nwant:\n%s", tt.input, tt.want, got, want)
+			}
+		})
+	}
+
+	if _, err := Render("x", WithCharMap("klingon")); err == nil {
+		t.Error("Expected error for unknown character map")
+	}
+}
+
+// TestWithCase tests the case transformations
+func TestWithCase(t *testing.T) {
+	tests := []struct {
+		mode  CaseMode
+		input string
+		want  string
+	}{
+		{CaseUpper, "hello World", "HELLO WORLD"},
+		{CaseLower, "Hello WORLD", "hello world"},
+		{CaseTitle, "hELLO jean-luc don't", "Hello Jean-Luc Don't"},
+		{CaseNone, "MiXeD", "MiXeD"},
+	}
+	for _, tt := range tests {
+		got, err := Render(tt.input, WithCase(tt.mode), WithWidth(200))
+		if err != nil {
+			t.Fatalf("Render with WithCase failed: %v", err)
+		}
+		want, _ := Render(tt.want, WithWidth(200))
+		if got != want {
+			t.Errorf("WithCase(%d): expected %q to render like %q", tt.mode, tt.input, tt.want)
+		}
+	}
+}
+
+// TestSmushRules tests the conversions between SmushRules and the legacy bitmask
+func TestSmushRules(t *testing.T) {
+	for bits := 0; bits < 64; bits++ {
+		smush := bits | SM_SMUSH
+		if got := SmushRulesFromBits(smush).Bits(); got != smush {
+			t.Errorf("round trip of %d gave %d", smush, got)
+		}
+	}
+	if got := SmushRulesFromBits(SM_KERN).Mode; got != LayoutKerning {
+		t.Errorf("Expected kerning, got %v", got)
+	}
+	if got := SmushRulesFromBits(0).Mode; got != LayoutFullWidth {
+		t.Errorf("Expected full width, got %v", got)
+	}
+
+	modes := map[int]int{-1: 0, 0: SM_KERN, 128: SM_SMUSH, 15: 15 | SM_SMUSH}
+	for mode, want := range modes {
+		if got := SmushRulesFromMode(mode).Bits(); got != want {
+			t.Errorf("SmushRulesFromMode(%d).Bits() = %d, want %d", mode, got, want)
+		}
+	}
+	if !SmushRulesFromMode(128).Universal() {
+		t.Error("smushing without rules should be universal")
+	}
+
+	// standard smushes with rules 1-4 (full layout 24463)
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	want := SmushRules{Mode: LayoutSmushing, Equal: true, Underscore: true, Hierarchy: true, OppositePairs: true, BigX: false, Hardblank: false}
+	if got := cfg.SmushRules(); got != want {
+		t.Errorf("standard: expected %+v, got %+v", want, got)
+	}
+
+	rules := SmushRules{Mode: LayoutSmushing, Equal: true}
+	viaRules, _ := Render("Hello", WithSmushRules(rules))
+	viaMode, _ := Render("Hello", WithSmushMode(1))
+	if viaRules != viaMode {
+		t.Error("WithSmushRules should render like the equivalent WithSmushMode")
+	}
+}
+
+// TestSmush tests the public smushing rules and the smush trace
+func TestSmush(t *testing.T) {
+	all := SmushRulesFromBits(SM_SMUSH | 63)
+	tests := []struct {
+		l, r  rune
+		rules SmushRules
+		want  rune
+		rule  RuleApplied
+	}{
+		{' ', 'a', all, 'a', RuleSpace},
+		{'a', 'b', SmushRules{Mode: LayoutSmushing}, 'b', RuleUniversal},
+		{'$', 'b', SmushRules{Mode: LayoutSmushing}, 'b', RuleUniversal},
+		{'a', 'a', SmushRules{Mode: LayoutKerning}, 0, RuleNone},
+		{'a', 'a', all, 'a', RuleEqual},
+		{'_', '/', all, '/', RuleUnderscore},
+		{'|', '}', all, '}', RuleHierarchy},
+		{')', '(', all, '|', RuleOppositePair},
+		{'\\', '/', all, 'Y', RuleBigX},
+		{'$', '$', all, '$', RuleHardblank},
+		{'$', 'a', all, 0, RuleNone},
+		{'a', 'b', all, 0, RuleNone},
+	}
+	for _, tt := range tests {
+		got, rule := Smush(tt.l, tt.r, tt.rules, '$')
+		if got != tt.want || rule != tt.rule {
+			t.Errorf("Smush(%q, %q, %+v) = %q, %v; want %q, %v", tt.l, tt.r, tt.rules, got, rule, tt.want, tt.rule)
+		}
+	}
+
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	want := cfg.RenderString("Hello|")
+	if len(cfg.SmushTrace()) != 0 {
+		t.Error("Expected no trace without TraceSmushing")
+	}
+	if got := cfg.RenderString("Hello|", WithTraceSmushing()); got != want {
+		t.Errorf("Tracing changed the output:\n%s\nexpected:\n%s", got, want)
+	}
+	trace := cfg.SmushTrace()
+	if len(trace) == 0 {
+		t.Fatal("Expected smushed pairs in the trace")
+	}
+	for _, e := range trace {
+		result, rule := Smush(e.Left, e.Right, cfg.SmushRules(), cfg.hardblank)
+		if e.Rule == RuleNone || e.Result != result || e.Rule != rule {
+			t.Errorf("Unexpected trace event %v", e)
+		}
+	}
+}
+
+// TestDebug checks the debug log covers font loading, layout and wrapping
+func TestDebug(t *testing.T) {
+	var log bytes.Buffer
+	cfg := New()
+	cfg.Debug(&log)
+	cfg.Outputwidth = 30
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	cfg.RenderString("Hello world")
+	for _, want := range []string{
+		"open fonts/standard.flf: embedded",
+		"header: magic flf2, hardblank '$', height 6",
+		"char 'H': width",
+		"wrap: line full at",
+	} {
+		if !strings.Contains(log.String(), want) {
+			t.Errorf("Expected %q in the debug log:\n%s", want, log.String())
+		}
+	}
+
+	log.Reset()
+	cfg.Debug(nil)
+	cfg.RenderString("Hello")
+	if log.Len() != 0 {
+		t.Errorf("Expected no log after Debug(nil), got %q", log.String())
+	}
+}
+
+// TestRenderStringOverrides tests per-call options and that they do not stick
+func TestRenderStringOverrides(t *testing.T) {
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	plain := cfg.RenderString("Hello World")
+
+	parser, _ := GetParser("html")
+	tests := []struct {
+		name string
+		opt  RenderOption
+		want string
+	}{
+		{"width", WithWidth(30), mustRender(t, "Hello World", WithWidth(30))},
+		{"justification", WithJustification(2), mustRender(t, "Hello World", WithJustification(2))},
+		{"smush", WithFullWidth(), mustRender(t, "Hello World", WithFullWidth())},
+		{"colors", WithColors(ColorRed), mustRender(t, "Hello World", WithColors(ColorRed), WithOutputParser(parser))},
+	}
+	for _, tt := range tests {
+		opts := []RenderOption{tt.opt}
+		if tt.name == "colors" {
+			opts = append(opts, WithOutputParser(parser))
+		}
+		if got := cfg.RenderString("Hello World", opts...); got != tt.want {
+			t.Errorf("%s: override rendered differently than a fresh config\ngot:\n%s\nwant:\n%s", tt.name, got, tt.want)
+		}
+		if got := cfg.RenderString("Hello World"); got != plain {
+			t.Errorf("%s: override leaked into later renders", tt.name)
+		}
+	}
+
+	// Changing the width field takes effect without reloading the font
+	cfg.Outputwidth = 30
+	if got := cfg.RenderString("Hello World"); got != tests[0].want {
+		t.Error("Outputwidth change should apply without LoadFont")
+	}
+}
+
+// mustRender renders text with a fresh config or fails the test
+func mustRender(t *testing.T, text string, options ...Option) string {
+	t.Helper()
+	result, err := Render(text, options...)
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	return result
+}
+
+// TestGlyphRowsInterned checks identical glyph rows share storage and
+// cannot grow into their neighbours
+func TestGlyphRowsInterned(t *testing.T) {
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	first := make(map[string]*rune)
+	shared := 0
+	for node := cfg.font.glyphs; node != nil; node = node.next {
+		for _, row := range node.thechar {
+			if cap(row) != len(row) {
+				t.Fatalf("character %d has a row with spare capacity", node.ord)
+			}
+			if len(row) == 0 {
+				continue
+			}
+			if p, ok := first[string(row)]; !ok {
+				first[string(row)] = &row[0]
+			} else if p != &row[0] {
+				t.Fatalf("row %q of character %d is stored twice", string(row), node.ord)
+			} else {
+				shared++
+			}
+		}
+	}
+	if shared == 0 {
+		t.Error("Expected the standard font to have repeated rows")
+	}
+}
+
+// TestListParsers checks every listed parser can be loaded
+func TestListParsers(t *testing.T) {
+	names := ListParsers()
+	if len(names) != len(parsers) {
+		t.Errorf("ListParsers returned %d parsers, expected %d", len(names), len(parsers))
+	}
+	for _, name := range names {
+		if _, err := GetParser(name); err != nil {
+			t.Errorf("GetParser(%q) failed: %v", name, err)
+		}
+	}
+}
+
+// TestColorRuns tests that neighboring cells of one color share their markup
+func TestColorRuns(t *testing.T) {
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	plain := cfg.RenderString("Hello", WithParser("html"))
+	span := regexp.MustCompile(`</?span[^>]*>`)
+	for _, colors := range [][]Color{{ColorRed}, {ColorRed, ColorBlue}} {
+		cfg.Colors = colors
+		cfg.OutputParser, _ = GetParser("html")
+		got := cfg.RenderString("Hello")
+		if stripped := span.ReplaceAllString(got, ""); stripped != plain {
+			t.Errorf("%d colors: text changed: %q", len(colors), stripped)
+		}
+		// The size with a span per cell, as every cell was written before
+		percell := len(cfg.OutputParser.Prefix) + len(cfg.OutputParser.Suffix)
+		for _, row := range cfg.cellgrid(cfg.layoutrows(cfg.compose("Hello"))) {
+			for _, cell := range row {
+				percell += len(cell)
+			}
+			percell += len(cfg.newline())
+		}
+		t.Logf("%d colors: %d bytes, %d with a span per cell", len(colors), len(got), percell)
+		if len(got)*3 > percell {
+			t.Errorf("%d colors: expected at least a threefold reduction, got %d bytes for %d", len(colors), len(got), percell)
+		}
+	}
+}
+
+// TestQuantize tests the nearest colors of smaller color depths
+func TestQuantize(t *testing.T) {
+	tests := []struct {
+		color Color
+		depth ColorDepth
+		want  Color
+	}{
+		{TrueColor{255, 0, 0}, Depth16, AnsiColor{91}},
+		{TrueColor{200, 10, 0}, Depth16, ColorRed},
+		{TrueColor{10, 10, 10}, Depth16, ColorBlack},
+		{TrueColor{255, 135, 0}, Depth256, Ansi256Color{208}},
+		{TrueColor{30, 144, 255}, Depth256, Ansi256Color{33}},
+		{TrueColor{128, 128, 128}, Depth256, Ansi256Color{244}},
+		{Ansi256Color{196}, Depth16, AnsiColor{91}},
+		{Ansi256Color{196}, Depth256, Ansi256Color{196}},
+		{ColorBlue, Depth256, ColorBlue},
+		{TrueColor{1, 2, 3}, DepthTrueColor, TrueColor{1, 2, 3}},
+	}
+	for _, tt := range tests {
+		if got := Quantize(tt.color, tt.depth); got != tt.want {
+			t.Errorf("Quantize(%v, %d) = %v, want %v", tt.color, tt.depth, got, tt.want)
+		}
+	}
+
+	for _, s := range []string{"16", "256", "truecolor", "24bit"} {
+		if _, err := ParseColorDepth(s); err != nil {
+			t.Errorf("ParseColorDepth(%q): %v", s, err)
+		}
+	}
+	if _, err := ParseColorDepth("8"); err == nil {
+		t.Error("Expected an error for depth 8")
+	}
+
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("Failed to load font: %v", err)
+	}
+	orange := TrueColor{255, 135, 0}
+	got := cfg.RenderString("I", WithColors(orange), WithColorDepth(Depth256))
+	if !strings.Contains(got, "\x1b[38;5;208m") || strings.Contains(got, "38;2;") {
+		t.Errorf("Expected 256 color codes, got %q", got)
+	}
+	got = cfg.RenderString("I", WithColors(orange), WithColorDepth(Depth16))
+	if !strings.Contains(got, "\x1b[0;") || strings.Contains(got, "38;") {
+		t.Errorf("Expected 16 color codes, got %q", got)
+	}
+	// Other parsers keep the color
+	got = cfg.RenderString("I", WithColors(orange), WithColorDepth(Depth16), WithParser("html"))
+	if !strings.Contains(got, "rgb(255,135,0)") {
+		t.Errorf("Expected the html color unchanged, got %q", got)
+	}
+}
+
+// TestSettings tests that settings round-trip through JSON
+func TestSettings(t *testing.T) {
+	cfg := New()
+	for _, opt := range []Option{
+		WithFont("slant"), WithWidth(60), WithJustification(1), WithRightToLeft(0),
+		WithSmushRules(SmushRules{Mode: LayoutSmushing, Equal: true, BigX: true}),
+		WithColors(ColorRed, TrueColor{255, 136, 0}), WithStyle(StyleBold), WithColorDepth(Depth256),
+	} {
+		opt(cfg)
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	want := `{"font":"slant","width":60,"justification":"center","direction":"left-to-right",` +
+		`"smush":{"mode":"smushing","equal":true,"bigX":true},"colors":["red","#ff8800"],` +
+		`"style":"bold","colorDepth":"256","parser":"terminal-color"}`
+	if string(data) != want {
+		t.Errorf("Expected %s, got %s", want, data)
+	}
+
+	loaded := New()
+	if err := json.Unmarshal(data, loaded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(loaded.Settings(), cfg.Settings()) {
+		t.Errorf("Expected %+v, got %+v", cfg.Settings(), loaded.Settings())
+	}
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("Failed to load font: %v", err)
+	}
+	if err := loaded.LoadFont(); err != nil {
+		t.Fatalf("Failed to load font: %v", err)
+	}
+	if got, want := loaded.RenderString("Hi"), cfg.RenderString("Hi"); got != want {
+		t.Errorf("Expected the same render, got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Empty settings change nothing and invalid ones are all rejected
+	before := loaded.Settings()
+	if err := loaded.ApplySettings(Settings{}); err != nil {
+		t.Errorf("ApplySettings: %v", err)
+	}
+	for _, s := range []string{
+		`{"width":20,"justification":"middle"}`,
+		`{"width":20,"colors":["nope"]}`,
+		`{"width":20,"smush":{"mode":"squash"}}`,
+		`{"width":20,"parser":"pdf"}`,
+	} {
+		if err := json.Unmarshal([]byte(s), loaded); err == nil {
+			t.Errorf("Expected an error for %s", s)
+		}
+	}
+	if !reflect.DeepEqual(loaded.Settings(), before) {
+		t.Errorf("Expected the settings unchanged, got %+v", loaded.Settings())
+	}
+	// An empty list removes the colors
+	if err := json.Unmarshal([]byte(`{"colors":[]}`), loaded); err != nil || len(loaded.Colors) != 0 {
+		t.Errorf("Expected no colors, got %v (%v)", loaded.Colors, err)
+	}
+}
+
+// TestColorRunsTerminal tests that terminal colors change without resets
+func TestColorRunsTerminal(t *testing.T) {
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	_, height := cfg.Measure("Hello")
+	for _, colors := range [][]Color{{ColorRed, ColorBlue}, {TrueColor{255, 0, 0}, TrueColor{0, 0, 255}}} {
+		got := cfg.RenderString("Hello", WithParser("terminal-color"), WithColors(colors...), WithStyle(StyleBold))
+		if resets := strings.Count(got, "\x1b[0m"); resets != height {
+			t.Errorf("Expected one reset per line, got %d in %q", resets, got)
+		}
+		parser, _ := GetParser("terminal-color")
+		if want, _ := StyleBold.wrap(colors[0].getPrefix(parser), "", parser); !strings.HasPrefix(got, want) {
+			t.Errorf("Expected %q first, got %q", want, got)
+		}
+		plain := regexp.MustCompile("\x1b\\[[0-9;]*m").ReplaceAllString(got, "")
+		if plain != cfg.RenderString("Hello") {
+			t.Errorf("Text changed: %q", plain)
+		}
+	}
+}
+
+// TestScriptParsers tests the cmd and powershell escaping
+func TestScriptParsers(t *testing.T) {
+	// A font drawing every printable character as itself
+	var glyphs []string
+	for c := ' '; c <= '~'; c++ {
+		if c == '@' {
+			glyphs = append(glyphs, "@##")
+		} else {
+			glyphs = append(glyphs, string(c)+"@@")
+		}
+	}
+	dir := writeTestFont(t, "plain", "flf2a\x7f 1 1 3 0 0", glyphs)
+	tests := []struct {
+		parser, text, want string
+	}{
+		{"cmd", `a&b%^"|x`, "echo(a^&b%%^^^\"^|x\r\n"},
+		{"powershell", "it's $x `y`", "Write-Output 'it''s $x `y`'\n"},
+	}
+	for _, tt := range tests {
+		got, err := Render(tt.text, WithFontDir(dir), WithFont("plain"), WithFullWidth(), WithParser(tt.parser))
+		if err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.parser, got, tt.want)
+		}
+	}
+	// Inserted carets must not be escaped again
+	cmd, _ := GetParser("cmd")
+	if got := handleReplaces("&^", cmd); got != "^&^^" {
+		t.Errorf("handleReplaces = %q, want %q", got, "^&^^")
+	}
+}
+
+// TestValidate tests configuration validation
+func TestValidate(t *testing.T) {
+	cfg := New()
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("default config should be valid: %v", err)
+	}
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("loaded config should be valid: %v", err)
+	}
+
+	bad := New()
+	bad.Outputwidth = 0
+	bad.Justification = 7
+	bad.OutputParser = nil
+	err := bad.Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Expected a ValidationError, got %v", err)
+	}
+	for _, field := range []string{"Outputwidth", "Justification", "OutputParser"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("Expected error to mention %s: %v", field, err)
+		}
+	}
+	if err := bad.LoadFont(); err == nil {
+		t.Error("LoadFont should fail for an invalid config")
+	}
+
+	// Invalid per-call options are reported and do not stick
+	if _, err := cfg.Render("Hi", WithJustification(5)); err == nil {
+		t.Error("Render should fail for an invalid override")
+	}
+	if got := cfg.RenderString("Hi", WithJustification(5)); got != "" {
+		t.Errorf("RenderString should return an empty string when invalid, got %q", got)
+	}
+	if got, err := cfg.Render("Hi"); err != nil || got == "" {
+		t.Errorf("Render after invalid override failed: %v", err)
+	}
+}
+
+// BenchmarkRender benchmarks the Render function
+func BenchmarkRender(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = Render("Hello")
+	}
+}
+
+// BenchmarkRenderWithFont benchmarks rendering with specific font
+func BenchmarkRenderWithFont(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = RenderWithFont("Hello", "standard")
+	}
+}
+
+// BenchmarkConfigReuse benchmarks reusing Config for multiple renders
+func BenchmarkConfigReuse(b *testing.B) {
+	cfg := New()
+	_ = cfg.LoadFont()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = cfg.RenderString("Hello")
+	}
+}
+
+// TestLazyGlyphs checks deferred code-tagged characters render like eagerly loaded ones
+func TestLazyGlyphs(t *testing.T) {
+	text := "Héllo ¿Ñ€?"
+	eager := New()
+	if err := eager.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	lazy := New()
+	lazy.LazyGlyphs = true
+	if err := lazy.LoadFont(); err != nil {
+		t.Fatalf("LoadFont with LazyGlyphs failed: %v", err)
+	}
+	eager.Multibyte, lazy.Multibyte = 2, 2
+	if lazy.font.lazy == nil || len(lazy.font.lazy.offsets) == 0 {
+		t.Fatal("Expected code-tagged characters to be deferred")
+	}
+	if len(lazy.font.lazy.loaded) != 0 {
+		t.Errorf("Expected no deferred character to be parsed yet, got %d", len(lazy.font.lazy.loaded))
+	}
+	if !lazy.hasglyph('é') {
+		t.Error("Expected hasglyph to find a deferred character")
+	}
+	for _, opts := range [][]Option{nil, {WithJustification(1)}} {
+		want, got := eager.RenderString(text, opts...), lazy.RenderString(text, opts...)
+		if got != want {
+			t.Errorf("Lazy output differs:\n%s\nexpected:\n%s", got, want)
+		}
+	}
+	if _, ok := lazy.font.lazy.loaded['é']; !ok {
+		t.Error("Expected a rendered character to stay parsed")
+	}
+
+	warnings, err := lazy.LoadFontWithDiagnostics()
+	if err != nil {
+		t.Fatalf("LoadFontWithDiagnostics failed: %v", err)
+	}
+	if len(lazy.font.lazy.loaded) != len(lazy.font.lazy.offsets) {
+		t.Errorf("Expected every deferred character to be parsed, got %d of %d",
+			len(lazy.font.lazy.loaded), len(lazy.font.lazy.offsets))
+	}
+	eagerWarnings, _ := eager.LoadFontWithDiagnostics()
+	if len(warnings) != len(eagerWarnings) {
+		t.Errorf("Expected %d warnings, got %d", len(eagerWarnings), len(warnings))
+	}
+}
+
+// TestCompiledFont checks compiled fonts render like the original and are
+// only used while they are newer than it
+func TestCompiledFont(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("fonts", "standard.flf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "copy.flf")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	text := "Héllo ¿Ñ?"
+	eager := New()
+	if err := eager.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	eager.Multibyte = 2
+	want := eager.RenderString(text)
+
+	cfg := New()
+	cfg.Fontdirname = dir
+	cfg.Fontname = "copy"
+	sidecar, err := cfg.CompileFont()
+	if err != nil {
+		t.Fatalf("CompileFont failed: %v", err)
+	}
+	if sidecar != path+"c" {
+		t.Errorf("Expected the compiled font at %q, got %q", path+"c", sidecar)
+	}
+
+	// Break the original, so loading only works from the compiled font
+	old := time.Now().Add(-time.Hour)
+	if err := os.WriteFile(path, []byte("broken"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	compiled := New()
+	compiled.Fontdirname = dir
+	compiled.Fontname = "copy"
+	if err := compiled.LoadFont(); err != nil {
+		t.Fatalf("LoadFont with a compiled font failed: %v", err)
+	}
+	compiled.Multibyte = 2
+	if got := compiled.RenderString(text); got != want {
+		t.Errorf("Compiled font output differs:\n%s\nexpected:\n%s", got, want)
+	}
+
+	// A stale compiled font is ignored
+	if err := os.Chtimes(path, time.Now().Add(time.Hour), time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := compiled.LoadFont(); err == nil {
+		t.Error("Expected a stale compiled font to be ignored")
+	}
+
+	// Fonts loaded lazily compile to the same characters
+	lazy := New()
+	lazy.LazyGlyphs = true
+	if err := lazy.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := lazy.WriteCompiledFont(&buf); err != nil {
+		t.Fatalf("WriteCompiledFont failed: %v", err)
+	}
+	roundtrip := New()
+	if err := roundtrip.ReadCompiledFont(&buf); err != nil {
+		t.Fatalf("ReadCompiledFont failed: %v", err)
+	}
+	roundtrip.Multibyte = 2
+	if got := roundtrip.RenderString(text); got != want {
+		t.Errorf("Round trip output differs:\n%s\nexpected:\n%s", got, want)
+	}
+	if err := roundtrip.ReadCompiledFont(strings.NewReader("flf2a$ 1 1 1 0 0")); err == nil {
+		t.Error("Expected an error reading a font file as a compiled font")
+	}
+}
+
+func TestWriteGIF(t *testing.T) {
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	cfg.Colors = []Color{ColorRed, TrueColor{0, 128, 255}}
+	animator := NewAnimator(cfg)
+	frames, err := animator.GenerateStructured("Hi", "scroll", 30*time.Millisecond)
+	if err != nil {
+		t.Fatalf("GenerateStructured failed: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := animator.WriteGIF(&buf, frames); err != nil {
+		t.Fatalf("WriteGIF failed: %v", err)
+	}
+	anim, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatalf("decoding GIF failed: %v", err)
+	}
+	if len(anim.Image) != len(frames) {
+		t.Fatalf("got %d images, want %d", len(anim.Image), len(frames))
+	}
+	if anim.Delay[0] != 3 {
+		t.Errorf("delay = %d, want 3", anim.Delay[0])
+	}
+	bounds := anim.Image[0].Bounds()
+	for _, img := range anim.Image {
+		if img.Bounds() != bounds {
+			t.Fatalf("image bounds %v differ from %v", img.Bounds(), bounds)
+		}
+	}
+	last := anim.Image[len(anim.Image)-1]
+	inked := map[uint8]bool{}
+	for _, p := range last.Pix {
+		inked[p] = true
+	}
+	if !inked[0] || len(inked) < 3 {
+		t.Errorf("last frame uses palette indexes %v, want the background and both colors", inked)
+	}
+
+	// No frames still makes a valid image
+	buf.Reset()
+	if err := animator.WriteGIF(&buf, nil); err != nil {
+		t.Fatalf("WriteGIF with no frames failed: %v", err)
+	}
+	if _, err := gif.DecodeAll(&buf); err != nil {
+		t.Fatalf("decoding empty GIF failed: %v", err)
+	}
+}
+
+func TestFontBuilder(t *testing.T) {
+	b := NewFontBuilder(2)
+	b.Comment = "two rows\nmade in a test"
+	b.Layout = SM_SMUSH | SM_EQUAL
+	for _, g := range []struct {
+		r    rune
+		rows []string
+	}{
+		{'A', []string{"/\\", "||"}},
+		{'@', []string{"@@", "@@"}}, // needs another endmark
+		{'é', []string{"e'", "e "}},
+		{'€', []string{"=C", "=C"}},
+	} {
+		if err := b.Glyph(g.r, g.rows...); err != nil {
+			t.Fatalf("Glyph(%q) failed: %v", g.r, err)
+		}
+	}
+	if err := b.Glyph('x', "x"); err == nil {
+		t.Error("Glyph accepted too few rows")
+	}
+	if err := b.Glyph('x', "x", "xx"); err == nil {
+		t.Error("Glyph accepted rows of different widths")
+	}
+
+	var buf bytes.Buffer
+	if _, err := b.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	cfg := New()
+	cfg.Multibyte = 2
+	if err := cfg.LoadFontFrom(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("LoadFontFrom failed: %v", err)
+	}
+	if len(cfg.Warnings()) != 0 {
+		t.Errorf("Unexpected warnings %v", cfg.Warnings())
+	}
+	if cfg.Smushmode != SM_SMUSH|SM_EQUAL {
+		t.Errorf("Smush mode %d", cfg.Smushmode)
+	}
+	if got, want := cfg.RenderString("A@é€"), "/\\@@e'=C\n||@@e =C\n"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestBlockFonts(t *testing.T) {
+	for _, name := range []string{"block3x3", "block5x5"} {
+		found := false
+		for _, font := range ListFonts() {
+			found = found || font == name
+		}
+		if !found {
+			t.Errorf("ListFonts is missing %s", name)
+		}
+	}
+
+	cfg := New()
+	cfg.Fontname = "block2x3"
+	cfg.Multibyte = 2
+	warnings, err := cfg.LoadFontWithDiagnostics()
+	if err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("Unexpected warnings %v", warnings)
+	}
+	// Existing art is scaled up, spaces and all
+	got := cfg.RenderString("/\\\n$ o")
+	want := "//\\\\\n//\\\\\n//\\\\\n$$  oo\n$$  oo\n$$  oo\n"
+	if got != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, got)
+	}
+
+	b, err := BlockFont(1, 1, '#')
+	if err != nil {
+		t.Fatalf("BlockFont failed: %v", err)
+	}
+	var buf bytes.Buffer
+	b.WriteTo(&buf)
+	if err := cfg.LoadFontFrom(&buf); err != nil {
+		t.Fatalf("LoadFontFrom failed: %v", err)
+	}
+	if got := cfg.RenderString("Hi ü!"); got != "## ##\n" {
+		t.Errorf("Expected \"## ##\", got %q", got)
+	}
+	if _, err := BlockFont(0, 3, 0); err == nil {
+		t.Error("BlockFont accepted a zero width")
+	}
+}
+
+func TestGlyphOverride(t *testing.T) {
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	smiley := []string{"  ___  ", " / _ \\ ", "| o o |", "|  ^  |", " \\___/ ", "       "}
+	out, err := cfg.Render("o", WithGlyphOverride('o', smiley))
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if want := strings.Join(smiley, "\n") + "\n"; out != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, out)
+	}
+	if cfg.GlyphOverrides != nil || cfg.RenderString("o") == out {
+		t.Error("The override outlived the render")
+	}
+
+	// Other characters still come from the font and smush with the override
+	WithGlyphOverride('o', smiley)(cfg)
+	if out := cfg.RenderString("Go"); !strings.Contains(out, "| o o |") || !strings.Contains(out, "/ ___|") {
+		t.Errorf("Unexpected output\n%s", out)
+	}
+
+	var verr *ValidationError
+	if _, err := cfg.Render("o", WithGlyphOverride('o', smiley[:3])); !errors.As(err, &verr) || verr.Field != `GlyphOverrides['o']` {
+		t.Errorf("Expected a height error, got %v", err)
+	}
+	ragged := append([]string{"x"}, smiley[1:]...)
+	if _, err := cfg.Render("o", WithGlyphOverride('x', ragged)); err == nil {
+		t.Error("Rows of different widths accepted")
+	}
+
+	// The height is checked once the font is loaded, and Render reports it
+	if out, err := Render("o", WithGlyphOverride('o', smiley[:3])); !errors.As(err, &verr) || out != "" {
+		t.Errorf("Render: expected a height error, got %q, %v", out, err)
+	}
+}
+
+func TestRenderMasked(t *testing.T) {
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	// A white disc on black, with a transparent corner
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			c := color.NRGBA{0, 0, 0, 255}
+			if dx, dy := x-20, (y-10)*2; dx*dx+dy*dy < 18*18 {
+				c = color.NRGBA{255, 255, 255, 255}
+			}
+			if x < 2 && y < 2 {
+				c = color.NRGBA{255, 255, 255, 0}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	grid := maskgrid(img, MaskOptions{})
+	if len(grid) != 20 || len(grid[0]) != 40 || grid[0][0] || grid[0][20] || !grid[10][20] {
+		t.Fatalf("Unexpected grid %dx%d", len(grid[0]), len(grid))
+	}
+	if inverted := maskgrid(img, MaskOptions{Invert: true}); inverted[0][0] || !inverted[0][20] || inverted[10][20] {
+		t.Error("Inverted grid does not light the dark pixels only")
+	}
+	if scaled := maskgrid(img, MaskOptions{Width: 20}); len(scaled) != 5 || len(scaled[0]) != 20 {
+		t.Errorf("Scaled grid is %dx%d, want 20x5", len(scaled[0]), len(scaled))
+	}
+
+	out, err := cfg.RenderMasked("Go", img, MaskOptions{}, WithParser("terminal-color"), WithColors(ColorRed, ColorBlue))
+	if err != nil {
+		t.Fatalf("RenderMasked failed: %v", err)
+	}
+	plain, _ := cfg.RenderMasked("Go", img, MaskOptions{})
+	lines := strings.Split(strings.TrimSuffix(plain, "\n"), "\n")
+	if len(lines) != 20 {
+		t.Fatalf("%d lines, want 20", len(lines))
+	}
+	art := strings.Split(cfg.RenderString("Go"), "\n")
+	for y, line := range lines {
+		for x, c := range line {
+			if !grid[y][x] && c != ' ' {
+				t.Fatalf("Cell %d,%d outside the mask is %q", x, y, c)
+			}
+			tile := []rune(art[y%(len(art)-1)])
+			if col := x % (len(tile) + 1); grid[y][x] && col < len(tile) && c != tile[col] {
+				t.Fatalf("Cell %d,%d is %q, want %q", x, y, c, tile[col])
+			}
+		}
+	}
+	if !strings.Contains(out, "\x1b[0;31m") || !strings.Contains(out, "\x1b[0;34m") {
+		t.Error("Masked text lost its colors")
+	}
+
+	out, err = cfg.RenderImageCaption(img, "Hi", MaskOptions{Width: 20, Fill: '@', Gap: 1})
+	if err != nil {
+		t.Fatalf("RenderImageCaption failed: %v", err)
+	}
+	lines = strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	_, height := cfg.Measure("Hi")
+	if len(lines) != 5+1+height || strings.Trim(lines[2], " @") != "" || !strings.Contains(lines[2], "@@@@") || lines[5] != "" {
+		t.Errorf("Unexpected image with caption\n%s", out)
+	}
+}
+
+func TestRenderBadge(t *testing.T) {
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	width, height := cfg.Measure("ok")
+	var sb strings.Builder
+	if err := cfg.RenderBadge(&sb, "ok", BadgeOptions{Label: "a&b"}, FormatSVG); err != nil {
+		t.Fatalf("RenderBadge failed: %v", err)
+	}
+	svg := sb.String()
+	labelwidth, _ := cfg.Measure("a&b")
+	want := fmt.Sprintf(`width="%d" height="%d"`, (labelwidth+2+width+2)*badgelineheight/2, (height+1)*badgelineheight)
+	if !strings.Contains(svg, want) || !strings.Contains(svg, "<title>a&amp;b: ok</title>") || !strings.Contains(svg, `rx="3"`) {
+		t.Errorf("Unexpected badge, want %s\n%s", want, svg)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(cfg.RenderString("ok"), "\n"), "\n") {
+		var esc strings.Builder
+		xml.EscapeText(&esc, []byte(line))
+		if !strings.Contains(svg, ">"+esc.String()+"</text>") {
+			t.Errorf("Badge lacks row %q", line)
+		}
+	}
+
+	sb.Reset()
+	cfg.RenderBadge(&sb, "ok", BadgeOptions{Style: BadgeFlatSquare, Height: 20, MaxWidth: 10}, FormatSVG)
+	if !strings.Contains(sb.String(), `width="10" height="20"`) || strings.Contains(sb.String(), "linearGradient") {
+		t.Errorf("Badge does not fit 10x20\n%s", sb.String())
+	}
+
+	var buf bytes.Buffer
+	if err := cfg.RenderBadge(&buf, "ok", BadgeOptions{Height: 250}, FormatPNG); err != nil {
+		t.Fatalf("RenderBadge failed: %v", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Invalid PNG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != (width+2)*cellwidth*2 || b.Dy() != 250 {
+		t.Errorf("PNG badge is %dx%d", b.Dx(), b.Dy())
+	}
+	if _, _, _, a := img.At(0, 0).RGBA(); a != 0 {
+		t.Error("Round corner is not transparent")
+	}
+	if err := cfg.RenderBadge(&buf, "ok", BadgeOptions{}, FormatJSON); err == nil {
+		t.Error("Expected an error for a JSON badge")
+	}
+	for _, name := range ListBadgeStyles() {
+		if style, err := ParseBadgeStyle(name); err != nil || style.String() != name {
+			t.Errorf("Style %s does not round trip", name)
+		}
+	}
+}
+
+// TestDisplayWidth tests measuring colors, wide and zero-width characters
+func TestDisplayWidth(t *testing.T) {
+	for _, tc := range []struct {
+		s    string
+		want int
+	}{
+		{"abc", 3},
+		{"\x1b[31mred\x1b[0m", 3},
+		{"\x1b]8;;https://example.com\x1b\\link\x1b]8;;\x1b\\", 4},
+		{"日本", 4},
+		{"é", 1},
+		{"🚀!", 3},
+		{"a\tb", 2},
+	} {
+		if got := DisplayWidth(tc.s); got != tc.want {
+			t.Errorf("DisplayWidth(%q) = %d, want %d", tc.s, got, tc.want)
+		}
+	}
+
+	// A glyph drawn with wide characters is centered by its columns
+	cfg := New()
+	cfg.Fontname = "term"
+	WithGlyphOverride('x', []string{"日本"})(cfg)
+	cfg.Justification = 1
+	cfg.Outputwidth = 11
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	if got := cfg.RenderString("x"); got != "   日本\n" {
+		t.Errorf("Expected the glyph centered in 10 columns, got %q", got)
+	}
+	if width, _ := cfg.Measure("x"); width != 7 {
+		t.Errorf("Measure: expected 7 columns, got %d", width)
+	}
+}
+
+// TestDELHardblankJustification tests that fonts whose hardblank is DEL
+// are centered and right justified as wide as they are drawn
+func TestDELHardblankJustification(t *testing.T) {
+	for _, font := range []string{"bubble", "digital"} {
+		left, err := Render("a b", WithFont(font), WithWidth(41))
+		if err != nil {
+			t.Fatalf("Render %s failed: %v", font, err)
+		}
+		for just, pad := range map[int]func(int) int{
+			1: func(w int) int { return (41 - w) / 2 },
+			2: func(w int) int { return 40 - w },
+		} {
+			got, err := Render("a b", WithFont(font), WithWidth(41), WithJustification(just))
+			if err != nil {
+				t.Fatalf("Render %s failed: %v", font, err)
+			}
+			var want strings.Builder
+			for _, line := range strings.SplitAfter(left, "\n") {
+				if line != "" {
+					want.WriteString(strings.Repeat(" ", pad(len([]rune(strings.TrimSuffix(line, "\n"))))) + line)
+				}
+			}
+			if got != want.String() {
+				t.Errorf("%s justified %d:\n%s\nwant:\n%s", font, just, got, want.String())
+			}
+		}
+		cfg := New()
+		cfg.Fontname = font
+		if err := cfg.LoadFont(); err != nil {
+			t.Fatalf("LoadFont %s failed: %v", font, err)
+		}
+		want := len([]rune(strings.Split(left, "\n")[0]))
+		if width, _ := cfg.Measure("a b"); width != want {
+			t.Errorf("Measure %s: got %d columns, want %d", font, width, want)
+		}
+	}
+}
+
+// TestRenderTable tests that banners are aligned in bordered cells
+func TestRenderTable(t *testing.T) {
+	cfg := New()
+	cfg.Fontname = "mini"
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	out, err := cfg.RenderTable([][]string{{"Team", "Score"}, {"Go", "42"}, {"Rust"}}, TableOptions{Align: 2, Padding: 1})
+	if err != nil {
+		t.Fatalf("RenderTable failed: %v", err)
+	}
+	_, height := cfg.Measure("Go")
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != 3*height+4 {
+		t.Fatalf("Expected %d lines, got %d:\n%s", 3*height+4, len(lines), out)
+	}
+	width := utf8.RuneCountInString(lines[0])
+	for i, line := range lines {
+		if utf8.RuneCountInString(line) != width {
+			t.Errorf("Line %d is not %d wide: %q", i, width, line)
+		}
+		if i%(height+1) == 0 {
+			if strings.Trim(line, "+-") != "" || strings.Count(line, "+") != 3 {
+				t.Errorf("Line %d is not a rule: %q", i, line)
+			}
+		} else if line[0] != '|' || line[len(line)-1] != '|' {
+			t.Errorf("Line %d has no borders: %q", i, line)
+		}
+	}
+
+	// Right aligned, the narrow banner ends where the wide one does
+	score, _ := cfg.Measure("Score")
+	fortytwo, _ := cfg.Measure("42")
+	row := strings.Split(lines[height+2], "|")[2]
+	if !strings.HasPrefix(row, strings.Repeat(" ", 1+score-fortytwo)) {
+		t.Errorf("42 is not right aligned: %q", row)
+	}
+
+	if _, err := cfg.RenderTable([][]string{{"Go"}}, TableOptions{Padding: -1}); err == nil {
+		t.Error("Expected a negative padding to be refused")
+	}
+}
+
+// TestLayout tests arranging blocks in columns and wrapping them to rows
+func TestLayout(t *testing.T) {
+	blocks := []string{"aa\naa\n", "b\n", "ccc\nc\nc\n"}
+	got := Layout(blocks, LayoutOptions{Gutter: 2})
+	want := "aa  b  ccc\naa     c\n       c\n"
+	if got != want {
+		t.Errorf("One row:\n%q\nwant\n%q", got, want)
+	}
+
+	// Three columns need 10 cells, so two are used
+	got = Layout(blocks, LayoutOptions{Gutter: 2, RowGap: 1, Width: 9})
+	want = "aa   b\naa\n\nccc\nc\nc\n"
+	if got != want {
+		t.Errorf("Wrapped:\n%q\nwant\n%q", got, want)
+	}
+
+	// Negative spacing is none
+	got = Layout(blocks, LayoutOptions{Columns: 2, Gutter: -1, RowGap: -1})
+	want = "aa b\naa\nccc\nc\nc\n"
+	if got != want {
+		t.Errorf("Negative spacing:\n%q\nwant\n%q", got, want)
+	}
+
+	// Colored banners line up like the plain ones
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	texts := []string{"Wide", "B", "C"}
+	var plain []string
+	for _, text := range texts {
+		plain = append(plain, cfg.RenderString(text))
+	}
+	cfg.Colors = []Color{ColorRed, ColorBlue}
+	cfg.OutputParser, _ = GetParser("terminal-color")
+	out, err := cfg.RenderLayout(texts, LayoutOptions{Columns: 2, Gutter: 1})
+	if err != nil {
+		t.Fatalf("RenderLayout failed: %v", err)
+	}
+	if !strings.Contains(out, "\x1b[") {
+		t.Errorf("Expected colored output, got %q", out)
+	}
+	// The last column keeps the spaces padding its banner
+	stripped := regexp.MustCompile(" +\n").ReplaceAllString(regexp.MustCompile("\x1b\\[[0-9;]*m").ReplaceAllString(out, ""), "\n")
+	if want := Layout(plain, LayoutOptions{Columns: 2, Gutter: 1}); stripped != want {
+		t.Errorf("RenderLayout:\n%s\nwant\n%s", stripped, want)
+	}
+}
+
+func TestCompareFonts(t *testing.T) {
+	a, b := New(), New()
+	b.Fontname = "small"
+	for _, cfg := range []*Config{a, b} {
+		if err := cfg.LoadFont(); err != nil {
+			t.Fatalf("LoadFont failed: %v", err)
+		}
+	}
+	c, err := CompareFonts(a, b, "Hi\nHi")
+	if err != nil {
+		t.Fatalf("CompareFonts failed: %v", err)
+	}
+	aw, ah := a.Measure("Hi\nHi")
+	bw, bh := b.Measure("Hi\nHi")
+	if c.Fonts != [2]string{"standard", "small"} || c.Width != [2]int{aw, bw} || c.Height != [2]int{ah, bh} || len(c.Lines) != 2 {
+		t.Fatalf("Unexpected comparison %v %v %v %d", c.Fonts, c.Width, c.Height, len(c.Lines))
+	}
+	if len(c.Glyphs) == 0 || c.Glyphs[0].Char != 'H' {
+		t.Errorf("Unexpected glyph widths %v", c.Glyphs)
+	}
+
+	side := strings.Split(strings.TrimSuffix(c.SideBySide(), "\n"), "\n")
+	if len(side) != 1+max(ah, bh) || side[0] != fmt.Sprintf("%-*s | small", max(aw, 8), "standard") {
+		t.Fatalf("Unexpected side by side\n%s", c.SideBySide())
+	}
+	left := strings.Split(a.RenderString("Hi\nHi"), "\n")
+	for y, line := range side[1:] {
+		if y < ah && !strings.HasPrefix(line, fmt.Sprintf("%-*s |", aw, left[y])) {
+			t.Errorf("Row %d is %q", y, line)
+		}
+	}
+	if got := strings.Count(c.Interleaved(), "-- small "); got != 2 {
+		t.Errorf("Interleaved shows small %d times\n%s", got, c.Interleaved())
+	}
+	want := fmt.Sprintf("small     %d columns, %d rows (%+d columns, %+d rows)\n", bw, bh, bw-aw, bh-ah)
+	if !strings.Contains(c.Report(), want) {
+		t.Errorf("Report lacks %q\n%s", want, c.Report())
+	}
+}
+
+func TestFontCoverage(t *testing.T) {
+	// 'A' is drawn, the other required characters are empty, and code
+	// tags add 0, U+0416 and an empty U+0417
+	var glyphs []string
+	for i := 0; i < 102; i++ {
+		glyphs = append(glyphs, "@@")
+	}
+	glyphs[0], glyphs['A'-' '] = "$@@", "A@@"
+	glyphs = append(glyphs, "0", "?@@", "0x416", "Zh@@", "1047", "@@")
+	dir := writeTestFont(t, "cov", "flf2a$ 1 1 10 0 0", glyphs)
+	c, err := FontCoverage("cov", WithFontDir(dir))
+	if err != nil {
+		t.Fatalf("FontCoverage failed: %v", err)
+	}
+	if !c.Default || !c.Has('A') || !c.Has(0x416) || c.Has(0x417) || c.Has('B') {
+		t.Errorf("Unexpected coverage %q, default %v", string(c.Chars), c.Default)
+	}
+	if got := string(c.Missing("AЖЗ\nBB")); got != "ЗB" {
+		t.Errorf("Missing returned %q", got)
+	}
+
+	c, err = FontCoverage("standard")
+	if err != nil {
+		t.Fatalf("FontCoverage failed: %v", err)
+	}
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	for n := cfg.font.glyphs; n != nil; n = n.next {
+		width := 0
+		for _, row := range n.thechar {
+			width = max(width, len(row))
+		}
+		if n.ord > 0 && (width > 0) != c.Has(n.ord) {
+			t.Errorf("Coverage of %q is %v, glyph width %d", n.ord, c.Has(n.ord), width)
+		}
+	}
+	if r := c.Ranges(); len(r) == 0 || r[0].String() != "U+0020-U+007E" {
+		t.Errorf("Unexpected ranges %v", r)
+	}
+	if s := c.Scripts(); s["Latin"] == 0 || s["Common"] == 0 {
+		t.Errorf("Unexpected scripts %v", s)
+	}
+	if _, err := FontCoverage("nosuchfont"); err == nil {
+		t.Error("Expected an error for a missing font")
+	}
+}
+
+func TestFontFallback(t *testing.T) {
+	small, big := New(), New()
+	small.Fontname, big.Fontname = "small", "big"
+	for _, cfg := range []*Config{small, big} {
+		cfg.Multibyte = 2
+		if err := cfg.LoadFont(); err != nil {
+			t.Fatalf("LoadFont failed: %v", err)
+		}
+	}
+	above := max(small.baseline, big.baseline)
+	height := above + max(small.charheight-small.baseline, big.charheight-big.baseline)
+
+	for _, lazy := range []bool{false, true} {
+		cfg := New()
+		cfg.Multibyte, cfg.Fontname, cfg.LazyGlyphs = 2, "small", lazy
+		WithFontFallback("big")(cfg)
+		if err := cfg.LoadFont(); err != nil {
+			t.Fatalf("LoadFont failed: %v", err)
+		}
+		if cfg.charheight != height || cfg.baseline != above {
+			t.Fatalf("Height %d, baseline %d, want %d and %d", cfg.charheight, cfg.baseline, height, above)
+		}
+		lines := strings.Split(cfg.RenderString("Ω"), "\n")
+		glyph := strings.Split(big.RenderString("Ω"), "\n")
+		for row, want := range glyph[:big.charheight] {
+			if got := lines[above-big.baseline+row]; got != want {
+				t.Errorf("Lazy %v: row %d of the fallback glyph is %q, want %q", lazy, row, got, want)
+			}
+		}
+		lines = strings.Split(cfg.RenderString("Hi"), "\n")
+		plain := strings.Split(small.RenderString("Hi"), "\n")
+		for row, want := range plain[:small.charheight] {
+			if got := lines[above-small.baseline+row]; got != want {
+				t.Errorf("Lazy %v: row %d of the font glyph is %q, want %q", lazy, row, got, want)
+			}
+		}
+		// Kerned, not smushed, at the boundary between the fonts
+		width, _ := cfg.Measure("HΩ")
+		hw, _ := small.Measure("H")
+		ow, _ := big.Measure("Ω")
+		if width < hw+ow-1 {
+			t.Errorf("Lazy %v: HΩ is %d columns, glyphs are %d and %d", lazy, width, hw, ow)
+		}
+	}
+
+	cfg := New()
+	cfg.Multibyte = 2
+	WithFontFallback("nosuchfont")(cfg)
+	if err := cfg.LoadFont(); err == nil || !strings.Contains(err.Error(), "nosuchfont") {
+		t.Errorf("Expected an error for a missing fallback font, got %v", err)
+	}
+	cfg.FontFallback = []string{"small"}
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	standard := New()
+	standard.Multibyte = 2
+	if err := standard.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	if got, want := cfg.RenderString("ж"), standard.RenderString("ж"); got != want {
+		t.Errorf("Character missing from every font is drawn as\n%s", got)
+	}
+}
+
+func TestLineHook(t *testing.T) {
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	plain := cfg.RenderString("Hi")
+	var seen []int
+	out := cfg.RenderString("Hi", WithParser("terminal-color"), WithColors(ColorRed), WithLineHook(func(line int, cells []rune) []rune {
+		seen = append(seen, line)
+		for i, c := range cells {
+			if c == '_' {
+				cells[i] = '='
+			}
+		}
+		return append(cells, '#')
+	}))
+	if fmt.Sprint(seen) != "[0 1 2 3 4 5]" {
+		t.Errorf("Hook called for rows %v", seen)
+	}
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	for i, line := range strings.Split(strings.TrimSuffix(plain, "\n"), "\n") {
+		if !strings.HasSuffix(lines[i], "\x1b[0m#") {
+			t.Errorf("Added cell colored on row %d: %q", i, lines[i])
+		}
+		if strings.Contains(line, "_") && !strings.Contains(lines[i], "=") {
+			t.Errorf("Row %d not changed: %q", i, lines[i])
+		}
+	}
+	if cfg.LineHook != nil || cfg.RenderString("Hi") != plain {
+		t.Error("The hook outlived the render")
+	}
+
+	// A hook can also shorten rows
+	out = cfg.RenderString("Hi", WithLineHook(func(line int, cells []rune) []rune {
+		return cells[:min(2, len(cells))]
+	}))
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		if len(line) > 2 {
+			t.Errorf("Row not shortened: %q", line)
+		}
+	}
+}
+
+func TestSpeechBubble(t *testing.T) {
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	art := strings.Split(strings.TrimSuffix(cfg.RenderString("Hi"), "\n"), "\n")
+	width := 0
+	for _, line := range art {
+		width = max(width, len([]rune(line)))
+	}
+
+	out := cfg.RenderString("Hi", WithSpeechBubble(BubbleSay), WithBubbleText("moo"))
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	want := []string{" " + strings.Repeat("_", width+2)}
+	for i, line := range art {
+		left, right := "|", "|"
+		if i == 0 {
+			left, right = "/", "\\"
+		}
+		want = append(want, left+" "+line+strings.Repeat(" ", width-len([]rune(line)))+" "+right)
+	}
+	want = append(want, "\\ moo"+strings.Repeat(" ", width-3)+" /", " "+strings.Repeat("-", width+2), "    \\", "     \\")
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("bubble =\n%s\nwant\n%s", out, strings.Join(want, "\n"))
+	}
+	if w, h := cfg.Measure("Hi"); w != width || h != len(art) {
+		t.Errorf("options leaked: Measure = %dx%d", w, h)
+	}
+
+	// A single row uses < >, and a negative tail draws none
+	single := cfg.RenderString("", WithSpeechBubble(BubbleSay), WithBubbleText("hello"), WithBubbleTail(-1))
+	if single != " _______\n< hello >\n -------\n" {
+		t.Errorf("single row bubble =\n%q", single)
+	}
+
+	// Text wraps to the width of the art
+	wrapped := cfg.RenderString("Hi", WithSpeechBubble(BubbleThink), WithBubbleText("the quick brown fox jumps over the lazy dog"))
+	for _, line := range strings.Split(strings.TrimSuffix(wrapped, "\n"), "\n") {
+		if n := len([]rune(line)); n > width+4 {
+			t.Errorf("line %q is %d wide, bubble is %d", line, n, width+4)
+		}
+	}
+	if !strings.Contains(wrapped, "( the quick") || !strings.Contains(wrapped, "    o\n     o\n") {
+		t.Errorf("think bubble =\n%s", wrapped)
+	}
+
+	// Art keeps its colors, the bubble and the text get none
+	colored := cfg.RenderString("Hi", WithParser("terminal-color"), WithColors(ColorRed),
+		WithSpeechBubble(BubbleRound), WithBubbleText("plain"))
+	for _, line := range strings.Split(colored, "\n") {
+		if strings.HasPrefix(line, "\x1b") || strings.Contains(line, "plain\x1b") {
+			t.Fatalf("bubble cell colored: %q", line)
+		}
+	}
+	if !strings.Contains(colored, "\x1b[0;31m") || !strings.HasPrefix(colored, "╭─") {
+		t.Errorf("round bubble =\n%s", colored)
+	}
+
+	_, meta, err := cfg.RenderGrid("Hi", WithSpeechBubble(BubbleRound))
+	if err != nil {
+		t.Fatalf("RenderGrid failed: %v", err)
+	}
+	if meta.Index[0][0] != -1 || meta.Width != width+4 {
+		t.Errorf("grid index %d, width %d", meta.Index[0][0], meta.Width)
+	}
+
+	if _, err := ParseBubbleStyle("shout"); err == nil {
+		t.Error("ParseBubbleStyle accepted an unknown style")
+	}
+	if s, _ := ParseBubbleStyle("Think"); s != BubbleThink || s.String() != "think" {
+		t.Errorf("ParseBubbleStyle(Think) = %v", s)
+	}
+}
+
+// TestBorder tests the boxes drawn around the output
+func TestBorder(t *testing.T) {
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	art := strings.Split(strings.TrimSuffix(cfg.RenderString("Hi"), "\n"), "\n")
+	width := 0
+	for _, line := range art {
+		width = max(width, len([]rune(line)))
+	}
+
+	out := cfg.RenderString("Hi", WithBorder(BorderASCII))
+	want := []string{"+" + strings.Repeat("-", width+2) + "+"}
+	for _, line := range art {
+		want = append(want, "| "+line+strings.Repeat(" ", width-len([]rune(line)))+" |")
+	}
+	want = append(want, want[0])
+	if got := strings.TrimSuffix(out, "\n"); got != strings.Join(want, "\n") {
+		t.Fatalf("border =\n%s\nwant\n%s", got, strings.Join(want, "\n"))
+	}
+
+	// The box stays where justification put the art
+	centered := cfg.RenderString("Hi", WithBorder(BorderRound), WithJustification(1), WithWidth(40))
+	lines := strings.Split(strings.TrimSuffix(centered, "\n"), "\n")
+	left := strings.Index(lines[0], "╭")
+	if left <= 0 || !strings.HasPrefix(lines[len(lines)-1], strings.Repeat(" ", left)+"╰") {
+		t.Errorf("centered border =\n%s", centered)
+	}
+
+	// Art keeps its colors, the box gets none
+	colored := cfg.RenderString("Hi", WithColors(ColorRed), WithBorder(BorderDouble))
+	for _, line := range strings.Split(strings.TrimSuffix(colored, "\n"), "\n") {
+		if strings.HasPrefix(line, "\x1b") || strings.HasSuffix(line, "║\x1b[0m") {
+			t.Fatalf("border cell colored: %q", line)
+		}
+	}
+
+	if _, err := ParseBorderStyle("dotted"); err == nil {
+		t.Error("ParseBorderStyle accepted an unknown style")
+	}
+	if s, _ := ParseBorderStyle("Heavy"); s != BorderHeavy || s.String() != "heavy" {
+		t.Errorf("ParseBorderStyle(Heavy) = %v", s)
+	}
+}
+
+// TestPresets tests the built-in and registered presets
+func TestPresets(t *testing.T) {
+	for _, name := range []string{"header", "warning", "success", "retro"} {
+		out, err := Render("Hi", WithPreset(name))
+		if err != nil || out == "" {
+			t.Errorf("preset %s: %v", name, err)
+		}
+	}
+	if _, err := GetPreset("nope"); err == nil {
+		t.Error("Expected an error for an unknown preset")
+	}
+	var verr *ValidationError
+	if _, err := Render("Hi", WithPreset("nope")); !errors.As(err, &verr) || verr.Field != "Preset" {
+		t.Errorf("Expected Render to fail for an unknown preset, got %v", err)
+	}
+
+	cfg := New()
+	WithPreset("warning")(cfg)
+	if cfg.Border != BorderHeavy || cfg.Style != StyleBold || cfg.Colors[0] != ColorYellow ||
+		cfg.OutputParser.Name != "terminal-color" || cfg.Trim&TrimBlankRows == 0 {
+		t.Errorf("warning preset gave %+v", cfg.Settings())
+	}
+
+	if err := RegisterPreset("bad", Preset{Theme: "nope"}); err == nil {
+		t.Error("Expected an error for an unknown theme")
+	}
+	if err := RegisterPreset("", Preset{}); err == nil {
+		t.Error("Expected an error for an empty name")
+	}
+	if err := RegisterPreset("Deploy", Preset{Font: "small", Theme: "ocean", Justification: JustifyAuto}); err != nil {
+		t.Fatalf("RegisterPreset failed: %v", err)
+	}
+	got, err := Render("Hi", WithPreset("deploy"))
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	ocean, _ := Theme("ocean")
+	want, _ := Render("Hi", WithFont("small"), WithColors(ocean...))
+	if got != want {
+		t.Errorf("deploy preset =\n%s\nwant\n%s", got, want)
+	}
+	// Options after the preset override it
+	if out, _ := Render("Hi", WithPreset("deploy"), WithParser("terminal")); strings.Contains(out, "\x1b") {
+		t.Errorf("Expected no colors, got %q", out)
+	}
+}
+
+// decodeQR reads a QR code made by NewQRCode back, checking its format
+// information and the Reed-Solomon syndromes of every block
+func decodeQR(t *testing.T, code *QRCode) string {
+	t.Helper()
+	size := len(code.Modules)
+	version := (size - 17) / 4
+	m := code.Modules
+
+	format := 0
+	for i := 14; i >= 0; i-- {
+		var bit bool
+		switch {
+		case i <= 5:
+			bit = m[i][8]
+		case i == 6:
+			bit = m[7][8]
+		case i == 7:
+			bit = m[8][8]
+		case i == 8:
+			bit = m[8][7]
+		default:
+			bit = m[8][14-i]
+		}
+		format <<= 1
+		if bit {
+			format |= 1
+		}
+		// The second copy must match
+		var copy2 bool
+		if i < 8 {
+			copy2 = m[8][size-1-i]
+		} else {
+			copy2 = m[size-15+i][8]
+		}
+		if copy2 != bit {
+			t.Fatalf("format bit %d copies differ", i)
+		}
+	}
+	format ^= 0x5412
+	mask, levelbits := format>>10&7, format>>13
+	if levelbits != qrlevels[code.Level].format {
+		t.Fatalf("format level %d, want %d", levelbits, qrlevels[code.Level].format)
+	}
+
+	q := newqrsymbol(version, levelbits)
+	q.modules = make([][]bool, size)
+	for y := range m {
+		q.modules[y] = append([]bool(nil), m[y]...)
+	}
+	q.applymask(mask)
+	var raw []byte
+	var cur byte
+	n := 0
+	for right := size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = size - 1 - vert
+				}
+				if q.function[y][x] {
+					continue
+				}
+				cur <<= 1
+				if q.modules[y][x] {
+					cur |= 1
+				}
+				if n++; n%8 == 0 {
+					raw = append(raw, cur)
+				}
+			}
+		}
+	}
+
+	table := qrlevels[code.Level].table
+	nblocks, ecclen := qrblocks[table][version], qreccperblock[table][version]
+	total := qrrawmodules(version) / 8
+	raw = raw[:total]
+	nshort, shortlen := nblocks-total%nblocks, total/nblocks
+	blocks := make([][]byte, nblocks)
+	k := 0
+	for i := 0; i <= shortlen; i++ {
+		for j := range blocks {
+			if i == shortlen-ecclen && j < nshort {
+				continue
+			}
+			blocks[j] = append(blocks[j], raw[k])
+			k++
+		}
+	}
+	var data []byte
+	for j, block := range blocks {
+		alpha := byte(1)
+		for e := 0; e < ecclen; e++ {
+			s := byte(0)
+			for _, b := range block {
+				s = qrmultiply(s, alpha) ^ b
+			}
+			if s != 0 {
+				t.Fatalf("block %d: syndrome %d is %d", j, e, s)
+			}
+			alpha = qrmultiply(alpha, 2)
+		}
+		data = append(data, block[:len(block)-ecclen]...)
+	}
+
+	var bits qrbits
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	read := func(n int) int {
+		v := 0
+		for _, bit := range bits[:n] {
+			v <<= 1
+			if bit {
+				v |= 1
+			}
+		}
+		bits = bits[n:]
+		return v
+	}
+	if mode := read(4); mode != 4 {
+		t.Fatalf("mode %d, want byte mode", mode)
+	}
+	count := read(8)
+	if version >= 10 {
+		count = count<<8 | read(8)
+	}
+	text := make([]byte, count)
+	for i := range text {
+		text[i] = byte(read(8))
+	}
+	return string(text)
+}
+
+func TestQRCode(t *testing.T) {
+	// Reed-Solomon codewords of the 1-M "HELLO WORLD" example of the standard
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := qrremainder(data, qrdivisor(10)); !bytes.Equal(got, want) {
+		t.Errorf("error correction = %v, want %v", got, want)
+	}
+	if got := qralignment(32); fmt.Sprint(got) != "[6 34 60 86 112 138]" {
+		t.Errorf("version 32 alignment = %v", got)
+	}
+
+	for _, c := range []struct {
+		text    string
+		level   QRLevel
+		version int
+	}{
+		{"hello", QRLow, 1},
+		{"https://github.com/lsferreira42/figlet-go", QRMedium, 3},
+		{"https://github.com/lsferreira42/figlet-go", QRHigh, 5},
+		{strings.Repeat("figlet ", 30), QRQuartile, 13},
+		{strings.Repeat("€", 200), QRMedium, 0},
+		{strings.Repeat("x", 2953), QRLow, 40},
+	} {
+		code, err := NewQRCode(c.text, c.level)
+		if err != nil {
+			t.Fatalf("NewQRCode(%d bytes) failed: %v", len(c.text), err)
+		}
+		if c.version != 0 && code.Version != c.version {
+			t.Errorf("%d bytes at level %d: version %d, want %d", len(c.text), c.level, code.Version, c.version)
+		}
+		if size := len(code.Modules); size != code.Version*4+17 {
+			t.Errorf("size %d for version %d", size, code.Version)
+		}
+		if got := decodeQR(t, code); got != c.text {
+			t.Errorf("decoded %q, want %q", got, c.text)
+		}
+	}
+	if _, err := NewQRCode(strings.Repeat("x", 2954), QRLow); err == nil {
+		t.Error("NewQRCode accepted more than a version 40 code holds")
+	}
+
+	code, _ := NewQRCode("hi", QRMedium)
+	size := len(code.Modules) + 8
+	if lines := code.Lines(QRHalfBlocks, false); len(lines) != (size+1)/2 || len([]rune(lines[0])) != size {
+		t.Errorf("half block lines: %d of %d", len(lines), len([]rune(lines[0])))
+	}
+	normal, inverted := code.Lines(QRASCII, false), code.Lines(QRASCII, true)
+	if len(normal) != size || strings.TrimSpace(normal[0]) != "" || strings.Count(inverted[0], "#") != size*2 {
+		t.Errorf("ASCII lines:\n%s\n%s", normal[0], inverted[0])
+	}
+}
+
+func TestRenderWithQR(t *testing.T) {
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	code, _ := NewQRCode("https://example.com", QRMedium)
+	qrlines := code.Lines(QRHalfBlocks, false)
+	width := len([]rune(qrlines[0]))
+
+	out, err := cfg.RenderWithQR("Hi", "https://example.com", QROptions{Gap: 1},
+		WithParser("terminal-color"), WithColors(ColorRed), WithJustification(1))
+	if err != nil {
+		t.Fatalf("RenderWithQR failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	titlewidth, height := cfg.Measure("Hi")
+	if len(lines) != height+1+len(qrlines) {
+		t.Fatalf("%d lines, want %d", len(lines), height+1+len(qrlines))
+	}
+	if !strings.Contains(lines[0], "\x1b[") {
+		t.Errorf("title line not colored: %q", lines[0])
+	}
+	for i, line := range lines[height+1:] {
+		if strings.Contains(line, "\x1b") {
+			t.Fatalf("QR line %d is colored", i)
+		}
+		if want := strings.Repeat(" ", max(0, (titlewidth-width)/2)) + qrlines[i]; line != want {
+			t.Fatalf("QR line %d = %q, want %q", i, line, want)
+		}
+	}
+
+	out, err = cfg.RenderWithQR("Hi", "https://example.com", QROptions{Placement: QRRight, Gap: 2, Style: QRASCII})
+	if err != nil {
+		t.Fatalf("RenderWithQR failed: %v", err)
+	}
+	lines = strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	ascii := code.Lines(QRASCII, false)
+	if len(lines) != len(ascii) {
+		t.Fatalf("%d lines, want %d", len(lines), len(ascii))
+	}
+	for i, line := range lines {
+		if !strings.HasSuffix(line, strings.Repeat(" ", 2)+ascii[i]) || len([]rune(line)) != titlewidth+2+len(ascii[i]) {
+			t.Fatalf("line %d = %q", i, line)
+		}
+	}
+
+	if _, err := cfg.RenderWithQR("Hi", strings.Repeat("x", 3000), QROptions{}); err == nil {
+		t.Error("RenderWithQR accepted data too long for a QR code")
+	}
+}
+
+func TestFitRender(t *testing.T) {
+	widths := make(map[string]int)
+	for _, name := range FitFonts {
+		cfg := New()
+		cfg.Fontname = name
+		if err := cfg.LoadFont(); err != nil {
+			t.Fatalf("LoadFont failed: %v", err)
+		}
+		widths[name], _ = cfg.Measure("Hello")
+	}
+	for i := 1; i < len(FitFonts); i++ {
+		if widths[FitFonts[i]] >= widths[FitFonts[i-1]] {
+			t.Fatalf("%s is not narrower than %s", FitFonts[i], FitFonts[i-1])
+		}
+	}
+
+	for _, tc := range []struct {
+		width int
+		want  string
+	}{
+		{widths["big"], "big"},
+		{widths["big"] - 1, "standard"},
+		{widths["mini"], "mini"},
+		{1, "term"},
+	} {
+		cfg := New()
+		if err := cfg.LoadFittingFont("Hello", tc.width, nil); err != nil {
+			t.Fatalf("LoadFittingFont failed: %v", err)
+		}
+		if cfg.Fontname != tc.want {
+			t.Errorf("Width %d: loaded %s, want %s", tc.width, cfg.Fontname, tc.want)
+		}
+	}
+
+	out, err := FitRender("Hello", widths["small"], []string{"big", "small"})
+	if err != nil {
+		t.Fatalf("FitRender failed: %v", err)
+	}
+	small := New()
+	small.Fontname = "small"
+	if err := small.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	if want := small.RenderString("Hello"); out != want {
+		t.Errorf("FitRender gave\n%s\nwant\n%s", out, want)
+	}
+	if _, err := FitRender("Hello", 0, nil); err == nil {
+		t.Error("FitRender accepted a width of 0")
+	}
+	if _, err := FitRender("Hello", 80, []string{"nosuchfont"}); err == nil {
+		t.Error("FitRender accepted a missing font")
+	}
+}
+
+// TestFitRenderAll tests that every title gets the font the widest one fits
+func TestFitRenderAll(t *testing.T) {
+	titles := []string{"Fixes", "Features"}
+	cfg := New()
+	cfg.Fontname = "big"
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	wide, _ := cfg.Measure(titles[1])
+	narrow, _ := cfg.Measure(titles[0])
+
+	if narrow > wide-1 {
+		t.Fatalf("%q should fit in big alone", titles[0])
+	}
+
+	banners, err := FitRenderAll(titles, wide-1, []string{"big", "small"})
+	if err != nil {
+		t.Fatalf("FitRenderAll failed: %v", err)
+	}
+	if len(banners) != len(titles) {
+		t.Fatalf("Expected %d banners, got %d", len(titles), len(banners))
+	}
+	for _, title := range titles {
+		want, err := Render(title, WithFont("small"), WithWidth(wide))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if banners[title] != want {
+			t.Errorf("%q is not rendered in small:\n%s", title, banners[title])
+		}
+	}
+}
+
+func TestScale(t *testing.T) {
+	grid := [][]rune{[]rune("ab"), []rune("c")}
+	got := Scale(grid, 2, 2)
+	want := []string{"aabb", "aabb", "cc", "cc"}
+	if len(got) != len(want) {
+		t.Fatalf("Scale(2, 2) gave %d rows, want %d", len(got), len(want))
+	}
+	for y, row := range got {
+		if string(row) != want[y] {
+			t.Errorf("Scale(2, 2) row %d is %q, want %q", y, string(row), want[y])
+		}
+	}
+	// Shrinking keeps the first drawn cell of those covered
+	got = Scale([][]rune{[]rune(" |  "), []rune("  _ ")}, 0.5, 0.5)
+	if len(got) != 1 || string(got[0]) != "|_" {
+		t.Errorf("Scale(0.5, 0.5) gave %q, want [\"|_\"]", got)
+	}
+
+	cfg := New()
+	cfg.Fontname = "big"
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	_, full, err := cfg.RenderGrid("Hello")
+	if err != nil {
+		t.Fatalf("RenderGrid failed: %v", err)
+	}
+	_, half, err := cfg.RenderGrid("Hello", WithScale(0.5))
+	if err != nil {
+		t.Fatalf("RenderGrid failed: %v", err)
+	}
+	if half.Width != (full.Width+1)/2 || half.Height != full.Height/2 || half.CharHeight != cfg.charheight/2 {
+		t.Errorf("Scaled grid is %dx%d with lines of %d rows, want %dx%d with lines of %d", half.Width, half.Height,
+			half.CharHeight, (full.Width+1)/2, full.Height/2, cfg.charheight/2)
+	}
+	if len(half.Baselines) != 1 {
+		t.Errorf("Scaled grid has baselines %v, want one", half.Baselines)
+	}
+	// Lines wrap at the width the scaled output fits in
+	width := full.Width/2 + 2
+	if _, meta, _ := cfg.RenderGrid("Hello", WithScale(0.5), WithWidth(width)); meta.Height != half.Height {
+		t.Errorf("Scaled banner wrapped at width %d: %d rows, want %d", width, meta.Height, half.Height)
+	}
+	if _, err := cfg.Render("Hello", WithScale(-1)); err == nil {
+		t.Error("Render accepted a negative scale")
+	}
+	if out, _ := cfg.Render("Hello", WithScale(1)); out != cfg.RenderString("Hello") {
+		t.Error("A scale of 1 changed the output")
+	}
+}
+
+func TestTransform(t *testing.T) {
+	grid := [][]rune{[]rune("xy/"), []rune("c_")}
+	for _, tc := range []struct {
+		t    Transform
+		want []string
+	}{
+		{Rotate90, []string{"cx", "|y", " \\"}},
+		{Rotate180, []string{" ‾c", "/yx"}},
+		{MirrorH, []string{"\\yx", " _c"}},
+		{MirrorV, []string{"c‾ ", "xy\\"}},
+	} {
+		got := tc.t.Apply(grid)
+		if len(got) != len(tc.want) {
+			t.Fatalf("%s gave %d rows, want %d", tc.t, len(got), len(tc.want))
+		}
+		for y, row := range got {
+			if string(row) != tc.want[y] {
+				t.Errorf("%s row %d is %q, want %q", tc.t, y, string(row), tc.want[y])
+			}
+		}
+		if parsed, err := ParseTransform(tc.t.String()); err != nil || parsed != tc.t {
+			t.Errorf("ParseTransform(%q) = %v, %v", tc.t.String(), parsed, err)
+		}
+	}
+	if _, err := ParseTransform("sideways"); err == nil {
+		t.Error("ParseTransform accepted an unknown name")
+	}
+
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	plain, _, _ := cfg.RenderGrid("Hi", WithPadding(PadBlock))
+	flipped, meta, err := cfg.RenderGrid("Hi", WithPadding(PadBlock), WithTransform(MirrorH, MirrorH))
+	if err != nil {
+		t.Fatalf("RenderGrid failed: %v", err)
+	}
+	for y := range plain {
+		if string(flipped[y]) != string(plain[y]) {
+			t.Errorf("Mirroring twice changed row %d: %q, want %q", y, string(flipped[y]), string(plain[y]))
+		}
+	}
+	if len(meta.Baselines) != 1 {
+		t.Errorf("Mirrored grid has baselines %v, want one", meta.Baselines)
+	}
+	rotated, meta, _ := cfg.RenderGrid("Hi", WithTransform(Rotate90))
+	if len(rotated) != blockwidth(cfg.layoutrows(cfg.compose("Hi"))) || len(meta.Baselines) != 0 {
+		t.Errorf("Rotated grid has %d rows and baselines %v", len(rotated), meta.Baselines)
+	}
+	if _, err := cfg.Render("Hi", WithTransform(Transform(9))); err == nil {
+		t.Error("Render accepted an unknown transform")
+	}
+}
+
+func TestFontSources(t *testing.T) {
+	data, err := embeddedFonts.ReadFile("fonts/small.flf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	outside := t.TempDir()
+	dir := filepath.Join(outside, "sandbox")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{filepath.Join(outside, "secret.flf"), filepath.Join(dir, "mine.flf")} {
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := os.Symlink(filepath.Join(outside, "secret.flf"), filepath.Join(dir, "escape.flf")) == nil
+
+	load := func(sources FontSource, name string) error {
+		cfg := New()
+		cfg.Fontdirname, cfg.Fontname = dir, name
+		cfg.AllowFilesystemPaths = true
+		WithFontSources(sources)(cfg)
+		return cfg.LoadFont()
+	}
+	for _, tc := range []struct {
+		sources FontSource
+		name    string
+		ok      bool
+	}{
+		{0, filepath.Join(outside, "secret"), true},
+		{FontSourceDir, "mine", true},
+		{FontSourceDir, "standard", false},
+		{FontSourceDir, "../secret", false},
+		{FontSourceDir, filepath.Join(outside, "secret"), false},
+		{FontSourceDir, "escape", false},
+		{FontSourceEmbedded, "standard", true},
+		{FontSourceEmbedded, "mine", false},
+		{FontSourceEmbedded | FontSourceDir, "mine", true},
+		{FontSourceEmbedded, "block3x5", true},
+	} {
+		if tc.name == "escape" && !links {
+			continue
+		}
+		if err := load(tc.sources, tc.name); (err == nil) != tc.ok {
+			t.Errorf("Loading %s from %v: error %v, want ok %v", tc.name, tc.sources, err, tc.ok)
+		}
+	}
+
+	sources, err := ParseFontSources("embedded, dir")
+	if err != nil || sources != FontSourceEmbedded|FontSourceDir || sources.String() != "embedded,dir" {
+		t.Errorf("ParseFontSources = %v, %v", sources, err)
+	}
+	if _, err := ParseFontSources("web"); err == nil {
+		t.Error("ParseFontSources accepted an unknown source")
+	}
+	if err := load(FontSource(64), "standard"); err == nil {
+		t.Error("LoadFont accepted an unknown font source")
+	}
+}
+
+func TestFontNameValidation(t *testing.T) {
+	dir := writeTestFont(t, "tiny", "flf2a$ 1 1 2 0 0", []string{"@@"})
+	abs := filepath.Join(dir, "tiny")
+	for _, name := range []string{abs, "../tiny", "fonts/../../tiny", `..\tiny`, "tiny\x00", ""} {
+		cfg := New()
+		cfg.Fontname = name
+		err := cfg.LoadFont()
+		var nameerr *FontNameError
+		if !errors.As(err, &nameerr) || !errors.Is(err, fs.ErrPermission) {
+			t.Errorf("Font %q: error %v, want a FontNameError", name, err)
+		}
+	}
+	control := New()
+	control.AddControlFile("../upper")
+	if err := control.LoadFont(); !errors.As(err, new(*FontNameError)) {
+		t.Errorf("Control file ../upper: error %v, want a FontNameError", err)
+	}
+
+	// Other names are looked up as before
+	for _, name := range []string{"a..b", "fonts/standard"} {
+		cfg := New()
+		cfg.Fontname = name
+		if err := cfg.LoadFont(); errors.As(err, new(*FontNameError)) {
+			t.Errorf("Font %q refused: %v", name, err)
+		}
+	}
+	cfg := New()
+	WithFilesystemPaths()(cfg)
+	cfg.Fontname = abs
+	if err := cfg.LoadFont(); err != nil {
+		t.Errorf("LoadFont with filesystem paths allowed failed: %v", err)
+	}
+}
+
+func TestLoadedFont(t *testing.T) {
+	font, err := LoadFontByName("standard", FontSourceEmbedded)
+	if err != nil {
+		t.Fatalf("LoadFontByName: %v", err)
+	}
+	if font.Name() != "standard" || font.Height() != 6 || font.Baseline() != 5 {
+		t.Errorf("Unexpected font %s, height %d, baseline %d", font.Name(), font.Height(), font.Baseline())
+	}
+	want, err := Render("Shared", WithFont("standard"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	results := make([]string, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = Render("Shared", WithLoadedFont(font))
+		}(i)
+	}
+	wg.Wait()
+	for i, got := range results {
+		if got != want {
+			t.Errorf("Render %d with the loaded font:\n%s\nwant:\n%s", i, got, want)
+		}
+	}
+
+	// Fallback fonts pad a copy, leaving the shared font as it was
+	cfg := New()
+	WithLoadedFont(font)(cfg)
+	WithFontFallback("big")(cfg)
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Font() == font || font.Height() != 6 {
+		t.Error("Expected fallback fonts to pad a copy of the loaded font")
+	}
+
+	if _, err := LoadFontByName("nosuchfont"); err == nil {
+		t.Error("Expected LoadFontByName to fail for a missing font")
+	}
+}
+
+// recordMetrics keeps what a Metrics is told
+type recordMetrics struct {
+	mu      sync.Mutex
+	renders []string
+	loads   []string
+	chars   int
+}
+
+func (m *recordMetrics) ObserveRender(d time.Duration, chars int, font string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.renders = append(m.renders, font)
+	m.chars += chars
+}
+
+func (m *recordMetrics) ObserveFontLoad(d time.Duration, font string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		font += " failed"
+	}
+	m.loads = append(m.loads, font)
+}
+
+func TestMetrics(t *testing.T) {
+	m := &recordMetrics{}
+	cfg := New()
+	WithMetrics(m)(cfg)
+	WithFont("mini")(cfg)
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatal(err)
+	}
+	cfg.RenderString("Hello")
+	if err := cfg.RenderTo(&bytes.Buffer{}, "Hi", FormatSVG, WithWidth(120)); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(m.renders, ","); got != "mini,mini" || m.chars != 7 {
+		t.Errorf("Renders %q of %d characters, want mini,mini of 7", got, m.chars)
+	}
+
+	cfg.Fontname = "nosuchfont"
+	cfg.LoadFont()
+	if got := strings.Join(m.loads, ","); got != "mini,nosuchfont failed" {
+		t.Errorf("Font loads %q", got)
+	}
+
+	mgr := NewFontManager("", FontSourceEmbedded)
+	mgr.Metrics = m
+	mgr.Font("small")
+	mgr.Font("small")
+	if got := m.loads[len(m.loads)-1]; got != "small" || len(m.loads) != 3 {
+		t.Errorf("Font manager loads %q, want small once", m.loads)
+	}
+}
+
+// recordTracer keeps the names of the spans started and ended
+type recordTracer struct {
+	spans []string
+}
+
+func (r *recordTracer) StartSpan(name string) func(error) {
+	r.spans = append(r.spans, "+"+name)
+	return func(err error) {
+		if err != nil {
+			name += " failed"
+		}
+		r.spans = append(r.spans, "-"+name)
+	}
+}
+
+func TestTracer(t *testing.T) {
+	tracer := &recordTracer{}
+	cfg := New()
+	WithTracer(tracer)(cfg)
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatal(err)
+	}
+	cfg.RenderString("Hi")
+	if _, err := cfg.Render("Hi", WithJustification(7)); err == nil {
+		t.Fatal("Expected an invalid justification to fail")
+	}
+	want := "+figlet.LoadFont -figlet.LoadFont " +
+		"+figlet.Render +figlet.compose -figlet.compose +figlet.layout -figlet.layout +figlet.emit -figlet.emit -figlet.Render " +
+		"+figlet.Render -figlet.Render failed"
+	if got := strings.Join(tracer.spans, " "); got != want {
+		t.Errorf("Spans:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestJustifyFull(t *testing.T) {
+	text := "the quick brown fox jumps over the lazy dog"
+	left, err := Render(text, WithFont("small"), WithWidth(60), WithJustification(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	full, err := Render(text, WithFont("small"), WithWidth(60), WithJustification(JustifyFull))
+	if err != nil {
+		t.Fatal(err)
+	}
+	leftlines := strings.Split(strings.TrimSuffix(left, "\n"), "\n")
+	lines := strings.Split(strings.TrimSuffix(full, "\n"), "\n")
+	if len(lines) != len(leftlines) || len(lines)%5 != 0 {
+		t.Fatalf("Full justification changed the line breaks:\n%s", full)
+	}
+	for i, line := range lines {
+		last := i >= len(lines)-5
+		switch {
+		case last && line != leftlines[i]:
+			t.Errorf("Last line %d = %q, want it flush-left as %q", i, line, leftlines[i])
+		case !last && utf8.RuneCountInString(line) != 59:
+			t.Errorf("Wrapped line %d is %d wide, want 59: %q", i, utf8.RuneCountInString(line), line)
+		}
+	}
+	// Every row of a line gets the same gaps, so the glyphs stay whole
+	if strings.ReplaceAll(lines[0], " ", "") != strings.ReplaceAll(leftlines[0], " ", "") {
+		t.Errorf("Full justification changed the glyphs:\n%s", full)
+	}
+
+	// A LineHook that shortens the rows leaves fewer gaps to widen
+	if _, err := Render("W W W W W W W W W W W W", WithJustification(JustifyFull), WithLineHook(func(int, []rune) []rune {
+		return nil
+	})); err != nil {
+		t.Errorf("Full justification with a LineHook failed: %v", err)
+	}
+}
+
+func TestLinePrefix(t *testing.T) {
+	plain, err := Render("Hi", WithFont("small"), WithJustification(1), WithWidth(30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefixed, err := Render("Hi", WithFont("small"), WithJustification(1), WithWidth(30),
+		WithIndent(2), WithLinePrefix("// "))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Split(plain, "\n")
+	got := strings.Split(prefixed, "\n")
+	for i := range want[:len(want)-1] {
+		if got[i] != "  // "+want[i] {
+			t.Errorf("Line %d = %q, want %q", i, got[i], "  // "+want[i])
+		}
+	}
+
+	colored, err := Render("Hi", WithFont("small"), WithLinePrefix("# "),
+		WithColors(ColorRed), WithParser("terminal-color"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(colored, "\n"), "\n") {
+		if !strings.HasPrefix(line, "# ") {
+			t.Errorf("Line %q does not start with an uncolored prefix", line)
+		}
+	}
+
+	if _, err := Render("Hi", WithIndent(-1)); err == nil {
+		t.Error("Expected a negative indent to be refused")
+	}
+}
+
+func TestCommentBlock(t *testing.T) {
+	art := " _  _ \n| || |  \n"
+	for _, tc := range []struct {
+		lang string
+		box  bool
+		want string
+	}{
+		{"go", false, "//  _  _\n// | || |\n"},
+		{"Python", false, "#  _  _\n# | || |\n"},
+		{"sql", true, "------------\n--  _  _  --\n-- | || | --\n------------\n"},
+		{"c", false, "/*\n *  _  _\n * | || |\n */\n"},
+		{"c", true, "/**********\n *  _  _  *\n * | || | *\n *********/\n"},
+	} {
+		got, err := CommentBlock(art, tc.lang, tc.box)
+		if err != nil {
+			t.Errorf("%s: %v", tc.lang, err)
+		} else if got != tc.want {
+			t.Errorf("%s, box %v:\n%s\nwant:\n%s", tc.lang, tc.box, got, tc.want)
+		}
+	}
+	if _, err := CommentBlock(" */\n", "c", false); err == nil {
+		t.Error("Expected art ending the comment to be refused")
+	}
+	if _, err := CommentBlock(art, "cobol", false); err == nil {
+		t.Error("Expected an unknown language to be refused")
+	}
+}
+
+// TestCIRef tests reading the ref being built from CI variables
+func TestCIRef(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want string
+	}{
+		{map[string]string{}, ""},
+		{map[string]string{"GITHUB_REF": "refs/tags/v1.2.0"}, "v1.2.0"},
+		{map[string]string{"GITHUB_REF": "refs/pull/7/merge", "GITHUB_HEAD_REF": "fix-x"}, "fix-x"},
+		{map[string]string{"GITHUB_REF": "refs/pull/7/merge"}, ""},
+		{map[string]string{"CI_COMMIT_REF_NAME": "main", "CI_COMMIT_TAG": "v2"}, "v2"},
+		{map[string]string{"BUILD_SOURCEBRANCH": "refs/heads/release/3"}, "release/3"},
+		{map[string]string{"GIT_BRANCH": "origin/develop"}, "develop"},
+	}
+	for _, tt := range tests {
+		if got := CIRef(func(name string) string { return tt.env[name] }); got != tt.want {
+			t.Errorf("CIRef(%v) = %q, want %q", tt.env, got, tt.want)
+		}
+	}
+}
+
+// TestExpandMarkdown tests that figlet blocks are rendered and other
+// blocks are kept
+func TestExpandMarkdown(t *testing.T) {
+	art, err := Render("Hi", WithFont("slant"), WithTrimWhitespace(TrimTrailing|TrimBlankRows))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := "# Title\n\n```figlet {font=slant}\nHi\n```\n\n````md\n```figlet\nKept\n```\n````\n"
+	got, err := ExpandMarkdown(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Title\n\n```\n" + art + "```\n\n````md\n```figlet\nKept\n```\n````\n"
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	// A block already expanded is left alone, so expanding is idempotent
+	if again, _ := ExpandMarkdown(got); again != got {
+		t.Errorf("expanding twice changed the document:\n%s", again)
+	}
+
+	for _, bad := range []string{
+		"```figlet {colour=red}\nHi\n```\n",
+		"```figlet {width=0}\nHi\n```\n",
+		"```figlet\nHi\n",
+	} {
+		if _, err := ExpandMarkdown(bad); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestLineEnding(t *testing.T) {
+	lf, err := Render("Hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	crlf, err := Render("Hi", WithLineEnding("\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.ReplaceAll(lf, "\n", "\r\n"); crlf != want {
+		t.Errorf("CRLF output = %q, want %q", crlf, want)
+	}
+	html, err := Render("Hi", WithParser("html"), WithLineEnding("\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html, "<br>\n") {
+		t.Errorf("HTML lines should end with <br> and the line ending: %q", html)
+	}
+}
+
+func TestRenderLines(t *testing.T) {
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatal(err)
+	}
+	hi, bye := cfg.RenderString("Hi"), cfg.RenderString("Bye")
+
+	got, err := cfg.RenderLines([]string{"Hi", "Bye"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != hi+bye {
+		t.Errorf("blocks without separator = %q, want %q", got, hi+bye)
+	}
+	got, _ = cfg.RenderLines([]string{"Hi", "Bye"}, WithSeparator(""))
+	if got != hi+"\n"+bye {
+		t.Errorf("blocks with blank line = %q", got)
+	}
+	got, _ = cfg.RenderLines([]string{"Hi", "Bye"}, WithSeparator("--"), WithTrailingNewline(false))
+	if want := hi + "--\n" + strings.TrimSuffix(bye, "\n"); got != want {
+		t.Errorf("blocks with separator line = %q, want %q", got, want)
+	}
+	if got := cfg.RenderString("Hi", WithTrailingNewline(false)); got != strings.TrimSuffix(hi, "\n") {
+		t.Errorf("no trailing newline = %q", got)
+	}
+	if cfg.NoTrailingNewline || cfg.Separate {
+		t.Error("per-call options changed the Config")
+	}
+}
+
+func TestRTLRightJustify(t *testing.T) {
+	right, err := Render("Hi", WithFont("ivrit"), WithWidth(40))
+	if err != nil {
+		t.Fatal(err)
+	}
+	left, err := Render("Hi", WithFont("ivrit"), WithWidth(40), WithRTLRightJustify(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(right, " ") || strings.HasPrefix(strings.TrimLeft(left, "\n"), "    ") {
+		t.Errorf("right-to-left text should be flush-right only by default:\n%s\n%s", right, left)
+	}
+
+	cfg := New()
+	WithFont("ivrit")(cfg)
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.RenderString("Hi", WithJustification(JustifyAuto), WithRTLRightJustify(false)); got != left {
+		t.Errorf("per-call options = %q, want %q", got, left)
+	}
+}
+
+func TestRetainedBanner(t *testing.T) {
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	banner := NewRetainedBanner(cfg, &out)
+
+	if err := banner.Update("12:00"); err != nil {
+		t.Fatal(err)
+	}
+	full := cfg.RenderString("12:00")
+	if first := out.String(); !strings.Contains(first, strings.Split(full, "\n")[0]) {
+		t.Fatalf("first update should draw the whole banner: %q", first)
+	}
+
+	out.Reset()
+	banner.Update("12:01")
+	update := out.String()
+	if strings.Contains(update, "\n") || len(update) >= len(full) {
+		t.Errorf("update should only rewrite the changed cells: %q", update)
+	}
+	if !strings.HasPrefix(update, fmt.Sprintf("\033[%dA", cfg.charheight)) || !strings.HasSuffix(update, "B\033[1G") {
+		t.Errorf("update should start and end below the banner: %q", update)
+	}
+
+	out.Reset()
+	banner.Update("12:01")
+	if got := out.String(); got != "\033[1G" {
+		t.Errorf("unchanged update = %q", got)
+	}
+
+	out.Reset()
+	banner.Clear()
+	if want := fmt.Sprintf("\033[%dA\033[J\033[?25h", cfg.charheight); out.String() != want {
+		t.Errorf("Clear = %q, want %q", out.String(), want)
 	}
 }
@@ -0,0 +1,95 @@
+package figlet
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// FitFonts lists embedded fonts from the biggest to the smallest. It is
+// what FitRender and LoadFittingFont try when given no candidates.
+var FitFonts = []string{"big", "standard", "small", "mini", "term"}
+
+// FitRender renders text in the biggest font that fits in maxWidth columns
+// without wrapping, trying the candidates in order, biggest first. When
+// none fits, the last one is used and the text wraps as usual. Options are
+// applied as with Render; the output width is set to fit maxWidth.
+func FitRender(text string, maxWidth int, candidates []string, options ...Option) (string, error) {
+	cfg := New()
+	for _, opt := range options {
+		opt(cfg)
+	}
+	cfg.Outputwidth = maxWidth + 1
+	if err := cfg.LoadFittingFont(text, maxWidth, candidates); err != nil {
+		return "", err
+	}
+	return cfg.Render(text)
+}
+
+// FitRenderAll renders every title in the same font, the biggest in which
+// the widest of them fits in maxWidth columns, so generated documents such
+// as changelogs do not mix header sizes. It returns the banners by title.
+// Candidates and options are used as with FitRender.
+func FitRenderAll(titles []string, maxWidth int, candidates []string, options ...Option) (map[string]string, error) {
+	cfg := New()
+	for _, opt := range options {
+		opt(cfg)
+	}
+	cfg.Outputwidth = maxWidth + 1
+	// Each title is a line of its own, so the block is as wide as the
+	// widest title
+	if err := cfg.LoadFittingFont(strings.Join(titles, "\n"), maxWidth, candidates); err != nil {
+		return nil, err
+	}
+	banners := make(map[string]string, len(titles))
+	for _, title := range titles {
+		banner, err := cfg.Render(title)
+		if err != nil {
+			return nil, err
+		}
+		banners[title] = banner
+	}
+	return banners, nil
+}
+
+// LoadFittingFont loads the first of the candidate fonts in which text is
+// at most maxWidth columns wide without wrapping, or the last one if none
+// is, and sets Fontname to it. Candidates are tried in order, so they
+// should go from the biggest to the smallest; nil means FitFonts. The
+// width is measured without justification and padding.
+func (cfg *Config) LoadFittingFont(text string, maxWidth int, candidates []string) error {
+	if maxWidth < 1 {
+		return fmt.Errorf("invalid width %d", maxWidth)
+	}
+	if len(candidates) == 0 {
+		candidates = FitFonts
+	}
+	// Wide enough for any glyph of every character of the longest line
+	longest := 0
+	for _, line := range strings.Split(text, "\n") {
+		longest = max(longest, utf8.RuneCountInString(line))
+	}
+	measure := []RenderOption{WithWidth((longest+1)*MAXLEN + 1), WithJustification(0), WithPadding(PadNone)}
+
+	for n, name := range candidates {
+		cfg.Fontname = strings.TrimSuffix(strings.TrimSuffix(name, FONTFILESUFFIX), TOILETFILESUFFIX)
+		if err := cfg.LoadFont(); err != nil {
+			return err
+		}
+		if n == len(candidates)-1 {
+			break
+		}
+		width := 0
+		if err := cfg.apply(measure, func() error {
+			width = blockwidth(cfg.layoutrows(cfg.compose(text)))
+			return nil
+		}); err != nil {
+			return err
+		}
+		cfg.debugf("fit: %s is %d columns wide, %d available", cfg.Fontname, width, maxWidth)
+		if width <= maxWidth {
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package figlet
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Font is a parsed FIGlet or TOIlet font. It is never changed once
+// loaded, so one Font can be shared by any number of Configs, in any
+// number of goroutines, and swapped for another at run time. Characters
+// deferred by LazyGlyphs are parsed under a lock the first time any
+// Config renders them.
+type Font struct {
+	name       string
+	hardblank  rune
+	height     int
+	baseline   int // rows from the top of a character to its baseline
+	smush      int // full layout, as SM_* flags
+	right2left int
+	toilet     bool
+	glyphs     *FCharNode
+	lazy       *lazyglyphs
+
+	mu       sync.Mutex // guards warnings
+	warnings []Warning
+}
+
+// LoadFontByName loads the font with the given name, as LoadFont would
+// with the default font directory, and only from the given sources when
+// there are any. Control files, character maps and fallback fonts belong
+// to a Config and are not loaded; use WithLoadedFont to render with it.
+func LoadFontByName(name string, sources ...FontSource) (*Font, error) {
+	var allowed FontSource
+	for _, s := range sources {
+		allowed |= s
+	}
+	return loadfont(name, "", allowed, nil)
+}
+
+// loadfont loads the named font from the given sources, looking for font
+// files in dir, or the default font directory if dir is empty, and
+// reports the load to metrics if not nil
+func loadfont(name, dir string, sources FontSource, metrics Metrics) (*Font, error) {
+	cfg := New()
+	cfg.Fontname, cfg.FontSources = name, sources
+	if dir != "" {
+		cfg.Fontdirname = dir
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	err := readfont(cfg)
+	observeload(metrics, start, name, err)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.font, nil
+}
+
+// WithLoadedFont makes LoadFont use font instead of reading the font named
+// by Fontname, then load the control files, character maps and fallback
+// fonts as usual. Loading a font once and giving it to a Config per
+// goroutine or request saves parsing it every time.
+func WithLoadedFont(font *Font) Option {
+	return func(cfg *Config) {
+		cfg.loadedfont = font
+		if font != nil {
+			cfg.Fontname = font.name
+		}
+	}
+}
+
+// Font returns the font loaded by the last LoadFont, or nil. It can be
+// given to other Configs with WithLoadedFont.
+func (cfg *Config) Font() *Font {
+	return cfg.font
+}
+
+// Name returns the name the font was loaded with
+func (f *Font) Name() string {
+	return f.name
+}
+
+// Height returns the number of rows of a character
+func (f *Font) Height() int {
+	return f.height
+}
+
+// Baseline returns the number of rows from the top of a character to its
+// baseline
+func (f *Font) Baseline() int {
+	return f.baseline
+}
+
+// Warnings returns the problems found while parsing the font so far
+func (f *Font) Warnings() []Warning {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Warning(nil), f.warnings...)
+}
+
+// warn records a problem found while parsing the font
+func (f *Font) warn(kind WarningKind, char rune, line int, format string, args ...interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.warnings = append(f.warnings, Warning{
+		Kind:    kind,
+		Char:    char,
+		Line:    line,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// glyph returns the character c of the font, or nil if it has none
+func (f *Font) glyph(c rune) *FCharNode {
+	if node := f.lazyglyph(c); node != nil {
+		return node
+	}
+	node := f.glyphs
+	for node != nil && node.ord != c {
+		node = node.next
+	}
+	return node
+}
+
+// hasglyph reports whether the font defines character c
+func (f *Font) hasglyph(c rune) bool {
+	if f.lazy != nil {
+		if _, ok := f.lazy.offsets[c]; ok {
+			return true
+		}
+	}
+	for charptr := f.glyphs; charptr != nil; charptr = charptr.next {
+		if charptr.ord == c {
+			return true
+		}
+	}
+	return false
+}
+
+// setfont makes f the font of the config, with its metrics and layout
+func (cfg *Config) setfont(f *Font) {
+	cfg.font = f
+	cfg.hardblank, cfg.charheight, cfg.baseline = f.hardblank, f.height, f.baseline
+	cfg.fontdefaults(true)
+}
+
+// padded returns a copy of the font with top blank rows above every
+// character and as many below as make it height rows tall. Deferred
+// characters are padded as they are parsed.
+func (f *Font) padded(top, height int) *Font {
+	p := &Font{
+		name:       f.name,
+		hardblank:  f.hardblank,
+		height:     height,
+		baseline:   f.baseline + top,
+		smush:      f.smush,
+		right2left: f.right2left,
+		toilet:     f.toilet,
+		warnings:   f.Warnings(),
+	}
+	list := &p.glyphs
+	for node := f.glyphs; node != nil; node = node.next {
+		*list = &FCharNode{ord: node.ord, thechar: padglyph(node.thechar, top, height)}
+		list = &(*list).next
+	}
+	if f.lazy != nil {
+		p.lazy = &lazyglyphs{
+			data:    f.lazy.data,
+			offsets: f.lazy.offsets,
+			loaded:  make(map[rune]*FCharNode),
+			arena:   newglypharena(),
+			height:  f.lazy.height,
+			top:     f.lazy.top + top,
+		}
+	}
+	return p
+}
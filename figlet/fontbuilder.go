@@ -0,0 +1,229 @@
+package figlet
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FontBuilder makes a FIGlet font in code, such as a font generated from
+// a pixel grid. WriteTo writes it as a font file; LoadFontFrom loads it.
+type FontBuilder struct {
+	Height int
+	// Baseline is the row of the baseline, from 1; 0 means the last row
+	Baseline int
+	// Hardblank is the ASCII character that stands for a space that is
+	// never smushed; 0 means '$'
+	Hardblank byte
+	// Layout is the full layout of the font, as SM_* flags. 0 draws every
+	// character at its full width.
+	Layout  int
+	Comment string
+	glyphs  map[rune][]string
+}
+
+// NewFontBuilder returns a builder for a font of the given height
+func NewFontBuilder(height int) *FontBuilder {
+	return &FontBuilder{Height: height, glyphs: make(map[rune][]string)}
+}
+
+// Glyph sets the rows of the glyph of r. There must be Height rows, all
+// of the same width.
+func (b *FontBuilder) Glyph(r rune, rows ...string) error {
+	if len(rows) != b.Height {
+		return fmt.Errorf("glyph %q has %d rows, want %d", r, len(rows), b.Height)
+	}
+	width := -1
+	for _, row := range rows {
+		n := utf8.RuneCountInString(row)
+		if width >= 0 && n != width {
+			return fmt.Errorf("glyph %q has rows of different widths", r)
+		}
+		if n > MAXLEN-2 || strings.ContainsAny(row, "\r\n") {
+			return fmt.Errorf("glyph %q has an invalid row %q", r, row)
+		}
+		width = n
+	}
+	b.glyphs[r] = append([]string(nil), rows...)
+	return nil
+}
+
+// WriteTo writes the font in the FIGlet font file format. The characters
+// FIGlet requires that were not set are written empty.
+func (b *FontBuilder) WriteTo(w io.Writer) (int64, error) {
+	if b.Height < 1 || b.Height > MAXHEIGHT {
+		return 0, fmt.Errorf("invalid font height %d", b.Height)
+	}
+	hardblank, baseline := b.Hardblank, b.Baseline
+	if hardblank == 0 {
+		hardblank = '$'
+	}
+	if baseline < 1 || baseline > b.Height {
+		baseline = b.Height
+	}
+	oldlayout := -1
+	switch {
+	case b.Layout&SM_SMUSH != 0:
+		oldlayout = b.Layout & 63
+	case b.Layout&SM_KERN != 0:
+		oldlayout = 0
+	}
+	maxlen := 2
+	for _, rows := range b.glyphs {
+		maxlen = max(maxlen, utf8.RuneCountInString(rows[0])+2)
+	}
+
+	var required []rune
+	for r := ' '; r <= '~'; r++ {
+		required = append(required, r)
+	}
+	required = append(required, Deutsch...)
+	var tagged []rune
+	for r := range b.glyphs {
+		if (r < ' ' || r > '~') && !strings.ContainsRune(string(Deutsch), r) {
+			tagged = append(tagged, r)
+		}
+	}
+	sort.Slice(tagged, func(i, j int) bool { return tagged[i] < tagged[j] })
+
+	bw := bufio.NewWriter(w)
+	cw := &countingwriter{w: bw}
+	var comment []string
+	if b.Comment != "" {
+		comment = strings.Split(b.Comment, "\n")
+	}
+	fmt.Fprintf(cw, "%sa%c %d %d %d %d %d 0 %d %d\n", FONTFILEMAGICNUMBER, hardblank,
+		b.Height, baseline, maxlen, oldlayout, len(comment), b.Layout, len(tagged))
+	for _, line := range comment {
+		fmt.Fprintln(cw, line)
+	}
+	for _, r := range required {
+		b.writeglyph(cw, r)
+	}
+	for _, r := range tagged {
+		fmt.Fprintf(cw, "%d\n", r)
+		b.writeglyph(cw, r)
+	}
+	if cw.err == nil {
+		cw.err = bw.Flush()
+	}
+	return cw.n, cw.err
+}
+
+// writeglyph writes the rows of a glyph with their endmarks, picking an
+// endmark that no row ends with, since trailing endmarks are stripped
+func (b *FontBuilder) writeglyph(w io.Writer, r rune) {
+	rows, ok := b.glyphs[r]
+	if !ok {
+		rows = make([]string, b.Height)
+	}
+	endmark := "@"
+	for _, mark := range []string{"@", "#", "%", "&"} {
+		endmark = mark
+		clash := false
+		for _, row := range rows {
+			clash = clash || strings.HasSuffix(row, mark)
+		}
+		if !clash {
+			break
+		}
+	}
+	for i, row := range rows {
+		if i == len(rows)-1 {
+			fmt.Fprintf(w, "%s%s%s\n", row, endmark, endmark)
+		} else {
+			fmt.Fprintf(w, "%s%s\n", row, endmark)
+		}
+	}
+}
+
+// countingwriter counts the bytes written and keeps the first error
+type countingwriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingwriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+	return n, err
+}
+
+// blockfontpattern matches the names of the generated block fonts
+var blockfontpattern = regexp.MustCompile(`^block([1-9][0-9]?)x([1-9][0-9]?)$`)
+
+// blockfonts lists the generated block fonts shown by ListFonts; any other
+// size can be loaded by name as well
+var blockfonts = []string{"block3x3", "block5x5"}
+
+// BlockFont returns a pixel font where every character is a block of width
+// by height copies of fill, like the output of banner printers, or of the
+// character itself when fill is 0. Rendering existing ASCII art with it
+// scales the art up. Spaces stay blank, and characters are never smushed.
+// It covers ASCII and Latin-1; the fonts named block3x3, block5x5 and so
+// on are made with it.
+func BlockFont(width, height int, fill rune) (*FontBuilder, error) {
+	if width < 1 || width > MAXLEN-2 || height < 1 || height > MAXHEIGHT {
+		return nil, fmt.Errorf("invalid block size %dx%d", width, height)
+	}
+	b := NewFontBuilder(height)
+	// Every printable character is a glyph, so none can be the hardblank
+	b.Hardblank = 0x7f
+	for r := ' '; r <= 0xff; r++ {
+		if r > '~' && r < 0xa0 {
+			continue
+		}
+		c := r
+		switch {
+		case r == ' ' || r == 0xa0:
+			c = ' '
+		case fill != 0:
+			c = fill
+		}
+		rows := make([]string, height)
+		for i := range rows {
+			rows[i] = strings.Repeat(string(c), width)
+		}
+		if err := b.Glyph(r, rows...); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// generatedfont returns the generated font with the given name, if any
+func generatedfont(name string) (*FontBuilder, bool) {
+	m := blockfontpattern.FindStringSubmatch(name)
+	if m == nil {
+		return nil, false
+	}
+	width, _ := strconv.Atoi(m[1])
+	height, _ := strconv.Atoi(m[2])
+	b, err := BlockFont(width, height, 0)
+	return b, err == nil
+}
+
+// LoadFontFrom loads a FIGlet or TOIlet font read from r instead of the one
+// named by Fontname, such as a font made with a FontBuilder. Control files
+// and character maps are loaded like with LoadFont.
+func (cfg *Config) LoadFontFrom(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return cfg.load(func(cfg *Config) error {
+		cfg.font = &Font{name: cfg.Fontname, toilet: bytes.HasPrefix(data, []byte(TOILETFILEMAGICNUMBER))}
+		return parsefont(cfg, &ZFILE{reader: bytes.NewReader(data)})
+	})
+}
@@ -0,0 +1,146 @@
+package figlet
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FontManager loads fonts by name the first time they are asked for and
+// hands out the same Font afterwards, so a server parses each font once.
+// Fonts edited in the font directory are picked up by Reload, or by Font
+// itself when WatchInterval is set, for font designers iterating on a
+// running server.
+type FontManager struct {
+	// Fontdirname is the directory fonts are loaded from; empty means
+	// the default font directory
+	Fontdirname string
+	// FontSources is where fonts may be loaded from; 0 allows every source
+	FontSources FontSource
+	// WatchInterval is how often Font checks whether the files of a font
+	// changed; 0 never checks
+	WatchInterval time.Duration
+	// Metrics, when set, is told how long font loads take
+	Metrics Metrics
+
+	mu    sync.Mutex
+	fonts map[string]*managedfont
+}
+
+// managedfont is a font loaded by a FontManager
+type managedfont struct {
+	font    *Font
+	stamp   string    // see FontManager.stamp
+	checked time.Time // when stamp was last compared to the files
+}
+
+// NewFontManager returns a FontManager loading fonts from dir and the
+// given sources
+func NewFontManager(dir string, sources FontSource) *FontManager {
+	return &FontManager{Fontdirname: dir, FontSources: sources}
+}
+
+// Font returns the named font, loading it on first use. With
+// WatchInterval set, a font whose files changed since it was loaded is
+// loaded again; if that fails, the font already loaded is kept.
+func (m *FontManager) Font(name string) (*Font, error) {
+	m.mu.Lock()
+	mf := m.fonts[name]
+	stale := mf != nil && m.WatchInterval > 0 && time.Since(mf.checked) >= m.WatchInterval
+	if stale {
+		mf.checked = time.Now()
+	}
+	m.mu.Unlock()
+
+	switch {
+	case mf == nil:
+		return m.load(name)
+	case stale && m.stamp(name) != mf.stamp:
+		if font, err := m.load(name); err == nil {
+			return font, nil
+		}
+	}
+	return mf.font, nil
+}
+
+// Loaded returns the named font if it is loaded, or nil
+func (m *FontManager) Loaded(name string) *Font {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if mf := m.fonts[name]; mf != nil {
+		return mf.font
+	}
+	return nil
+}
+
+// Reload loads every loaded font again whose files changed, so the next
+// Font returns the new version. A font that fails to load keeps its
+// previous version, and the errors are returned together. Reload returns
+// the names of the fonts loaded again.
+func (m *FontManager) Reload() ([]string, error) {
+	m.mu.Lock()
+	stamps := make(map[string]string, len(m.fonts))
+	for name, mf := range m.fonts {
+		stamps[name] = mf.stamp
+	}
+	m.mu.Unlock()
+
+	var names []string
+	for name, stamp := range stamps {
+		if m.stamp(name) != stamp {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var reloaded []string
+	var errs []error
+	for _, name := range names {
+		if _, err := m.load(name); err != nil {
+			errs = append(errs, fmt.Errorf("font %s: %w", name, err))
+			continue
+		}
+		reloaded = append(reloaded, name)
+	}
+	return reloaded, errors.Join(errs...)
+}
+
+// load loads the named font and keeps it
+func (m *FontManager) load(name string) (*Font, error) {
+	stamp := m.stamp(name)
+	font, err := loadfont(name, m.Fontdirname, m.FontSources, m.Metrics)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.fonts == nil {
+		m.fonts = make(map[string]*managedfont)
+	}
+	m.fonts[name] = &managedfont{font: font, stamp: stamp, checked: time.Now()}
+	return font, nil
+}
+
+// stamp returns the size and modification time of each file in the font
+// directory that could hold the named font, which changes when one of
+// them is edited, added or removed
+func (m *FontManager) stamp(name string) string {
+	dir := m.Fontdirname
+	if dir == "" {
+		dir = New().Fontdirname
+	}
+	var b strings.Builder
+	for _, suffix := range []string{FONTFILESUFFIX, TOILETFILESUFFIX} {
+		for _, path := range []string{filepath.Join(dir, name+suffix), sidecarpath(filepath.Join(dir, name+suffix))} {
+			if info, err := os.Stat(path); err == nil {
+				fmt.Fprintf(&b, "%s %d %d\n", filepath.Base(path), info.Size(), info.ModTime().UnixNano())
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,186 @@
+package figlet
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// FontSource is a set of places fonts and control files may be loaded
+// from, see WithFontSources
+type FontSource int
+
+const (
+	// FontSourceEmbedded allows the fonts built into the package
+	FontSourceEmbedded FontSource = 1 << iota
+	// FontSourceDir allows files named without a path, looked up in
+	// Fontdirname and never outside it, even through symbolic links
+	FontSourceDir
+	// FontSourcePath allows files given by path; absolute paths and ".."
+	// segments also need AllowFilesystemPaths
+	FontSourcePath
+	// FontSourceAny allows every source, the default
+	FontSourceAny = FontSourceEmbedded | FontSourceDir | FontSourcePath
+)
+
+// WithFontSources restricts where fonts and control files are loaded
+// from, such as to FontSourceEmbedded, or to FontSourceDir to sandbox a
+// directory. Servers loading fonts named in requests should not allow
+// FontSourcePath, so names like ../../etc/passwd are refused. Generated
+// fonts, such as block3x5, are always allowed.
+func WithFontSources(sources FontSource) Option {
+	return func(cfg *Config) {
+		cfg.FontSources = sources
+	}
+}
+
+// fontsources returns the allowed font sources; none set means all
+func (cfg *Config) fontsources() FontSource {
+	if cfg.FontSources == 0 {
+		return FontSourceAny
+	}
+	return cfg.FontSources
+}
+
+// figopenfrom opens a font or control file like FIGopen, from the given
+// sources only
+func (cfg *Config) figopenfrom(sources FontSource, name, suffix string) (*ZFILE, error) {
+	local := !hasdirsep(name) && filepath.IsLocal(name+suffix)
+	if local && sources&FontSourceDir != 0 {
+		path := filepath.Join(cfg.Fontdirname, name+suffix)
+		zf, err := opensandboxed(cfg.Fontdirname, path)
+		cfg.logopen(path, zf, err)
+		if err == nil {
+			return zf, nil
+		}
+	}
+	if local && sources&FontSourceEmbedded != 0 {
+		// The embedded fonts are always in a fonts directory
+		path := "fonts/" + name + suffix
+		zf, err := zopenembedded(path)
+		cfg.logopen(path, zf, err)
+		if err == nil {
+			return zf, nil
+		}
+	}
+	if !local && sources&FontSourcePath != 0 {
+		path := name + suffix
+		zf, err := zopenfile(path)
+		return cfg.logopen(path, zf, err)
+	}
+	if !local {
+		cfg.debugf("open %s%s: paths are not allowed", name, suffix)
+		return nil, fmt.Errorf("%s%s: paths are not allowed: %w", name, suffix, fs.ErrPermission)
+	}
+	return nil, fmt.Errorf("%s%s: %w", name, suffix, fs.ErrNotExist)
+}
+
+// opensandboxed opens path from the filesystem if, once symbolic links are
+// resolved, it is inside dir
+func opensandboxed(dir, path string) (*ZFILE, error) {
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return nil, err
+	}
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return nil, err
+	}
+	if rel, err := filepath.Rel(root, real); err != nil || !filepath.IsLocal(rel) {
+		return nil, fmt.Errorf("%s: outside %s: %w", path, dir, fs.ErrPermission)
+	}
+	return zopenfile(real)
+}
+
+// fontsourcenames names each font source for ParseFontSources and String
+var fontsourcenames = []struct {
+	source FontSource
+	name   string
+}{
+	{FontSourceEmbedded, "embedded"},
+	{FontSourceDir, "dir"},
+	{FontSourcePath, "path"},
+}
+
+// ParseFontSources parses a comma separated list of font sources:
+// embedded, dir, path or any
+func ParseFontSources(list string) (FontSource, error) {
+	var sources FontSource
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if strings.EqualFold(name, "any") {
+			sources |= FontSourceAny
+			continue
+		}
+		found := false
+		for _, s := range fontsourcenames {
+			if strings.EqualFold(name, s.name) {
+				sources |= s.source
+				found = true
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("invalid font source: %s (valid: embedded, dir, path, any)", name)
+		}
+	}
+	return sources, nil
+}
+
+// String returns the font sources as a comma separated list
+func (s FontSource) String() string {
+	var names []string
+	for _, n := range fontsourcenames {
+		if s&n.source != 0 {
+			names = append(names, n.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// FontNameError reports a font or control file name refused before any
+// file is opened, such as one escaping the font directory
+type FontNameError struct {
+	Name   string
+	Reason string
+}
+
+// Error formats the font name error
+func (e *FontNameError) Error() string {
+	return fmt.Sprintf("invalid font name %q: %s", e.Name, e.Reason)
+}
+
+// Unwrap lets errors.Is match fs.ErrPermission
+func (e *FontNameError) Unwrap() error {
+	return fs.ErrPermission
+}
+
+// WithFilesystemPaths allows font and control file names that are
+// absolute paths or go up a directory with "..", as a command line tool
+// taking file names from its user does
+func WithFilesystemPaths() Option {
+	return func(cfg *Config) {
+		cfg.AllowFilesystemPaths = true
+	}
+}
+
+// checkname refuses empty names, names with NUL bytes and, unless
+// AllowFilesystemPaths is set, absolute paths and ".." segments
+func (cfg *Config) checkname(name string) error {
+	switch {
+	case name == "":
+		return &FontNameError{name, "empty"}
+	case strings.ContainsRune(name, 0):
+		return &FontNameError{name, "contains a NUL byte"}
+	case cfg.AllowFilesystemPaths:
+		return nil
+	case filepath.IsAbs(name) || strings.HasPrefix(name, "/") || strings.HasPrefix(name, `\`) || filepath.VolumeName(name) != "":
+		return &FontNameError{name, "absolute paths are not allowed"}
+	}
+	for _, segment := range strings.FieldsFunc(name, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if segment == ".." {
+			return &FontNameError{name, `".." is not allowed`}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package figlet
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Format selects the kind of document written by RenderTo
+type Format string
+
+const (
+	// FormatText writes the output as RenderString returns it
+	FormatText Format = "text"
+	// FormatHTML writes the output with the html parser
+	FormatHTML Format = "html"
+	// FormatSVG writes a vector image with one text element per row
+	FormatSVG Format = "svg"
+	// FormatJSON writes the rows and the size of the output as JSON
+	FormatJSON Format = "json"
+	// FormatPNG writes a PNG image drawn with a built-in bitmap font
+	FormatPNG Format = "png"
+	// FormatGIF writes a GIF image drawn with a built-in bitmap font
+	FormatGIF Format = "gif"
+)
+
+// Raster cell size in pixels. Glyph rows are drawn twice so cells keep
+// the tall shape of a terminal cell.
+const (
+	cellwidth  = 8
+	cellheight = 16
+)
+
+// SVG cell size in user units
+const (
+	svgfontsize   = 16
+	svgcellwidth  = 10
+	svglineheight = 20
+)
+
+// ListFormats returns the names of the formats RenderTo can write
+func ListFormats() []string {
+	return []string{string(FormatText), string(FormatHTML), string(FormatSVG),
+		string(FormatJSON), string(FormatPNG), string(FormatGIF)}
+}
+
+// ParseFormat returns the format with the given name
+func ParseFormat(name string) (Format, error) {
+	for _, f := range ListFormats() {
+		if name == f {
+			return Format(f), nil
+		}
+	}
+	return "", fmt.Errorf("invalid format: %s (valid: %s)", name, strings.Join(ListFormats(), ", "))
+}
+
+// RenderTo renders text in the given format and writes it to w.
+// Options apply to this call only, as with RenderString. Colors are used by
+// every format except text with the plain terminal parser.
+func (cfg *Config) RenderTo(w io.Writer, text string, format Format, opts ...RenderOption) error {
+	if format == FormatHTML {
+		opts = append(opts[:len(opts):len(opts)], WithParser("html"))
+	}
+	return cfg.apply(opts, func() error {
+		rows := cfg.layoutrows(cfg.compose(text))
+		switch format {
+		case FormatText, FormatHTML:
+			_, err := io.WriteString(w, cfg.emit(rows))
+			return err
+		case FormatSVG:
+			_, err := io.WriteString(w, cfg.svg(rows))
+			return err
+		case FormatJSON:
+			return cfg.json(w, rows)
+		case FormatPNG:
+			return png.Encode(w, cfg.image(rows))
+		case FormatGIF:
+			return gif.Encode(w, cfg.image(rows), nil)
+		}
+		return fmt.Errorf("invalid format: %s", format)
+	})
+}
+
+// RenderSVG renders text as an SVG document
+func (cfg *Config) RenderSVG(text string, opts ...RenderOption) (string, error) {
+	var sb strings.Builder
+	err := cfg.RenderTo(&sb, text, FormatSVG, opts...)
+	return sb.String(), err
+}
+
+// RenderImage renders text as an image, drawing every output cell as an
+// 8x16 pixel glyph in its color, black on white if no colors are set
+func (cfg *Config) RenderImage(text string, opts ...RenderOption) (*image.Paletted, error) {
+	var img *image.Paletted
+	err := cfg.apply(opts, func() error {
+		img = cfg.image(cfg.layoutrows(cfg.compose(text)))
+		return nil
+	})
+	return img, err
+}
+
+// blockwidth returns the width of the widest laid out row
+func blockwidth(rows []outputrow) int {
+	width := 0
+	for _, row := range rows {
+		if w := row.pad + cellswidth(row.cells) + row.fill; w > width {
+			width = w
+		}
+	}
+	return width
+}
+
+// cellcolor returns the color of the cell at column i of row y, if any
+func (cfg *Config) cellcolor(row outputrow, y, i int) (TrueColor, bool) {
+	if len(cfg.Colors) == 0 {
+		return TrueColor{}, false
+	}
+	index := cfg.cellindex(row, y, i)
+	if index < 0 {
+		return TrueColor{}, false
+	}
+	return truecolor(cfg.Colors[index%len(cfg.Colors)])
+}
+
+// svg serializes laid out rows as an SVG document. Each row is stretched to
+// its cell count so columns line up whatever monospace font is used.
+func (cfg *Config) svg(rows []outputrow) string {
+	width, height := blockwidth(rows)*svgcellwidth, len(rows)*svglineheight
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n",
+		width, height, width, height)
+	fmt.Fprintf(&sb, "<g font-family=\"monospace\" font-size=\"%d\" xml:space=\"preserve\">\n", svgfontsize)
+	cfg.svgtext(&sb, rows)
+	sb.WriteString("</g>\n</svg>\n")
+	return sb.String()
+}
+
+// svgtext writes one text element per laid out row, in cells of the SVG
+// cell size from the origin
+func (cfg *Config) svgtext(sb *strings.Builder, rows []outputrow) {
+	for y, row := range rows {
+		n := row.pad + len(row.cells)
+		if n == 0 {
+			continue
+		}
+		fmt.Fprintf(sb, "<text x=\"0\" y=\"%d\" textLength=\"%d\" lengthAdjust=\"spacingAndGlyphs\">",
+			(y+1)*svglineheight-svglineheight/4, n*svgcellwidth)
+		var current *TrueColor
+		for x := 0; x < n; x++ {
+			cell := " "
+			if x >= row.pad {
+				cell = cfg.cellstring(row.cells[x-row.pad])
+			}
+			tc, ok := cfg.cellcolor(row, y, x)
+			if ok && cell != " " && (current == nil || *current != tc) {
+				if current != nil {
+					sb.WriteString("</tspan>")
+				}
+				current = &tc
+				fmt.Fprintf(sb, "<tspan fill=\"rgb(%d,%d,%d)\">", tc.R, tc.G, tc.B)
+			} else if !ok && current != nil {
+				sb.WriteString("</tspan>")
+				current = nil
+			}
+			xml.EscapeText(sb, []byte(cell))
+		}
+		if current != nil {
+			sb.WriteString("</tspan>")
+		}
+		sb.WriteString("</text>\n")
+	}
+}
+
+// json writes laid out rows as a JSON document
+func (cfg *Config) json(w io.Writer, rows []outputrow) error {
+	doc := struct {
+		Font      string   `json:"font"`
+		Width     int      `json:"width"`
+		Height    int      `json:"height"`
+		Baselines []int    `json:"baselines"`
+		Lines     []string `json:"lines"`
+	}{
+		Font:      cfg.Fontname,
+		Width:     blockwidth(rows),
+		Height:    len(rows),
+		Baselines: []int{},
+		Lines:     make([]string, len(rows)),
+	}
+	for i, row := range rows {
+		doc.Lines[i] = cfg.text(row)
+		if row.baseline {
+			doc.Baselines = append(doc.Baselines, i)
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// image rasterizes laid out rows with the built-in bitmap font
+func (cfg *Config) image(rows []outputrow) *image.Paletted {
+	palette := color.Palette{color.White, color.Black}
+	width, height := blockwidth(rows), len(rows)
+	img := image.NewPaletted(image.Rect(0, 0, max(width, 1)*cellwidth, max(height, 1)*cellheight), palette)
+
+	for y, row := range rows {
+		for x, r := range row.cells {
+			if cfg.isblank(r) {
+				continue
+			}
+			ink := uint8(1)
+			if tc, ok := cfg.cellcolor(row, y, row.pad+x); ok {
+				ink = inkindex(img, tc)
+			}
+			glyphrune, _ := utf8.DecodeRuneInString(cfg.cellstring(r))
+			drawglyph(img, row.pad+x, y, glyphrune, ink)
+		}
+	}
+	return img
+}
+
+// inkindex returns the palette index of a color, adding it to the palette
+// if there is room
+func inkindex(img *image.Paletted, tc TrueColor) uint8 {
+	c := color.RGBA{uint8(tc.R), uint8(tc.G), uint8(tc.B), 0xff}
+	ink := uint8(img.Palette.Index(c))
+	if img.Palette[ink] != color.Color(c) && len(img.Palette) < 256 {
+		img.Palette = append(img.Palette, c)
+		ink = uint8(len(img.Palette) - 1)
+	}
+	return ink
+}
+
+// drawglyph draws r in the cell at column x, row y
+func drawglyph(img *image.Paletted, x, y int, r rune, ink uint8) {
+	glyph := bitmapglyph(r)
+	left, top := x*cellwidth, y*cellheight
+	for gy, bits := range glyph {
+		for gx := 0; gx < 8; gx++ {
+			if bits>>gx&1 != 0 {
+				img.SetColorIndex(left+gx, top+2*gy, ink)
+				img.SetColorIndex(left+gx, top+2*gy+1, ink)
+			}
+		}
+	}
+}
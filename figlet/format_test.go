@@ -0,0 +1,128 @@
+package figlet
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"image/gif"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRenderTo(t *testing.T) {
+	cfg := New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	width, height := cfg.Measure("Hi")
+
+	t.Run("text", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := cfg.RenderTo(&buf, "Hi", FormatText); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != cfg.RenderString("Hi") {
+			t.Errorf("text format differs from RenderString:\n%s", buf.String())
+		}
+	})
+
+	t.Run("html", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := cfg.RenderTo(&buf, "Hi", FormatHTML); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(buf.String(), "<code>") {
+			t.Errorf("Expected HTML output, got %q", buf.String())
+		}
+		if cfg.OutputParser.Name != "terminal" {
+			t.Error("html format should not change the Config parser")
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := cfg.RenderTo(&buf, "Hi", FormatJSON); err != nil {
+			t.Fatal(err)
+		}
+		var doc struct {
+			Font   string
+			Width  int
+			Height int
+			Lines  []string
+		}
+		if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+			t.Fatalf("invalid JSON: %v", err)
+		}
+		if doc.Font != "standard" || doc.Width != width || doc.Height != height || len(doc.Lines) != height {
+			t.Errorf("unexpected document: %+v", doc)
+		}
+		if strings.Join(doc.Lines, "\n")+"\n" != cfg.RenderString("Hi") {
+			t.Errorf("JSON lines differ from RenderString: %q", doc.Lines)
+		}
+	})
+
+	t.Run("svg", func(t *testing.T) {
+		svg, err := cfg.RenderSVG("<&>", WithColors(ColorRed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		dec := xml.NewDecoder(strings.NewReader(svg))
+		for {
+			if _, err := dec.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("invalid SVG: %v\n%s", err, svg)
+			}
+		}
+		if !strings.Contains(svg, "rgb(255,65,54)") {
+			t.Error("Expected colored tspans in SVG output")
+		}
+	})
+
+	t.Run("png", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := cfg.RenderTo(&buf, "Hi", FormatPNG); err != nil {
+			t.Fatal(err)
+		}
+		img, err := png.Decode(&buf)
+		if err != nil {
+			t.Fatalf("invalid PNG: %v", err)
+		}
+		if b := img.Bounds(); b.Dx() != width*cellwidth || b.Dy() != height*cellheight {
+			t.Errorf("Expected %dx%d image, got %v", width*cellwidth, height*cellheight, b)
+		}
+	})
+
+	t.Run("gif", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := cfg.RenderTo(&buf, "Hi", FormatGIF, WithColors(ColorBlue)); err != nil {
+			t.Fatal(err)
+		}
+		img, err := gif.Decode(&buf)
+		if err != nil {
+			t.Fatalf("invalid GIF: %v", err)
+		}
+		found := false
+		b := img.Bounds()
+		for y := b.Min.Y; y < b.Max.Y && !found; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				if r, g, bl, _ := img.At(x, y).RGBA(); r>>8 == 0 && g>>8 == 116 && bl>>8 == 217 {
+					found = true
+					break
+				}
+			}
+		}
+		if !found {
+			t.Error("Expected blue pixels in GIF output")
+		}
+	})
+
+	if _, err := ParseFormat("bmp"); err == nil {
+		t.Error("ParseFormat should reject unknown formats")
+	}
+	if err := cfg.RenderTo(io.Discard, "Hi", Format("bmp")); err == nil {
+		t.Error("RenderTo should reject unknown formats")
+	}
+}
@@ -0,0 +1,118 @@
+package figlet
+
+import (
+	"strings"
+	"time"
+)
+
+// FrameGrid is an animation frame as characters with a parallel layer of
+// colors. Unlike Frame, whose colors are baked into the text, and
+// StructuredFrame, whose colors are palette indexes, every cell holds its
+// own color, so effects such as fades can change colors frame by frame
+// before the frame is emitted.
+type FrameGrid struct {
+	// Chars holds one slice per line; lines can have different lengths
+	Chars [][]rune
+	// Colors has the shape of Chars, with nil for an uncolored cell
+	Colors         [][]Color
+	Delay          time.Duration
+	BaselineOffset int
+	Baseline       int
+}
+
+// GenerateGrids generates an animation like GenerateStructured and
+// returns its frames with the colors of the Animator's Palette
+func (a *Animator) GenerateGrids(text string, animType string, delay time.Duration) ([]FrameGrid, error) {
+	frames, err := a.GenerateStructured(text, animType, delay)
+	if err != nil {
+		return nil, err
+	}
+	palette := a.Palette()
+	grids := make([]FrameGrid, len(frames))
+	for i, f := range frames {
+		g := FrameGrid{
+			Chars:          make([][]rune, len(f.Cells)),
+			Colors:         make([][]Color, len(f.Cells)),
+			Delay:          f.Delay,
+			BaselineOffset: f.BaselineOffset,
+			Baseline:       f.Baseline,
+		}
+		for y, line := range f.Cells {
+			g.Chars[y] = make([]rune, len(line))
+			g.Colors[y] = make([]Color, len(line))
+			for x, cell := range line {
+				g.Chars[y][x] = cell.Char
+				if cell.Color >= 0 && cell.Color < len(palette) {
+					g.Colors[y][x] = palette[cell.Color]
+				}
+			}
+		}
+		grids[i] = g
+	}
+	return grids, nil
+}
+
+// Recolor returns a copy of the grid with every color replaced by
+// f(x, y, color), which may return nil to leave the cell uncolored.
+// Uncolored cells are passed as nil.
+func (g FrameGrid) Recolor(f func(x, y int, c Color) Color) FrameGrid {
+	out := g
+	out.Colors = make([][]Color, len(g.Chars))
+	for y, line := range g.Chars {
+		out.Colors[y] = make([]Color, len(line))
+		for x := range line {
+			var c Color
+			if y < len(g.Colors) && x < len(g.Colors[y]) {
+				c = g.Colors[y][x]
+			}
+			out.Colors[y][x] = f(x, y, c)
+		}
+	}
+	return out
+}
+
+// Emit returns the grid as text for parser, with its prefix, suffix and
+// replacements, like the Content of a generated Frame. The plain terminal
+// parser leaves out the colors.
+func (g FrameGrid) Emit(parser *OutputParser) string {
+	var sb strings.Builder
+	if parser != nil {
+		sb.WriteString(parser.Prefix)
+	}
+	colored := parser != nil && parser.Name != "terminal"
+	for y, line := range g.Chars {
+		var run colorrun
+		for x, r := range line {
+			s := string(r)
+			if parser != nil {
+				s = handleReplaces(s, parser)
+			}
+			var c Color
+			if y < len(g.Colors) && x < len(g.Colors[y]) {
+				c = g.Colors[y][x]
+			}
+			var prefix, suffix string
+			if colored && c != nil {
+				prefix, suffix = c.getPrefix(parser), c.getSuffix(parser)
+			}
+			run.write(&sb, prefix, s, suffix)
+		}
+		run.end(&sb)
+		sb.WriteString("\n")
+	}
+	if parser != nil {
+		sb.WriteString(parser.Suffix)
+	}
+	return sb.String()
+}
+
+// Frame returns the grid as a Frame emitted for parser, to play or export
+// with the functions taking frames
+func (g FrameGrid) Frame(parser *OutputParser) Frame {
+	return Frame{
+		Content:        g.Emit(parser),
+		Delay:          g.Delay,
+		BaselineOffset: g.BaselineOffset,
+		Baseline:       g.Baseline,
+	}
+}
@@ -0,0 +1,118 @@
+package figlet
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// fuzzFont loads a font from raw bytes, bypassing the filesystem
+func fuzzFont(data []byte) (*Config, error) {
+	cfg := New()
+	cfg.outlinelenlimit = cfg.Outputwidth - 1
+	cfg.font = &Font{}
+	if err := parsefont(cfg, &ZFILE{reader: bytes.NewReader(data)}); err != nil {
+		return nil, err
+	}
+	linealloc(cfg)
+	return cfg, nil
+}
+
+// FuzzReadFont feeds arbitrary bytes to the font parser and renders with the result
+func FuzzReadFont(f *testing.F) {
+	// Large seeds make minimization very slow, so only the start of each
+	// real font is used; the truncation also exercises the missing glyph path.
+	for _, name := range []string{"standard", "mini", "ivrit"} {
+		data, err := embeddedFonts.ReadFile("fonts/" + name + FONTFILESUFFIX)
+		if err != nil {
+			f.Fatal(err)
+		}
+		if len(data) > 2048 {
+			data = data[:2048]
+		}
+		f.Add(data)
+	}
+	f.Add([]byte("flf2a$ 2 1 8 -1 0\n @\n @@\n!@\n!@@\n"))
+	f.Add([]byte("flf2a$ 1 1 2 -1 0\n@\n@@\n"))
+	f.Add([]byte("flf2a$ 0 0 0 0 0 1 0 0\n"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		cfg, err := fuzzFont(data)
+		if err != nil {
+			return
+		}
+		_ = cfg.RenderString("Hello, World! \u00c4\u00e4")
+	})
+}
+
+// FuzzReadControl feeds arbitrary bytes to the control file parser
+func FuzzReadControl(f *testing.F) {
+	for _, name := range []string{"utf8", "upper", "8859-2", "jis0201", "hz", "frango"} {
+		data, err := embeddedFonts.ReadFile("fonts/" + name + CONTROLFILESUFFIX)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data, "ABC abc")
+	}
+	f.Add([]byte("flc2\ngl 9\ngr 7\n"), "\x0e\x8e\xa1x")
+	f.Add([]byte("flc2\nt \\-\\0x41 \\65\n"), "-A")
+
+	f.Fuzz(func(t *testing.T, data []byte, text string) {
+		cfg := New()
+		if err := cfg.LoadFont(); err != nil {
+			t.Fatal(err)
+		}
+		parsecontrol(cfg, &ZFILE{reader: bytes.NewReader(data)})
+		_ = cfg.RenderString(text)
+	})
+}
+
+// FuzzRenderString renders arbitrary input with different layout settings
+func FuzzRenderString(f *testing.F) {
+	f.Add("Hello", 80, 0, false)
+	f.Add("A\nB\tC", 5, 1, true)
+	f.Add("\x1b$(B\x0e\x8f~{~}", 1, 2, false)
+	f.Add("wrap these words please", 20, -1, true)
+
+	f.Fuzz(func(t *testing.T, text string, width int, smush int, rtl bool) {
+		if width < 0 || width > 1000 {
+			return
+		}
+		opts := []Option{WithWidth(width), WithSmushMode(smush)}
+		if rtl {
+			opts = append(opts, WithRightToLeft(1))
+		}
+		if _, err := Render(text, opts...); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// TestRaggedGlyphs renders glyphs with rows missing or of different
+// widths, which used to make smushamt and addchar index past the glyph
+func TestRaggedGlyphs(t *testing.T) {
+	for name, rows := range map[string][][]rune{
+		"short rows":  {[]rune("ab"), []rune("a"), []rune("abcd")},
+		"missing row": {[]rune("ab"), []rune("ab")},
+		"no rows":     nil,
+		"empty rows":  {{}, {}, {}},
+	} {
+		for _, rtl := range []bool{false, true} {
+			for _, mode := range []int{SM_KERN, SM_SMUSH | 63} {
+				cfg, err := fuzzFont([]byte("flf2a$ 3 2 8 -1 0\n"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				cfg.Smushmode = mode
+				if rtl {
+					cfg.Right2left = 1
+				}
+				cfg.font.glyphs = &FCharNode{ord: 'x', thechar: rows,
+					next: &FCharNode{ord: 'y', thechar: [][]rune{[]rune("/ "), []rune("| "), []rune("\\ ")}}}
+				if out := cfg.RenderString("xyxxy"); !strings.Contains(out, "|") {
+					t.Errorf("%s, rtl %v, mode %d: unexpected output %q", name, rtl, mode, out)
+				}
+			}
+		}
+	}
+}
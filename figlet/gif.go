@@ -0,0 +1,83 @@
+package figlet
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+	"time"
+)
+
+// WriteGIF rasterizes structured frames with the built-in bitmap font,
+// like the png format, and writes them as an animated GIF that loops
+// forever. Frames are drawn on a canvas big enough for all of them, each
+// moved up by its BaselineOffset like on a terminal. Colors come from the
+// Animator's Config; the palette holds at most 254 of them. Frame delays
+// follow the frames' Schedule, so rounding them to hundredths of a second
+// does not make long animations drift.
+func (a *Animator) WriteGIF(w io.Writer, frames []StructuredFrame) error {
+	images := a.frameimages(frames)
+	anim := &gif.GIF{Image: images}
+	delays := make([]time.Duration, len(frames))
+	for i, f := range frames {
+		delays[i] = f.Delay
+	}
+	// GIF delays are in hundredths of a second
+	for _, delay := range NewSchedule(delays).Delays(10 * time.Millisecond) {
+		anim.Delay = append(anim.Delay, int(delay/(10*time.Millisecond)))
+	}
+	if len(anim.Image) == 0 {
+		anim.Image = append(anim.Image, a.frameimages([]StructuredFrame{{}})[0])
+		anim.Delay = append(anim.Delay, 0)
+	}
+	return gif.EncodeAll(w, anim)
+}
+
+// frameimages rasterizes structured frames on canvases of the same size,
+// big enough for all of them, each moved up by its BaselineOffset
+func (a *Animator) frameimages(frames []StructuredFrame) []*image.Paletted {
+	maxoffset, width := 0, 1
+	for _, f := range frames {
+		maxoffset = max(maxoffset, f.BaselineOffset)
+		for _, line := range f.Cells {
+			width = max(width, len(line))
+		}
+	}
+	height := 1
+	for _, f := range frames {
+		height = max(height, maxoffset-f.BaselineOffset+len(f.Cells))
+	}
+
+	palette := color.Palette{color.White, color.Black}
+	colors := a.Palette()
+	inks := make([]uint8, len(colors))
+	for i, c := range colors {
+		inks[i] = 1
+		if tc, ok := RGB(c); ok {
+			img := &image.Paletted{Palette: palette}
+			inks[i] = inkindex(img, tc)
+			palette = img.Palette
+		}
+	}
+
+	images := make([]*image.Paletted, 0, len(frames))
+	bounds := image.Rect(0, 0, width*cellwidth, height*cellheight)
+	for _, f := range frames {
+		img := image.NewPaletted(bounds, palette)
+		top := maxoffset - f.BaselineOffset
+		for y, line := range f.Cells {
+			for x, cell := range line {
+				if cell.Char == ' ' || cell.Char == 0 {
+					continue
+				}
+				ink := uint8(1)
+				if cell.Color >= 0 && cell.Color < len(inks) {
+					ink = inks[cell.Color]
+				}
+				drawglyph(img, x, top+y, cell.Char, ink)
+			}
+		}
+		images = append(images, img)
+	}
+	return images
+}
@@ -0,0 +1,61 @@
+package figlet
+
+// Metadata describes a grid returned by RenderGrid
+type Metadata struct {
+	// Font is the name of the font used
+	Font string
+	// Width is the length of the longest row and Height the number of rows
+	Width, Height int
+	// CharHeight is the number of rows in each FIGlet line
+	CharHeight int
+	// Baselines lists the rows the characters of each FIGlet line stand on
+	Baselines []int
+	// Hardblank is the font's hardblank character
+	Hardblank rune
+	// Index holds, for every cell of the grid, the index of the input
+	// character it was drawn from, or -1 for justification, padding and
+	// speech bubbles
+	Index [][]int
+}
+
+// RenderGrid renders text and returns the output cells, one slice per row,
+// without colors or markup. Rows are laid out like RenderString lines:
+// justification spaces are included and, unless a padding mode is set,
+// rows are ragged on the right. Hardblanks become spaces unless
+// KeepHardblanks is set. Options apply to this call only.
+func (cfg *Config) RenderGrid(text string, opts ...RenderOption) ([][]rune, Metadata, error) {
+	var grid [][]rune
+	var meta Metadata
+	err := cfg.apply(opts, func() error {
+		rows := cfg.layoutrows(cfg.compose(text))
+		grid = make([][]rune, len(rows))
+		meta = Metadata{
+			Font:       cfg.Fontname,
+			Width:      blockwidth(rows),
+			Height:     len(rows),
+			CharHeight: cfg.lineheight(),
+			Baselines:  []int{},
+			Hardblank:  cfg.hardblank,
+			Index:      make([][]int, len(rows)),
+		}
+		for y, row := range rows {
+			width := row.pad + len(row.cells) + row.fill
+			cells, index := make([]rune, width), make([]int, width)
+			for x := range cells {
+				cells[x], index[x] = ' ', -1
+			}
+			for i, r := range row.cells {
+				cells[row.pad+i] = cfg.cellrune(r)
+				if i < len(row.index) && row.index[i] != nocolor {
+					index[row.pad+i] = row.index[i]
+				}
+			}
+			grid[y], meta.Index[y] = cells, index
+			if row.baseline {
+				meta.Baselines = append(meta.Baselines, y)
+			}
+		}
+		return nil
+	})
+	return grid, meta, err
+}
@@ -0,0 +1,120 @@
+package figlet
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// highlighted is the color index of the character lit by the highlight
+// animation, before it becomes an index into the Animator's Palette
+const highlighted = -3
+
+// highlighttiming is how long GenerateHighlight lights the characters:
+// each for its entry in timings if set, or for an even share of total
+type highlighttiming struct {
+	total   time.Duration
+	timings []time.Duration
+}
+
+// Palette returns the colors the Color of a structured frame's cells
+// refers to: Config.Colors, then the highlight color
+func (a *Animator) Palette() []Color {
+	colors := a.Config.Colors
+	return append(colors[:len(colors):len(colors)], a.highlightcolor())
+}
+
+// highlightcolor returns the HighlightColor, or its default
+func (a *Animator) highlightcolor() Color {
+	if a.HighlightColor == nil {
+		return ColorYellow
+	}
+	return a.HighlightColor
+}
+
+// GenerateHighlight generates the highlight animation, which lights one
+// character after the other with HighlightColor, for karaoke style
+// captions synced with audio or timers. timings gives how long each input
+// character stays lit, newlines excluded; without it, total is shared
+// evenly between them. The last frame shows the text without highlight.
+// The highlight needs a parser with colors, such as terminal-color or
+// html.
+func (a *Animator) GenerateHighlight(text string, total time.Duration, timings []time.Duration) ([]Frame, error) {
+	a.timing = &highlighttiming{total, timings}
+	defer func() {
+		a.timing = nil
+	}()
+	return a.GenerateAnimationContext(context.Background(), text, "highlight", 0)
+}
+
+// generateHighlight lights every input character for delay, or for its
+// time from GenerateHighlight
+func (a *Animator) generateHighlight(rows []string, maps, letters [][]int, delay time.Duration) ([]Frame, error) {
+	count := 0
+	for _, row := range letters {
+		for _, letter := range row {
+			count = max(count, letter+1)
+		}
+	}
+	durations := make([]time.Duration, count)
+	for i := range durations {
+		durations[i] = delay
+	}
+	if t := a.timing; t != nil && t.timings != nil {
+		if len(t.timings) != count {
+			return nil, fmt.Errorf("highlight: %d timings for %d characters", len(t.timings), count)
+		}
+		copy(durations, t.timings)
+	} else if t != nil && count > 0 {
+		for i := range durations {
+			// Spread the rounding so the total is exact
+			durations[i] = t.total*time.Duration(i+1)/time.Duration(count) - t.total*time.Duration(i)/time.Duration(count)
+		}
+	}
+
+	// Under a frame budget, neighbouring characters are lit together for
+	// their combined time, keeping a frame for the unlit text
+	groups := count
+	if a.MaxFrames > 0 && a.MaxFrames-1 < count {
+		groups = a.MaxFrames - 1
+	}
+	var rest time.Duration
+	if groups == 0 {
+		for _, d := range durations {
+			rest += d
+		}
+	}
+
+	frames := make([]Frame, 0, groups+1)
+	for g := 0; g <= groups; g++ {
+		if a.done() {
+			break
+		}
+		from, to := g*count/max(groups, 1), (g+1)*count/max(groups, 1)
+		if g == groups {
+			from, to = count, count
+		}
+		var sb framebuilder
+		for r, row := range rows {
+			rowMap := maps[r]
+			if from < to {
+				runes := []rune(row)
+				rowMap = make([]int, len(maps[r]))
+				for i, index := range maps[r] {
+					rowMap[i] = index
+					if l := letters[r][i]; i < len(runes) && runes[i] != ' ' && l >= from && l < to {
+						rowMap[i] = highlighted
+					}
+				}
+			}
+			a.appendStyledRange(&sb, row, rowMap, 0, len([]rune(row)))
+			a.endline(&sb)
+		}
+		d := rest
+		for _, lit := range durations[from:to] {
+			d += lit
+		}
+		frames = append(frames, a.createFrame(&sb, d, 0))
+	}
+	return frames, nil
+}
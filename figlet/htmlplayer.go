@@ -0,0 +1,161 @@
+package figlet
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// HTMLPlayerOptions configures the standalone player written by ExportHTML
+type HTMLPlayerOptions struct {
+	// Autoplay starts the animation when the page loads
+	Autoplay bool
+	// Loop restarts the animation after the last frame
+	Loop bool
+	// Controls shows a play/pause button and a speed slider
+	Controls bool
+	// Speed multiplies the playback speed; 0 means 1
+	Speed float64
+	// Theme is "dark" (the default when empty) or "light"
+	Theme string
+	// Title is the page title; empty means "FIGlet Animation"
+	Title string
+}
+
+// DefaultHTMLPlayerOptions returns the options PlayAnimation uses: a dark
+// page that plays in a loop as soon as it loads, without controls
+func DefaultHTMLPlayerOptions() HTMLPlayerOptions {
+	return HTMLPlayerOptions{Autoplay: true, Loop: true, Speed: 1, Theme: "dark"}
+}
+
+// playerthemes holds the page colors of each player theme
+var playerthemes = map[string]struct{ background, foreground, accent string }{
+	"dark":  {"#0c0c0c", "#cccccc", "#3a96dd"},
+	"light": {"#ffffff", "#222222", "#0063b1"},
+}
+
+// ExportHTML writes frames as a standalone HTML page that plays them.
+// Frames should come from an Animator whose Config uses the html parser,
+// so colors and spaces are already HTML. The frames are embedded as
+// deltas and decoded by the page.
+func (a *Animator) ExportHTML(w io.Writer, frames []Frame, opts HTMLPlayerOptions) error {
+	return writehtmlplayer(w, EncodeFrames(frames), opts)
+}
+
+// writehtmlplayer writes the standalone player for deltas
+func writehtmlplayer(w io.Writer, deltas []FrameDelta, opts HTMLPlayerOptions) error {
+	if opts.Theme == "" {
+		opts.Theme = "dark"
+	}
+	theme, ok := playerthemes[opts.Theme]
+	if !ok {
+		return fmt.Errorf("invalid player theme: %s (valid: dark, light)", opts.Theme)
+	}
+	if opts.Speed <= 0 {
+		opts.Speed = 1
+	}
+	if opts.Title == "" {
+		opts.Title = "FIGlet Animation"
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n")
+	sb.WriteString("<meta charset='utf-8'>\n")
+	sb.WriteString(fmt.Sprintf("<title>%s</title>\n", html.EscapeString(opts.Title)))
+	sb.WriteString("<style>\n")
+	sb.WriteString(fmt.Sprintf("  body { background: %s; color: %s; font-family: 'Cascadia Code', 'Ubuntu Mono', 'Roboto Mono', 'DejaVu Sans Mono', monospace; margin: 0; padding: 20px; overflow: auto; }\n",
+		theme.background, theme.foreground))
+	sb.WriteString("  #terminal { white-space: pre; line-height: 1.25; font-size: 14px; position: relative; cursor: pointer; }\n")
+	sb.WriteString(fmt.Sprintf("  #controls { display: flex; gap: 12px; align-items: center; margin-bottom: 16px; font-size: 13px; accent-color: %s; }\n", theme.accent))
+	sb.WriteString(fmt.Sprintf("  #controls button { font: inherit; color: inherit; background: none; border: 1px solid %s; border-radius: 4px; padding: 2px 10px; cursor: pointer; }\n", theme.accent))
+	sb.WriteString("</style>\n")
+	sb.WriteString("</head>\n<body>\n")
+	if opts.Controls {
+		sb.WriteString("<div id='controls'>\n")
+		sb.WriteString("  <button id='toggle'>Play</button>\n")
+		sb.WriteString(fmt.Sprintf("  <label>Speed <input id='speed' type='range' min='0.25' max='4' step='0.25' value='%g'></label>\n", opts.Speed))
+		sb.WriteString(fmt.Sprintf("  <span id='speedvalue'>%gx</span>\n", opts.Speed))
+		sb.WriteString("</div>\n")
+	}
+	sb.WriteString("<div id='terminal'></div>\n")
+	sb.WriteString("<script>\n")
+	sb.WriteString("  const frames = [\n")
+
+	for _, delta := range deltas {
+		sb.WriteString("    { c: [")
+		for i, change := range delta.Changes {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			// Escape backticks and backslashes for JS template literal
+			content := strings.ReplaceAll(change.Text, "\\", "\\\\")
+			content = strings.ReplaceAll(content, "`", "\\`")
+			content = strings.ReplaceAll(content, "${", "\\${")
+			content = strings.ReplaceAll(content, "</script", "<\\/script")
+			sb.WriteString(fmt.Sprintf("[%d, %d, `%s`]", change.Line, change.Column, content))
+		}
+		sb.WriteString(fmt.Sprintf("], l: %d, d: %d, o: %d },\n",
+			delta.Lines, delta.Delay.Milliseconds(), delta.BaselineOffset))
+	}
+
+	sb.WriteString("  ];\n")
+	sb.WriteString(fmt.Sprintf("  const LOOP = %t;\n", opts.Loop))
+	sb.WriteString("  const LINE_HEIGHT = 17.5;\n")
+	sb.WriteString("  const term = document.getElementById('terminal');\n")
+	sb.WriteString("  const toggle = document.getElementById('toggle');\n")
+	sb.WriteString("  const slider = document.getElementById('speed');\n")
+	sb.WriteString(fmt.Sprintf("  let speed = %g;\n", opts.Speed))
+	sb.WriteString("  let idx = 0;\n")
+	sb.WriteString("  let lines = [];\n")
+	sb.WriteString("  let timer = null;\n")
+	sb.WriteString("\n")
+	sb.WriteString("  function show(i) {\n")
+	sb.WriteString("    // Frames are deltas, so going back restarts from the first one\n")
+	sb.WriteString("    if (i === 0) lines = [];\n")
+	sb.WriteString("    const frame = frames[i];\n")
+	sb.WriteString("    lines.length = frame.l;\n")
+	sb.WriteString("    for (const [n, col, text] of frame.c) {\n")
+	sb.WriteString("      lines[n] = (col > 0 ? Array.from(lines[n] || '').slice(0, col).join('') : '') + text;\n")
+	sb.WriteString("    }\n")
+	sb.WriteString("    term.innerHTML = lines.join('\\n');\n")
+	sb.WriteString("    term.style.marginTop = (frame.o * LINE_HEIGHT) + 'px';\n")
+	sb.WriteString("  }\n")
+	sb.WriteString("  function tick() {\n")
+	sb.WriteString("    show(idx);\n")
+	sb.WriteString("    const delay = (frames[idx].d || 50) / speed;\n")
+	sb.WriteString("    idx++;\n")
+	sb.WriteString("    if (idx >= frames.length) {\n")
+	sb.WriteString("      if (!LOOP) { timer = null; idx = 0; update(); return; }\n")
+	sb.WriteString("      idx = 0;\n")
+	sb.WriteString("    }\n")
+	sb.WriteString("    timer = setTimeout(tick, delay);\n")
+	sb.WriteString("  }\n")
+	sb.WriteString("  function update() {\n")
+	sb.WriteString("    if (toggle) toggle.textContent = timer ? 'Pause' : 'Play';\n")
+	sb.WriteString("  }\n")
+	sb.WriteString("  function play() { if (!timer) { timer = setTimeout(tick, 0); update(); } }\n")
+	sb.WriteString("  function pause() { clearTimeout(timer); timer = null; update(); }\n")
+	sb.WriteString("  function toggleplay() { timer ? pause() : play(); }\n")
+	sb.WriteString("\n")
+	sb.WriteString("  term.addEventListener('click', toggleplay);\n")
+	sb.WriteString("  if (toggle) toggle.addEventListener('click', toggleplay);\n")
+	sb.WriteString("  if (slider) slider.addEventListener('input', () => {\n")
+	sb.WriteString("    speed = parseFloat(slider.value);\n")
+	sb.WriteString("    document.getElementById('speedvalue').textContent = speed + 'x';\n")
+	sb.WriteString("  });\n")
+	sb.WriteString("  if (frames.length > 0) {\n")
+	sb.WriteString("    show(0);\n")
+	if opts.Autoplay {
+		sb.WriteString("    play();\n")
+	} else {
+		sb.WriteString("    update();\n")
+	}
+	sb.WriteString("  }\n")
+	sb.WriteString("</script>\n")
+	sb.WriteString("</body>\n</html>\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,135 @@
+package figlet
+
+import (
+	"io"
+	"os"
+
+	"github.com/lsferreira42/figlet-go/internal/terminal"
+)
+
+// InteractiveOptions sets how PlayInteractive plays an animation
+type InteractiveOptions struct {
+	// Input is read for key presses; nil reads standard input, switched
+	// to raw mode while playing so keys work without Enter
+	Input io.Reader
+	// Output receives the frames; nil writes to standard output
+	Output io.Writer
+	// Loop restarts the animation after the last frame, until q is pressed
+	Loop bool
+	// Speed multiplies the playback speed; 0 means 1
+	Speed float64
+	// SkipLateFrames skips the frames whose time is already over when
+	// playback falls behind, like WithSkipLateFrames
+	SkipLateFrames bool
+}
+
+// Playback speeds reachable with + and -
+const (
+	minplayspeed = 1.0 / 16
+	maxplayspeed = 16
+)
+
+// PlayInteractive plays frames on the terminal like PlayAnimation and
+// lets the viewer control playback: space pauses and resumes, + (or =)
+// doubles the speed, - halves it, and q or Ctrl-C quits. A speed change
+// also applies to the frame on screen. When standard input is read and
+// raw mode is not available, as on a pipe, keys only arrive after Enter.
+// A read of standard input blocked when playback ends takes the next key.
+func PlayInteractive(frames []Frame, opts InteractiveOptions) {
+	if len(frames) == 0 {
+		return
+	}
+	in, out := opts.Input, opts.Output
+	if out == nil {
+		out = os.Stdout
+	}
+	if in == nil {
+		in = os.Stdin
+		if restore, err := terminal.EnableRaw(os.Stdin); err == nil {
+			defer restore()
+		}
+	}
+	speed := opts.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	keys := make(chan byte)
+	go readkeys(in, keys, done)
+
+	// Frames are drawn here, in the order the Player shows them
+	shown := make(chan Frame, 1)
+	ended := make(chan struct{})
+	player := NewPlayer(frames)
+	player.Loop = opts.Loop
+	player.SkipLateFrames = opts.SkipLateFrames
+	player.SetSpeed(speed)
+	player.OnFrame = func(_ int, frame Frame) {
+		select {
+		case shown <- frame:
+		case <-done:
+		}
+	}
+	player.OnEnd = func() { close(ended) }
+	defer player.Pause()
+
+	var enc deltaencoder
+	p := terminalplayer{w: out}
+	defer p.close()
+	player.Play()
+	for {
+		select {
+		case frame := <-shown:
+			p.draw(enc.encode(frame))
+		case <-ended:
+			// The last frame may still be waiting
+			select {
+			case frame := <-shown:
+				p.draw(enc.encode(frame))
+			default:
+			}
+			return
+		case key, ok := <-keys:
+			switch {
+			case !ok:
+				// No more keys: play on without controls
+				keys = nil
+				player.Play()
+			case key == 'q' || key == 'Q' || key == 3:
+				return
+			case key == ' ':
+				if player.Playing() {
+					player.Pause()
+				} else {
+					player.Play()
+				}
+			case key == '+' || key == '=':
+				player.SetSpeed(min(player.Speed()*2, maxplayspeed))
+			case key == '-' || key == '_':
+				player.SetSpeed(max(player.Speed()/2, minplayspeed))
+			}
+		}
+	}
+}
+
+// readkeys sends every byte read from r to keys, and closes keys when r
+// ends, until done is closed
+func readkeys(r io.Reader, keys chan<- byte, done <-chan struct{}) {
+	defer close(keys)
+	buf := make([]byte, 16)
+	for {
+		n, err := r.Read(buf)
+		for _, b := range buf[:n] {
+			select {
+			case keys <- b:
+			case <-done:
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
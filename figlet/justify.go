@@ -0,0 +1,100 @@
+package figlet
+
+import "sort"
+
+// JustifyAuto is the default Justification, resolved when the font is
+// loaded: flush-right for right-to-left text, flush-left otherwise, or
+// always flush-left with WithRTLRightJustify(false).
+const JustifyAuto = -1
+
+// JustifyFull is the Justification that stretches every wrapped line to
+// the output width, widening the spaces between its words, so both
+// margins line up in a paragraph. The last line of a paragraph, and a
+// line with a single word, stay flush-left.
+const JustifyFull = 3
+
+// wordgaps returns the columns of the current line where a word follows
+// a space, for full justification: extra blank columns go before them
+func (cfg *Config) wordgaps() []int {
+	// column returns the first column drawn for input character index
+	column := func(index int) int {
+		col := -1
+		for row := 0; row < cfg.charheight; row++ {
+			for k, n := range cfg.outputmap[row] {
+				if n == index {
+					if col < 0 || k < col {
+						col = k
+					}
+					break
+				}
+			}
+		}
+		return col
+	}
+
+	var gaps []int
+	for i := 1; i < cfg.inchrlinelen; i++ {
+		if cfg.inchrline[i] != ' ' || cfg.inchrline[i-1] == ' ' {
+			continue
+		}
+		next := i
+		for next < cfg.inchrlinelen && cfg.inchrline[next] == ' ' {
+			next++
+		}
+		if next == cfg.inchrlinelen {
+			break
+		}
+		// A space smushed away entirely leaves only the next word
+		col := column(cfg.inchrindex[i])
+		if col < 0 {
+			col = column(cfg.inchrindex[next])
+		}
+		if col > 0 {
+			gaps = append(gaps, col)
+		}
+	}
+	sort.Ints(gaps)
+	return gaps
+}
+
+// fulljustify widens the word gaps of the wrapped rows so they reach the
+// last column, Outputwidth-1. Leftmost gaps get the spare columns when
+// they can't be shared evenly.
+func (cfg *Config) fulljustify(rows []outputrow) []outputrow {
+	for n := range rows {
+		row := &rows[n]
+		extra := cfg.Outputwidth - 1 - cellswidth(row.cells)
+		if len(row.gaps) == 0 || extra <= 0 {
+			continue
+		}
+		cells := make([]rune, 0, len(row.cells)+extra)
+		var index []int
+		if row.index != nil {
+			index = make([]int, 0, len(row.cells)+extra)
+		}
+		from := 0
+		for g, gap := range row.gaps {
+			add := extra / len(row.gaps)
+			if g < extra%len(row.gaps) {
+				add++
+			}
+			cells = append(cells, row.cells[from:gap]...)
+			if index != nil {
+				index = append(index, row.index[from:min(gap, len(row.index))]...)
+			}
+			for ; add > 0; add-- {
+				cells = append(cells, ' ')
+				if index != nil {
+					index = append(index, nocolor)
+				}
+			}
+			from = gap
+		}
+		cells = append(cells, row.cells[from:]...)
+		if index != nil {
+			index = append(index, row.index[min(from, len(row.index)):]...)
+		}
+		row.cells, row.index = cells, index
+	}
+	return rows
+}
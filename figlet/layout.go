@@ -0,0 +1,129 @@
+package figlet
+
+import "strings"
+
+// LayoutOptions sets how Layout and RenderLayout arrange blocks
+type LayoutOptions struct {
+	// Columns is the most blocks on a row; zero puts them all on one row
+	// unless Width is exceeded
+	Columns int
+	// Gutter is the number of spaces between columns; negative is 0
+	Gutter int
+	// RowGap is the number of blank lines between rows; negative is 0
+	RowGap int
+	// Width is the widest the layout may be; zero is no limit. Fewer
+	// columns are used until the layout fits, down to one.
+	Width int
+}
+
+// Layout arranges plain text blocks, such as rendered banners, on a grid,
+// left to right and then in rows. Each column is as wide as its widest
+// block and each row as high as its highest, and blocks are aligned to the
+// top left of their cell. Blocks are measured with DisplayWidth, so they
+// may hold ANSI colors; use RenderLayout for other markup.
+func Layout(blocks []string, opts LayoutOptions) string {
+	lines := make([][]string, len(blocks))
+	widths := make([]int, len(blocks))
+	for i, block := range blocks {
+		lines[i] = strings.Split(strings.TrimSuffix(block, "\n"), "\n")
+		for j, line := range lines[i] {
+			line = strings.TrimRight(line, " ")
+			lines[i][j] = line
+			widths[i] = max(widths[i], DisplayWidth(line))
+		}
+		// Pad every line to the block width, like PadBlock
+		for j, line := range lines[i] {
+			lines[i][j] = line + strings.Repeat(" ", widths[i]-DisplayWidth(line))
+		}
+	}
+	return layout(lines, widths, opts)
+}
+
+// RenderLayout renders every text with the Config and arranges the banners
+// like Layout, measuring them as Measure does, so colored output lines up
+// too. The output parser must write one line per output line, as the
+// terminal parsers do. Colored lines keep the spaces padding their banner.
+func (cfg *Config) RenderLayout(texts []string, opts LayoutOptions) (string, error) {
+	lines := make([][]string, len(texts))
+	widths := make([]int, len(texts))
+	for i, text := range texts {
+		var err error
+		if lines[i], widths[i], err = cfg.renderblock(text); err != nil {
+			return "", err
+		}
+	}
+	return layout(lines, widths, opts), nil
+}
+
+// renderblock renders text with every line padded to the width of the
+// widest, and returns the lines and that width
+func (cfg *Config) renderblock(text string) ([]string, int, error) {
+	var out string
+	var width int
+	err := cfg.apply([]RenderOption{WithPadding(PadBlock)}, func() error {
+		rows := cfg.layoutrows(cfg.compose(text))
+		width = blockwidth(rows)
+		out = cfg.emit(rows)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return strings.Split(strings.TrimSuffix(out, "\n"), "\n"), width, nil
+}
+
+// layout arranges blocks of lines all as wide as the block's width
+func layout(blocks [][]string, widths []int, opts LayoutOptions) string {
+	if len(blocks) == 0 {
+		return ""
+	}
+	opts.Gutter, opts.RowGap = max(opts.Gutter, 0), max(opts.RowGap, 0)
+	cols := opts.Columns
+	if cols <= 0 || cols > len(blocks) {
+		cols = len(blocks)
+	}
+	var colwidths []int
+	for ; cols >= 1; cols-- {
+		colwidths = make([]int, cols)
+		for i, w := range widths {
+			colwidths[i%cols] = max(colwidths[i%cols], w)
+		}
+		total := (cols - 1) * opts.Gutter
+		for _, w := range colwidths {
+			total += w
+		}
+		if opts.Width <= 0 || total <= opts.Width || cols == 1 {
+			break
+		}
+	}
+
+	var sb strings.Builder
+	gutter := strings.Repeat(" ", opts.Gutter)
+	for top := 0; top < len(blocks); top += cols {
+		if top > 0 {
+			sb.WriteString(strings.Repeat("\n", opts.RowGap))
+		}
+		row := blocks[top:min(top+cols, len(blocks))]
+		height := 0
+		for _, block := range row {
+			height = max(height, len(block))
+		}
+		for y := 0; y < height; y++ {
+			var line strings.Builder
+			for c, block := range row {
+				if c > 0 {
+					line.WriteString(gutter)
+				}
+				pad := colwidths[c]
+				if y < len(block) {
+					line.WriteString(block[y])
+					pad -= widths[top+c]
+				}
+				line.WriteString(strings.Repeat(" ", pad))
+			}
+			sb.WriteString(strings.TrimRight(line.String(), " "))
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
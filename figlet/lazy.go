@@ -0,0 +1,138 @@
+package figlet
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"sync"
+)
+
+// lazyglyphs indexes the code-tagged characters of a font loaded with
+// LazyGlyphs. Each character is parsed the first time it is looked up.
+// Parsed characters are kept here rather than in the font's list, which
+// is never changed once loaded.
+type lazyglyphs struct {
+	mu      sync.Mutex          // guards loaded and arena
+	data    []byte              // the font file from the first code tag on
+	offsets map[rune]lazyoffset // where each character's rows start
+	loaded  map[rune]*FCharNode // characters parsed so far
+	arena   *glypharena
+	height  int // rows of a character in the font file
+	top     int // blank rows added above each character, see loadfallbacks
+}
+
+// lazyoffset locates the rows of a character in lazyglyphs.data
+type lazyoffset struct {
+	pos  int
+	line int // line number of the first row, minus one
+}
+
+// WithLazyGlyphs defers parsing the code-tagged characters of a font until
+// they are first rendered. LoadFont gets much faster for big Unicode fonts
+// when the input is mostly ASCII.
+func WithLazyGlyphs() Option {
+	return func(cfg *Config) {
+		cfg.LazyGlyphs = true
+	}
+}
+
+// indexglyphs records where each code-tagged character starts, skipping
+// over its rows without parsing them
+func (f *Font) indexglyphs(fontfile *ZFILE, arena *glypharena) {
+	var rest []byte
+	if fontfile.pos < len(fontfile.buffer) {
+		rest = append(rest, fontfile.buffer[fontfile.pos:]...)
+	}
+	if !fontfile.eof {
+		more, _ := io.ReadAll(fontfile.reader)
+		rest = append(rest, more...)
+	}
+	lazy := &lazyglyphs{
+		data:    rest,
+		offsets: make(map[rune]lazyoffset),
+		loaded:  make(map[rune]*FCharNode),
+		arena:   arena,
+		height:  f.height,
+	}
+	f.lazy = lazy
+
+	pos, line := 0, fontfile.line
+	nextline := func() []byte {
+		if pos >= len(rest) {
+			return nil
+		}
+		start := pos
+		end := bytes.IndexAny(rest[pos:], "\r\n")
+		if end < 0 {
+			pos = len(rest)
+			return rest[start:]
+		}
+		pos += end + 1
+		if rest[pos-1] == '\r' && pos < len(rest) && rest[pos] == '\n' {
+			pos++
+		}
+		line++
+		return rest[start : start+end]
+	}
+	for {
+		tag := nextline()
+		if tag == nil {
+			return
+		}
+		theord, ok := parsecodetag(tag)
+		if !ok {
+			return
+		}
+		lazy.offsets[theord] = lazyoffset{pos: pos, line: line}
+		for row := 0; row < f.height; row++ {
+			if nextline() == nil {
+				return
+			}
+		}
+	}
+}
+
+// lazyglyph returns the code-tagged character c, parsing it on first use,
+// or nil if c was not deferred
+func (f *Font) lazyglyph(c rune) *FCharNode {
+	lazy := f.lazy
+	if lazy == nil {
+		return nil
+	}
+	lazy.mu.Lock()
+	defer lazy.mu.Unlock()
+	if node, ok := lazy.loaded[c]; ok {
+		return node
+	}
+	off, ok := lazy.offsets[c]
+	if !ok {
+		return nil
+	}
+	file := &ZFILE{reader: bytes.NewReader(lazy.data[off.pos:]), line: off.line}
+	node, complete := readfontchar(f, lazy.arena, file, c, lazy.height, nil)
+	if !complete {
+		f.warn(WarnMissingGlyph, c, file.line,
+			"character %d is truncated by the end of the file", c)
+	}
+	if lazy.height != f.height {
+		node.thechar = padglyph(node.thechar, lazy.top, f.height)
+	}
+	lazy.loaded[c] = node
+	return node
+}
+
+// loadglyphs parses every character still deferred by LazyGlyphs
+func (f *Font) loadglyphs() {
+	if f.lazy == nil {
+		return
+	}
+	offsets := f.lazy.offsets
+	codes := make([]rune, 0, len(offsets))
+	for c := range offsets {
+		codes = append(codes, c)
+	}
+	sort.Slice(codes, func(i, j int) bool { return offsets[codes[i]].pos < offsets[codes[j]].pos })
+	for _, c := range codes {
+		f.lazyglyph(c)
+	}
+}
@@ -0,0 +1,146 @@
+package figlet
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Timing of the per-letter animations, in frames: each letter starts
+// letterstagger frames after the previous one and takes letterframes
+// frames to arrive
+const (
+	letterstagger = 2
+	letterframes  = 8
+)
+
+// lettercell is a visible cell of the rendered text
+type lettercell struct {
+	char     rune
+	color    int
+	row, col int
+}
+
+// generateLetters animates every input character on its own, one after
+// the other: "drop" drops them in from above, "slide" slides them in from
+// alternating sides and "spin" spins them in around their middle. Cells
+// drawn from no input character, such as a border, appear with the last
+// frame.
+func (a *Animator) generateLetters(kind string, rows []string, maps, letters [][]int, delay time.Duration) []Frame {
+	height, width := len(rows), 0
+	groups := make(map[int][]lettercell)
+	var rest []lettercell
+	for r, row := range rows {
+		runes := []rune(row)
+		width = max(width, len(runes))
+		for c, char := range runes {
+			if char == ' ' {
+				continue
+			}
+			cell := lettercell{char, -1, r, c}
+			if c < len(maps[r]) {
+				cell.color = maps[r][c]
+			}
+			if c < len(letters[r]) && letters[r][c] >= 0 {
+				groups[letters[r][c]] = append(groups[letters[r][c]], cell)
+			} else {
+				rest = append(rest, cell)
+			}
+		}
+	}
+	order := make([]int, 0, len(groups))
+	for letter := range groups {
+		order = append(order, letter)
+	}
+	sort.Ints(order)
+
+	numFrames := max(len(order)-1, 0)*letterstagger + letterframes + 1
+	steps, delay := framesteps(numFrames, a.MaxFrames, delay)
+	frames := make([]Frame, 0, len(steps))
+	mirror := MirrorH.swaps()
+
+	for _, f := range steps {
+		if a.done() {
+			break
+		}
+		grid := make([][]rune, height)
+		gridMap := make([][]int, height)
+		for i := range grid {
+			grid[i] = make([]rune, width)
+			gridMap[i] = make([]int, width)
+			for j := range grid[i] {
+				grid[i][j] = ' '
+				gridMap[i][j] = -1
+			}
+		}
+		put := func(cell lettercell, r, c int) {
+			if r >= 0 && r < height && c >= 0 && c < width {
+				grid[r][c] = cell.char
+				gridMap[r][c] = cell.color
+			}
+		}
+
+		for k, letter := range order {
+			t := float64(f-k*letterstagger) / letterframes
+			if t <= 0 {
+				continue
+			}
+			t = math.Min(t, 1)
+			// Ease out, so letters slow down as they land
+			e := 1 - (1-t)*(1-t)
+			cells := groups[letter]
+			switch kind {
+			case "drop":
+				dy := int(math.Round((1 - e) * float64(height)))
+				for _, cell := range cells {
+					put(cell, cell.row-dy, cell.col)
+				}
+			case "slide":
+				dx := int(math.Round((1 - e) * float64(width)))
+				if k%2 == 0 {
+					dx = -dx
+				}
+				for _, cell := range cells {
+					put(cell, cell.row, cell.col+dx)
+				}
+			case "spin":
+				// The letter turns three quarters around its middle, facing
+				// backwards halfway, so its width follows a sine
+				scale := -math.Sin(1.5 * math.Pi * e)
+				if t == 1 {
+					scale = 1
+				}
+				left, right := cells[0].col, cells[0].col
+				for _, cell := range cells {
+					left, right = min(left, cell.col), max(right, cell.col)
+				}
+				middle := float64(left+right) / 2
+				for _, cell := range cells {
+					if scale < 0 {
+						if s, ok := mirror[cell.char]; ok {
+							cell.char = s
+						}
+					}
+					put(cell, cell.row, int(math.Round(middle+(float64(cell.col)-middle)*scale)))
+				}
+			}
+		}
+		if f == numFrames-1 {
+			for _, cell := range rest {
+				put(cell, cell.row, cell.col)
+			}
+		}
+
+		var sb framebuilder
+		for r, gridRow := range grid {
+			trimmedRow := strings.TrimRight(string(gridRow), " ")
+			runes := []rune(trimmedRow)
+			a.appendStyledRange(&sb, trimmedRow, gridMap[r][:len(runes)], 0, len(runes))
+			a.endline(&sb)
+		}
+		frames = append(frames, a.createFrame(&sb, delay, 0))
+	}
+
+	return frames
+}
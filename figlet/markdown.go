@@ -0,0 +1,161 @@
+package figlet
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ExpandMarkdown replaces every fenced code block tagged figlet in a
+// Markdown or MDX document with a plain fenced block holding its text
+// rendered as a banner, so docs can be generated from a source with
+// banners that are always up to date:
+//
+//	```figlet {font=slant width=60 justify=center}
+//	Hello
+//	```
+//
+// The attributes are optional: font, width, and justify (left, center or
+// right). Each line of the block is a line of the banner. Rendering uses
+// options first, then the attributes, and drops trailing spaces and the
+// blank rows at the top and bottom. Other fenced blocks, including ones
+// showing a figlet block, are copied as they are.
+func ExpandMarkdown(src string, options ...Option) (string, error) {
+	lines := strings.SplitAfter(src, "\n")
+	var sb strings.Builder
+	for i := 0; i < len(lines); i++ {
+		indent, fence, info, ok := mdfence(lines[i])
+		if !ok {
+			sb.WriteString(lines[i])
+			continue
+		}
+		// Find the closing fence; a block left open runs to the end
+		end := i + 1
+		for end < len(lines) && !mdclosing(lines[end], fence) {
+			end++
+		}
+		lang, attrs := info, ""
+		if k := strings.IndexAny(info, " \t{"); k >= 0 {
+			lang, attrs = info[:k], info[k:]
+		}
+		if lang != "figlet" {
+			for _, line := range lines[i:min(end+1, len(lines))] {
+				sb.WriteString(line)
+			}
+			i = end
+			continue
+		}
+
+		if end == len(lines) {
+			return "", fmt.Errorf("line %d: figlet block is not closed", i+1)
+		}
+		opts, err := mdattrs(attrs)
+		if err != nil {
+			return "", fmt.Errorf("line %d: %w", i+1, err)
+		}
+		var text []string
+		for _, line := range lines[i+1 : end] {
+			line = strings.TrimRight(line, "\r\n")
+			text = append(text, strings.TrimPrefix(line, indent))
+		}
+		opts = append(append([]Option{WithTrimWhitespace(TrimTrailing | TrimBlankRows)}, options...), opts...)
+		art, err := Render(strings.Join(text, "\n"), opts...)
+		if err != nil {
+			return "", fmt.Errorf("line %d: %w", i+1, err)
+		}
+		art = strings.TrimSuffix(art, "\n")
+
+		// The new fence must be longer than any run of backticks in the art
+		longest, run := 2, 0
+		for _, r := range art {
+			if r == '`' {
+				run++
+				longest = max(longest, run)
+			} else {
+				run = 0
+			}
+		}
+		newfence := indent + strings.Repeat("`", longest+1)
+		sb.WriteString(newfence + "\n")
+		for _, line := range strings.Split(art, "\n") {
+			if line != "" {
+				line = indent + line
+			}
+			sb.WriteString(line + "\n")
+		}
+		sb.WriteString(newfence)
+		if strings.HasSuffix(lines[end], "\n") {
+			sb.WriteString("\n")
+		}
+		i = end
+	}
+	return sb.String(), nil
+}
+
+// mdfence reports whether line opens a fenced code block, returning its
+// indentation, fence and info string
+func mdfence(line string) (indent, fence, info string, ok bool) {
+	line = strings.TrimRight(line, "\r\n")
+	rest := strings.TrimLeft(line, " ")
+	if len(line)-len(rest) > 3 || rest == "" || (rest[0] != '`' && rest[0] != '~') {
+		return "", "", "", false
+	}
+	n := len(rest) - len(strings.TrimLeft(rest, rest[:1]))
+	if n < 3 {
+		return "", "", "", false
+	}
+	info = strings.TrimSpace(rest[n:])
+	if rest[0] == '`' && strings.Contains(info, "`") {
+		return "", "", "", false
+	}
+	return line[:len(line)-len(rest)], rest[:n], info, true
+}
+
+// mdclosing reports whether line closes a block opened with fence
+func mdclosing(line, fence string) bool {
+	rest := strings.TrimLeft(strings.TrimRight(line, "\r\n"), " ")
+	if len(strings.TrimRight(line, "\r\n"))-len(rest) > 3 {
+		return false
+	}
+	rest = strings.TrimRight(rest, " \t")
+	return len(rest) >= len(fence) && strings.Trim(rest, fence[:1]) == ""
+}
+
+// mdattrs parses the attributes of a figlet block, such as
+// {font=slant width=60}
+func mdattrs(s string) ([]Option, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(s, "{") || !strings.HasSuffix(s, "}") {
+		return nil, fmt.Errorf("invalid figlet attributes %q", s)
+	}
+	var opts []Option
+	for _, field := range strings.FieldsFunc(s[1:len(s)-1], func(r rune) bool { return r == ' ' || r == ',' }) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid figlet attribute %q", field)
+		}
+		switch key {
+		case "font":
+			opts = append(opts, WithFont(value))
+		case "width":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid width %q", value)
+			}
+			opts = append(opts, WithWidth(n))
+		case "justify":
+			j := map[string]int{"left": 0, "center": 1, "right": 2}
+			n, ok := j[value]
+			if !ok {
+				return nil, fmt.Errorf("invalid justify %q (valid: left, center, right)", value)
+			}
+			opts = append(opts, WithJustification(n))
+		default:
+			return nil, fmt.Errorf("unknown figlet attribute %q (valid: font, width, justify)", key)
+		}
+	}
+	return opts, nil
+}
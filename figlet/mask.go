@@ -0,0 +1,143 @@
+package figlet
+
+import (
+	"image"
+	"image/color"
+)
+
+// MaskOptions configures how RenderMasked and RenderImageCaption turn an
+// image into a grid of lit and unlit cells
+type MaskOptions struct {
+	// Width is the number of cells across; 0 means one cell per pixel
+	Width int
+	// Height is the number of rows. 0 keeps the aspect ratio of the image
+	// for cells twice as tall as wide, or means one row per pixel when
+	// Width is 0 as well.
+	Height int
+	// Threshold is the brightness, from 0 to 255, from which a pixel is
+	// lit; 0 means 128. Transparent pixels are never lit.
+	Threshold uint8
+	// Invert lights the dark pixels instead, for a dark logo on a light
+	// background
+	Invert bool
+	// Fill draws the lit cells of RenderImageCaption; 0 means '#'
+	Fill rune
+	// Gap is the number of blank rows between an image and its caption
+	Gap int
+}
+
+// RenderMasked fills the lit pixels of mask with copies of text rendered
+// in the current font, tiled across the image, and leaves the rest blank,
+// so the art takes the shape of the image. Colors follow the characters
+// of text as usual. Options apply to this call only, as with RenderString.
+func (cfg *Config) RenderMasked(text string, mask image.Image, mo MaskOptions, opts ...RenderOption) (string, error) {
+	var out string
+	err := cfg.apply(opts, func() error {
+		out = cfg.emit(cfg.masked(cfg.compose(text), maskgrid(mask, mo)))
+		return nil
+	})
+	return out, err
+}
+
+// RenderImageCaption draws the lit pixels of img with the Fill character,
+// with caption rendered in the current font under it. The image is never
+// colored; the caption keeps its colors. Options apply to this call only.
+func (cfg *Config) RenderImageCaption(img image.Image, caption string, mo MaskOptions, opts ...RenderOption) (string, error) {
+	fill := mo.Fill
+	if fill == 0 {
+		fill = '#'
+	}
+	var art []outputrow
+	for _, lit := range maskgrid(img, mo) {
+		cells := make([]rune, len(lit))
+		for x, on := range lit {
+			cells[x] = ' '
+			if on {
+				cells[x] = fill
+			}
+		}
+		art = append(art, plainrow(cells))
+	}
+	var out string
+	err := cfg.apply(opts, func() error {
+		var rows []outputrow
+		if caption != "" {
+			rows = cfg.layoutrows(cfg.compose(caption))
+		}
+		out = cfg.emit(cfg.canvas(rows, art, QRAbove, mo.Gap))
+		return nil
+	})
+	return out, err
+}
+
+// maskgrid samples img at the center of every cell and reports which
+// cells are lit
+func maskgrid(img image.Image, mo MaskOptions) [][]bool {
+	b := img.Bounds()
+	if b.Empty() {
+		return nil
+	}
+	cols, rows := mo.Width, mo.Height
+	switch {
+	case cols <= 0 && rows <= 0:
+		cols, rows = b.Dx(), b.Dy()
+	case cols <= 0:
+		cols = max(1, b.Dx()*rows*2/b.Dy())
+	case rows <= 0:
+		rows = max(1, b.Dy()*cols/b.Dx()/2)
+	}
+	threshold := int(mo.Threshold)
+	if threshold == 0 {
+		threshold = 128
+	}
+
+	grid := make([][]bool, rows)
+	for y := range grid {
+		grid[y] = make([]bool, cols)
+		py := b.Min.Y + (2*y+1)*b.Dy()/(2*rows)
+		for x := range grid[y] {
+			px := b.Min.X + (2*x+1)*b.Dx()/(2*cols)
+			c := color.NRGBAModel.Convert(img.At(px, py)).(color.NRGBA)
+			if c.A < 128 {
+				continue
+			}
+			bright := (299*int(c.R)+587*int(c.G)+114*int(c.B))/1000 >= threshold
+			grid[y][x] = bright != mo.Invert
+		}
+	}
+	return grid
+}
+
+// masked tiles composed rows over the lit cells of a grid. A blank column
+// separates the copies across.
+func (cfg *Config) masked(rows []outputrow, grid [][]bool) []outputrow {
+	width := blockwidth(rows) + 1
+	out := make([]outputrow, len(grid))
+	for y, lit := range grid {
+		cells, index := make([]rune, len(lit)), make([]int, len(lit))
+		for x, on := range lit {
+			cells[x], index[x] = ' ', nocolor
+			if !on || len(rows) == 0 {
+				continue
+			}
+			row := rows[y%len(rows)]
+			if col := x % width; col < len(row.cells) {
+				cells[x], index[x] = row.cells[col], -1
+				if col < len(row.index) {
+					index[x] = row.index[col]
+				}
+			}
+		}
+		out[y] = outputrow{cells: cells, index: index}
+	}
+	if cfg.Pad != PadNone {
+		width := blockwidth(out)
+		if cfg.Pad == PadOutputWidth {
+			width = max(width, cfg.Outputwidth-1)
+		}
+		for n := range out {
+			out[n].fill = max(0, width-len(out[n].cells))
+		}
+	}
+	return out
+}
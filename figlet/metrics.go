@@ -0,0 +1,30 @@
+package figlet
+
+import "time"
+
+// Metrics receives measurements of rendering and font loading, to be
+// exported to Prometheus, OpenTelemetry or a log. Its methods are called
+// from the goroutine that renders or loads, so an implementation shared
+// by several Configs must be safe for concurrent use.
+type Metrics interface {
+	// ObserveRender is called after every render with how long it took,
+	// the number of input characters and the font name
+	ObserveRender(d time.Duration, chars int, font string)
+	// ObserveFontLoad is called after every font load with how long it
+	// took, the font name and the error, if it failed
+	ObserveFontLoad(d time.Duration, font string, err error)
+}
+
+// WithMetrics reports how long renders and font loads take to m
+func WithMetrics(m Metrics) Option {
+	return func(cfg *Config) {
+		cfg.Metrics = m
+	}
+}
+
+// observeload reports a font load that started at start
+func observeload(m Metrics, start time.Time, font string, err error) {
+	if m != nil {
+		m.ObserveFontLoad(time.Since(start), font, err)
+	}
+}
@@ -0,0 +1,528 @@
+package figlet
+
+import "strings"
+
+// TrimMode selects which whitespace is removed from rendered output.
+// Modes can be combined with |.
+type TrimMode int
+
+const (
+	// TrimTrailing removes trailing spaces from every output line
+	TrimTrailing TrimMode = 1 << iota
+	// TrimBlankRows removes fully blank rows at the top and bottom of the output
+	TrimBlankRows
+	// TrimLeftMargin removes the blank columns (usually hardblanks) shared by
+	// all rows of each FIGlet line, before justification is applied
+	TrimLeftMargin
+	// TrimBlockMargin removes the blank columns shared by all rows of the
+	// output, so fonts padding their glyphs with hardblanks leave no
+	// gutter while lines keep their indentation relative to each other.
+	// Like TrimLeftMargin it is applied before justification, so centered
+	// and right-justified output keeps its alignment.
+	TrimBlockMargin
+
+	// TrimAll enables every trim mode
+	TrimAll = TrimTrailing | TrimBlankRows | TrimLeftMargin | TrimBlockMargin
+)
+
+// PadMode selects how output lines are padded on the right
+type PadMode int
+
+const (
+	// PadNone leaves lines ragged on the right (default)
+	PadNone PadMode = iota
+	// PadBlock pads every line to the width of the widest line in the output
+	PadBlock
+	// PadOutputWidth pads every line to Outputwidth-1, the widest line
+	// FIGlet produces for the configured width
+	PadOutputWidth
+)
+
+// WithTrimWhitespace sets which whitespace is trimmed from the output
+func WithTrimWhitespace(mode TrimMode) Option {
+	return func(cfg *Config) {
+		cfg.Trim = mode
+	}
+}
+
+// WithPadding pads output lines so they all have the same width
+func WithPadding(mode PadMode) Option {
+	return func(cfg *Config) {
+		cfg.Pad = mode
+	}
+}
+
+// WithKeepHardblanks emits hardblanks instead of converting them to spaces.
+// If substitute is 0 the font's own hardblank character is used.
+func WithKeepHardblanks(substitute rune) Option {
+	return func(cfg *Config) {
+		cfg.KeepHardblanks = true
+		cfg.HardblankSubstitute = substitute
+	}
+}
+
+// outputrow is one composed output line, kept until the whole block is known
+type outputrow struct {
+	cells []rune // glyph cells, hardblanks not yet resolved
+	index []int  // input character index for each cell, nil for positional colors
+	pad   int    // leading spaces added by justification
+	fill  int    // trailing spaces added by padding
+	// baseline marks the row the characters of its FIGlet line stand on
+	baseline bool
+	// gaps are the columns where words start in a wrapped line, for
+	// JustifyFull
+	gaps []int
+}
+
+// colorindex returns the color index for cell i of the row, counting the
+// justification spaces, or -1 if the cell must not be colored
+func (row outputrow) colorindex(i int) int {
+	i -= row.pad
+	if i < 0 || i >= len(row.cells) {
+		return -1
+	}
+	if i < len(row.index) {
+		if row.index[i] >= 0 {
+			return row.index[i]
+		}
+		if row.index[i] == nocolor {
+			return -1
+		}
+	}
+	// If we couldn't map to an input character, use position-based cycling
+	return i
+}
+
+// flatten returns the row as cells only: the justification spaces past
+// column from become cells that are never colored, and every cell gets an
+// explicit color index, so the row can be moved or combined with others
+func (row outputrow) flatten(from int) outputrow {
+	n := max(0, row.pad-from)
+	out := outputrow{
+		cells:    make([]rune, 0, n+len(row.cells)),
+		index:    make([]int, 0, n+len(row.cells)),
+		baseline: row.baseline,
+	}
+	for i := 0; i < n; i++ {
+		out.cells, out.index = append(out.cells, ' '), append(out.index, nocolor)
+	}
+	for i, r := range row.cells {
+		out.cells = append(out.cells, r)
+		if i < len(row.index) && (row.index[i] >= 0 || row.index[i] == nocolor) {
+			out.index = append(out.index, row.index[i])
+		} else {
+			// Positional colors count from the first glyph cell
+			out.index = append(out.index, i)
+		}
+	}
+	return out
+}
+
+// text returns the row as plain text, including justification and padding
+func (cfg *Config) text(row outputrow) string {
+	var sb strings.Builder
+	sb.WriteString(strings.Repeat(" ", row.pad))
+	for _, r := range row.cells {
+		sb.WriteString(cfg.cellstring(r))
+	}
+	sb.WriteString(strings.Repeat(" ", row.fill))
+	return sb.String()
+}
+
+// isblank reports whether a cell renders as a space
+func (cfg *Config) isblank(r rune) bool {
+	return r == ' ' || (r == cfg.hardblank && !cfg.KeepHardblanks)
+}
+
+// cellstring returns the text for a cell, resolving hardblanks
+func (cfg *Config) cellstring(r rune) string {
+	return string(cfg.cellrune(r))
+}
+
+// cellrune resolves a hardblank cell to a space, or keeps it when
+// KeepHardblanks is set
+func (cfg *Config) cellrune(r rune) rune {
+	if r != cfg.hardblank {
+		return r
+	}
+	if !cfg.KeepHardblanks {
+		return ' '
+	}
+	if cfg.HardblankSubstitute != 0 {
+		return cfg.HardblankSubstitute
+	}
+	return r
+}
+
+// trimrows applies the configured trim modes to the buffered rows
+func (cfg *Config) trimrows(rows []outputrow) []outputrow {
+	if height := cfg.lineheight(); cfg.Trim&TrimLeftMargin != 0 && height > 0 {
+		for start := 0; start < len(rows); start += height {
+			end := start + height
+			if end > len(rows) {
+				end = len(rows)
+			}
+			margin := cfg.leftmargin(rows[start:end])
+			if margin <= 0 {
+				continue
+			}
+			for i := start; i < end; i++ {
+				rows[i] = rows[i].slice(margin, len(rows[i].cells))
+			}
+		}
+	}
+
+	if cfg.Trim&TrimBlockMargin != 0 {
+		if margin := cfg.leftmargin(rows); margin > 0 {
+			for i := range rows {
+				rows[i] = rows[i].slice(margin, len(rows[i].cells))
+			}
+		}
+	}
+
+	if cfg.Trim&TrimBlankRows != 0 {
+		blank := func(row outputrow) bool {
+			for _, r := range row.cells {
+				if !cfg.isblank(r) {
+					return false
+				}
+			}
+			return true
+		}
+		for len(rows) > 0 && blank(rows[0]) {
+			rows = rows[1:]
+		}
+		for len(rows) > 0 && blank(rows[len(rows)-1]) {
+			rows = rows[:len(rows)-1]
+		}
+	}
+	return rows
+}
+
+// leftmargin returns the number of blank columns before the first
+// non-blank cell of every row, ignoring blank rows, or -1 if all are blank
+func (cfg *Config) leftmargin(rows []outputrow) int {
+	margin := -1
+	for _, row := range rows {
+		n := 0
+		for n < len(row.cells) && cfg.isblank(row.cells[n]) {
+			n++
+		}
+		if n < len(row.cells) && (margin < 0 || n < margin) {
+			margin = n
+		}
+	}
+	return margin
+}
+
+// slice returns the cells between from and to along with their map entries
+func (row outputrow) slice(from, to int) outputrow {
+	if from > len(row.cells) {
+		from = len(row.cells)
+	}
+	if to > len(row.cells) {
+		to = len(row.cells)
+	}
+	out := outputrow{cells: row.cells[from:to], baseline: row.baseline}
+	if row.index != nil {
+		lo, hi := from, to
+		if lo > len(row.index) {
+			lo = len(row.index)
+		}
+		if hi > len(row.index) {
+			hi = len(row.index)
+		}
+		out.index = row.index[lo:hi]
+	}
+	return out
+}
+
+// WithUnderline draws a line of underscores under the text, on the row
+// below the font's baseline
+func WithUnderline() Option {
+	return func(cfg *Config) {
+		cfg.Underline = true
+	}
+}
+
+// WithLineHook sets a function that post-processes every composed row
+// before it is justified, colored and written, such as to swap characters
+// or redact parts of the art. lineIndex counts rows from 0 over the whole
+// output. Cells keep the color of the input character they came from;
+// cells past the row's original length get no color.
+func WithLineHook(hook func(lineIndex int, cells []rune) []rune) Option {
+	return func(cfg *Config) {
+		cfg.LineHook = hook
+	}
+}
+
+// Baseline returns the number of rows from the top of a character of the
+// loaded font to its baseline, the row capital letters stand on. Rows
+// below the baseline hold descenders.
+func (cfg *Config) Baseline() int {
+	return cfg.baseline
+}
+
+// underline fills the blank cells of the row below the baseline of the
+// current line with underscores, across the width of the text. Without
+// a row below the baseline the baseline row is used.
+func (cfg *Config) underline() {
+	row := min(cfg.baseline, cfg.charheight-1)
+	if row < 0 {
+		return
+	}
+	start, end := -1, 0
+	for _, line := range cfg.outputline[:cfg.charheight] {
+		for i, r := range line {
+			if !cfg.isblank(r) {
+				if start < 0 || i < start {
+					start = i
+				}
+				end = max(end, i+1)
+			}
+		}
+	}
+	if start < 0 {
+		return
+	}
+	line, index := cfg.outputline[row], cfg.outputmap[row]
+	for i := start; i < end; i++ {
+		if i >= len(line) {
+			// Extend the row, coloring the cells like the rows above
+			column := -1
+			for _, above := range cfg.outputmap[:row] {
+				if i < len(above) {
+					column = above[i]
+				}
+			}
+			line = append(line, '_')
+			index = append(index, column)
+		} else if cfg.isblank(line[i]) {
+			line[i] = '_'
+		}
+	}
+	cfg.outputline[row], cfg.outputmap[row] = line, index
+}
+
+// layoutrows scales, trims, transforms, truncates, justifies and pads
+// composed rows. Layout only looks at glyph cells, so colors and markup
+// added later never affect alignment.
+func (cfg *Config) layoutrows(rows []outputrow) []outputrow {
+	defer cfg.span("figlet.layout")(nil)
+	if cfg.Justification == JustifyFull {
+		rows = cfg.fulljustify(rows)
+	}
+	rows = cfg.transformrows(cfg.trimrows(cfg.scalerows(rows)))
+
+	blockwidth := 0
+	for n := range rows {
+		row := &rows[n]
+		if cfg.Outputwidth > 1 && len(row.cells) > cfg.Outputwidth-1 {
+			*row = row.slice(0, cfg.Outputwidth-1)
+		}
+		row.pad = 0
+		if (cfg.Justification == 1 || cfg.Justification == 2) && cfg.Outputwidth > 1 {
+			width := cellswidth(row.cells)
+			for i := 1; (3-cfg.Justification)*i+width+cfg.Justification-2 < cfg.Outputwidth; i++ {
+				row.pad++
+			}
+		}
+		if cfg.Trim&TrimTrailing != 0 {
+			end := len(row.cells)
+			for end > 0 && cfg.isblank(row.cells[end-1]) {
+				end--
+			}
+			*row = row.slice(0, end)
+		}
+		if w := row.pad + cellswidth(row.cells); w > blockwidth {
+			blockwidth = w
+		}
+	}
+	if cfg.Pad == PadOutputWidth && cfg.Outputwidth-1 > blockwidth {
+		blockwidth = cfg.Outputwidth - 1
+	}
+	for n := range rows {
+		rows[n].fill = 0
+		if cfg.Pad != PadNone {
+			if fill := blockwidth - rows[n].pad - cellswidth(rows[n].cells); fill > 0 {
+				rows[n].fill = fill
+			}
+		}
+	}
+	if cfg.Bubble.Style != BubbleNone {
+		rows = cfg.bubble(rows)
+	}
+	if cfg.Border != BorderNone {
+		rows = cfg.border(rows)
+	}
+	return cfg.prefixrows(rows)
+}
+
+// WithLineEnding ends every output line with ending, such as "\r\n" for
+// Windows files or protocols that require CRLF, instead of "\n". Markup
+// such as the HTML parser's <br> is kept before it.
+func WithLineEnding(ending string) Option {
+	return func(cfg *Config) {
+		cfg.LineEnding = ending
+	}
+}
+
+// WithTrailingNewline sets whether the last output line ends with a
+// newline, as it does by default. Without it the art can be embedded in
+// other text or written to a terminal line that is redrawn.
+func WithTrailingNewline(on bool) Option {
+	return func(cfg *Config) {
+		cfg.NoTrailingNewline = !on
+	}
+}
+
+// WithSeparator puts line between the blocks of RenderLines; an empty line
+// leaves a blank line. By default blocks follow each other directly.
+func WithSeparator(line string) Option {
+	return func(cfg *Config) {
+		cfg.Separate = true
+		cfg.Separator = line
+	}
+}
+
+// WithLinePrefix puts prefix before every output line, after justification,
+// such as "# " or "// " to embed the banner in a comment block
+func WithLinePrefix(prefix string) Option {
+	return func(cfg *Config) {
+		cfg.LinePrefix = prefix
+	}
+}
+
+// WithIndent indents every output line by n spaces, after justification
+// and before the line prefix
+func WithIndent(n int) Option {
+	return func(cfg *Config) {
+		cfg.Indent = n
+	}
+}
+
+// prefixrows puts the indent and the line prefix before every laid out
+// row. They are never colored.
+func (cfg *Config) prefixrows(rows []outputrow) []outputrow {
+	if cfg.Indent == 0 && cfg.LinePrefix == "" {
+		return rows
+	}
+	prefix := []rune(strings.Repeat(" ", cfg.Indent) + cfg.LinePrefix)
+	for n, row := range rows {
+		flat := row.flatten(0)
+		index := make([]int, len(prefix), len(prefix)+len(flat.index))
+		for i := range index {
+			index[i] = nocolor
+		}
+		rows[n] = outputrow{
+			cells:    append(append([]rune(nil), prefix...), flat.cells...),
+			index:    append(index, flat.index...),
+			fill:     row.fill,
+			baseline: row.baseline,
+		}
+	}
+	return rows
+}
+
+// newline returns the line terminator: the parser's newline
+// representation, ending with LineEnding if set
+func (cfg *Config) newline() string {
+	newline := "\n"
+	if cfg.OutputParser != nil && cfg.OutputParser.NewLine != "" {
+		newline = cfg.OutputParser.NewLine
+	}
+	if cfg.LineEnding != "" {
+		newline = strings.TrimSuffix(newline, "\n") + cfg.LineEnding
+	}
+	return newline
+}
+
+// RenderLines renders every line as a block of its own, one under the
+// other, with the separator line set by WithSeparator between blocks.
+// NoTrailingNewline applies to the last block only.
+func (cfg *Config) RenderLines(lines []string, opts ...RenderOption) (string, error) {
+	var out strings.Builder
+	err := cfg.apply(opts, func() error {
+		notrailing := cfg.NoTrailingNewline
+		defer func() { cfg.NoTrailingNewline = notrailing }()
+		for i, line := range lines {
+			if i > 0 && cfg.Separate {
+				separator := cfg.Separator
+				if cfg.OutputParser != nil {
+					separator = cfg.OutputParser.LinePrefix + handleReplaces(separator, cfg.OutputParser) +
+						cfg.OutputParser.LineSuffix
+				}
+				out.WriteString(separator + cfg.newline())
+			}
+			cfg.NoTrailingNewline = notrailing && i == len(lines)-1
+			out.WriteString(cfg.emit(cfg.layoutrows(cfg.compose(line))))
+		}
+		return nil
+	})
+	return out.String(), err
+}
+
+// emit serializes laid out rows with the configured colors and output parser
+func (cfg *Config) emit(rows []outputrow) string {
+	defer cfg.span("figlet.emit")(nil)
+	var out strings.Builder
+
+	// Write parser prefix if any
+	if cfg.OutputParser != nil && cfg.OutputParser.Prefix != "" {
+		out.WriteString(cfg.OutputParser.Prefix)
+	}
+
+	// Apply colors if enabled
+	hasColors := (len(cfg.Colors) > 0 || cfg.Style != 0) && cfg.OutputParser != nil && cfg.OutputParser.Name != "terminal"
+
+	// Justification and padding spaces are never colored
+	space := " "
+	if cfg.OutputParser != nil {
+		space = handleReplaces(space, cfg.OutputParser)
+	}
+
+	newline := cfg.newline()
+	var lineprefix, linesuffix string
+	if cfg.OutputParser != nil {
+		lineprefix, linesuffix = cfg.OutputParser.LinePrefix, cfg.OutputParser.LineSuffix
+	}
+	for y, row := range rows {
+		out.WriteString(lineprefix)
+		out.WriteString(strings.Repeat(space, row.pad))
+
+		var run colorrun
+		for i, r := range row.cells {
+			charStr := cfg.cellstring(r)
+			if cfg.OutputParser != nil {
+				// Apply parser replacements even without colors
+				charStr = handleReplaces(charStr, cfg.OutputParser)
+			}
+			var prefix, suffix string
+			if hasColors {
+				prefix, suffix = cfg.colorcodes(cfg.cellindex(row, y, row.pad+i))
+			}
+			run.write(&out, prefix, charStr, suffix)
+		}
+		run.end(&out)
+
+		out.WriteString(strings.Repeat(space, row.fill))
+		out.WriteString(linesuffix)
+		if y < len(rows)-1 || !cfg.NoTrailingNewline {
+			out.WriteString(newline)
+		}
+	}
+
+	// Write parser suffix if any
+	if cfg.OutputParser != nil && cfg.OutputParser.Suffix != "" {
+		out.WriteString(cfg.OutputParser.Suffix)
+	}
+	return out.String()
+}
+
+// Measure returns the width and height of text as RenderString would lay it
+// out, without serializing it. The width is in terminal columns, counted
+// like DisplayWidth, and does not include color codes or markup.
+func (cfg *Config) Measure(text string) (width, height int) {
+	rows := cfg.layoutrows(cfg.compose(text))
+	return blockwidth(rows), len(rows)
+}
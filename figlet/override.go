@@ -0,0 +1,33 @@
+package figlet
+
+// WithGlyphOverride replaces the glyph of r with art, one string per row,
+// for this Config only; the font file is left alone. The art must have as
+// many rows as the font is high, all of the same width. It is written
+// like a glyph in a font file without the endmarks, so the font's
+// hardblank character stands for a space that is never smushed.
+func WithGlyphOverride(r rune, art []string) Option {
+	return func(cfg *Config) {
+		// Copy the map, so options given for a single render leave the
+		// Config's own overrides unchanged
+		overrides := make(map[rune][]string, len(cfg.GlyphOverrides)+1)
+		for c, a := range cfg.GlyphOverrides {
+			overrides[c] = a
+		}
+		overrides[r] = append([]string(nil), art...)
+		cfg.GlyphOverrides = overrides
+	}
+}
+
+// overrideglyph returns the rows of the override for c, or nil if there is
+// none
+func (cfg *Config) overrideglyph(c rune) [][]rune {
+	art, ok := cfg.GlyphOverrides[c]
+	if !ok {
+		return nil
+	}
+	rows := make([][]rune, len(art))
+	for i, row := range art {
+		rows[i] = []rune(row)
+	}
+	return rows
+}
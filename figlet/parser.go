@@ -3,6 +3,7 @@ package figlet
 
 import (
 	"errors"
+	"sort"
 	"strings"
 )
 
@@ -16,6 +17,10 @@ type OutputParser struct {
 	Suffix string
 	// Newline representation
 	NewLine string
+	// LinePrefix and LineSuffix are put around every output line, such
+	// as the command printing it in a script
+	LinePrefix string
+	LineSuffix string
 	// Character replacements (e.g., " " to "&nbsp;" for HTML)
 	Replaces map[string]string
 }
@@ -37,7 +42,7 @@ var parsers = map[string]OutputParser{
 		NewLine:  "\n",
 		Replaces: nil,
 	},
-	// HTML parser
+	// HTML parser, with the characters of the art that are markup escaped
 	"html": {
 		Name:    "html",
 		Prefix:  "<code>",
@@ -45,27 +50,86 @@ var parsers = map[string]OutputParser{
 		NewLine: "<br>",
 		Replaces: map[string]string{
 			" ": "&nbsp;",
+			"&": "&amp;",
+			"<": "&lt;",
+			">": "&gt;",
 		},
 	},
+	// cmd.exe batch file: one echo( line per output line, with the
+	// characters cmd interprets escaped with a caret, and % doubled.
+	// Delayed expansion must be off, or ! is lost.
+	"cmd": {
+		Name:       "cmd",
+		NewLine:    "\r\n",
+		LinePrefix: "echo(",
+		Replaces: map[string]string{
+			"%": "%%",
+			"^": "^^",
+			"&": "^&",
+			"|": "^|",
+			"<": "^<",
+			">": "^>",
+			"(": "^(",
+			")": "^)",
+			`"`: `^"`,
+		},
+	},
+	// PowerShell script: one Write-Output line per output line, in single
+	// quotes, so $ and backticks are literal, with the quote characters
+	// PowerShell accepts doubled
+	"powershell": {
+		Name:       "powershell",
+		NewLine:    "\n",
+		LinePrefix: "Write-Output '",
+		LineSuffix: "'",
+		Replaces: map[string]string{
+			"'":      "''",
+			"\u2018": "\u2018\u2018",
+			"\u2019": "\u2019\u2019",
+			"\u201a": "\u201a\u201a",
+			"\u201b": "\u201b\u201b",
+		},
+	},
+}
+
+// ListParsers returns the keys accepted by GetParser
+func ListParsers() []string {
+	return []string{"terminal", "terminal-color", "html", "cmd", "powershell"}
 }
 
 // GetParser returns a parser by its key
 func GetParser(key string) (*OutputParser, error) {
 	parser, ok := parsers[key]
 	if !ok {
-		return nil, errors.New("invalid parser key: " + key + " (valid: terminal, terminal-color, html)")
+		return nil, errors.New("invalid parser key: " + key + " (valid: terminal, terminal-color, html, cmd, powershell)")
 	}
 	return &parser, nil
 }
 
-// handleReplaces applies character replacements based on parser configuration
+// handleReplaces applies character replacements based on parser configuration.
+// Replacements are made in one pass, so text put in by one is never replaced
+// again; where several match at the same place, the longest wins.
 func handleReplaces(str string, parser *OutputParser) string {
 	if parser.Replaces == nil {
 		return str
 	}
-	result := str
-	for old, new := range parser.Replaces {
-		result = strings.ReplaceAll(result, old, new)
+	// Output is replaced one cell at a time
+	if new, ok := parser.Replaces[str]; ok {
+		return new
+	}
+	olds := make([]string, 0, len(parser.Replaces))
+	for old := range parser.Replaces {
+		olds = append(olds, old)
+	}
+	sort.Slice(olds, func(i, j int) bool {
+		if len(olds[i]) != len(olds[j]) {
+			return len(olds[i]) > len(olds[j])
+		}
+		return olds[i] < olds[j]
+	})
+	pairs := make([]string, 0, 2*len(olds))
+	for _, old := range olds {
+		pairs = append(pairs, old, parser.Replaces[old])
 	}
-	return result
+	return strings.NewReplacer(pairs...).Replace(str)
 }
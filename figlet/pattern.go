@@ -0,0 +1,57 @@
+package figlet
+
+// ColorStrategy picks which of the configured Colors paints a visible cell.
+// row and column locate the cell in the laid out output, counting
+// justification spaces, and char is the index of the input character the
+// cell belongs to. The result is taken modulo the number of colors; a
+// negative result leaves the cell uncolored. The default strategy returns
+// char, so colors cycle from one input character to the next.
+type ColorStrategy func(row, column, char int) int
+
+// WithColorStrategy sets how the configured colors are spread over the cells
+func WithColorStrategy(strategy ColorStrategy) Option {
+	return func(cfg *Config) {
+		cfg.ColorStrategy = strategy
+	}
+}
+
+// Checkerboard alternates the first two colors from cell to cell, in both
+// directions
+func Checkerboard() ColorStrategy {
+	return func(row, column, char int) int {
+		return (row + column) % 2
+	}
+}
+
+// Stripes paints vertical stripes width columns wide, going through the
+// colors from left to right
+func Stripes(width int) ColorStrategy {
+	width = max(width, 1)
+	return func(row, column, char int) int {
+		return column / width
+	}
+}
+
+// RandomGlyphs gives every input character a color picked at random from
+// the palette. The choice only depends on seed and the character's index,
+// so rendering the same text again, or animating it, keeps the colors.
+func RandomGlyphs(seed int64) ColorStrategy {
+	return func(row, column, char int) int {
+		// splitmix64 finalizer
+		x := uint64(seed) + uint64(char)*0x9e3779b97f4a7c15
+		x = (x ^ x>>30) * 0xbf58476d1ce4e5b9
+		x = (x ^ x>>27) * 0x94d049bb133111eb
+		x ^= x >> 31
+		return int(x >> 33)
+	}
+}
+
+// cellindex returns the color index for column i of laid out row y, after
+// the color strategy, or -1 if the cell must not be colored
+func (cfg *Config) cellindex(row outputrow, y, i int) int {
+	index := row.colorindex(i)
+	if index < 0 || cfg.ColorStrategy == nil {
+		return index
+	}
+	return cfg.ColorStrategy(y, i, index)
+}
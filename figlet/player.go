@@ -0,0 +1,214 @@
+package figlet
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Player plays frames on its own clock without drawing them: OnFrame is
+// told whenever the frame to show changes, and Play, Pause, Seek and
+// SetSpeed control playback. A TUI draws the frames from OnFrame, usually
+// by posting them to its event loop. A Player is safe for concurrent use.
+type Player struct {
+	// OnFrame is called with the frame to show and its index when
+	// playback starts, moves on or seeks. It is called from the Player's
+	// timer goroutine or from the method that changed the frame, without
+	// locks held, so it may call the Player's methods.
+	OnFrame func(index int, frame Frame)
+	// OnEnd is called when the last frame's delay is over, unless Loop
+	// is set. Playback is then paused on the last frame.
+	OnEnd func()
+	// Loop restarts playback after the last frame
+	Loop bool
+	// SkipLateFrames skips the frames whose time is already over when
+	// the Player falls behind, like WithSkipLateFrames
+	SkipLateFrames bool
+
+	mu      sync.Mutex
+	frames  []Frame
+	index   int
+	speed   float64
+	playing bool
+	started bool          // OnFrame has been told about a frame
+	ended   bool          // the last frame's delay is over
+	left    time.Duration // delay left on the current frame, at speed 1
+	since   time.Time     // when left was last updated while playing
+	timer   *time.Timer
+	gen     int // incremented to ignore timers that are stopped too late
+}
+
+// NewPlayer returns a paused Player on the first of frames, at speed 1
+func NewPlayer(frames []Frame) *Player {
+	p := &Player{frames: frames, speed: 1}
+	if len(frames) > 0 {
+		p.left = frames[0].Delay
+	}
+	return p
+}
+
+// Play starts or resumes playback. The first call shows the current
+// frame, and a call after the end starts over from the first.
+func (p *Player) Play() {
+	p.mu.Lock()
+	if p.playing || len(p.frames) == 0 {
+		p.mu.Unlock()
+		return
+	}
+	if p.ended {
+		p.index, p.left, p.ended, p.started = 0, p.frames[0].Delay, false, false
+	}
+	p.playing = true
+	if p.started {
+		p.schedule()
+		p.mu.Unlock()
+		return
+	}
+	p.show()
+}
+
+// Pause stops playback on the current frame; Play resumes it with the
+// rest of the frame's delay
+func (p *Player) Pause() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.settle()
+	p.playing = false
+	p.schedule()
+}
+
+// Playing reports whether the Player is playing
+func (p *Player) Playing() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.playing
+}
+
+// Seek shows frame index and starts its delay over, playing or not
+func (p *Player) Seek(index int) error {
+	p.mu.Lock()
+	if index < 0 || index >= len(p.frames) {
+		p.mu.Unlock()
+		return fmt.Errorf("frame %d out of range [0, %d)", index, len(p.frames))
+	}
+	p.index = index
+	p.left = p.frames[index].Delay
+	p.ended = false
+	p.show()
+	return nil
+}
+
+// SetSpeed multiplies the playback speed by speed, 2 playing twice as
+// fast; 0 or less means 1. The frame on screen keeps the share of its
+// delay already played.
+func (p *Player) SetSpeed(speed float64) {
+	if speed <= 0 {
+		speed = 1
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.settle()
+	p.speed = speed
+	p.schedule()
+}
+
+// Speed returns the playback speed
+func (p *Player) Speed() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.speed
+}
+
+// Frame returns the current frame and its index
+func (p *Player) Frame() (int, Frame) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.frames) == 0 {
+		return 0, Frame{}
+	}
+	return p.index, p.frames[p.index]
+}
+
+// settle counts the time played since the last update off the current
+// frame's delay
+func (p *Player) settle() {
+	if p.playing {
+		now := time.Now()
+		p.left -= time.Duration(float64(now.Sub(p.since)) * p.speed)
+		p.since = now
+	}
+}
+
+// schedule replaces the timer for the end of the current frame
+func (p *Player) schedule() {
+	p.gen++
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	if !p.playing {
+		return
+	}
+	p.since = time.Now()
+	gen := p.gen
+	p.timer = time.AfterFunc(time.Duration(float64(max(p.left, 0))/p.speed), func() { p.advance(gen) })
+}
+
+// advance moves on to the next frame when the timer of generation gen
+// fires
+func (p *Player) advance(gen int) {
+	p.mu.Lock()
+	if gen != p.gen || !p.playing {
+		p.mu.Unlock()
+		return
+	}
+	// A late timer shortens the next frame, so playback keeps to the
+	// frames' Schedule
+	p.settle()
+	for skipped := 0; ; skipped++ {
+		if p.index+1 == len(p.frames) && !p.Loop {
+			p.playing, p.ended = false, true
+			p.left = 0
+			p.schedule()
+			p.mu.Unlock()
+			if p.OnEnd != nil {
+				p.OnEnd()
+			}
+			return
+		}
+		p.index = (p.index + 1) % len(p.frames)
+		p.left = min(p.left, 0) + p.frames[p.index].Delay
+		// The last frame is always shown
+		if !p.SkipLateFrames || p.left > 0 || skipped == len(p.frames) ||
+			p.index+1 == len(p.frames) && !p.Loop {
+			break
+		}
+	}
+	p.show()
+}
+
+// show tells OnFrame about the current frame and only then starts its
+// timer, so the frames of a quick animation reach OnFrame in order. It is
+// called with mu held and releases it. The time OnFrame takes counts
+// toward the frame's delay.
+func (p *Player) show() {
+	p.started = true
+	p.gen++
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	p.since = time.Now()
+	gen, index, frame := p.gen, p.index, p.frames[p.index]
+	p.mu.Unlock()
+	if p.OnFrame != nil {
+		p.OnFrame(index, frame)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// Another call changed the frame or the timing meanwhile
+	if gen == p.gen {
+		p.settle()
+		p.schedule()
+	}
+}
@@ -0,0 +1,146 @@
+package figlet
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Preset bundles the settings of a banner style, so banners look the same
+// wherever a codebase prints them
+type Preset struct {
+	// Font is the font name; empty keeps the Config's
+	Font string
+	// Colors are the colors of the banner; when empty, Theme names a
+	// palette instead, and when both are empty the colors are kept
+	Colors []Color
+	Theme  string
+	// Border is the box drawn around the banner, whose blank rows at the
+	// top and bottom are then trimmed
+	Border BorderStyle
+	// Justification is set as with WithJustification; JustifyAuto leaves
+	// it to the font
+	Justification int
+	// Effects are the text attributes of the banner, such as StyleBold
+	Effects Style
+}
+
+// presets holds the named presets, built-in and registered
+var presets = struct {
+	sync.RWMutex
+	styles map[string]Preset
+}{styles: map[string]Preset{
+	"header": {
+		Font: "standard", Colors: []Color{ColorCyan}, Border: BorderDouble,
+		Justification: 1, Effects: StyleBold,
+	},
+	"warning": {
+		Font: "standard", Colors: []Color{ColorYellow}, Border: BorderHeavy,
+		Justification: JustifyAuto, Effects: StyleBold,
+	},
+	"success": {
+		Font: "small", Colors: []Color{ColorGreen}, Border: BorderRound,
+		Justification: JustifyAuto,
+	},
+	"retro": {
+		Font: "doom", Colors: []Color{TrueColor{255, 176, 0}}, Border: BorderASCII,
+		Justification: JustifyAuto,
+	},
+}}
+
+// WithPreset applies a named preset: its font, colors, border,
+// justification and effects. An unknown name changes nothing and is
+// reported by Validate, so LoadFont and Render fail; use GetPreset to
+// check a name first. The font is loaded by LoadFont, so give the option
+// before loading.
+func WithPreset(name string) Option {
+	return func(cfg *Config) {
+		p, err := GetPreset(name)
+		if err != nil {
+			cfg.unknownpreset = name
+			return
+		}
+		cfg.unknownpreset = ""
+		p.apply(cfg)
+	}
+}
+
+// apply sets the Config to the preset
+func (p Preset) apply(cfg *Config) {
+	if p.Font != "" {
+		WithFont(p.Font)(cfg)
+	}
+	if len(p.Colors) > 0 {
+		WithColors(p.Colors...)(cfg)
+	} else if p.Theme != "" {
+		WithTheme(p.Theme)(cfg)
+	}
+	cfg.Border = p.Border
+	if p.Border != BorderNone {
+		// Blank rows under the art would leave a gap in the box
+		cfg.Trim |= TrimBlankRows
+	}
+	cfg.Justification = p.Justification
+	cfg.Style = p.Effects
+	// Effects show on the terminal-color parser, like colors
+	if p.Effects != 0 && cfg.OutputParser != nil && cfg.OutputParser.Name == "terminal" {
+		cfg.OutputParser, _ = GetParser("terminal-color")
+	}
+}
+
+// GetPreset returns the named preset
+func GetPreset(name string) (Preset, error) {
+	presets.RLock()
+	defer presets.RUnlock()
+	p, ok := presets.styles[strings.ToLower(name)]
+	if !ok {
+		return Preset{}, fmt.Errorf("invalid preset: %s (valid: %s)", name, strings.Join(listpresets(), ", "))
+	}
+	p.Colors = append([]Color(nil), p.Colors...)
+	return p, nil
+}
+
+// RegisterPreset adds a named preset, or replaces an existing one, so it
+// can be used with WithPreset and the CLI's --preset option. Names are
+// not case sensitive.
+func RegisterPreset(name string, p Preset) error {
+	if name == "" {
+		return errors.New("preset name must not be empty")
+	}
+	for i, color := range p.Colors {
+		if color == nil {
+			return fmt.Errorf("preset %s: color %d is nil", name, i)
+		}
+	}
+	if p.Theme != "" {
+		if _, err := Theme(p.Theme); err != nil {
+			return fmt.Errorf("preset %s: %v", name, err)
+		}
+	}
+	if p.Justification < JustifyAuto || p.Justification > JustifyFull {
+		return fmt.Errorf("preset %s: invalid justification %d", name, p.Justification)
+	}
+	p.Colors = append([]Color(nil), p.Colors...)
+	presets.Lock()
+	defer presets.Unlock()
+	presets.styles[strings.ToLower(name)] = p
+	return nil
+}
+
+// ListPresets returns the names accepted by WithPreset, sorted
+func ListPresets() []string {
+	presets.RLock()
+	defer presets.RUnlock()
+	return listpresets()
+}
+
+func listpresets() []string {
+	names := make([]string, 0, len(presets.styles))
+	for name := range presets.styles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
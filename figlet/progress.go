@@ -0,0 +1,136 @@
+package figlet
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+)
+
+// ProgressBanner shows the progress of a long-running job as a banner that
+// is redrawn in place on a terminal. Log output written through Writer is
+// printed above the banner, which is drawn again below it, so the two never
+// garble each other. A ProgressBanner is safe for concurrent use; the
+// Config it renders with must not be used elsewhere meanwhile.
+type ProgressBanner struct {
+	// Label is rendered before the counter, such as "Build "
+	Label string
+
+	cfg     *Config
+	w       io.Writer
+	mu      sync.Mutex
+	content string // the banner last drawn
+	enc     deltaencoder
+	player  terminalplayer
+	pending []byte // log output after the last newline
+}
+
+// NewProgressBanner returns a banner rendered with cfg, whose font must be
+// loaded, and drawn on w, which should be a terminal
+func NewProgressBanner(cfg *Config, w io.Writer) *ProgressBanner {
+	return &ProgressBanner{cfg: cfg, w: w, player: terminalplayer{w: w}}
+}
+
+// Update shows pct, a percentage from 0 to 100, rounded down so 100% is
+// only shown once the job is complete
+func (p *ProgressBanner) Update(pct float64) {
+	if math.IsNaN(pct) {
+		pct = 0
+	}
+	pct = math.Max(0, math.Min(100, pct))
+	p.show(fmt.Sprintf("%d%%", int(pct)))
+}
+
+// Step shows a step counter, such as 3/10
+func (p *ProgressBanner) Step(n, total int) {
+	p.show(fmt.Sprintf("%d/%d", n, total))
+}
+
+// Writer returns a writer for log output shown above the banner. Output
+// is held back until a newline, so a line is never split by the banner.
+func (p *ProgressBanner) Writer() io.Writer {
+	return progresswriter{p}
+}
+
+// Done leaves the banner in place and shows the cursor again. Log output
+// without a final newline is printed first. Updates after Done draw a new
+// banner below this one.
+func (p *ProgressBanner) Done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.pending) > 0 {
+		p.log(append(p.pending, '\n'))
+		p.pending = nil
+	}
+	p.player.close()
+	p.reset()
+}
+
+// Clear erases the banner and shows the cursor again
+func (p *ProgressBanner) Clear() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.pending) > 0 {
+		p.log(append(p.pending, '\n'))
+		p.pending = nil
+	}
+	p.erase()
+	p.player.close()
+	p.reset()
+	p.content = ""
+}
+
+// show renders the counter and redraws the lines of the banner that changed
+func (p *ProgressBanner) show(counter string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.content = p.cfg.RenderString(p.Label + counter)
+	p.player.draw(p.enc.encode(Frame{Content: p.content}))
+}
+
+// log writes complete lines of log output above the banner
+func (p *ProgressBanner) log(lines []byte) error {
+	p.erase()
+	_, err := p.w.Write(lines)
+	p.reset()
+	if p.content != "" {
+		p.player.draw(p.enc.encode(Frame{Content: p.content}))
+	}
+	return err
+}
+
+// erase moves the cursor to the first line of the banner and clears it
+// to the end of the screen
+func (p *ProgressBanner) erase() {
+	if p.player.lastlines > 0 {
+		fmt.Fprintf(p.w, "\033[%dA\033[J", p.player.lastlines)
+	}
+}
+
+// reset makes the next update draw the whole banner where the cursor is
+func (p *ProgressBanner) reset() {
+	p.enc, p.player = deltaencoder{}, terminalplayer{w: p.w}
+}
+
+// progresswriter is the writer returned by ProgressBanner.Writer
+type progresswriter struct {
+	p *ProgressBanner
+}
+
+func (w progresswriter) Write(b []byte) (int, error) {
+	p := w.p
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pending = append(p.pending, b...)
+	i := bytes.LastIndexByte(p.pending, '\n')
+	if i < 0 {
+		return len(b), nil
+	}
+	lines := p.pending[:i+1]
+	p.pending = append([]byte(nil), p.pending[i+1:]...)
+	if err := p.log(lines); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
@@ -0,0 +1,700 @@
+package figlet
+
+import (
+	"fmt"
+	"strings"
+)
+
+// QRLevel is the error correction level of a QR code: how much of the
+// symbol can be damaged and still be read. Higher levels make bigger codes.
+type QRLevel int
+
+const (
+	// QRMedium recovers 15% of the data; it is the zero value
+	QRMedium QRLevel = iota
+	// QRLow recovers 7% of the data
+	QRLow
+	// QRQuartile recovers 25% of the data
+	QRQuartile
+	// QRHigh recovers 30% of the data
+	QRHigh
+)
+
+// qrlevels holds, by QRLevel, the bits of the level in the format
+// information and its row in the block tables
+var qrlevels = [4]struct{ format, table int }{
+	QRMedium:   {0, 1},
+	QRLow:      {1, 0},
+	QRQuartile: {3, 2},
+	QRHigh:     {2, 3},
+}
+
+// qreccperblock is the number of error correction codewords in each block,
+// by level (L, M, Q, H) and version
+var qreccperblock = [4][41]int{
+	{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+	{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+}
+
+// qrblocks is the number of error correction blocks, by level (L, M, Q, H)
+// and version
+var qrblocks = [4][41]int{
+	{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+	{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+	{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+	{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+}
+
+// QRCode is a QR code symbol
+type QRCode struct {
+	// Modules holds the modules row by row, true for dark ones. The quiet
+	// zone around the symbol is not included.
+	Modules [][]bool
+	// Version is the symbol version, from 1 (21x21 modules) to 40
+	Version int
+	Level   QRLevel
+}
+
+// QRStyle selects the characters a QR code is drawn with
+type QRStyle int
+
+const (
+	// QRHalfBlocks draws two rows of modules per line with ▀ ▄ █, so the
+	// modules look square in a terminal; it is the zero value
+	QRHalfBlocks QRStyle = iota
+	// QRFullBlocks draws each module as two █ or spaces
+	QRFullBlocks
+	// QRASCII draws each module as two # or spaces, for terminals and
+	// fonts without block characters
+	QRASCII
+)
+
+// qrquietzone is the width in modules of the light border a reader needs
+// around the symbol
+const qrquietzone = 4
+
+// NewQRCode encodes text as a QR code in byte mode, using the smallest
+// version that holds it at the given error correction level
+func NewQRCode(text string, level QRLevel) (*QRCode, error) {
+	if level < QRMedium || level > QRHigh {
+		return nil, fmt.Errorf("invalid QR code level: %d", level)
+	}
+	data := []byte(text)
+	table := qrlevels[level].table
+	version := 0
+	for v := 1; v <= 40; v++ {
+		countbits := 8
+		if v >= 10 {
+			countbits = 16
+		}
+		if len(data) < 1<<countbits && 4+countbits+8*len(data) <= qrdatacodewords(v, table)*8 {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, fmt.Errorf("text too long for a QR code: %d bytes", len(data))
+	}
+
+	// Mode indicator, character count, data, terminator and padding
+	var bits qrbits
+	bits.append(0x4, 4)
+	if version < 10 {
+		bits.append(len(data), 8)
+	} else {
+		bits.append(len(data), 16)
+	}
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	capacity := qrdatacodewords(version, table) * 8
+	bits.append(0, min(4, capacity-len(bits)))
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xec; len(bits) < capacity; pad ^= 0xec ^ 0x11 {
+		bits.append(pad, 8)
+	}
+	codewords := make([]byte, len(bits)/8)
+	for i, bit := range bits {
+		if bit {
+			codewords[i>>3] |= 1 << (7 - i&7)
+		}
+	}
+
+	q := newqrsymbol(version, qrlevels[level].format)
+	q.drawcodewords(qrinterleave(codewords, version, table))
+
+	best, penalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		q.applymask(mask)
+		q.drawformat(mask)
+		if p := q.penalty(); penalty < 0 || p < penalty {
+			best, penalty = mask, p
+		}
+		q.applymask(mask) // masking twice undoes it
+	}
+	q.applymask(best)
+	q.drawformat(best)
+	return &QRCode{Modules: q.modules, Version: version, Level: level}, nil
+}
+
+// Lines draws the code with its quiet zone, one string per line. Codes are
+// meant to be dark on light; invert draws the light modules instead, for
+// light text on a dark background, as most terminals use.
+func (q *QRCode) Lines(style QRStyle, invert bool) []string {
+	size := len(q.Modules) + 2*qrquietzone
+	dark := func(x, y int) bool {
+		x, y = x-qrquietzone, y-qrquietzone
+		on := y >= 0 && y < len(q.Modules) && x >= 0 && x < len(q.Modules) && q.Modules[y][x]
+		if y >= len(q.Modules)+qrquietzone {
+			// The half line past the quiet zone is never drawn
+			return false
+		}
+		return on != invert
+	}
+	var lines []string
+	switch style {
+	case QRHalfBlocks:
+		for y := 0; y < size; y += 2 {
+			var sb strings.Builder
+			for x := 0; x < size; x++ {
+				top, bottom := dark(x, y), dark(x, y+1)
+				switch {
+				case top && bottom:
+					sb.WriteRune('█')
+				case top:
+					sb.WriteRune('▀')
+				case bottom:
+					sb.WriteRune('▄')
+				default:
+					sb.WriteByte(' ')
+				}
+			}
+			lines = append(lines, sb.String())
+		}
+	default:
+		on := "██"
+		if style == QRASCII {
+			on = "##"
+		}
+		for y := 0; y < size; y++ {
+			var sb strings.Builder
+			for x := 0; x < size; x++ {
+				if dark(x, y) {
+					sb.WriteString(on)
+				} else {
+					sb.WriteString("  ")
+				}
+			}
+			lines = append(lines, sb.String())
+		}
+	}
+	return lines
+}
+
+// String draws the code with half blocks, dark on light
+func (q *QRCode) String() string {
+	return strings.Join(q.Lines(QRHalfBlocks, false), "\n") + "\n"
+}
+
+// qrbits is a bit buffer, most significant bit first
+type qrbits []bool
+
+func (b *qrbits) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, value>>i&1 != 0)
+	}
+}
+
+// qrrawmodules returns the number of modules of a version that hold
+// codewords, after the function patterns
+func qrrawmodules(version int) int {
+	n := (16*version+128)*version + 64
+	if version >= 2 {
+		align := version/7 + 2
+		n -= (25*align-10)*align - 55
+		if version >= 7 {
+			n -= 36
+		}
+	}
+	return n
+}
+
+// qrdatacodewords returns the number of data codewords of a version at the
+// level in the given table row
+func qrdatacodewords(version, table int) int {
+	return qrrawmodules(version)/8 - qreccperblock[table][version]*qrblocks[table][version]
+}
+
+// qrinterleave splits the data into blocks, adds their error correction
+// codewords and interleaves them in the order they are placed
+func qrinterleave(data []byte, version, table int) []byte {
+	nblocks := qrblocks[table][version]
+	ecclen := qreccperblock[table][version]
+	raw := qrrawmodules(version) / 8
+	nshort := nblocks - raw%nblocks
+	shortlen := raw / nblocks
+
+	divisor := qrdivisor(ecclen)
+	blocks := make([][]byte, nblocks)
+	k := 0
+	for i := range blocks {
+		n := shortlen - ecclen
+		if i >= nshort {
+			n++
+		}
+		block := append([]byte(nil), data[k:k+n]...)
+		k += n
+		ecc := qrremainder(block, divisor)
+		if i < nshort {
+			// Placeholder so all blocks have the same length
+			block = append(block, 0)
+		}
+		blocks[i] = append(block, ecc...)
+	}
+
+	result := make([]byte, 0, raw)
+	for i := range blocks[0] {
+		for j, block := range blocks {
+			if i != shortlen-ecclen || j >= nshort {
+				result = append(result, block[i])
+			}
+		}
+	}
+	return result
+}
+
+// qrmultiply multiplies two elements of GF(2^8) modulo x^8+x^4+x^3+x^2+1
+func qrmultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11d
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
+
+// qrdivisor returns the Reed-Solomon generator polynomial of a degree,
+// highest coefficient first and without the leading 1
+func qrdivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = qrmultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = qrmultiply(root, 0x02)
+	}
+	return result
+}
+
+// qrremainder returns the Reed-Solomon error correction codewords of data
+func qrremainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, coef := range divisor {
+			result[i] ^= qrmultiply(coef, factor)
+		}
+	}
+	return result
+}
+
+// qrsymbol is a QR code being built
+type qrsymbol struct {
+	size     int
+	format   int // level bits of the format information
+	modules  [][]bool
+	function [][]bool // modules of the function patterns, never masked
+}
+
+// newqrsymbol returns a symbol with its function patterns drawn and room
+// reserved for the format information
+func newqrsymbol(version, format int) *qrsymbol {
+	size := version*4 + 17
+	q := &qrsymbol{size: size, format: format}
+	q.modules, q.function = make([][]bool, size), make([][]bool, size)
+	for y := range q.modules {
+		q.modules[y], q.function[y] = make([]bool, size), make([]bool, size)
+	}
+
+	// Timing patterns
+	for i := 0; i < size; i++ {
+		q.set(6, i, i%2 == 0)
+		q.set(i, 6, i%2 == 0)
+	}
+
+	// Finder patterns with their separators
+	for _, c := range [][2]int{{3, 3}, {size - 4, 3}, {3, size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := c[0]+dx, c[1]+dy
+				if x >= 0 && x < size && y >= 0 && y < size {
+					d := max(abs(dx), abs(dy))
+					q.set(x, y, d != 2 && d != 4)
+				}
+			}
+		}
+	}
+
+	// Alignment patterns, except where they would overlap the finders
+	positions := qralignment(version)
+	n := len(positions)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if i == 0 && j == 0 || i == 0 && j == n-1 || i == n-1 && j == 0 {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					q.set(positions[i]+dx, positions[j]+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	q.drawformat(0)
+
+	// Version information
+	if version >= 7 {
+		rem := version
+		for i := 0; i < 12; i++ {
+			rem = rem<<1 ^ (rem>>11)*0x1f25
+		}
+		bits := version<<12 | rem
+		for i := 0; i < 18; i++ {
+			bit := bits>>i&1 != 0
+			a, b := size-11+i%3, i/3
+			q.set(a, b, bit)
+			q.set(b, a, bit)
+		}
+	}
+	return q
+}
+
+// set draws a function module
+func (q *qrsymbol) set(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.function[y][x] = true
+}
+
+// qralignment returns the centers of the alignment patterns of a version,
+// along each axis
+func qralignment(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	n := version/7 + 2
+	step := (version*8 + n*3 + 5) / (n*4 - 4) * 2
+	positions := make([]int, n)
+	positions[0] = 6
+	for i, pos := n-1, version*4+17-7; i >= 1; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+	return positions
+}
+
+// drawformat draws both copies of the format information for a mask
+func (q *qrsymbol) drawformat(mask int) {
+	data := q.format<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool {
+		return bits>>i&1 != 0
+	}
+
+	for i := 0; i <= 5; i++ {
+		q.set(8, i, bit(i))
+	}
+	q.set(8, 7, bit(6))
+	q.set(8, 8, bit(7))
+	q.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.set(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		q.set(q.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.set(8, q.size-15+i, bit(i))
+	}
+	q.set(8, q.size-8, true) // the dark module
+}
+
+// drawcodewords places the codewords in the zigzag order, two columns at a
+// time from the bottom right, skipping the function patterns
+func (q *qrsymbol) drawcodewords(data []byte) {
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // skip the vertical timing pattern
+		}
+		for vert := 0; vert < q.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = q.size - 1 - vert // upward
+				}
+				if !q.function[y][x] && i < len(data)*8 {
+					q.modules[y][x] = data[i>>3]>>(7-i&7)&1 != 0
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applymask inverts the data modules selected by a mask pattern
+func (q *qrsymbol) applymask(mask int) {
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !q.function[y][x] {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores how hard the symbol is to read, following the four rules
+// of the standard: long runs, 2x2 blocks, finder-like patterns and an
+// unbalanced share of dark modules. The mask with the lowest score is used.
+func (q *qrsymbol) penalty() int {
+	score := 0
+	at := func(x, y int, transpose bool) bool {
+		if transpose {
+			return q.modules[x][y]
+		}
+		return q.modules[y][x]
+	}
+	finder := []bool{true, false, true, true, true, false, true}
+	for _, transpose := range []bool{false, true} {
+		for y := 0; y < q.size; y++ {
+			run := 1
+			for x := 1; x <= q.size; x++ {
+				if x < q.size && at(x, y, transpose) == at(x-1, y, transpose) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					score += 3 + run - 5
+				}
+				run = 1
+			}
+			// 1:1:3:1:1 with four light modules on one side
+			for x := 0; x+7 <= q.size; x++ {
+				match := true
+				for i, dark := range finder {
+					if at(x+i, y, transpose) != dark {
+						match = false
+						break
+					}
+				}
+				if !match {
+					continue
+				}
+				light := func(from, to int) bool {
+					if from < 0 || to > q.size {
+						return false
+					}
+					for i := from; i < to; i++ {
+						if at(i, y, transpose) {
+							return false
+						}
+					}
+					return true
+				}
+				if light(x-4, x) || light(x+7, x+11) {
+					score += 40
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if q.modules[y][x] {
+				dark++
+			}
+			if x+1 < q.size && y+1 < q.size {
+				c := q.modules[y][x]
+				if q.modules[y][x+1] == c && q.modules[y+1][x] == c && q.modules[y+1][x+1] == c {
+					score += 3
+				}
+			}
+		}
+	}
+	total := q.size * q.size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	score += k * 10
+	return score
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// QRPlacement selects where RenderWithQR puts the QR code
+type QRPlacement int
+
+const (
+	// QRBelow puts the code under the title, the narrower of the two
+	// centered on the other; it is the zero value
+	QRBelow QRPlacement = iota
+	// QRRight puts the code right of the title, the shorter of the two
+	// centered on the other
+	QRRight
+	// QRLeft puts the code left of the title
+	QRLeft
+	// QRAbove puts the code over the title
+	QRAbove
+)
+
+// QROptions configures the QR code drawn by RenderWithQR
+type QROptions struct {
+	Level     QRLevel
+	Style     QRStyle
+	Placement QRPlacement
+	// Invert draws the light modules instead of the dark ones, for
+	// terminals with a dark background
+	Invert bool
+	// Gap is the number of blank rows or columns between the title and
+	// the code, on top of the code's quiet zone
+	Gap int
+}
+
+// RenderWithQR renders title with a QR code of data on one canvas, lined
+// up as set by qr. The title keeps its colors and the code gets none, so
+// it stays readable. Justification is ignored, since the canvas is laid
+// out as a block. Options apply to this call only, as with RenderString.
+func (cfg *Config) RenderWithQR(title, data string, qr QROptions, opts ...RenderOption) (string, error) {
+	code, err := NewQRCode(data, qr.Level)
+	if err != nil {
+		return "", err
+	}
+	var out string
+	err = cfg.apply(opts, func() error {
+		var art []outputrow
+		for _, line := range code.Lines(qr.Style, qr.Invert) {
+			art = append(art, plainrow([]rune(line)))
+		}
+		out = cfg.emit(cfg.canvas(cfg.layoutrows(cfg.compose(title)), art, qr.Placement, qr.Gap))
+		return nil
+	})
+	return out, err
+}
+
+// canvas combines laid out title rows with rows of art, such as a QR code,
+// placed relative to the title with gap blank rows or columns between them
+func (cfg *Config) canvas(rows, art []outputrow, placement QRPlacement, gap int) []outputrow {
+	margin := -1
+	for _, row := range rows {
+		if margin < 0 || row.pad < margin {
+			margin = row.pad
+		}
+	}
+	titlewidth := 0
+	title := make([]outputrow, len(rows))
+	for i, row := range rows {
+		title[i] = row.flatten(margin)
+		titlewidth = max(titlewidth, len(title[i].cells))
+	}
+	artwidth := 0
+	for _, row := range art {
+		artwidth = max(artwidth, len(row.cells))
+	}
+	gap = max(gap, 0)
+
+	var out []outputrow
+	if placement == QRBelow || placement == QRAbove {
+		for _, row := range title {
+			row.pad = max(0, (artwidth-titlewidth)/2)
+			out = append(out, row)
+		}
+		var below []outputrow
+		for _, row := range art {
+			row.pad = max(0, (titlewidth-artwidth)/2)
+			below = append(below, row)
+		}
+		if placement == QRAbove {
+			out, below = below, out
+		}
+		for i := 0; i < gap; i++ {
+			out = append(out, outputrow{})
+		}
+		out = append(out, below...)
+	} else {
+		height := max(len(title), len(art))
+		blank := func(width int) outputrow {
+			return plainrow([]rune(strings.Repeat(" ", width)))
+		}
+		for y := 0; y < height; y++ {
+			t, c := blank(titlewidth), blank(artwidth)
+			if i := y - (height-len(title))/2; i >= 0 && i < len(title) {
+				t = title[i]
+				for len(t.cells) < titlewidth {
+					t.cells, t.index = append(t.cells, ' '), append(t.index, nocolor)
+				}
+			}
+			if i := y - (height-len(art))/2; i >= 0 && i < len(art) {
+				c = art[i]
+				for len(c.cells) < artwidth {
+					c.cells, c.index = append(c.cells, ' '), append(c.index, nocolor)
+				}
+			}
+			left, right := t, c
+			if placement == QRLeft {
+				left, right = c, t
+			}
+			row := outputrow{baseline: t.baseline}
+			row.cells = append(append(append([]rune(nil), left.cells...), blank(gap).cells...), right.cells...)
+			row.index = append(append(append([]int(nil), left.index...), blank(gap).index...), right.index...)
+			out = append(out, row)
+		}
+	}
+
+	if cfg.Pad != PadNone {
+		width := blockwidth(out)
+		if cfg.Pad == PadOutputWidth {
+			width = max(width, cfg.Outputwidth-1)
+		}
+		for n := range out {
+			out[n].fill = max(0, width-out[n].pad-len(out[n].cells))
+		}
+	}
+	return out
+}
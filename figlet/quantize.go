@@ -0,0 +1,198 @@
+package figlet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lsferreira42/figlet-go/internal/terminal"
+)
+
+// ColorDepth is the number of colors the terminal-color parser may use
+type ColorDepth int
+
+const (
+	// DepthTrueColor writes colors as they are, 24-bit RGB included
+	DepthTrueColor ColorDepth = iota
+	// Depth256 turns RGB colors into the nearest of the 256 xterm colors
+	Depth256
+	// Depth16 turns every color into the nearest of the 16 ANSI colors
+	Depth16
+)
+
+// ParseColorDepth parses a color depth: "16", "256", or "truecolor" or
+// "24bit"
+func ParseColorDepth(s string) (ColorDepth, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "truecolor", "24bit":
+		return DepthTrueColor, nil
+	case "256":
+		return Depth256, nil
+	case "16":
+		return Depth16, nil
+	}
+	return 0, fmt.Errorf("invalid color depth %q (use 16, 256 or truecolor)", s)
+}
+
+// String returns the name of the color depth, as ParseColorDepth reads it
+func (d ColorDepth) String() string {
+	switch d {
+	case Depth256:
+		return "256"
+	case Depth16:
+		return "16"
+	}
+	return "truecolor"
+}
+
+// DetectColorDepth returns the color depth of the terminal, from the
+// COLORTERM and TERM environment variables. Without a terminal colors
+// are written as they are.
+func DetectColorDepth() ColorDepth {
+	c := terminal.Probe()
+	switch {
+	case c.Rows < 0 || c.Colors == terminal.TrueColor:
+		return DepthTrueColor
+	case c.Colors == terminal.Color256:
+		return Depth256
+	}
+	return Depth16
+}
+
+// WithColorDepth sets the number of colors the terminal-color parser may
+// use. Colors the terminal cannot show are replaced with the nearest it
+// can, instead of escape sequences it ignores or draws wrong. Other
+// parsers keep the colors as they are.
+func WithColorDepth(depth ColorDepth) Option {
+	return func(cfg *Config) {
+		cfg.ColorDepth = depth
+	}
+}
+
+// Ansi256Color is one of the 256 colors of xterm: the 16 ANSI colors,
+// a 6x6x6 color cube from 16 to 231 and 24 grays from 232 to 255
+type Ansi256Color struct {
+	Index int
+}
+
+// getPrefix returns the prefix for Ansi256Color based on parser type
+func (c Ansi256Color) getPrefix(parser *OutputParser) string {
+	switch parser.Name {
+	case "terminal-color":
+		return fmt.Sprintf("%s[38;5;%dm", escape, c.Index)
+	case "html":
+		return c.RGB().getPrefix(parser)
+	}
+	return ""
+}
+
+// getSuffix returns the suffix for Ansi256Color based on parser type
+func (c Ansi256Color) getSuffix(parser *OutputParser) string {
+	return c.RGB().getSuffix(parser)
+}
+
+// RGB returns the RGB value xterm gives the color
+func (c Ansi256Color) RGB() TrueColor {
+	switch i := c.Index; {
+	case i < 16:
+		return xterm16[max(i, 0)]
+	case i < 232:
+		i -= 16
+		return TrueColor{cubelevels[i/36], cubelevels[i/6%6], cubelevels[i%6]}
+	case i < 256:
+		gray := 8 + (i-232)*10
+		return TrueColor{gray, gray, gray}
+	}
+	return xterm16[15]
+}
+
+// xterm16 holds the RGB values of the 16 ANSI colors in xterm, the
+// normal ones then the bright ones. Terminals let users change them, so
+// they only serve to pick the nearest.
+var xterm16 = [16]TrueColor{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// cubelevels holds the values of each channel in the xterm color cube
+var cubelevels = [6]int{0, 95, 135, 175, 215, 255}
+
+// Quantize returns the color nearest to c that a terminal with the
+// given depth shows: an AnsiColor, normal or bright, for Depth16 and an
+// Ansi256Color for Depth256. Colors the depth already covers, and
+// colors without an RGB value, are returned as they are.
+func Quantize(c Color, depth ColorDepth) Color {
+	switch c.(type) {
+	case AnsiColor:
+		return c
+	case Ansi256Color:
+		if depth != Depth16 {
+			return c
+		}
+	}
+	tc, ok := truecolor(c)
+	if !ok {
+		return c
+	}
+	switch depth {
+	case Depth256:
+		return quantize256(tc)
+	case Depth16:
+		return quantize16(tc)
+	}
+	return c
+}
+
+// quantize16 returns the ANSI color nearest to tc
+func quantize16(tc TrueColor) AnsiColor {
+	best := 0
+	for i := range xterm16 {
+		if colordistance(tc, xterm16[i]) < colordistance(tc, xterm16[best]) {
+			best = i
+		}
+	}
+	if best < 8 {
+		return AnsiColor{30 + best}
+	}
+	return AnsiColor{90 + best - 8}
+}
+
+// quantize256 returns the xterm color nearest to tc, from the color cube
+// or the grays. The first 16 are left out as terminals change them.
+func quantize256(tc TrueColor) Ansi256Color {
+	level := func(v int) int {
+		best := 0
+		for i, l := range cubelevels {
+			if abs(v-l) < abs(v-cubelevels[best]) {
+				best = i
+			}
+		}
+		return best
+	}
+	r, g, b := level(tc.R), level(tc.G), level(tc.B)
+	cube := Ansi256Color{16 + 36*r + 6*g + b}
+	gray := Ansi256Color{232 + max(0, min(23, ((tc.R+tc.G+tc.B)/3-3)/10))}
+	if colordistance(tc, gray.RGB()) < colordistance(tc, cube.RGB()) {
+		return gray
+	}
+	return cube
+}
+
+// colordistance returns how far apart two colors look, as a squared
+// distance weighted by the mean red, which is close enough to how eyes
+// see it to pick the nearest color
+func colordistance(a, b TrueColor) int {
+	rmean := (a.R + b.R) / 2
+	dr, dg, db := a.R-b.R, a.G-b.G, a.B-b.B
+	return (512+rmean)*dr*dr>>8 + 4*dg*dg + (767-rmean)*db*db>>8
+}
+
+// quantize returns the color the Config writes for c, reduced to its
+// ColorDepth on the terminal-color parser
+func (cfg *Config) quantize(c Color) Color {
+	if cfg.ColorDepth == DepthTrueColor || cfg.OutputParser == nil || cfg.OutputParser.Name != "terminal-color" {
+		return c
+	}
+	return Quantize(c, cfg.ColorDepth)
+}
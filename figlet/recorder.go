@@ -0,0 +1,119 @@
+package figlet
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Recorder renders and plays animations with a Config, like RenderString
+// and PlayAnimation, and records what they output with the time it
+// happened, so a tool using the library can save a session and replay it
+// for demo recordings with `figlet replay`
+type Recorder struct {
+	Config *Config
+	start  time.Time
+	mu     sync.Mutex
+	events []SessionEvent
+}
+
+// Session is a recording made by a Recorder
+type Session struct {
+	Events []SessionEvent `json:"events"`
+}
+
+// SessionEvent is a banner or an animation output during a session
+type SessionEvent struct {
+	// TimeMs is when the output started, in milliseconds from the start
+	// of the session
+	TimeMs int64 `json:"timeMs"`
+	// Output is a rendered banner, empty for an animation
+	Output string `json:"output,omitempty"`
+	// Frames are the frames of a played animation
+	Frames []SessionFrame `json:"frames,omitempty"`
+}
+
+// SessionFrame is a frame of an animation played during a session
+type SessionFrame struct {
+	Content        string `json:"content"`
+	DelayMs        int64  `json:"delayMs"`
+	BaselineOffset int    `json:"baselineOffset"`
+	Baseline       int    `json:"baseline"`
+}
+
+// NewRecorder returns a Recorder for cfg whose session starts now
+func NewRecorder(cfg *Config) *Recorder {
+	return &Recorder{Config: cfg, start: time.Now()}
+}
+
+// RenderString renders text like Config.RenderString and records the result
+func (r *Recorder) RenderString(text string, opts ...RenderOption) string {
+	at := time.Since(r.start)
+	out := r.Config.RenderString(text, opts...)
+	r.record(SessionEvent{TimeMs: at.Milliseconds(), Output: out})
+	return out
+}
+
+// PlayAnimation records frames and plays them like PlayAnimation
+func (r *Recorder) PlayAnimation(frames []Frame) {
+	event := SessionEvent{TimeMs: time.Since(r.start).Milliseconds(), Frames: make([]SessionFrame, len(frames))}
+	for i, f := range frames {
+		event.Frames[i] = SessionFrame{f.Content, f.Delay.Milliseconds(), f.BaselineOffset, f.Baseline}
+	}
+	r.record(event)
+	PlayAnimation(r.Config, frames)
+}
+
+// record adds an event to the session
+func (r *Recorder) record(event SessionEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+// Session returns what was recorded so far
+func (r *Recorder) Session() Session {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return Session{Events: append([]SessionEvent{}, r.events...)}
+}
+
+// WriteSession writes what was recorded so far as JSON
+func (r *Recorder) WriteSession(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r.Session())
+}
+
+// ReadSession reads a session written by WriteSession
+func ReadSession(rd io.Reader) (Session, error) {
+	var s Session
+	if err := json.NewDecoder(rd).Decode(&s); err != nil {
+		return Session{}, fmt.Errorf("invalid session: %w", err)
+	}
+	return s, nil
+}
+
+// ReplaySession prints the banners and plays the animations of a session
+// on stdout, each at the time it was recorded, or after the previous one
+// ends if that is later
+func ReplaySession(cfg *Config, s Session) {
+	start := time.Now()
+	for _, event := range s.Events {
+		if wait := time.Duration(event.TimeMs)*time.Millisecond - time.Since(start); wait > 0 {
+			time.Sleep(wait)
+		}
+		if event.Frames == nil {
+			os.Stdout.WriteString(event.Output)
+			continue
+		}
+		frames := make([]Frame, len(event.Frames))
+		for i, f := range event.Frames {
+			frames[i] = Frame{f.Content, time.Duration(f.DelayMs) * time.Millisecond, f.BaselineOffset, f.Baseline}
+		}
+		PlayAnimation(cfg, frames)
+	}
+}
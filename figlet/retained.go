@@ -0,0 +1,155 @@
+package figlet
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// RetainedBanner keeps a banner on a terminal that is updated often, such
+// as a clock or a score. It remembers the cells it last drew and, on each
+// Update, writes only the cells that changed, moving the cursor to them
+// with ANSI codes, instead of redrawing the whole banner. A RetainedBanner
+// is safe for concurrent use; the Config it renders with must not be used
+// elsewhere meanwhile.
+type RetainedBanner struct {
+	cfg     *Config
+	w       io.Writer
+	mu      sync.Mutex
+	cells   [][]string // the cells last drawn, with their color codes
+	started bool
+}
+
+// NewRetainedBanner returns a banner rendered with cfg, whose font must be
+// loaded, and drawn on w, which should be a terminal. The banner is drawn
+// from the line the cursor is on.
+func NewRetainedBanner(cfg *Config, w io.Writer) *RetainedBanner {
+	return &RetainedBanner{cfg: cfg, w: w}
+}
+
+// Update renders text and redraws the cells that differ from the last
+// update. The cursor is left on the line below the banner.
+func (b *RetainedBanner) Update(text string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var cells [][]string
+	err := b.cfg.apply(nil, func() error {
+		cells = b.cfg.cellgrid(b.cfg.layoutrows(b.cfg.compose(text)))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(b.w, b.diff(cells))
+	b.cells, b.started = cells, true
+	return err
+}
+
+// Done leaves the banner in place and shows the cursor again. Updates
+// after Done draw a new banner below this one.
+func (b *RetainedBanner) Done() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.close("")
+}
+
+// Clear erases the banner and shows the cursor again
+func (b *RetainedBanner) Clear() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var erase string
+	if len(b.cells) > 0 {
+		erase = fmt.Sprintf("\033[%dA\033[J", len(b.cells))
+	}
+	return b.close(erase)
+}
+
+// close writes codes, shows the cursor and forgets the banner
+func (b *RetainedBanner) close(codes string) error {
+	if b.started {
+		codes += "\033[?25h"
+	}
+	b.cells, b.started = nil, false
+	_, err := io.WriteString(b.w, codes)
+	return err
+}
+
+// diff returns the codes that turn the banner last drawn into cells. The
+// cursor starts and ends on the line below the banner.
+func (b *RetainedBanner) diff(cells [][]string) string {
+	var sb strings.Builder
+	if !b.started {
+		sb.WriteString("\033[?25l") // Hide cursor
+	}
+	y := len(b.cells)
+	moveto := func(row, col int) {
+		if row < y {
+			fmt.Fprintf(&sb, "\033[%dA", y-row)
+		} else if row > y {
+			fmt.Fprintf(&sb, "\033[%dB", row-y)
+		}
+		fmt.Fprintf(&sb, "\033[%dG", col+1)
+		y = row
+	}
+	// cell returns the cell at col of a row, past its end a space
+	cell := func(row []string, col int) string {
+		if col < len(row) {
+			return row[col]
+		}
+		return " "
+	}
+
+	for row := 0; row < len(cells) && row < len(b.cells); row++ {
+		next, prev := cells[row], b.cells[row]
+		width := max(len(next), len(prev))
+		for col := 0; col < width; col++ {
+			if cell(next, col) == cell(prev, col) {
+				continue
+			}
+			moveto(row, col)
+			for ; col < width && cell(next, col) != cell(prev, col); col++ {
+				sb.WriteString(cell(next, col))
+			}
+		}
+	}
+
+	if len(cells) < len(b.cells) {
+		// Clear the rows of a taller banner
+		moveto(len(cells), 0)
+		sb.WriteString("\033[J")
+	} else {
+		// Rows past the last banner are new lines, written in full
+		moveto(len(b.cells), 0)
+		for _, row := range cells[len(b.cells):] {
+			sb.WriteString(strings.Join(row, ""))
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+// cellgrid returns the cells of laid out rows, padding and fill
+// included, as strings with their color codes
+func (cfg *Config) cellgrid(rows []outputrow) [][]string {
+	hasColors := (len(cfg.Colors) > 0 || cfg.Style != 0) && cfg.OutputParser != nil && cfg.OutputParser.Name != "terminal"
+	cells := make([][]string, len(rows))
+	for y, row := range rows {
+		line := make([]string, 0, row.pad+len(row.cells)+row.fill)
+		for i := 0; i < row.pad; i++ {
+			line = append(line, " ")
+		}
+		for i, r := range row.cells {
+			s := cfg.cellstring(r)
+			if hasColors {
+				s = cfg.applyColorWithIndex(s, cfg.cellindex(row, y, row.pad+i))
+			}
+			line = append(line, s)
+		}
+		for i := 0; i < row.fill; i++ {
+			line = append(line, " ")
+		}
+		cells[y] = line
+	}
+	return cells
+}
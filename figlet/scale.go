@@ -0,0 +1,171 @@
+package figlet
+
+import "math"
+
+// MAXSCALE is the largest accepted scale factor
+const MAXSCALE = 16
+
+// WithScale resizes the output by factor in both directions once it is
+// composed, such as 0.5 to squeeze a big font into a narrow terminal
+// without switching fonts. Lines wrap at the width the scaled output
+// fits in. Set ScaleX and ScaleY to scale each direction on its own.
+func WithScale(factor float64) Option {
+	return func(cfg *Config) {
+		cfg.ScaleX, cfg.ScaleY = factor, factor
+	}
+}
+
+// Scale resizes a grid, such as one returned by RenderGrid, by factorX
+// across and factorY down. Enlarging duplicates cells; shrinking samples
+// them, keeping for each output cell the first non-space cell of those
+// it covers so thin strokes are not lost. Rows keep their ragged ends.
+func Scale(grid [][]rune, factorX, factorY float64) [][]rune {
+	out, _ := scaleblock(grid, nil, factorX, factorY, func(r rune) bool { return r == ' ' })
+	return out
+}
+
+// scales reports whether the output is resized, and by how much
+func (cfg *Config) scales() (x, y float64, ok bool) {
+	x, y = cfg.ScaleX, cfg.ScaleY
+	if x == 0 {
+		x = 1
+	}
+	if y == 0 {
+		y = 1
+	}
+	return x, y, x != 1 || y != 1
+}
+
+// linelimit returns the number of columns a line may take before it is
+// scaled, so that it fits in the output width once it is
+func (cfg *Config) linelimit() int {
+	x, _, ok := cfg.scales()
+	if !ok || cfg.Outputwidth <= 1 {
+		return cfg.Outputwidth - 1
+	}
+	return max(1, int(float64(cfg.Outputwidth-1)/x))
+}
+
+// lineheight returns the number of rows of a FIGlet line once scaled
+func (cfg *Config) lineheight() int {
+	_, y, _ := cfg.scales()
+	return scaledsize(cfg.charheight, y)
+}
+
+// scalerows resizes each FIGlet line of the composed rows, keeping the
+// baseline on the last row that covers it
+func (cfg *Config) scalerows(rows []outputrow) []outputrow {
+	x, y, ok := cfg.scales()
+	if !ok || cfg.charheight == 0 {
+		return rows
+	}
+	var out []outputrow
+	for start := 0; start < len(rows); start += cfg.charheight {
+		line := rows[start:min(start+cfg.charheight, len(rows))]
+		cells, index := make([][]rune, len(line)), make([][]int, len(line))
+		baseline := -1
+		for n, row := range line {
+			cells[n], index[n] = row.cells, row.index
+			if row.baseline {
+				baseline = n
+			}
+		}
+		scaled, scaledindex := scaleblock(cells, index, x, y, cfg.isblank)
+		last := -1
+		for n := range scaled {
+			from, to := scalespan(n, len(line), len(scaled))
+			if baseline >= from && baseline < to {
+				last = n
+			}
+		}
+		for n := range scaled {
+			out = append(out, outputrow{cells: scaled[n], index: scaledindex[n], baseline: n == last})
+		}
+	}
+	return out
+}
+
+// scaledsize returns size scaled by factor and rounded, at least 1 unless
+// size is 0
+func scaledsize(size int, factor float64) int {
+	if size == 0 {
+		return 0
+	}
+	return max(1, int(math.Round(float64(size)*factor)))
+}
+
+// scalespan returns the range of source cells, from to to-1, that cell n
+// covers when size cells are resized to scaled
+func scalespan(n, size, scaled int) (from, to int) {
+	from = n * size / scaled
+	return from, max(from+1, (n+1)*size/scaled)
+}
+
+// scaleblock resizes rows of cells and, if index is not nil, their input
+// character indexes. Columns are mapped the same way in every row, from
+// the width of the widest, so the rows stay aligned.
+func scaleblock(cells [][]rune, index [][]int, x, y float64, blank func(rune) bool) ([][]rune, [][]int) {
+	width := 0
+	for _, row := range cells {
+		width = max(width, len(row))
+	}
+	height, scaledwidth := scaledsize(len(cells), y), scaledsize(width, x)
+	out, outindex := make([][]rune, height), make([][]int, height)
+	for n := range out {
+		top, bottom := scalespan(n, len(cells), height)
+		row := make([]rune, 0, scaledwidth)
+		var rowindex []int
+		if index != nil && index[top] != nil {
+			rowindex = make([]int, 0, scaledwidth)
+		}
+		for m := 0; m < scaledwidth; m++ {
+			left, right := scalespan(m, width, scaledwidth)
+			// The first drawn cell, else the first cell, of those covered
+			sy, sx := -1, -1
+			for yy := top; yy < bottom && (sy < 0 || blank(cells[sy][sx])); yy++ {
+				for xx := left; xx < min(right, len(cells[yy])); xx++ {
+					if sy < 0 || !blank(cells[yy][xx]) {
+						sy, sx = yy, xx
+						if !blank(cells[yy][xx]) {
+							break
+						}
+					}
+				}
+			}
+			if sy < 0 {
+				// Past the end of every covered row
+				row = append(row, ' ')
+				if rowindex != nil {
+					rowindex = append(rowindex, nocolor)
+				}
+				continue
+			}
+			row = append(row, cells[sy][sx])
+			if rowindex != nil {
+				i := nocolor
+				if sx < len(index[sy]) {
+					i = index[sy][sx]
+				}
+				rowindex = append(rowindex, i)
+			}
+		}
+		// Keep the ragged end: drop the columns past every covered row
+		end := len(row)
+		for end > 0 {
+			left, _ := scalespan(end-1, width, scaledwidth)
+			covered := false
+			for yy := top; yy < bottom; yy++ {
+				covered = covered || left < len(cells[yy])
+			}
+			if covered {
+				break
+			}
+			end--
+		}
+		out[n] = row[:end]
+		if rowindex != nil {
+			outindex[n] = rowindex[:end]
+		}
+	}
+	return out, outindex
+}
@@ -0,0 +1,65 @@
+package figlet
+
+import (
+	"sort"
+	"time"
+)
+
+// Schedule holds when each frame of an animation starts, counted from the
+// start of the animation; its last element is when the animation ends.
+// Players wait for these times on the monotonic clock instead of sleeping
+// each frame's delay after drawing it, so slow terminals do not drift, and
+// exports take their timestamps from it so they play in step.
+type Schedule []time.Duration
+
+// NewSchedule returns the schedule of frames shown for delays
+func NewSchedule(delays []time.Duration) Schedule {
+	s := make(Schedule, len(delays)+1)
+	for i, delay := range delays {
+		s[i+1] = s[i] + delay
+	}
+	return s
+}
+
+// FrameDelays returns the delay of every frame, for NewSchedule
+func FrameDelays(frames []Frame) []time.Duration {
+	delays := make([]time.Duration, len(frames))
+	for i, frame := range frames {
+		delays[i] = frame.Delay
+	}
+	return delays
+}
+
+// Duration returns how long the animation plays
+func (s Schedule) Duration() time.Duration {
+	return s[len(s)-1]
+}
+
+// FrameAt returns the frame due elapsed after the start: the last one
+// once the animation is over, or -1 if there are no frames
+func (s Schedule) FrameAt(elapsed time.Duration) int {
+	frames := len(s) - 1
+	i := sort.Search(frames, func(i int) bool { return s[i+1] > elapsed })
+	return min(i, frames-1)
+}
+
+// Delays returns the delay of every frame rounded to a multiple of unit,
+// such as the hundredths of a second of a GIF. The start times are
+// rounded rather than the delays, so rounding errors do not add up.
+func (s Schedule) Delays(unit time.Duration) []time.Duration {
+	delays := make([]time.Duration, len(s)-1)
+	for i := range delays {
+		delays[i] = s[i+1].Round(unit) - s[i].Round(unit)
+	}
+	return delays
+}
+
+// WithSkipLateFrames makes PlayAnimation and PlayDeltas skip the frames
+// whose time is already over when playback falls behind, as on a slow
+// terminal, so the animation keeps to its schedule. The last frame is
+// always drawn.
+func WithSkipLateFrames() Option {
+	return func(cfg *Config) {
+		cfg.SkipLateFrames = true
+	}
+}
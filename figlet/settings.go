@@ -0,0 +1,223 @@
+package figlet
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Settings holds the settings of a Config that can be saved and loaded as
+// JSON: in user config files, in requests to the HTTP handler or across
+// the WebAssembly layer. Empty fields leave a Config as it is when the
+// settings are applied.
+type Settings struct {
+	// Font is the font name
+	Font string `json:"font,omitempty"`
+	// Width is the output width
+	Width int `json:"width,omitempty"`
+	// Justification is auto, left, center, right or full
+	Justification string `json:"justification,omitempty"`
+	// Direction is auto, left-to-right or right-to-left
+	Direction string `json:"direction,omitempty"`
+	// Smush overrides the font's layout; nil keeps it
+	Smush *SmushRules `json:"smush,omitempty"`
+	// Colors are written as ParseColor reads them. An empty list, as
+	// opposed to none, removes the colors.
+	Colors []string `json:"colors,omitempty"`
+	// Style is a list of style names, as ParseStyle reads them
+	Style string `json:"style,omitempty"`
+	// ColorDepth is 16, 256 or truecolor, as ParseColorDepth reads it
+	ColorDepth string `json:"colorDepth,omitempty"`
+	// Parser is the name of the output parser
+	Parser string `json:"parser,omitempty"`
+	// Border is a border style name, as ParseBorderStyle reads it, or none
+	Border string `json:"border,omitempty"`
+}
+
+// justifications names the values of Config.Justification, from JustifyAuto
+var justifications = []string{"auto", "left", "center", "right", "full"}
+
+// directions names the values of Config.Right2left, from -1
+var directions = []string{"auto", "left-to-right", "right-to-left"}
+
+// Settings returns the settings of the Config. Justification and
+// direction are those resolved from the font once it is loaded; the
+// layout is only given when it overrides the font's.
+func (cfg *Config) Settings() Settings {
+	s := Settings{
+		Font:   cfg.Fontname,
+		Width:  cfg.Outputwidth,
+		Style:  cfg.Style.String(),
+		Colors: make([]string, 0, len(cfg.Colors)),
+	}
+	if j := cfg.Justification - JustifyAuto; j >= 0 && j < len(justifications) {
+		s.Justification = justifications[j]
+	}
+	if d := cfg.Right2left + 1; d >= 0 && d < len(directions) {
+		s.Direction = directions[d]
+	}
+	if cfg.Smushoverride != SMO_NO {
+		rules := cfg.SmushRules()
+		s.Smush = &rules
+	}
+	for _, c := range cfg.Colors {
+		s.Colors = append(s.Colors, colorname(c))
+	}
+	if cfg.ColorDepth != DepthTrueColor {
+		s.ColorDepth = cfg.ColorDepth.String()
+	}
+	if cfg.OutputParser != nil {
+		s.Parser = cfg.OutputParser.Name
+	}
+	if cfg.Border != BorderNone {
+		s.Border = cfg.Border.String()
+	}
+	return s
+}
+
+// ApplySettings sets the Config from the non-empty settings. Nothing is
+// changed when one of them is invalid. A new font is loaded by the next
+// LoadFont.
+func (cfg *Config) ApplySettings(s Settings) error {
+	opts, err := s.options()
+	if err != nil {
+		return err
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return nil
+}
+
+// options returns the options setting the non-empty settings
+func (s Settings) options() ([]Option, error) {
+	var opts []Option
+	if s.Font != "" {
+		opts = append(opts, WithFont(s.Font))
+	}
+	if s.Width < 0 {
+		return nil, fmt.Errorf("invalid width: %d", s.Width)
+	}
+	if s.Width > 0 {
+		opts = append(opts, WithWidth(s.Width))
+	}
+	if s.Justification != "" {
+		j := indexof(justifications, s.Justification)
+		if j < 0 {
+			return nil, fmt.Errorf("invalid justification: %s (valid: %s)", s.Justification, strings.Join(justifications, ", "))
+		}
+		opts = append(opts, WithJustification(j+JustifyAuto))
+	}
+	if s.Direction != "" {
+		d := indexof(directions, s.Direction)
+		if d < 0 {
+			return nil, fmt.Errorf("invalid direction: %s (valid: %s)", s.Direction, strings.Join(directions, ", "))
+		}
+		opts = append(opts, WithRightToLeft(d-1))
+	}
+	if s.Smush != nil {
+		opts = append(opts, WithSmushRules(*s.Smush))
+	}
+	// The parser goes before the colors, which only pick terminal-color
+	// in place of terminal
+	if s.Parser != "" {
+		parser, err := GetParser(s.Parser)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithOutputParser(parser))
+	}
+	if s.Colors != nil {
+		colors := make([]Color, len(s.Colors))
+		for i, name := range s.Colors {
+			var err error
+			if colors[i], err = ParseColor(name); err != nil {
+				return nil, err
+			}
+		}
+		opts = append(opts, WithColors(colors...))
+	}
+	if s.Style != "" {
+		style, err := ParseStyle(s.Style)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithStyle(style))
+	}
+	if s.ColorDepth != "" {
+		depth, err := ParseColorDepth(s.ColorDepth)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithColorDepth(depth))
+	}
+	if s.Border != "" {
+		border := BorderNone
+		if !strings.EqualFold(s.Border, "none") {
+			var err error
+			if border, err = ParseBorderStyle(s.Border); err != nil {
+				return nil, err
+			}
+		}
+		opts = append(opts, WithBorder(border))
+	}
+	return opts, nil
+}
+
+// indexof returns the index of name in names, ignoring case, or -1
+func indexof(names []string, name string) int {
+	for i, n := range names {
+		if strings.EqualFold(n, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// colorname returns c as ParseColor reads it: the name of the eight ANSI
+// colors and the hex RGB value of any other
+func colorname(c Color) string {
+	if ac, ok := c.(AnsiColor); ok {
+		for name, named := range ansinames {
+			if named == ac {
+				return name
+			}
+		}
+	}
+	tc, _ := truecolor(c)
+	return fmt.Sprintf("#%02x%02x%02x", tc.R, tc.G, tc.B)
+}
+
+// MarshalJSON writes the Settings of the Config
+func (cfg *Config) MarshalJSON() ([]byte, error) {
+	return json.Marshal(cfg.Settings())
+}
+
+// UnmarshalJSON applies settings read from JSON to the Config, which
+// should be made with New
+func (cfg *Config) UnmarshalJSON(data []byte) error {
+	var s Settings
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return cfg.ApplySettings(s)
+}
+
+// MarshalText returns the name of the layout mode
+func (m LayoutMode) MarshalText() ([]byte, error) {
+	if m < LayoutFullWidth || m > LayoutSmushing {
+		return nil, fmt.Errorf("invalid layout mode: %d", int(m))
+	}
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText reads a layout mode name: full-width, kerning or smushing
+func (m *LayoutMode) UnmarshalText(text []byte) error {
+	for mode := LayoutFullWidth; mode <= LayoutSmushing; mode++ {
+		if mode.String() == string(text) {
+			*m = mode
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid layout mode: %s (valid: full-width, kerning, smushing)", text)
+}
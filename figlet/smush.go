@@ -0,0 +1,384 @@
+package figlet
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LayoutMode is the horizontal layout used to join characters
+type LayoutMode int
+
+const (
+	// LayoutFullWidth places characters side by side at their full width
+	LayoutFullWidth LayoutMode = iota
+	// LayoutKerning moves characters together until they touch
+	LayoutKerning
+	// LayoutSmushing overlaps characters by one column, merging the touching
+	// sub-characters with the enabled rules (universal smushing if none is set)
+	LayoutSmushing
+)
+
+// String returns the name of the layout mode
+func (m LayoutMode) String() string {
+	switch m {
+	case LayoutFullWidth:
+		return "full-width"
+	case LayoutKerning:
+		return "kerning"
+	case LayoutSmushing:
+		return "smushing"
+	}
+	return "unknown"
+}
+
+// SmushRules is a symbolic form of the smush mode bitmask (Config.Smushmode)
+type SmushRules struct {
+	Mode LayoutMode `json:"mode"`
+	// Equal smushes two identical sub-characters into one (rule 1)
+	Equal bool `json:"equal,omitempty"`
+	// Underscore lets an underscore be replaced by |/\[]{}()<> (rule 2)
+	Underscore bool `json:"underscore,omitempty"`
+	// Hierarchy lets the higher class of |, /\, [], {}, (), <> win (rule 3)
+	Hierarchy bool `json:"hierarchy,omitempty"`
+	// OppositePairs turns opposing brackets into a vertical bar (rule 4)
+	OppositePairs bool `json:"oppositePairs,omitempty"`
+	// BigX turns /\ into |, \/ into Y and >< into X (rule 5)
+	BigX bool `json:"bigX,omitempty"`
+	// Hardblank smushes two hardblanks into one (rule 6)
+	Hardblank bool `json:"hardblank,omitempty"`
+}
+
+// SmushRulesFromBits converts a Config.Smushmode bitmask (SM_* flags)
+func SmushRulesFromBits(bits int) SmushRules {
+	rules := SmushRules{
+		Equal:         bits&SM_EQUAL != 0,
+		Underscore:    bits&SM_LOWLINE != 0,
+		Hierarchy:     bits&SM_HIERARCHY != 0,
+		OppositePairs: bits&SM_PAIR != 0,
+		BigX:          bits&SM_BIGX != 0,
+		Hardblank:     bits&SM_HARDBLANK != 0,
+	}
+	if bits&SM_SMUSH != 0 {
+		rules.Mode = LayoutSmushing
+	} else if bits&SM_KERN != 0 {
+		rules.Mode = LayoutKerning
+	}
+	return rules
+}
+
+// SmushRulesFromMode converts a smush mode as given to -m or WithSmushMode:
+// -1 is full width, 0 is kerning and a positive value is smushing with the
+// rules in its low 6 bits (a value with no rule bits means universal smushing).
+// Values below -1, meaning "use the font's default", give full width.
+func SmushRulesFromMode(mode int) SmushRules {
+	switch {
+	case mode == 0:
+		return SmushRules{Mode: LayoutKerning}
+	case mode < 0:
+		return SmushRules{Mode: LayoutFullWidth}
+	}
+	return SmushRulesFromBits((mode & 63) | SM_SMUSH)
+}
+
+// Bits returns the Config.Smushmode bitmask for the rules. Rules only take
+// effect in LayoutSmushing mode.
+func (r SmushRules) Bits() int {
+	bits := 0
+	switch r.Mode {
+	case LayoutKerning:
+		return SM_KERN
+	case LayoutSmushing:
+		bits = SM_SMUSH
+	default:
+		return 0
+	}
+	if r.Equal {
+		bits |= SM_EQUAL
+	}
+	if r.Underscore {
+		bits |= SM_LOWLINE
+	}
+	if r.Hierarchy {
+		bits |= SM_HIERARCHY
+	}
+	if r.OppositePairs {
+		bits |= SM_PAIR
+	}
+	if r.BigX {
+		bits |= SM_BIGX
+	}
+	if r.Hardblank {
+		bits |= SM_HARDBLANK
+	}
+	return bits
+}
+
+// Universal reports whether smushing uses no rules, overlapping any two
+// sub-characters
+func (r SmushRules) Universal() bool {
+	return r.Mode == LayoutSmushing && r.Bits()&63 == 0
+}
+
+// SmushRules returns the layout in effect. After LoadFont this includes the
+// font's default layout unless it was overridden.
+func (cfg *Config) SmushRules() SmushRules {
+	return SmushRulesFromBits(cfg.Smushmode)
+}
+
+// WithSmushRules overrides the font's layout with the given rules
+func WithSmushRules(rules SmushRules) Option {
+	return func(cfg *Config) {
+		cfg.Smushmode = rules.Bits()
+		cfg.Smushoverride = SMO_YES
+	}
+}
+
+// WithOverlap moves every character up to n more columns into the one
+// before it than the layout allows, to fit text where space is scarce.
+// Overlapping sub-characters are smushed by the font's rules; when none
+// applies, a visible sub-character wins over a blank one and the later
+// character wins over the earlier.
+func WithOverlap(n int) Option {
+	return func(cfg *Config) {
+		cfg.Overlap = n
+	}
+}
+
+// RuleApplied identifies how two overlapping sub-characters were smushed
+type RuleApplied int
+
+const (
+	// RuleNone means the sub-characters cannot be smushed
+	RuleNone RuleApplied = iota
+	// RuleSpace means one sub-character is a space and the other one is kept
+	RuleSpace
+	// RuleUniversal means universal smushing kept the later sub-character
+	// (the earlier one for right-to-left text), ignoring hardblanks
+	RuleUniversal
+	// RuleEqual is rule 1, equal character smushing
+	RuleEqual
+	// RuleUnderscore is rule 2, underscore smushing
+	RuleUnderscore
+	// RuleHierarchy is rule 3, hierarchy smushing
+	RuleHierarchy
+	// RuleOppositePair is rule 4, opposite pair smushing
+	RuleOppositePair
+	// RuleBigX is rule 5, big X smushing
+	RuleBigX
+	// RuleHardblank is rule 6, hardblank smushing
+	RuleHardblank
+)
+
+// String returns a short name for the rule
+func (r RuleApplied) String() string {
+	switch r {
+	case RuleNone:
+		return "none"
+	case RuleSpace:
+		return "space"
+	case RuleUniversal:
+		return "universal"
+	case RuleEqual:
+		return "equal"
+	case RuleUnderscore:
+		return "underscore"
+	case RuleHierarchy:
+		return "hierarchy"
+	case RuleOppositePair:
+		return "opposite-pair"
+	case RuleBigX:
+		return "big-x"
+	case RuleHardblank:
+		return "hardblank"
+	}
+	return "unknown"
+}
+
+// Smush merges the sub-character l, at the right edge of one character,
+// with r, at the left edge of the next, as FIGlet does when smushing. It
+// returns the resulting sub-character and the rule that produced it, or 0
+// and RuleNone if they cannot be smushed. Only LayoutSmushing rules smush
+// two visible sub-characters.
+func Smush(l, r rune, rules SmushRules, hardblank rune) (rune, RuleApplied) {
+	return smush(l, r, rules.Bits(), hardblank, false)
+}
+
+// smush applies the smush mode bitmask to a pair of sub-characters. In
+// universal smushing right2left keeps lch instead of rch.
+func smush(lch, rch rune, mode int, hardblank rune, right2left bool) (rune, RuleApplied) {
+	if lch == ' ' {
+		return rch, RuleSpace
+	}
+	if rch == ' ' {
+		return lch, RuleSpace
+	}
+
+	if (mode & SM_SMUSH) == 0 {
+		return 0, RuleNone
+	}
+
+	if (mode & 63) == 0 {
+		if lch == hardblank {
+			return rch, RuleUniversal
+		}
+		if rch == hardblank {
+			return lch, RuleUniversal
+		}
+		if right2left {
+			return lch, RuleUniversal
+		}
+		return rch, RuleUniversal
+	}
+
+	if (mode & SM_HARDBLANK) != 0 {
+		if lch == hardblank && rch == hardblank {
+			return lch, RuleHardblank
+		}
+	}
+
+	if lch == hardblank || rch == hardblank {
+		return 0, RuleNone
+	}
+
+	if (mode & SM_EQUAL) != 0 {
+		if lch == rch {
+			return lch, RuleEqual
+		}
+	}
+
+	if (mode & SM_LOWLINE) != 0 {
+		if lch == '_' && strings.ContainsRune("|/\\[]{}()<>", rch) {
+			return rch, RuleUnderscore
+		}
+		if rch == '_' && strings.ContainsRune("|/\\[]{}()<>", lch) {
+			return lch, RuleUnderscore
+		}
+	}
+
+	if (mode & SM_HIERARCHY) != 0 {
+		if lch == '|' && strings.ContainsRune("/\\[]{}()<>", rch) {
+			return rch, RuleHierarchy
+		}
+		if rch == '|' && strings.ContainsRune("/\\[]{}()<>", lch) {
+			return lch, RuleHierarchy
+		}
+		if strings.ContainsRune("/\\", lch) && strings.ContainsRune("[]{}()<>", rch) {
+			return rch, RuleHierarchy
+		}
+		if strings.ContainsRune("/\\", rch) && strings.ContainsRune("[]{}()<>", lch) {
+			return lch, RuleHierarchy
+		}
+		if strings.ContainsRune("[]", lch) && strings.ContainsRune("{}()<>", rch) {
+			return rch, RuleHierarchy
+		}
+		if strings.ContainsRune("[]", rch) && strings.ContainsRune("{}()<>", lch) {
+			return lch, RuleHierarchy
+		}
+		if strings.ContainsRune("{}", lch) && strings.ContainsRune("()<>", rch) {
+			return rch, RuleHierarchy
+		}
+		if strings.ContainsRune("{}", rch) && strings.ContainsRune("()<>", lch) {
+			return lch, RuleHierarchy
+		}
+		if strings.ContainsRune("()", lch) && strings.ContainsRune("<>", rch) {
+			return rch, RuleHierarchy
+		}
+		if strings.ContainsRune("()", rch) && strings.ContainsRune("<>", lch) {
+			return lch, RuleHierarchy
+		}
+	}
+
+	if (mode & SM_PAIR) != 0 {
+		if lch == '[' && rch == ']' {
+			return '|', RuleOppositePair
+		}
+		if rch == '[' && lch == ']' {
+			return '|', RuleOppositePair
+		}
+		if lch == '{' && rch == '}' {
+			return '|', RuleOppositePair
+		}
+		if rch == '{' && lch == '}' {
+			return '|', RuleOppositePair
+		}
+		if lch == '(' && rch == ')' {
+			return '|', RuleOppositePair
+		}
+		if rch == '(' && lch == ')' {
+			return '|', RuleOppositePair
+		}
+	}
+
+	if (mode & SM_BIGX) != 0 {
+		if lch == '/' && rch == '\\' {
+			return '|', RuleBigX
+		}
+		if rch == '/' && lch == '\\' {
+			return 'Y', RuleBigX
+		}
+		if lch == '>' && rch == '<' {
+			return 'X', RuleBigX
+		}
+	}
+
+	return 0, RuleNone
+}
+
+// SmushEvent records one pair of sub-characters smushed while rendering
+type SmushEvent struct {
+	// Line is the FIGlet line of the output, counting from 0
+	Line int
+	// Row is the row within the FIGlet line
+	Row int
+	// Column is the column of the result in the line, before justification
+	Column int
+	// Char is the input character being added
+	Char rune
+	// Left and Right are the sub-characters that overlapped
+	Left, Right rune
+	// Result is the sub-character that replaced them
+	Result rune
+	Rule   RuleApplied
+}
+
+// String formats the event for display
+func (e SmushEvent) String() string {
+	return fmt.Sprintf("line %d row %d column %d %q: %q + %q = %q (%s)",
+		e.Line, e.Row, e.Column, e.Char, e.Left, e.Right, e.Result, e.Rule)
+}
+
+// WithTraceSmushing records the smushed pairs of sub-characters, see SmushTrace
+func WithTraceSmushing() Option {
+	return func(cfg *Config) {
+		cfg.TraceSmushing = true
+	}
+}
+
+// SmushTrace returns the pairs of visible sub-characters smushed by the last
+// render, in the order they were merged. It is only recorded when
+// TraceSmushing is set.
+func (cfg *Config) SmushTrace() []SmushEvent {
+	return cfg.smushtrace
+}
+
+// tracesmush smushes the sub-characters that overlap when c is added,
+// recording the event if TraceSmushing is set
+func (cfg *Config) tracesmush(c rune, row, column int, lch, rch rune) rune {
+	if !cfg.TraceSmushing {
+		return cfg.smushem(lch, rch)
+	}
+	result, rule := cfg.smushrule(lch, rch)
+	if rule != RuleSpace {
+		cfg.smushtrace = append(cfg.smushtrace, SmushEvent{
+			Line:   len(cfg.rows) / max(cfg.charheight, 1),
+			Row:    row,
+			Column: column,
+			Char:   c,
+			Left:   lch,
+			Right:  rch,
+			Result: result,
+			Rule:   rule,
+		})
+	}
+	return result
+}
@@ -0,0 +1,103 @@
+package figlet
+
+import (
+	"context"
+	"time"
+)
+
+// Cell is one character cell of a structured frame
+type Cell struct {
+	Char rune
+	// Color is an index into the Animator's Palette, Config.Colors then
+	// the highlight color, or -1 for an uncolored cell
+	Color int
+}
+
+// StructuredFrame is an animation frame as a grid of cells, for renderers
+// that draw characters themselves, such as a canvas, instead of printing
+// text with color codes or markup
+type StructuredFrame struct {
+	// Cells holds one slice per line; lines can have different lengths
+	Cells          [][]Cell
+	Delay          time.Duration
+	BaselineOffset int
+	Baseline       int
+}
+
+// CellChange is a cell that differs from the previous frame
+type CellChange struct {
+	X, Y int
+	Cell Cell
+}
+
+// GenerateStructured generates an animation like GenerateAnimation and
+// returns its frames as cell grids. Frame filters apply to the delays and
+// offsets, but not to the cells.
+func (a *Animator) GenerateStructured(text string, animType string, delay time.Duration) ([]StructuredFrame, error) {
+	return a.GenerateStructuredContext(context.Background(), text, animType, delay)
+}
+
+// GenerateStructuredContext generates structured frames like
+// GenerateStructured, stopping with the context's error when it is done
+// before all the frames are generated
+func (a *Animator) GenerateStructuredContext(ctx context.Context, text string, animType string, delay time.Duration) ([]StructuredFrame, error) {
+	a.structured, a.grids = true, nil
+	defer func() {
+		a.structured, a.grids = false, nil
+	}()
+	frames, err := a.GenerateAnimationContext(ctx, text, animType, delay)
+	if err != nil {
+		return nil, err
+	}
+	structured := make([]StructuredFrame, len(frames))
+	for i, f := range frames {
+		structured[i] = StructuredFrame{
+			Cells:          a.grids[i],
+			Delay:          f.Delay,
+			BaselineOffset: f.BaselineOffset,
+			Baseline:       f.Baseline,
+		}
+	}
+	return structured, nil
+}
+
+// cell returns the cell for a character with the given color index
+func (a *Animator) cell(r rune, index int) Cell {
+	if index == highlighted {
+		return Cell{r, len(a.Config.Colors)}
+	}
+	if index < 0 || len(a.Config.Colors) == 0 {
+		return Cell{r, -1}
+	}
+	return Cell{r, index % len(a.Config.Colors)}
+}
+
+// DiffCells returns the cells of next that differ from prev. Cells of prev
+// outside next are reported as uncolored spaces, so applying the changes
+// to a screen showing prev leaves it showing next.
+func DiffCells(prev, next [][]Cell) []CellChange {
+	var changes []CellChange
+	blank := Cell{' ', -1}
+	for y := 0; y < max(len(prev), len(next)); y++ {
+		var before, after []Cell
+		if y < len(prev) {
+			before = prev[y]
+		}
+		if y < len(next) {
+			after = next[y]
+		}
+		for x := 0; x < max(len(before), len(after)); x++ {
+			old, cell := blank, blank
+			if x < len(before) {
+				old = before[x]
+			}
+			if x < len(after) {
+				cell = after[x]
+			}
+			if old != cell {
+				changes = append(changes, CellChange{x, y, cell})
+			}
+		}
+	}
+	return changes
+}
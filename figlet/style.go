@@ -0,0 +1,136 @@
+package figlet
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Style is a set of text attributes applied to the output cells, on top of
+// any colors. Styles can be combined with |. Like colors, they are only
+// emitted by the terminal-color and html parsers.
+type Style int
+
+const (
+	// StyleBold draws bold (bright) text
+	StyleBold Style = 1 << iota
+	// StyleDim draws faint text
+	StyleDim
+	// StyleItalic draws italic text
+	StyleItalic
+	// StyleUnderline underlines every cell
+	StyleUnderline
+	// StyleBlink makes the text blink. There is no inline CSS for it, so
+	// html output uses text-decoration: blink, which browsers ignore.
+	StyleBlink
+	// StyleReverse swaps the foreground and background colors
+	StyleReverse
+	// StyleStrikethrough draws a line through every cell
+	StyleStrikethrough
+)
+
+// styles lists every style with its name, SGR code and CSS declaration
+var styles = []struct {
+	style Style
+	name  string
+	sgr   int
+	css   string
+}{
+	{StyleBold, "bold", 1, "font-weight: bold"},
+	{StyleDim, "dim", 2, "opacity: 0.5"},
+	{StyleItalic, "italic", 3, "font-style: italic"},
+	{StyleUnderline, "underline", 4, "text-decoration: underline"},
+	{StyleBlink, "blink", 5, "text-decoration: blink"},
+	{StyleReverse, "reverse", 7, "filter: invert(100%)"},
+	{StyleStrikethrough, "strikethrough", 9, "text-decoration: line-through"},
+}
+
+// WithStyle sets the text attributes of the output. Several styles are
+// combined, so WithStyle(StyleBold, StyleUnderline) is the same as
+// WithStyle(StyleBold|StyleUnderline).
+func WithStyle(style ...Style) Option {
+	return func(cfg *Config) {
+		cfg.Style = 0
+		for _, s := range style {
+			cfg.Style |= s
+		}
+	}
+}
+
+// ListStyles returns the style names accepted by ParseStyle
+func ListStyles() []string {
+	names := make([]string, len(styles))
+	for i, s := range styles {
+		names[i] = s.name
+	}
+	return names
+}
+
+// ParseStyle parses a comma separated list of style names, such as
+// "bold,underline"
+func ParseStyle(names string) (Style, error) {
+	var style Style
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, s := range styles {
+			if strings.EqualFold(name, s.name) {
+				style |= s.style
+				found = true
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("invalid style: %s (valid: %s)", name, strings.Join(ListStyles(), ", "))
+		}
+	}
+	return style, nil
+}
+
+// String returns the style names separated by commas
+func (s Style) String() string {
+	var names []string
+	for _, st := range styles {
+		if s&st.style != 0 {
+			names = append(names, st.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// wrap adds the style to the prefix and suffix of a colored cell. The
+// terminal color suffix already resets every attribute.
+func (s Style) wrap(prefix, suffix string, parser *OutputParser) (string, string) {
+	if s == 0 {
+		return prefix, suffix
+	}
+	switch parser.Name {
+	case "terminal-color":
+		var codes []string
+		for _, st := range styles {
+			if s&st.style != 0 {
+				codes = append(codes, fmt.Sprint(st.sgr))
+			}
+		}
+		if suffix == "" {
+			suffix = escape + "[0m"
+		}
+		return prefix + escape + "[" + strings.Join(codes, ";") + "m", suffix
+	case "html":
+		var decl, decorations []string
+		for _, st := range styles {
+			if s&st.style == 0 {
+				continue
+			}
+			// Decorations are one property, so they are merged
+			if value, ok := strings.CutPrefix(st.css, "text-decoration: "); ok {
+				decorations = append(decorations, value)
+			} else {
+				decl = append(decl, st.css)
+			}
+		}
+		if len(decorations) > 0 {
+			decl = append(decl, "text-decoration: "+strings.Join(decorations, " "))
+		}
+		return prefix + "<span style='" + strings.Join(decl, "; ") + ";'>", "</span>" + suffix
+	}
+	return prefix, suffix
+}
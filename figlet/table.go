@@ -0,0 +1,89 @@
+package figlet
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TableOptions sets how RenderTable draws a table
+type TableOptions struct {
+	// Align justifies the banners in their cells: 0 left, 1 center or 2
+	// right, like Justification
+	Align int
+	// Padding is the number of spaces between a banner and the borders;
+	// RenderTable refuses a negative padding
+	Padding int
+}
+
+// RenderTable renders the text of every cell with the Config and draws the
+// banners in an ASCII table with borders, for scoreboards and the like.
+// Small fonts, such as small or mini, keep it readable. Rows may have
+// fewer cells than others; the missing ones are empty. Each column is as
+// wide as its widest banner and each row as high as its highest, with
+// banners at the top of their cell. Colored banners line up too, as with
+// RenderLayout.
+func (cfg *Config) RenderTable(rows [][]string, opts TableOptions) (string, error) {
+	if opts.Padding < 0 {
+		return "", fmt.Errorf("invalid table padding: %d", opts.Padding)
+	}
+	if len(rows) == 0 {
+		return "", nil
+	}
+	cols := 0
+	for _, row := range rows {
+		cols = max(cols, len(row))
+	}
+	blocks := make([][][]string, len(rows))
+	widths := make([][]int, len(rows))
+	colwidths := make([]int, cols)
+	for r, row := range rows {
+		blocks[r] = make([][]string, cols)
+		widths[r] = make([]int, cols)
+		for c, text := range row {
+			var err error
+			if blocks[r][c], widths[r][c], err = cfg.renderblock(text); err != nil {
+				return "", err
+			}
+			colwidths[c] = max(colwidths[c], widths[r][c])
+		}
+	}
+
+	var sb strings.Builder
+	rule := func() {
+		sb.WriteString("+")
+		for _, w := range colwidths {
+			sb.WriteString(strings.Repeat("-", w+2*opts.Padding))
+			sb.WriteString("+")
+		}
+		sb.WriteString("\n")
+	}
+	padding := strings.Repeat(" ", opts.Padding)
+	rule()
+	for r := range rows {
+		height := 0
+		for _, block := range blocks[r] {
+			height = max(height, len(block))
+		}
+		for y := 0; y < height; y++ {
+			sb.WriteString("|")
+			for c, block := range blocks[r] {
+				line, width := "", 0
+				if y < len(block) {
+					line, width = block[y], widths[r][c]
+				}
+				left := 0
+				switch opts.Align {
+				case 1:
+					left = (colwidths[c] - width) / 2
+				case 2:
+					left = colwidths[c] - width
+				}
+				sb.WriteString(padding + strings.Repeat(" ", left) + line)
+				sb.WriteString(strings.Repeat(" ", colwidths[c]-width-left) + padding + "|")
+			}
+			sb.WriteString("\n")
+		}
+		rule()
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,142 @@
+// Package templatefuncs provides template functions rendering FIGlet
+// banners, for text/template and html/template:
+//
+//	tmpl := template.New("page").Funcs(templatefuncs.HTMLFuncs(templatefuncs.Options{}))
+//	tmpl.Parse(`<pre>{{ figlet "Hello" }}</pre>`)
+//
+// The functions are figlet TEXT, figletFont FONT TEXT and figletColor
+// COLORS TEXT, where COLORS is a list of colors separated by semicolons,
+// such as "red;#00ff00". Banners are rendered as lines of text, to place
+// inside a pre element in HTML. With HTMLFuncs, banners are escaped, and
+// figletColor returns the output of the html parser: a code element with
+// spans styled with the colors and lines ended by br elements.
+package templatefuncs
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/lsferreira42/figlet-go/figlet"
+)
+
+// Options configures the template functions
+type Options struct {
+	// Font is the font of figlet and figletColor, standard if empty
+	Font string
+	// Fonts loads the fonts, the embedded fonts if nil. Templates filled
+	// with data from users should not load fonts from paths.
+	Fonts *figlet.FontManager
+	// Width is the output width, 80 if zero
+	Width int
+}
+
+// funcs renders the banners of the template functions
+type funcs struct {
+	opts Options
+}
+
+func newfuncs(opts Options) *funcs {
+	if opts.Font == "" {
+		opts.Font = "standard"
+	}
+	if opts.Fonts == nil {
+		opts.Fonts = figlet.NewFontManager("", figlet.FontSourceEmbedded)
+	}
+	if opts.Width <= 0 {
+		opts.Width = 80
+	}
+	return &funcs{opts}
+}
+
+// TextFuncs returns the template functions for text/template. figletColor
+// colors banners with terminal escape sequences.
+func TextFuncs(opts Options) texttemplate.FuncMap {
+	f := newfuncs(opts)
+	return texttemplate.FuncMap{
+		"figlet": func(text string) (string, error) {
+			return f.render(f.opts.Font, text)
+		},
+		"figletFont": func(font, text string) (string, error) {
+			return f.render(font, text)
+		},
+		"figletColor": func(colors, text string) (string, error) {
+			list, err := parsecolors(colors)
+			if err != nil {
+				return "", err
+			}
+			return f.render(f.opts.Font, text, figlet.WithColors(list...), figlet.WithParser("terminal-color"))
+		},
+	}
+}
+
+// HTMLFuncs returns the template functions for html/template. figlet and
+// figletFont return text, which the template escapes; figletColor returns
+// the escaped HTML of the html parser, with a span for every run of cells
+// of the same color.
+func HTMLFuncs(opts Options) htmltemplate.FuncMap {
+	f := newfuncs(opts)
+	return htmltemplate.FuncMap{
+		"figlet": func(text string) (string, error) {
+			return f.render(f.opts.Font, text)
+		},
+		"figletFont": func(font, text string) (string, error) {
+			return f.render(font, text)
+		},
+		"figletColor": func(colors, text string) (htmltemplate.HTML, error) {
+			list, err := parsecolors(colors)
+			if err != nil {
+				return "", err
+			}
+			out, err := f.render(f.opts.Font, text, figlet.WithColors(list...), figlet.WithParser("html"))
+			return htmltemplate.HTML(out), err
+		},
+	}
+}
+
+// config returns a new Config for font, so template executions running at
+// the same time do not share one
+func (f *funcs) config(font string, opts ...figlet.Option) (*figlet.Config, error) {
+	loaded, err := f.opts.Fonts.Font(font)
+	if err != nil {
+		return nil, fmt.Errorf("font %s: %w", font, err)
+	}
+	cfg := figlet.New()
+	opts = append([]figlet.Option{figlet.WithLoadedFont(loaded), figlet.WithWidth(f.opts.Width)}, opts...)
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if err := cfg.LoadFont(); err != nil {
+		return nil, fmt.Errorf("font %s: %w", font, err)
+	}
+	return cfg, nil
+}
+
+// render renders text in font with the options
+func (f *funcs) render(font, text string, opts ...figlet.Option) (string, error) {
+	cfg, err := f.config(font, opts...)
+	if err != nil {
+		return "", err
+	}
+	return cfg.Render(text)
+}
+
+// parsecolors parses colors separated by semicolons
+func parsecolors(s string) ([]figlet.Color, error) {
+	var colors []figlet.Color
+	for _, part := range strings.Split(s, ";") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		color, err := figlet.ParseColor(part)
+		if err != nil {
+			return nil, err
+		}
+		colors = append(colors, color)
+	}
+	if len(colors) == 0 {
+		return nil, fmt.Errorf("no colors in %q", s)
+	}
+	return colors, nil
+}
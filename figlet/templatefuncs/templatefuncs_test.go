@@ -0,0 +1,74 @@
+package templatefuncs
+
+import (
+	htmltemplate "html/template"
+	"regexp"
+	"strings"
+	"testing"
+	texttemplate "text/template"
+
+	"github.com/lsferreira42/figlet-go/figlet"
+)
+
+func TestTextFuncs(t *testing.T) {
+	want, err := figlet.RenderWithFont("Hi", "slant")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := texttemplate.Must(texttemplate.New("t").Funcs(TextFuncs(Options{})).Parse(`{{ figletFont "slant" "Hi" }}`))
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, nil); err != nil {
+		t.Fatal(err)
+	}
+	if sb.String() != want {
+		t.Errorf("figletFont: got\n%s\nwant\n%s", sb.String(), want)
+	}
+
+	tmpl = texttemplate.Must(texttemplate.New("t").Funcs(TextFuncs(Options{})).Parse(`{{ figletColor "red" "Hi" }}`))
+	sb.Reset()
+	if err := tmpl.Execute(&sb, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sb.String(), "\x1b[") {
+		t.Errorf("figletColor: no escape sequences in %q", sb.String())
+	}
+
+	tmpl = texttemplate.Must(texttemplate.New("t").Funcs(TextFuncs(Options{})).Parse(`{{ figletFont "../../etc/passwd" "Hi" }}`))
+	if err := tmpl.Execute(&sb, nil); err == nil {
+		t.Error("expected an error for a font path")
+	}
+}
+
+func TestHTMLFuncs(t *testing.T) {
+	tmpl := htmltemplate.Must(htmltemplate.New("t").Funcs(HTMLFuncs(Options{})).Parse(
+		`<pre>{{ figlet "<&>" }}</pre><pre>{{ figletColor "#ff0000;#00ff00" "<&>" }}</pre>`))
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, nil); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+	plain, colored, _ := strings.Cut(strings.TrimPrefix(out, "<pre>"), "</pre><pre>")
+	colored = strings.TrimSuffix(colored, "</pre>")
+	markup := regexp.MustCompile(`</?code>|<br>|<span style='color: rgb\(\d+,\d+,\d+\);'>|</span>`)
+	for _, part := range []string{plain, markup.ReplaceAllString(colored, "")} {
+		if strings.ContainsAny(part, "<>") {
+			t.Errorf("unescaped markup in %q", part)
+		}
+	}
+	if !strings.Contains(colored, "rgb(255,0,0)") || !strings.Contains(colored, "rgb(0,255,0)") {
+		t.Errorf("figletColor: missing colors in %q", colored)
+	}
+	// The output is the html parser's, with its spans merged
+	want, err := figlet.Render("<&>", figlet.WithColors(figlet.TrueColor{R: 255}, figlet.TrueColor{G: 255}), figlet.WithParser("html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if colored != want {
+		t.Errorf("figletColor: got\n%s\nwant\n%s", colored, want)
+	}
+
+	tmpl = htmltemplate.Must(htmltemplate.New("t").Funcs(HTMLFuncs(Options{})).Parse(`{{ figletColor "nocolor" "Hi" }}`))
+	if err := tmpl.Execute(&sb, nil); err == nil {
+		t.Error("expected an error for an invalid color")
+	}
+}
@@ -0,0 +1,15 @@
+package figlet
+
+import "github.com/lsferreira42/figlet-go/internal/terminal"
+
+// GetColumns returns the terminal width, or -1 when there is no terminal.
+// WASM builds return 0, so the default width is used.
+func GetColumns() int {
+	return terminal.Probe().Columns
+}
+
+// GetRows returns the terminal height, or -1 when there is no terminal.
+// WASM builds return 0.
+func GetRows() int {
+	return terminal.Probe().Rows
+}
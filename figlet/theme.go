@@ -0,0 +1,99 @@
+package figlet
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// themes holds the named palettes, built-in and registered
+var themes = struct {
+	sync.RWMutex
+	palettes map[string][]Color
+}{palettes: map[string][]Color{
+	"fire": {
+		TrueColor{128, 0, 0}, TrueColor{200, 30, 0}, TrueColor{255, 80, 0},
+		TrueColor{255, 140, 0}, TrueColor{255, 200, 0}, TrueColor{255, 240, 120},
+	},
+	"ocean": {
+		TrueColor{0, 30, 80}, TrueColor{0, 70, 140}, TrueColor{0, 119, 182},
+		TrueColor{0, 150, 199}, TrueColor{72, 202, 228}, TrueColor{144, 224, 239},
+	},
+	"pride": {
+		TrueColor{228, 3, 3}, TrueColor{255, 140, 0}, TrueColor{255, 237, 0},
+		TrueColor{0, 128, 38}, TrueColor{36, 64, 142}, TrueColor{115, 41, 130},
+	},
+	"matrix": {
+		TrueColor{0, 59, 0}, TrueColor{0, 143, 17}, TrueColor{0, 200, 50}, TrueColor{0, 255, 65},
+	},
+	"dracula": {
+		TrueColor{255, 121, 198}, TrueColor{189, 147, 249}, TrueColor{139, 233, 253},
+		TrueColor{80, 250, 123}, TrueColor{241, 250, 140}, TrueColor{255, 184, 108},
+		TrueColor{255, 85, 85},
+	},
+}}
+
+// WithTheme sets the colors to a named palette, like WithColors. An
+// unknown name leaves the colors alone and is reported by Validate, so
+// LoadFont and Render fail; use Theme to check a name first.
+func WithTheme(name string) Option {
+	return func(cfg *Config) {
+		colors, err := Theme(name)
+		if err != nil {
+			cfg.unknowntheme = name
+			return
+		}
+		cfg.unknowntheme = ""
+		WithColors(colors...)(cfg)
+	}
+}
+
+// Theme returns the colors of a named palette, in order
+func Theme(name string) ([]Color, error) {
+	themes.RLock()
+	defer themes.RUnlock()
+	colors, ok := themes.palettes[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("invalid theme: %s (valid: %s)", name, strings.Join(listthemes(), ", "))
+	}
+	return append([]Color(nil), colors...), nil
+}
+
+// RegisterTheme adds a named palette, or replaces an existing one, so it
+// can be used with WithTheme and the CLI's --theme option. Names are not
+// case sensitive.
+func RegisterTheme(name string, colors ...Color) error {
+	if name == "" {
+		return errors.New("theme name must not be empty")
+	}
+	if len(colors) == 0 {
+		return fmt.Errorf("theme %s has no colors", name)
+	}
+	for i, color := range colors {
+		if color == nil {
+			return fmt.Errorf("theme %s: color %d is nil", name, i)
+		}
+	}
+	themes.Lock()
+	defer themes.Unlock()
+	themes.palettes[strings.ToLower(name)] = append([]Color(nil), colors...)
+	return nil
+}
+
+// ListThemes returns the names accepted by WithTheme, sorted
+func ListThemes() []string {
+	themes.RLock()
+	defer themes.RUnlock()
+	return listthemes()
+}
+
+func listthemes() []string {
+	names := make([]string, 0, len(themes.palettes))
+	for name := range themes.palettes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
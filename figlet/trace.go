@@ -0,0 +1,45 @@
+package figlet
+
+// Tracer starts spans around the stages of the render pipeline, so a
+// service rendering banners per request can see where the time goes. An
+// OpenTelemetry adapter starts a span from the request's context:
+//
+//	type otelTracer struct {
+//		ctx    context.Context
+//		tracer trace.Tracer
+//	}
+//
+//	func (t otelTracer) StartSpan(name string) func(error) {
+//		_, span := t.tracer.Start(t.ctx, name)
+//		return func(err error) {
+//			if err != nil {
+//				span.RecordError(err)
+//			}
+//			span.End()
+//		}
+//	}
+//
+// The spans are figlet.LoadFont, figlet.Render and, inside a render,
+// figlet.compose, figlet.layout and figlet.emit, which applies colors and
+// the output parser.
+type Tracer interface {
+	// StartSpan starts a span and returns the function ending it, given
+	// the error the stage failed with, if any
+	StartSpan(name string) (end func(err error))
+}
+
+// WithTracer traces LoadFont and every render with t, see Tracer
+func WithTracer(t Tracer) Option {
+	return func(cfg *Config) {
+		cfg.Tracer = t
+	}
+}
+
+// span starts a span with the Tracer, if there is one, and returns the
+// function ending it
+func (cfg *Config) span(name string) func(error) {
+	if cfg.Tracer == nil {
+		return func(error) {}
+	}
+	return cfg.Tracer.StartSpan(name)
+}
@@ -0,0 +1,196 @@
+package figlet
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Transform rotates or mirrors the output once it is composed, see
+// WithTransform
+type Transform int
+
+const (
+	// Rotate90 turns the output a quarter turn clockwise, so the text
+	// reads from top to bottom
+	Rotate90 Transform = iota + 1
+	// Rotate180 turns the output upside down
+	Rotate180
+	// MirrorH flips the output from left to right
+	MirrorH
+	// MirrorV flips the output from top to bottom
+	MirrorV
+)
+
+// transforms lists every transform with its name and the characters it
+// swaps for ones facing the new way
+var transforms = []struct {
+	transform Transform
+	name      string
+	swaps     map[rune]rune
+}{
+	{Rotate90, "rotate90", map[rune]rune{
+		'|': '-', '-': '|', '_': '|', '=': '"', '"': '=', '/': '\\', '\\': '/',
+		'<': '^', '^': '>', '>': 'v', 'v': '<',
+	}},
+	{Rotate180, "rotate180", map[rune]rune{
+		'_': '‾', '‾': '_', '(': ')', ')': '(', '[': ']', ']': '[', '{': '}', '}': '{',
+		'<': '>', '>': '<', '^': 'v', 'v': '^', '\'': ',', ',': '\'', '`': ',',
+		'b': 'q', 'q': 'b', 'd': 'p', 'p': 'd', 'n': 'u', 'u': 'n', 'M': 'W', 'W': 'M',
+	}},
+	{MirrorH, "mirror-h", map[rune]rune{
+		'/': '\\', '\\': '/', '(': ')', ')': '(', '[': ']', ']': '[', '{': '}', '}': '{',
+		'<': '>', '>': '<', 'b': 'd', 'd': 'b', 'p': 'q', 'q': 'p', '`': '\'',
+	}},
+	{MirrorV, "mirror-v", map[rune]rune{
+		'/': '\\', '\\': '/', '_': '‾', '‾': '_', '^': 'v', 'v': '^', '\'': ',', ',': '\'',
+		'`': ',', 'b': 'p', 'p': 'b', 'd': 'q', 'q': 'd', 'M': 'W', 'W': 'M', 'n': 'u', 'u': 'n',
+	}},
+}
+
+// WithTransform rotates or mirrors the output once it is composed, scaled
+// and trimmed, applying the transforms in order; useful for vertical
+// sidebars and flipped effects. Characters with a direction, such as
+// slashes and brackets, are swapped for ones facing the new way. Lines
+// still wrap at the output width before they are transformed.
+func WithTransform(transforms ...Transform) Option {
+	return func(cfg *Config) {
+		cfg.Transforms = append([]Transform(nil), transforms...)
+	}
+}
+
+// ListTransforms returns the transform names accepted by ParseTransform
+func ListTransforms() []string {
+	names := make([]string, len(transforms))
+	for i, t := range transforms {
+		names[i] = t.name
+	}
+	return names
+}
+
+// ParseTransform returns the transform with the given name
+func ParseTransform(name string) (Transform, error) {
+	for _, t := range transforms {
+		if strings.EqualFold(name, t.name) {
+			return t.transform, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid transform: %s (valid: %s)", name, strings.Join(ListTransforms(), ", "))
+}
+
+// String returns the name of the transform
+func (t Transform) String() string {
+	for _, tr := range transforms {
+		if tr.transform == t {
+			return tr.name
+		}
+	}
+	return fmt.Sprintf("Transform(%d)", int(t))
+}
+
+// Apply returns the grid, such as one returned by RenderGrid, rotated or
+// mirrored. Rows are padded with spaces to the width of the widest first.
+func (t Transform) Apply(grid [][]rune) [][]rune {
+	out, _ := transformblock(grid, nil, t, 0)
+	return out
+}
+
+// transformrows applies the configured transforms to the laid out rows.
+// Mirroring keeps each row's baseline mark; rotating drops them, as the
+// FIGlet lines no longer run across.
+func (cfg *Config) transformrows(rows []outputrow) []outputrow {
+	for _, t := range cfg.Transforms {
+		cells, index := make([][]rune, len(rows)), make([][]int, len(rows))
+		for n, row := range rows {
+			cells[n], index[n] = row.cells, row.index
+		}
+		cells, index = transformblock(cells, index, t, cfg.hardblank)
+		out := make([]outputrow, len(cells))
+		for n := range cells {
+			out[n] = outputrow{cells: cells[n], index: index[n]}
+			switch t {
+			case MirrorH:
+				out[n].baseline = rows[n].baseline
+			case MirrorV:
+				out[n].baseline = rows[len(rows)-1-n].baseline
+			}
+		}
+		rows = out
+	}
+	return rows
+}
+
+// swaps returns the characters the transform swaps for ones facing the new
+// way, or nil for an unknown transform
+func (t Transform) swaps() map[rune]rune {
+	for _, tr := range transforms {
+		if tr.transform == t {
+			return tr.swaps
+		}
+	}
+	return nil
+}
+
+// transformblock rotates or mirrors rows of cells and, if index is not
+// nil, their input character indexes, swapping the characters in the
+// transform's table but never the hardblank
+func transformblock(cells [][]rune, index [][]int, t Transform, hardblank rune) ([][]rune, [][]int) {
+	swaps := t.swaps()
+	if swaps == nil {
+		return cells, index
+	}
+	width := 0
+	for _, row := range cells {
+		width = max(width, len(row))
+	}
+	height := len(cells)
+	// at returns the cell and index at x, y of the source, padded
+	at := func(x, y int) (rune, int) {
+		r, i := ' ', nocolor
+		if x < len(cells[y]) {
+			r = cells[y][x]
+			if r != hardblank {
+				if s, ok := swaps[r]; ok {
+					r = s
+				}
+			}
+		}
+		if index != nil && x < len(index[y]) {
+			i = index[y][x]
+		}
+		return r, i
+	}
+	outwidth, outheight := width, height
+	if t == Rotate90 {
+		outwidth, outheight = height, width
+	}
+	indexed := false
+	for _, row := range index {
+		indexed = indexed || row != nil
+	}
+	out, outindex := make([][]rune, outheight), make([][]int, outheight)
+	for y := range out {
+		out[y] = make([]rune, outwidth)
+		if indexed {
+			outindex[y] = make([]int, outwidth)
+		}
+		for x := range out[y] {
+			var r rune
+			var i int
+			switch t {
+			case Rotate90:
+				r, i = at(y, height-1-x)
+			case Rotate180:
+				r, i = at(width-1-x, height-1-y)
+			case MirrorH:
+				r, i = at(width-1-x, y)
+			case MirrorV:
+				r, i = at(x, height-1-y)
+			}
+			out[y][x] = r
+			if indexed {
+				outindex[y][x] = i
+			}
+		}
+	}
+	return out, outindex
+}
@@ -0,0 +1,124 @@
+package figlet
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MAXOUTPUTWIDTH is the largest accepted Outputwidth
+const MAXOUTPUTWIDTH = 1 << 16
+
+// ValidationError reports a Config field with an invalid value
+type ValidationError struct {
+	Field  string
+	Value  interface{}
+	Reason string
+}
+
+// Error formats the validation error
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s %v: %s", e.Field, e.Value, e.Reason)
+}
+
+// Validate checks the configuration and returns a ValidationError for each
+// invalid field, joined with errors.Join. LoadFont and Render call it, so an
+// invalid Config never produces corrupted output.
+func (cfg *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, field string, value interface{}, reason string) bool {
+		if !ok {
+			errs = append(errs, &ValidationError{Field: field, Value: value, Reason: reason})
+		}
+		return ok
+	}
+
+	check(cfg.Outputwidth >= 1 && cfg.Outputwidth <= MAXOUTPUTWIDTH, "Outputwidth", cfg.Outputwidth,
+		fmt.Sprintf("must be between 1 and %d", MAXOUTPUTWIDTH))
+	check(cfg.Justification >= JustifyAuto && cfg.Justification <= JustifyFull, "Justification", cfg.Justification,
+		"must be -1 (auto), 0 (left), 1 (center), 2 (right) or 3 (full)")
+	check(cfg.Right2left >= -1 && cfg.Right2left <= 1, "Right2left", cfg.Right2left,
+		"must be -1 (auto), 0 (left to right) or 1 (right to left)")
+	check(cfg.Multibyte >= 0 && cfg.Multibyte <= 4, "Multibyte", cfg.Multibyte,
+		"must be between 0 (ISO 2022) and 4 (Shift-JIS)")
+	check(cfg.Smushoverride >= SMO_NO && cfg.Smushoverride <= SMO_FORCE, "Smushoverride", cfg.Smushoverride,
+		"must be SMO_NO, SMO_YES or SMO_FORCE")
+	// The font may add vertical layout bits; an explicit mode may only use the SM_* flags
+	check(cfg.Smushmode >= 0, "Smushmode", cfg.Smushmode, "must not be negative")
+	check(cfg.Smushoverride != SMO_YES || cfg.Smushmode&^(SM_SMUSH|SM_KERN|63) == 0, "Smushmode", cfg.Smushmode,
+		"an explicit smush mode may only use the SM_* flags")
+	check(cfg.UnsafeCellReplacement == 0 || runewidth(cfg.UnsafeCellReplacement) == 1, "UnsafeCellReplacement",
+		cfg.UnsafeCellReplacement, "must take one column")
+	check(cfg.Overlap >= 0, "Overlap", cfg.Overlap, "must not be negative")
+	check(cfg.Trim&^TrimAll == 0, "Trim", cfg.Trim, "unknown trim mode")
+	check(cfg.Pad >= PadNone && cfg.Pad <= PadOutputWidth, "Pad", cfg.Pad, "unknown pad mode")
+	check(cfg.Indent >= 0 && cfg.Indent <= MAXOUTPUTWIDTH, "Indent", cfg.Indent, fmt.Sprintf("must be between 0 and %d", MAXOUTPUTWIDTH))
+	check(cfg.ScaleX >= 0 && cfg.ScaleX <= MAXSCALE, "ScaleX", cfg.ScaleX, fmt.Sprintf("must be between 0 and %d", MAXSCALE))
+	check(cfg.ScaleY >= 0 && cfg.ScaleY <= MAXSCALE, "ScaleY", cfg.ScaleY, fmt.Sprintf("must be between 0 and %d", MAXSCALE))
+	for i, t := range cfg.Transforms {
+		check(t >= Rotate90 && t <= MirrorV, fmt.Sprintf("Transforms[%d]", i), t, "unknown transform")
+	}
+	check(cfg.FontSources&^FontSourceAny == 0, "FontSources", cfg.FontSources, "unknown font source")
+	check(cfg.Case >= CaseNone && cfg.Case <= CaseTitle, "Case", cfg.Case, "unknown case mode")
+	check(cfg.OutputParser != nil, "OutputParser", cfg.OutputParser, "must not be nil")
+	for i, color := range cfg.Colors {
+		check(color != nil, fmt.Sprintf("Colors[%d]", i), color, "must not be nil")
+	}
+	check(cfg.unknowntheme == "", "Theme", cfg.unknowntheme, "unknown theme (valid: "+strings.Join(ListThemes(), ", ")+")")
+	check(cfg.unknownpreset == "", "Preset", cfg.unknownpreset, "unknown preset (valid: "+strings.Join(ListPresets(), ", ")+")")
+	for r, art := range cfg.GlyphOverrides {
+		field := fmt.Sprintf("GlyphOverrides[%q]", r)
+		// The height is only known once a font is loaded
+		check(cfg.charheight == 0 || len(art) == cfg.charheight, field, len(art),
+			fmt.Sprintf("must have %d rows, the font height", cfg.charheight))
+		for _, row := range art {
+			if !check(utf8.RuneCountInString(row) == utf8.RuneCountInString(art[0]), field, row, "rows must all be as wide") {
+				break
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Render renders text like RenderString but reports an invalid
+// configuration, including one produced by the per-call options
+func (cfg *Config) Render(text string, opts ...RenderOption) (string, error) {
+	var result string
+	err := cfg.apply(opts, func() error {
+		result = cfg.emit(cfg.layoutrows(cfg.compose(text)))
+		return nil
+	})
+	return result, err
+}
+
+// apply runs fn with the per-call options applied and the configuration
+// validated, leaving the Config unchanged afterwards
+func (cfg *Config) apply(opts []RenderOption, fn func() error) error {
+	if len(opts) > 0 {
+		saved := *cfg
+		defer func() {
+			// The trace describes this render, keep it
+			trace := cfg.smushtrace
+			*cfg = saved
+			cfg.smushtrace = trace
+		}()
+		for _, opt := range opts {
+			opt(cfg)
+		}
+		cfg.fontdefaults(cfg.Smushmode != saved.Smushmode || cfg.Smushoverride != saved.Smushoverride)
+	}
+	end := cfg.span("figlet.Render")
+	err := cfg.Validate()
+	if err == nil {
+		cfg.renderchars = 0
+		start := time.Now()
+		err = fn()
+		if cfg.Metrics != nil {
+			cfg.Metrics.ObserveRender(time.Since(start), cfg.renderchars, cfg.Fontname)
+		}
+	}
+	end(err)
+	return err
+}
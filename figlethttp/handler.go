@@ -0,0 +1,566 @@
+// Package figlethttp serves FIGlet banners and animations over HTTP.
+//
+// NewHandler returns an http.Handler that can be mounted in any mux, behind
+// the application's own authentication and logging middleware:
+//
+//	mux.Handle("/figlet/", http.StripPrefix("/figlet", figlethttp.NewHandler(figlethttp.DefaultOptions())))
+//
+// It answers GET /render, GET /animate and GET /metrics, relative to where
+// it is mounted, and POST /reload when Options.Reload is set. The "figlet serve" command runs the same handler.
+package figlethttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lsferreira42/figlet-go/figlet"
+)
+
+// Options configures the handler returned by NewHandler. Zero limits are
+// disabled, so use DefaultOptions as a base when serving the public.
+type Options struct {
+	// FontDir is the directory fonts are loaded from; empty means "fonts".
+	FontDir string
+	// FontSources is where requested fonts may be loaded from; 0 means
+	// the embedded fonts and those in FontDir. Fonts are looked up by
+	// name only, never by path, and never outside FontDir.
+	FontSources figlet.FontSource
+	// Rate is the number of requests per minute allowed to each client
+	// address, with bursts of as many
+	Rate int
+	// MaxText is the longest text in characters
+	MaxText int
+	// MaxWidth is the largest output width
+	MaxWidth int
+	// Timeout is the longest time a render may take; the render is
+	// stopped when it runs out
+	Timeout time.Duration
+	// MaxDelay is the longest delay between animation frames
+	MaxDelay time.Duration
+	// MaxFrames and MaxCells are the frame budgets of animations: longer
+	// animations drop frames and wider ones are narrowed, see
+	// figlet.Animator
+	MaxFrames int
+	MaxCells  int
+	// WatchFonts is how often the files of a loaded font are checked for
+	// changes, which are then loaded for the next request; 0 never checks
+	WatchFonts time.Duration
+	// Reload enables POST /reload, which loads the fonts whose files
+	// changed again. Mount the handler behind authentication to use it.
+	Reload bool
+	// Metrics, when set, is told how long each render and font load
+	// takes, in addition to the counters served at /metrics
+	Metrics figlet.Metrics
+	// Tracer, when set, returns the tracer for the render of a request,
+	// such as one starting OpenTelemetry spans from its context
+	Tracer func(r *http.Request) figlet.Tracer
+}
+
+// DefaultOptions returns the limits "figlet serve" uses: 60 requests per
+// minute per client, 256 characters, a width of 1000, 5 seconds per
+// render, 1 second between animation frames and 300 frames of 100000
+// cells, with the embedded fonts and those in the fonts directory
+func DefaultOptions() Options {
+	return Options{
+		FontDir:     "fonts",
+		FontSources: figlet.FontSourceEmbedded | figlet.FontSourceDir,
+		Rate:        60,
+		MaxText:     256,
+		MaxWidth:    1000,
+		Timeout:     5 * time.Second,
+		MaxDelay:    time.Second,
+		MaxFrames:   300,
+		MaxCells:    100000,
+	}
+}
+
+// handler holds the state of a handler: its options, the loaded fonts,
+// the clients' rate limits and the metrics
+type handler struct {
+	opts Options
+	mux  *http.ServeMux
+
+	fontmgr *figlet.FontManager
+
+	mu      sync.Mutex
+	fonts   map[string][]*figlet.Config // idle loaded fonts by name
+	buckets map[string]*bucket          // rate limit state by client address
+
+	metrics metrics
+}
+
+// bucket is a token bucket: a client can make Rate requests at once, then
+// one more every 60/Rate seconds
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// fontsIdle is how many idle loaded configurations are kept per font
+const fontsIdle = 8
+
+// maxBuckets is how many clients are tracked before those whose bucket
+// has refilled are forgotten
+const maxBuckets = 10000
+
+// requestError is an error answered with its status code and counted
+// under its type in the metrics
+type requestError struct {
+	status int
+	kind   string
+	err    error
+}
+
+func (e *requestError) Error() string {
+	return e.err.Error()
+}
+
+// contentTypes maps each render format to its HTTP content type
+var contentTypes = map[figlet.Format]string{
+	figlet.FormatText: "text/plain; charset=utf-8",
+	figlet.FormatHTML: "text/html; charset=utf-8",
+	figlet.FormatSVG:  "image/svg+xml",
+	figlet.FormatJSON: "application/json",
+	figlet.FormatPNG:  "image/png",
+	figlet.FormatGIF:  "image/gif",
+}
+
+// NewHandler returns a handler serving these endpoints:
+//
+//	GET /render?text=&format=        the banner in a RenderTo format, text by default
+//	GET /animate?text=&type=&delay=  animation frames as Server-Sent Events
+//	GET /animate?...&format=gif      the animation as an animated GIF
+//	GET /metrics                     counters in the Prometheus text format
+//	POST /reload                     with Options.Reload, reload changed fonts
+//
+// /render and /animate also take font, colors (separated by semicolons),
+// theme and width, and settings, a JSON figlet.Settings object that the
+// other parameters override. Frames are sent after the delay of the frame before
+// them, as "frame" events holding a JSON object with index, content,
+// delay, baselineOffset and baseline, followed by an "end" event. They
+// use the parser given by parser, or terminal, or terminal-color when
+// colors are set.
+func NewHandler(opts Options) http.Handler {
+	if opts.FontDir == "" {
+		opts.FontDir = "fonts"
+	}
+	if opts.FontSources == 0 {
+		opts.FontSources = figlet.FontSourceEmbedded | figlet.FontSourceDir
+	}
+	// Font names come from requests, so never load them as paths
+	opts.FontSources &^= figlet.FontSourcePath
+	h := &handler{
+		opts:    opts,
+		mux:     http.NewServeMux(),
+		fontmgr: figlet.NewFontManager(opts.FontDir, opts.FontSources),
+		fonts:   make(map[string][]*figlet.Config),
+		buckets: make(map[string]*bucket),
+	}
+	h.fontmgr.WatchInterval = opts.WatchFonts
+	h.fontmgr.Metrics = opts.Metrics
+	h.mux.HandleFunc("/render", h.handle("render", h.render))
+	h.mux.HandleFunc("/animate", h.handle("animate", h.animate))
+	h.mux.HandleFunc("/metrics", h.metrics.serve)
+	if opts.Reload {
+		h.mux.HandleFunc("/reload", h.reload)
+	}
+	return h
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// handle returns the handler for an endpoint. It applies the rate limit
+// and the render timeout, gets a configuration for the request's font and
+// settings, and records the request in the metrics. render runs with the
+// configuration and a context done when the request is canceled or times
+// out, and returns a function writing the response, which must not use the
+// configuration: it goes back to the font cache as soon as render returns.
+func (h *handler) handle(endpoint string, render func(ctx context.Context, cfg *figlet.Config, query url.Values) (func(w http.ResponseWriter, r *http.Request), error)) http.HandlerFunc {
+	type result struct {
+		write func(w http.ResponseWriter, r *http.Request)
+		err   error
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		fail := func(err error) {
+			re, ok := err.(*requestError)
+			if !ok {
+				re = &requestError{http.StatusBadRequest, "bad_request", err}
+			}
+			if re.status == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", strconv.Itoa(max(1, 60/max(h.opts.Rate, 1))))
+			}
+			h.metrics.request(endpoint, re.status, re.kind)
+			http.Error(w, re.Error(), re.status)
+		}
+		if r.Method != http.MethodGet {
+			fail(&requestError{http.StatusMethodNotAllowed, "bad_request", fmt.Errorf("method %s not allowed", r.Method)})
+			return
+		}
+		if !h.allow(r) {
+			fail(&requestError{http.StatusTooManyRequests, "rate_limited", fmt.Errorf("too many requests")})
+			return
+		}
+
+		start := time.Now()
+		query := r.URL.Query()
+		cfg, err := h.config(query)
+		if err != nil {
+			fail(err)
+			return
+		}
+		if h.opts.Tracer != nil {
+			cfg.Tracer = h.opts.Tracer(r)
+		}
+		ctx, cancel := r.Context(), context.CancelFunc(func() {})
+		if h.opts.Timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, h.opts.Timeout)
+		}
+		// Renders still running stop, and return their font, when the
+		// handler returns
+		defer cancel()
+		done := make(chan result, 1)
+		go func() {
+			write, err := render(ctx, cfg, query)
+			// Settings may change anything, so those configurations
+			// are not reused
+			if query.Get("settings") == "" {
+				h.release(cfg)
+			}
+			done <- result{write, err}
+		}()
+		select {
+		case res := <-done:
+			if res.err == nil {
+				h.metrics.observe(endpoint, time.Since(start))
+				h.metrics.request(endpoint, http.StatusOK, "")
+				res.write(w, r)
+				return
+			}
+			if !errors.Is(res.err, context.DeadlineExceeded) && !errors.Is(res.err, context.Canceled) {
+				fail(res.err)
+				return
+			}
+		case <-ctx.Done():
+		}
+		if r.Context().Err() != nil {
+			h.metrics.request(endpoint, 499, "canceled")
+			return
+		}
+		fail(&requestError{http.StatusServiceUnavailable, "timeout", fmt.Errorf("render timed out")})
+	}
+}
+
+// allow reports whether the client of r is within the rate limit. Clients
+// are told apart by address only, since forwarding headers can be forged;
+// behind a proxy, set RemoteAddr in a middleware.
+func (h *handler) allow(r *http.Request) bool {
+	rate := float64(h.opts.Rate)
+	if rate <= 0 {
+		return true
+	}
+	client, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		client = r.RemoteAddr
+	}
+	now := time.Now()
+	perSecond := rate / 60
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.buckets) > maxBuckets {
+		for addr, b := range h.buckets {
+			if now.Sub(b.last).Seconds()*perSecond >= rate {
+				delete(h.buckets, addr)
+			}
+		}
+	}
+	b, ok := h.buckets[client]
+	if !ok {
+		b = &bucket{tokens: rate, last: now}
+		h.buckets[client] = b
+	}
+	b.tokens = math.Min(rate, b.tokens+now.Sub(b.last).Seconds()*perSecond)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// config checks the query against the limits and returns a configuration
+// for its font, colors, theme, width and settings
+func (h *handler) config(query url.Values) (*figlet.Config, error) {
+	text := query.Get("text")
+	if text == "" {
+		return nil, fmt.Errorf("missing text parameter")
+	}
+	if n := utf8.RuneCountInString(text); h.opts.MaxText > 0 && n > h.opts.MaxText {
+		return nil, &requestError{http.StatusRequestEntityTooLarge, "too_large", fmt.Errorf("text is %d characters long, the limit is %d", n, h.opts.MaxText)}
+	}
+	var settings figlet.Settings
+	if v := query.Get("settings"); v != "" {
+		if err := json.Unmarshal([]byte(v), &settings); err != nil {
+			return nil, fmt.Errorf("invalid settings: %v", err)
+		}
+	}
+	width := figlet.DEFAULTCOLUMNS
+	if settings.Width > 0 {
+		width = settings.Width
+	}
+	if v := query.Get("width"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid width: %s", v)
+		}
+		width = n
+	}
+	if h.opts.MaxWidth > 0 && width > h.opts.MaxWidth {
+		return nil, &requestError{http.StatusRequestEntityTooLarge, "too_large", fmt.Errorf("width %d is over the limit of %d", width, h.opts.MaxWidth)}
+	}
+	var colors []figlet.Color
+	if theme := query.Get("theme"); theme != "" {
+		var err error
+		if colors, err = figlet.Theme(theme); err != nil {
+			return nil, err
+		}
+	}
+	if v := query.Get("colors"); v != "" {
+		colors = nil
+		for _, part := range strings.Split(v, ";") {
+			if part = strings.TrimSpace(part); part == "" {
+				continue
+			}
+			color, err := figlet.ParseColor(part)
+			if err != nil {
+				return nil, err
+			}
+			colors = append(colors, color)
+		}
+	}
+	font := query.Get("font")
+	if font == "" {
+		font = settings.Font
+	}
+	if font == "" {
+		font = "standard"
+	}
+	if strings.ContainsAny(font, `/\`) {
+		return nil, fmt.Errorf("invalid font: %s", font)
+	}
+
+	cfg, err := h.font(font)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Outputwidth = width
+	cfg.Colors = colors
+	cfg.OutputParser, _ = figlet.GetParser("terminal")
+	// The font and width are checked above, and the colors parameters
+	// override those of the settings
+	settings.Font, settings.Width = "", 0
+	if colors != nil {
+		settings.Colors = nil
+	}
+	if err := cfg.ApplySettings(settings); err != nil {
+		// Invalid settings change nothing
+		h.release(cfg)
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// font returns a configuration with the named font loaded, from the cache
+// when one is idle. A configuration holds render state, so each is used
+// by one request at a time. Fonts are parsed once by the font manager and
+// shared by every configuration; those holding a font since reloaded are
+// dropped.
+func (h *handler) font(name string) (*figlet.Config, error) {
+	font, err := h.fontmgr.Font(name)
+	if err != nil {
+		return nil, &requestError{http.StatusNotFound, "font", err}
+	}
+	h.mu.Lock()
+	if idle := h.fonts[name]; len(idle) > 0 && idle[0].Font() != font {
+		delete(h.fonts, name)
+	} else if len(idle) > 0 {
+		cfg := idle[len(idle)-1]
+		h.fonts[name] = idle[:len(idle)-1]
+		h.mu.Unlock()
+		h.metrics.cache(true)
+		return cfg, nil
+	}
+	h.mu.Unlock()
+	h.metrics.cache(false)
+
+	cfg := figlet.New()
+	cfg.Fontdirname = h.opts.FontDir
+	cfg.FontSources = h.opts.FontSources
+	figlet.WithLoadedFont(font)(cfg)
+	if err := cfg.LoadFont(); err != nil {
+		return nil, &requestError{http.StatusNotFound, "font", err}
+	}
+	// Set after loading, since the font manager reports the font load
+	cfg.Metrics = h.opts.Metrics
+	return cfg, nil
+}
+
+// release puts a configuration back in the font cache, unless its font
+// has been reloaded
+func (h *handler) release(cfg *figlet.Config) {
+	cfg.Tracer = nil
+	if cfg.Font() != h.fontmgr.Loaded(cfg.Fontname) {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.fonts[cfg.Fontname]) < fontsIdle {
+		h.fonts[cfg.Fontname] = append(h.fonts[cfg.Fontname], cfg)
+	}
+}
+
+// reload answers POST /reload by loading the fonts whose files changed
+// again, and lists them as JSON
+func (h *handler) reload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.metrics.request("reload", http.StatusMethodNotAllowed, "bad_request")
+		http.Error(w, fmt.Sprintf("method %s not allowed", r.Method), http.StatusMethodNotAllowed)
+		return
+	}
+	reloaded, err := h.fontmgr.Reload()
+	if err != nil {
+		h.metrics.request("reload", http.StatusInternalServerError, "font")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.metrics.request("reload", http.StatusOK, "")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"reloaded": append([]string{}, reloaded...)})
+}
+
+// render answers /render with the banner
+func (h *handler) render(ctx context.Context, cfg *figlet.Config, query url.Values) (func(w http.ResponseWriter, r *http.Request), error) {
+	format := figlet.FormatText
+	if name := query.Get("format"); name != "" {
+		var err error
+		if format, err = figlet.ParseFormat(name); err != nil {
+			return nil, err
+		}
+	}
+	if format == figlet.FormatText && len(cfg.Colors) > 0 {
+		cfg.OutputParser, _ = figlet.GetParser("terminal-color")
+	}
+	var buf bytes.Buffer
+	if err := cfg.RenderTo(&buf, query.Get("text"), format); err != nil {
+		return nil, err
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentTypes[format])
+		w.Write(buf.Bytes())
+	}, nil
+}
+
+// animator returns an animator for cfg within the frame budgets
+func (h *handler) animator(cfg *figlet.Config) *figlet.Animator {
+	animator := figlet.NewAnimator(cfg)
+	animator.MaxFrames, animator.MaxCells = h.opts.MaxFrames, h.opts.MaxCells
+	return animator
+}
+
+// animate answers /animate with a stream of frames or an animated GIF
+func (h *handler) animate(ctx context.Context, cfg *figlet.Config, query url.Values) (func(w http.ResponseWriter, r *http.Request), error) {
+	text := query.Get("text")
+	animType := query.Get("type")
+	if animType == "" {
+		animType = "reveal"
+	}
+	delay := 50 * time.Millisecond
+	if v := query.Get("delay"); v != "" {
+		ms, err := strconv.Atoi(v)
+		if err != nil || ms < 0 {
+			return nil, fmt.Errorf("invalid delay: %s", v)
+		}
+		delay = time.Duration(ms) * time.Millisecond
+		if h.opts.MaxDelay > 0 && delay > h.opts.MaxDelay {
+			return nil, &requestError{http.StatusRequestEntityTooLarge, "too_large", fmt.Errorf("delay %s is over the limit of %s", delay, h.opts.MaxDelay)}
+		}
+	}
+
+	switch query.Get("format") {
+	case "gif":
+		animator := h.animator(cfg)
+		frames, err := animator.GenerateStructuredContext(ctx, text, animType, delay)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := animator.WriteGIF(&buf, frames); err != nil {
+			return nil, &requestError{http.StatusInternalServerError, "internal", err}
+		}
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "image/gif")
+			w.Write(buf.Bytes())
+		}, nil
+	case "", "sse":
+	default:
+		return nil, fmt.Errorf("invalid format: %s (valid: sse, gif)", query.Get("format"))
+	}
+
+	parser := query.Get("parser")
+	if parser == "" {
+		parser = cfg.OutputParser.Name
+		if parser == "terminal" && len(cfg.Colors) > 0 {
+			parser = "terminal-color"
+		}
+	}
+	var err error
+	if cfg.OutputParser, err = figlet.GetParser(parser); err != nil {
+		return nil, err
+	}
+	frames, err := h.animator(cfg).GenerateAnimationContext(ctx, text, animType, delay)
+	if err != nil {
+		return nil, err
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		for i, frame := range frames {
+			data, _ := json.Marshal(map[string]any{
+				"index":          i,
+				"content":        frame.Content,
+				"delay":          frame.Delay.Milliseconds(),
+				"baselineOffset": frame.BaselineOffset,
+				"baseline":       frame.Baseline,
+			})
+			fmt.Fprintf(w, "event: frame\ndata: %s\n\n", data)
+			flusher.Flush()
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(frame.Delay):
+			}
+		}
+		fmt.Fprintf(w, "event: end\ndata: {\"frames\":%d}\n\n", len(frames))
+		flusher.Flush()
+	}, nil
+}
@@ -0,0 +1,354 @@
+package figlethttp
+
+import (
+	"encoding/json"
+	"image/gif"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lsferreira42/figlet-go/figlet"
+)
+
+func get(t *testing.T, h http.Handler, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	return w
+}
+
+func TestRender(t *testing.T) {
+	h := NewHandler(Options{})
+	w := get(t, h, "/render?text=Hi")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	cfg := figlet.New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	want := cfg.RenderString("Hi")
+	if w.Body.String() != want {
+		t.Errorf("body =\n%s\nwant\n%s", w.Body, want)
+	}
+
+	w = get(t, h, "/render?text=Hi&format=svg&colors=red;blue")
+	if ct := w.Header().Get("Content-Type"); ct != "image/svg+xml" {
+		t.Errorf("content type = %q", ct)
+	}
+	if !strings.Contains(w.Body.String(), "<svg") {
+		t.Errorf("body is not SVG: %s", w.Body)
+	}
+
+	for target, status := range map[string]int{
+		"/render":                      http.StatusBadRequest,
+		"/render?text=Hi&format=bogus": http.StatusBadRequest,
+		"/render?text=Hi&colors=nope":  http.StatusBadRequest,
+		"/render?text=Hi&font=../x":    http.StatusBadRequest,
+		"/render?text=Hi&font=nosuch":  http.StatusNotFound,
+		"/render?text=Hi&width=0":      http.StatusBadRequest,
+	} {
+		if w := get(t, h, target); w.Code != status {
+			t.Errorf("%s: status = %d, want %d", target, w.Code, status)
+		}
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/render?text=Hi", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status = %d", w.Code)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	h := NewHandler(Options{Rate: 2, MaxText: 5, MaxWidth: 100})
+	if w := get(t, h, "/render?text=toolong"); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("long text: status = %d", w.Code)
+	}
+	if w := get(t, h, "/render?text=Hi&width=200"); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("wide output: status = %d", w.Code)
+	}
+	w := get(t, h, "/render?text=Hi")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("third request: status = %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("missing Retry-After")
+	}
+
+	// Clients are limited separately
+	r := httptest.NewRequest(http.MethodGet, "/render?text=Hi", nil)
+	r.RemoteAddr = "192.0.2.99:1234"
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("other client: status = %d", w.Code)
+	}
+}
+
+func TestSettings(t *testing.T) {
+	h := NewHandler(Options{MaxWidth: 100})
+	settings := `{"font":"slant","width":40,"justification":"center","smush":{"mode":"kerning"}}`
+	w := get(t, h, "/render?text=Hi&settings="+url.QueryEscape(settings))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	cfg := figlet.New()
+	if err := json.Unmarshal([]byte(settings), cfg); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	if want := cfg.RenderString("Hi"); w.Body.String() != want {
+		t.Errorf("body =\n%s\nwant\n%s", w.Body, want)
+	}
+
+	// The settings do not stay with the font for later requests
+	w = get(t, h, "/render?text=Hi&font=slant")
+	cfg = figlet.New()
+	figlet.WithFont("slant")(cfg)
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	if want := cfg.RenderString("Hi"); w.Body.String() != want {
+		t.Errorf("body =\n%s\nwant\n%s", w.Body, want)
+	}
+
+	for settings, status := range map[string]int{
+		`{"width":200}`:              http.StatusRequestEntityTooLarge,
+		`{"font":"../x"}`:            http.StatusBadRequest,
+		`{"justification":"middle"}`: http.StatusBadRequest,
+		`not json`:                   http.StatusBadRequest,
+	} {
+		if w := get(t, h, "/render?text=Hi&settings="+url.QueryEscape(settings)); w.Code != status {
+			t.Errorf("%s: status = %d, want %d", settings, w.Code, status)
+		}
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	h := NewHandler(Options{Timeout: 50 * time.Millisecond}).(*handler)
+	w := get(t, h, "/animate?type=scroll&width=1000&text="+strings.Repeat("W", 100))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	// The render stops and returns its configuration to the font cache
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		h.mu.Lock()
+		idle := len(h.fonts["standard"])
+		h.mu.Unlock()
+		if idle == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the render kept running after timing out")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	h = NewHandler(DefaultOptions()).(*handler)
+	if w := get(t, h, "/animate?text=Hi&delay=60000"); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("long delay: status = %d", w.Code)
+	}
+}
+
+func TestAnimate(t *testing.T) {
+	h := NewHandler(Options{})
+	w := get(t, h, "/animate?text=Hi&type=reveal&delay=0")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("content type = %q", ct)
+	}
+	cfg := figlet.New()
+	if err := cfg.LoadFont(); err != nil {
+		t.Fatalf("LoadFont failed: %v", err)
+	}
+	frames, err := figlet.NewAnimator(cfg).GenerateAnimation("Hi", "reveal", 0)
+	if err != nil {
+		t.Fatalf("GenerateAnimation failed: %v", err)
+	}
+	body := w.Body.String()
+	if n := strings.Count(body, "event: frame\n"); n != len(frames) {
+		t.Errorf("%d frame events, want %d", n, len(frames))
+	}
+	if !strings.HasSuffix(body, "event: end\ndata: {\"frames\":"+strconv.Itoa(len(frames))+"}\n\n") {
+		t.Errorf("stream does not end with an end event: %q", body[max(0, len(body)-80):])
+	}
+
+	w = get(t, h, "/animate?text=Hi&type=wave&delay=20&format=gif&theme=fire")
+	if w.Code != http.StatusOK {
+		t.Fatalf("gif: status = %d: %s", w.Code, w.Body)
+	}
+	anim, err := gif.DecodeAll(w.Body)
+	if err != nil {
+		t.Fatalf("decoding GIF failed: %v", err)
+	}
+	if len(anim.Image) < 2 || anim.Delay[0] != 2 {
+		t.Errorf("got %d images with delay %d", len(anim.Image), anim.Delay[0])
+	}
+
+	if w := get(t, h, "/animate?text=Hi&type=bogus"); w.Code != http.StatusBadRequest {
+		t.Errorf("bad type: status = %d", w.Code)
+	}
+
+	h = NewHandler(Options{MaxFrames: 5})
+	w = get(t, h, "/animate?text=Hi&type=scroll&delay=0")
+	if n := strings.Count(w.Body.String(), "event: frame\n"); n != 5 {
+		t.Errorf("%d frame events with MaxFrames 5", n)
+	}
+	w = get(t, h, "/animate?text=Hi&type=scroll&delay=0&format=gif")
+	if anim, err := gif.DecodeAll(w.Body); err != nil {
+		t.Errorf("decoding GIF failed: %v", err)
+	} else if len(anim.Image) != 5 {
+		t.Errorf("%d images with MaxFrames 5", len(anim.Image))
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	h := NewHandler(Options{})
+	get(t, h, "/render?text=a")
+	get(t, h, "/render?text=b")
+	get(t, h, "/render")
+	body := get(t, h, "/metrics").Body.String()
+	for _, want := range []string{
+		`figlet_requests_total{endpoint="render",code="200"} 2`,
+		`figlet_requests_total{endpoint="render",code="400"} 1`,
+		`figlet_errors_total{type="bad_request"} 1`,
+		`figlet_render_duration_seconds_count{endpoint="render"} 2`,
+		"figlet_font_cache_hits_total 1",
+		"figlet_font_cache_misses_total 1",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics miss %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestMounted(t *testing.T) {
+	// Mounted under a prefix behind a middleware, as in an application
+	authorized := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "secret" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/figlet/", http.StripPrefix("/figlet", authorized(NewHandler(DefaultOptions()))))
+
+	if w := get(t, mux, "/figlet/render?text=Hi"); w.Code != http.StatusUnauthorized {
+		t.Errorf("without auth: status = %d", w.Code)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/figlet/render?text=Hi", nil)
+	r.Header.Set("Authorization", "secret")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "_") {
+		t.Errorf("with auth: status = %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestFontSources(t *testing.T) {
+	dir := t.TempDir()
+	data, err := os.ReadFile("../figlet/fonts/mini.flf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "tiny.flf"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		sources figlet.FontSource
+		font    string
+		status  int
+	}{
+		{0, "tiny", http.StatusOK},
+		{0, "standard", http.StatusOK},
+		{figlet.FontSourceDir, "standard", http.StatusNotFound},
+		{figlet.FontSourceEmbedded, "tiny", http.StatusNotFound},
+		{figlet.FontSourceAny, "..", http.StatusNotFound},
+	} {
+		h := NewHandler(Options{FontDir: dir, FontSources: tc.sources})
+		if w := get(t, h, "/render?text=Hi&font="+tc.font); w.Code != tc.status {
+			t.Errorf("%s from %v: status = %d, want %d", tc.font, tc.sources, w.Code, tc.status)
+		}
+	}
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tiny.flf")
+	install := func(font string) {
+		t.Helper()
+		data, err := os.ReadFile("../figlet/fonts/" + font + ".flf")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	render := func(font string) string {
+		out, err := figlet.Render("Hi", figlet.WithFont(font))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	post := func(h http.Handler) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/reload", nil))
+		return w
+	}
+
+	install("mini")
+	h := NewHandler(Options{FontDir: dir, Reload: true})
+	if w := get(t, h, "/render?text=Hi&font=tiny"); w.Body.String() != render("mini") {
+		t.Fatalf("before reload:\n%s", w.Body)
+	}
+	install("small")
+	if w := get(t, h, "/render?text=Hi&font=tiny"); w.Body.String() != render("mini") {
+		t.Errorf("font changed before reload:\n%s", w.Body)
+	}
+	if w := post(h); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `["tiny"]`) {
+		t.Errorf("reload: status = %d: %s", w.Code, w.Body)
+	}
+	if w := get(t, h, "/render?text=Hi&font=tiny"); w.Body.String() != render("small") {
+		t.Errorf("after reload:\n%s", w.Body)
+	}
+
+	// A broken font is reported and the loaded one kept
+	if err := os.WriteFile(path, []byte("not a font\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if w := post(h); w.Code != http.StatusInternalServerError {
+		t.Errorf("reload of a broken font: status = %d", w.Code)
+	}
+	if w := get(t, h, "/render?text=Hi&font=tiny"); w.Body.String() != render("small") {
+		t.Errorf("after a failed reload:\n%s", w.Body)
+	}
+
+	if w := get(t, NewHandler(Options{FontDir: dir}), "/reload"); w.Code != http.StatusNotFound {
+		t.Errorf("reload without Options.Reload: status = %d", w.Code)
+	}
+
+	// With WatchFonts, changes are picked up without a reload
+	install("mini")
+	h = NewHandler(Options{FontDir: dir, WatchFonts: time.Nanosecond})
+	get(t, h, "/render?text=Hi&font=tiny")
+	install("small")
+	if w := get(t, h, "/render?text=Hi&font=tiny"); w.Body.String() != render("small") {
+		t.Errorf("watched font not reloaded:\n%s", w.Body)
+	}
+}
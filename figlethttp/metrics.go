@@ -0,0 +1,135 @@
+package figlethttp
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// durationBuckets are the upper bounds of the render duration histogram,
+// in seconds
+var durationBuckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5}
+
+// metrics counts requests, errors and render durations, and writes them
+// in the Prometheus text format
+type metrics struct {
+	mu        sync.Mutex
+	requests  map[[2]string]uint64 // by endpoint and status code
+	errors    map[string]uint64    // by type
+	durations map[string][]uint64  // histogram buckets by endpoint, the last is +Inf
+	sums      map[string]float64
+	hits      uint64
+	misses    uint64
+}
+
+// request counts a finished request, and its error type if it failed
+func (m *metrics) request(endpoint string, status int, kind string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.requests == nil {
+		m.requests = make(map[[2]string]uint64)
+		m.errors = make(map[string]uint64)
+	}
+	m.requests[[2]string{endpoint, strconv.Itoa(status)}]++
+	if kind != "" {
+		m.errors[kind]++
+	}
+}
+
+// observe adds a render duration to the histogram of an endpoint
+func (m *metrics) observe(endpoint string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.durations == nil {
+		m.durations = make(map[string][]uint64)
+		m.sums = make(map[string]float64)
+	}
+	counts := m.durations[endpoint]
+	if counts == nil {
+		counts = make([]uint64, len(durationBuckets)+1)
+		m.durations[endpoint] = counts
+	}
+	seconds := d.Seconds()
+	for i, bound := range durationBuckets {
+		if seconds <= bound {
+			counts[i]++
+		}
+	}
+	counts[len(durationBuckets)]++
+	m.sums[endpoint] += seconds
+}
+
+// cache counts a font cache lookup
+func (m *metrics) cache(hit bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if hit {
+		m.hits++
+	} else {
+		m.misses++
+	}
+}
+
+// serve answers /metrics in the Prometheus text format
+func (m *metrics) serve(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var sb strings.Builder
+
+	sb.WriteString("# HELP figlet_requests_total Requests by endpoint and status code.\n")
+	sb.WriteString("# TYPE figlet_requests_total counter\n")
+	keys := make([][2]string, 0, len(m.requests))
+	for key := range m.requests {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i][0] < keys[j][0] || keys[i][0] == keys[j][0] && keys[i][1] < keys[j][1]
+	})
+	for _, key := range keys {
+		fmt.Fprintf(&sb, "figlet_requests_total{endpoint=%q,code=%q} %d\n", key[0], key[1], m.requests[key])
+	}
+
+	sb.WriteString("# HELP figlet_errors_total Failed requests by error type.\n")
+	sb.WriteString("# TYPE figlet_errors_total counter\n")
+	kinds := make([]string, 0, len(m.errors))
+	for kind := range m.errors {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		fmt.Fprintf(&sb, "figlet_errors_total{type=%q} %d\n", kind, m.errors[kind])
+	}
+
+	sb.WriteString("# HELP figlet_render_duration_seconds Time to render a successful request.\n")
+	sb.WriteString("# TYPE figlet_render_duration_seconds histogram\n")
+	endpoints := make([]string, 0, len(m.durations))
+	for endpoint := range m.durations {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+	for _, endpoint := range endpoints {
+		counts := m.durations[endpoint]
+		for i, bound := range durationBuckets {
+			fmt.Fprintf(&sb, "figlet_render_duration_seconds_bucket{endpoint=%q,le=\"%g\"} %d\n", endpoint, bound, counts[i])
+		}
+		total := counts[len(durationBuckets)]
+		fmt.Fprintf(&sb, "figlet_render_duration_seconds_bucket{endpoint=%q,le=\"+Inf\"} %d\n", endpoint, total)
+		fmt.Fprintf(&sb, "figlet_render_duration_seconds_sum{endpoint=%q} %g\n", endpoint, m.sums[endpoint])
+		fmt.Fprintf(&sb, "figlet_render_duration_seconds_count{endpoint=%q} %d\n", endpoint, total)
+	}
+
+	sb.WriteString("# HELP figlet_font_cache_hits_total Requests served with an already loaded font.\n")
+	sb.WriteString("# TYPE figlet_font_cache_hits_total counter\n")
+	fmt.Fprintf(&sb, "figlet_font_cache_hits_total %d\n", m.hits)
+	sb.WriteString("# HELP figlet_font_cache_misses_total Requests that loaded their font.\n")
+	sb.WriteString("# TYPE figlet_font_cache_misses_total counter\n")
+	fmt.Fprintf(&sb, "figlet_font_cache_misses_total %d\n", m.misses)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	io.WriteString(w, sb.String())
+}
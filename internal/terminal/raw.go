@@ -0,0 +1,7 @@
+package terminal
+
+import "errors"
+
+// ErrNoRawMode is returned by EnableRaw where a terminal cannot be put in
+// raw mode
+var ErrNoRawMode = errors.New("terminal: raw mode is not supported")
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !windows
+
+package terminal
+
+import "os"
+
+// EnableRaw returns ErrNoRawMode: this platform has no terminal or no
+// termios calls in the syscall package
+func EnableRaw(f *os.File) (func(), error) {
+	return nil, ErrNoRawMode
+}
@@ -0,0 +1,37 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package terminal
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// EnableRaw switches the terminal f reads from to read every key as soon
+// as it is pressed, without echoing it, and returns a function restoring
+// the previous mode. Ctrl-C is read as a key too, so the program can
+// restore the terminal before quitting. Output is left as is.
+func EnableRaw(f *os.File) (func(), error) {
+	var saved syscall.Termios
+	if err := termios(f, ioctlGetTermios, &saved); err != nil {
+		return nil, err
+	}
+	raw := saved
+	raw.Lflag &^= syscall.ICANON | syscall.ECHO | syscall.ISIG
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := termios(f, ioctlSetTermios, &raw); err != nil {
+		return nil, err
+	}
+	return func() { termios(f, ioctlSetTermios, &saved) }, nil
+}
+
+// termios gets or sets the terminal attributes of f
+func termios(f *os.File, req uintptr, t *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), req, uintptr(unsafe.Pointer(t)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
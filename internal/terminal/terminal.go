@@ -0,0 +1,76 @@
+// Package terminal finds out what the terminal figlet draws on can do:
+// its size, how many colors it shows and whether it understands VT escape
+// sequences. The platform calls live behind Prober, so the rules built
+// on them can be tested with a fake one.
+package terminal
+
+import (
+	"os"
+	"strings"
+)
+
+// ColorDepth is the number of colors a terminal can show
+type ColorDepth int
+
+const (
+	// NoColor means escape sequences for colors are not understood
+	NoColor ColorDepth = iota
+	// Color16 means the 16 ANSI colors
+	Color16
+	// Color256 means the 256 colors of xterm
+	Color256
+	// TrueColor means 24-bit RGB colors
+	TrueColor
+)
+
+// Capability describes a terminal
+type Capability struct {
+	// Columns and Rows are the terminal size, or -1 when there is no
+	// terminal
+	Columns int
+	Rows    int
+	// Colors is the most colors the terminal shows
+	Colors ColorDepth
+	// VT reports whether the terminal interprets VT100 escape sequences,
+	// such as colors and cursor moves
+	VT bool
+}
+
+// Prober asks the platform about the terminal
+type Prober interface {
+	// Size returns the terminal height and width, or -1 and -1 when
+	// there is no terminal
+	Size() (rows, cols int)
+	// VT reports whether the terminal interprets VT100 escape sequences
+	VT() bool
+}
+
+// Probe returns the capability of the terminal of the process
+func Probe() Capability {
+	return Detect(system{}, os.Getenv)
+}
+
+// Detect returns the capability reported by p, with the color depth
+// taken from the COLORTERM and TERM environment variables read with
+// getenv. TERM=dumb turns VT sequences off.
+func Detect(p Prober, getenv func(string) string) Capability {
+	var c Capability
+	c.Rows, c.Columns = p.Size()
+	term := getenv("TERM")
+	c.VT = term != "dumb" && p.VT()
+	if !c.VT {
+		return c
+	}
+	switch colorterm := strings.ToLower(getenv("COLORTERM")); {
+	case colorterm == "truecolor" || colorterm == "24bit":
+		c.Colors = TrueColor
+	// Windows Terminal sets neither COLORTERM nor TERM
+	case getenv("WT_SESSION") != "":
+		c.Colors = TrueColor
+	case strings.Contains(term, "256color"):
+		c.Colors = Color256
+	default:
+		c.Colors = Color16
+	}
+	return c
+}
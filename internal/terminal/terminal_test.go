@@ -0,0 +1,51 @@
+package terminal
+
+import "testing"
+
+// fakeProber reports a fixed terminal
+type fakeProber struct {
+	rows, cols int
+	vt         bool
+}
+
+func (p fakeProber) Size() (int, int) { return p.rows, p.cols }
+func (p fakeProber) VT() bool         { return p.vt }
+
+// env returns a getenv function reading vars
+func env(vars map[string]string) func(string) string {
+	return func(name string) string { return vars[name] }
+}
+
+func TestDetect(t *testing.T) {
+	tty := fakeProber{rows: 24, cols: 80, vt: true}
+	tests := []struct {
+		name   string
+		prober fakeProber
+		vars   map[string]string
+		want   Capability
+	}{
+		{"no terminal", fakeProber{rows: -1, cols: -1}, map[string]string{"TERM": "xterm-256color"},
+			Capability{Columns: -1, Rows: -1}},
+		{"plain", tty, map[string]string{"TERM": "xterm"},
+			Capability{Columns: 80, Rows: 24, Colors: Color16, VT: true}},
+		{"256 colors", tty, map[string]string{"TERM": "xterm-256color"},
+			Capability{Columns: 80, Rows: 24, Colors: Color256, VT: true}},
+		{"truecolor", tty, map[string]string{"TERM": "xterm-256color", "COLORTERM": "truecolor"},
+			Capability{Columns: 80, Rows: 24, Colors: TrueColor, VT: true}},
+		{"24bit", tty, map[string]string{"COLORTERM": "24bit"},
+			Capability{Columns: 80, Rows: 24, Colors: TrueColor, VT: true}},
+		{"windows terminal", tty, map[string]string{"WT_SESSION": "1"},
+			Capability{Columns: 80, Rows: 24, Colors: TrueColor, VT: true}},
+		{"dumb", tty, map[string]string{"TERM": "dumb", "COLORTERM": "truecolor"},
+			Capability{Columns: 80, Rows: 24}},
+		{"legacy console", fakeProber{rows: 30, cols: 120}, nil,
+			Capability{Columns: 120, Rows: 30}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect(tt.prober, env(tt.vars)); got != tt.want {
+				t.Errorf("Detect() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
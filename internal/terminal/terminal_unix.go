@@ -0,0 +1,41 @@
+//go:build !windows && !js
+
+package terminal
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// system probes the controlling terminal with ioctl
+type system struct{}
+
+// Size reads the size of /dev/tty
+func (system) Size() (int, int) {
+	fd, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return -1, -1
+	}
+	defer fd.Close()
+
+	var ws struct {
+		Row    uint16
+		Col    uint16
+		Xpixel uint16
+		Ypixel uint16
+	}
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 {
+		return -1, -1
+	}
+	return int(ws.Row), int(ws.Col)
+}
+
+// VT reports whether there is a terminal; Unix terminals all interpret
+// VT100 sequences
+func (s system) VT() bool {
+	rows, _ := s.Size()
+	return rows >= 0
+}
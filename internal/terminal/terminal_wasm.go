@@ -0,0 +1,16 @@
+//go:build js && wasm
+
+package terminal
+
+// system stands for the browser, which has no terminal
+type system struct{}
+
+// Size returns 0 and 0, so callers use their default width
+func (system) Size() (int, int) {
+	return 0, 0
+}
+
+// VT returns false; output goes to a page, not a terminal
+func (system) VT() bool {
+	return false
+}
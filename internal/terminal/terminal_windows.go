@@ -0,0 +1,92 @@
+//go:build windows
+
+package terminal
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32                       = syscall.NewLazyDLL("kernel32.dll")
+	procGetConsoleScreenBufferInfo = kernel32.NewProc("GetConsoleScreenBufferInfo")
+	procGetConsoleMode             = kernel32.NewProc("GetConsoleMode")
+	procSetConsoleMode             = kernel32.NewProc("SetConsoleMode")
+)
+
+// Console mode flags
+const (
+	enableProcessedInput            = 0x0001
+	enableLineInput                 = 0x0002
+	enableEchoInput                 = 0x0004
+	enableVirtualTerminalProcessing = 0x0004
+)
+
+type coord struct {
+	X int16
+	Y int16
+}
+
+type smallRect struct {
+	Left   int16
+	Top    int16
+	Right  int16
+	Bottom int16
+}
+
+type consoleScreenBufferInfo struct {
+	Size              coord
+	CursorPosition    coord
+	Attributes        uint16
+	Window            smallRect
+	MaximumWindowSize coord
+}
+
+// system probes the console of standard output
+type system struct{}
+
+// Size returns the lines of the visible window and the buffer width
+func (system) Size() (int, int) {
+	handle, err := syscall.GetStdHandle(syscall.STD_OUTPUT_HANDLE)
+	if err != nil {
+		return -1, -1
+	}
+
+	var info consoleScreenBufferInfo
+	r1, _, _ := procGetConsoleScreenBufferInfo.Call(uintptr(handle), uintptr(unsafe.Pointer(&info)))
+	if r1 == 0 {
+		return -1, -1
+	}
+
+	return int(info.Window.Bottom-info.Window.Top) + 1, int(info.Size.X)
+}
+
+// VT reports whether the console has virtual terminal processing on
+func (system) VT() bool {
+	handle, err := syscall.GetStdHandle(syscall.STD_OUTPUT_HANDLE)
+	if err != nil {
+		return false
+	}
+
+	var mode uint32
+	r1, _, _ := procGetConsoleMode.Call(uintptr(handle), uintptr(unsafe.Pointer(&mode)))
+	return r1 != 0 && mode&enableVirtualTerminalProcessing != 0
+}
+
+// EnableRaw switches the console f reads from to read every key as soon
+// as it is pressed, without echoing it, and returns a function restoring
+// the previous mode. Ctrl-C is read as a key too, so the program can
+// restore the console before quitting.
+func EnableRaw(f *os.File) (func(), error) {
+	handle := f.Fd()
+	var saved uint32
+	if r1, _, err := procGetConsoleMode.Call(handle, uintptr(unsafe.Pointer(&saved))); r1 == 0 {
+		return nil, err
+	}
+	raw := saved &^ (enableProcessedInput | enableLineInput | enableEchoInput)
+	if r1, _, err := procSetConsoleMode.Call(handle, uintptr(raw)); r1 == 0 {
+		return nil, err
+	}
+	return func() { procSetConsoleMode.Call(handle, uintptr(saved)) }, nil
+}
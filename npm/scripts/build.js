@@ -41,6 +41,9 @@ export {
     render,
     renderWithFont,
     listFonts,
+    generateAnimationDeltas,
+    generateAnimationStructured,
+    decodeFrames,
     getVersion,
     createInstance,
 };
@@ -50,6 +53,9 @@ export default {
     render,
     renderWithFont,
     listFonts,
+    generateAnimationDeltas,
+    generateAnimationStructured,
+    decodeFrames,
     getVersion,
     createInstance,
 };
@@ -18,6 +18,75 @@ export interface ListFontsResult {
     fonts: string[];
 }
 
+export interface SmushRules {
+    /** Layout mode; rules only apply when smushing (default when setting) */
+    mode?: 'full-width' | 'kerning' | 'smushing';
+    /** Rule 1: identical sub-characters merge */
+    equal?: boolean;
+    /** Rule 2: underscores are replaced by |/\\[]{}()<> */
+    underscore?: boolean;
+    /** Rule 3: the higher class of |, /\\, [], {}, (), <> wins */
+    hierarchy?: boolean;
+    /** Rule 4: opposing brackets become a vertical bar */
+    oppositePairs?: boolean;
+    /** Rule 5: /\\ becomes |, \\/ becomes Y, >< becomes X */
+    bigX?: boolean;
+    /** Rule 6: two hardblanks merge */
+    hardblank?: boolean;
+}
+
+/** Animation frame stored as the lines changed since the previous frame */
+export interface AnimationDelta {
+    /** Each change keeps the first `column` code points of the line and appends `text` */
+    changes: { line: number; column: number; text: string }[];
+    lines: number;
+    delay: number;
+    baselineOffset: number;
+    baseline: number;
+}
+
+/**
+ * Animation frame as cells: full rows, or [x, y, char, color] changes from
+ * the previous frame. Colors index the palette; -1 means uncolored.
+ */
+export interface StructuredFrame {
+    rows?: { text: string; colors: number[] }[];
+    changes?: [number, number, string, number][];
+    delay: number;
+    baselineOffset: number;
+    baseline: number;
+}
+
+export interface StructuredAnimation {
+    error: string | null;
+    palette: string[];
+    frames: StructuredFrame[];
+}
+
+/** Full animation frame, as rebuilt by decodeFrames */
+export interface AnimationFrame {
+    content: string;
+    delay: number;
+    baselineOffset: number;
+    baseline: number;
+}
+
+/** Settings that can be saved and restored as JSON */
+export interface Settings {
+    font?: string;
+    width?: number;
+    justification?: 'auto' | 'left' | 'center' | 'right' | 'full';
+    direction?: 'auto' | 'left-to-right' | 'right-to-left';
+    smush?: SmushRules;
+    /** Color names, hex or rgb()/hsl(); an empty list removes the colors */
+    colors?: string[];
+    /** Comma separated style names, such as "bold,underline" */
+    style?: string;
+    colorDepth?: '16' | '256' | 'truecolor';
+    parser?: string;
+    border?: 'none' | 'single' | 'double' | 'round' | 'heavy' | 'ascii';
+}
+
 export interface FigletInstance {
     /**
      * Render text with the current font
@@ -25,6 +94,12 @@ export interface FigletInstance {
     renderWithFont(text: string, font: string): RenderResult;
     setFont(font: string): FontResult;
     listFonts(): ListFontsResult;
+    generateAnimationDeltas(text: string, animation?: string, delay?: number): { error: string | null; deltas: AnimationDelta[] };
+    generateAnimationStructured(text: string, animation?: string, delay?: number, diff?: boolean): StructuredAnimation;
+    /**
+     * Cap the frames and grid cells of generated animations, 0 for no limit
+     */
+    setAnimationBudget(maxFrames: number, maxCells: number): boolean;
     getVersion(): string;
     setWidth(width: number): boolean;
     setJustification(align: 'left' | 'center' | 'right' | 'auto'): boolean;
@@ -41,6 +116,26 @@ export interface FigletInstance {
      */
     setSmushMode(mode: number): boolean;
 
+    /**
+     * Get the layout in effect as named smushing rules
+     */
+    getSmushRules(): SmushRules;
+
+    /**
+     * Override the font's layout with named smushing rules
+     */
+    setSmushRules(rules: SmushRules): boolean;
+
+    /**
+     * Get the settings, to save and restore them later
+     */
+    getSettings(): Settings;
+
+    /**
+     * Apply settings; missing ones are left as they are
+     */
+    setSettings(settings: Settings): boolean;
+
     /**
      * Set right-to-left mode
      * @param mode 0 = left, 1 = right, -1 = auto
@@ -113,6 +208,31 @@ export function renderWithFont(text: string, font: string): Promise<string>;
  */
 export function listFonts(): Promise<string[]>;
 
+/**
+ * Generate animation frames as deltas, which are much smaller to transfer
+ * for long animations. Use decodeFrames to get full frames back.
+ * @param text - The text to animate
+ * @param animation - Animation name
+ * @param delay - Delay between frames in milliseconds
+ */
+export function generateAnimationDeltas(text: string, animation?: string, delay?: number): Promise<AnimationDelta[]>;
+
+/**
+ * Generate animation frames as cell grids with palette indexes, for canvas
+ * or WebGL renderers
+ * @param text - The text to animate
+ * @param animation - Animation name
+ * @param delay - Delay between frames in milliseconds
+ * @param diff - List only the cells that changed after the first frame
+ */
+export function generateAnimationStructured(text: string, animation?: string, delay?: number, diff?: boolean): Promise<StructuredAnimation>;
+
+/**
+ * Rebuild full frames from deltas
+ * @param deltas - Frame deltas from generateAnimationDeltas
+ */
+export function decodeFrames(deltas: AnimationDelta[]): AnimationFrame[];
+
 /**
  * Get the FIGlet version
  */
@@ -129,6 +249,9 @@ declare const figletGo: {
     render: typeof render;
     renderWithFont: typeof renderWithFont;
     listFonts: typeof listFonts;
+    generateAnimationDeltas: typeof generateAnimationDeltas;
+    generateAnimationStructured: typeof generateAnimationStructured;
+    decodeFrames: typeof decodeFrames;
     getVersion: typeof getVersion;
     createInstance: typeof createInstance;
 };
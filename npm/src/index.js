@@ -109,6 +109,67 @@ async function listFonts() {
     return result.fonts;
 }
 
+/**
+ * Generate animation frames as deltas, which are much smaller to transfer
+ * for long animations. Use decodeFrames to get full frames back.
+ * @param {string} text - The text to animate
+ * @param {string} [animation] - Animation name
+ * @param {number} [delay] - Delay between frames in milliseconds
+ * @returns {Promise<object[]>} - The frame deltas
+ */
+async function generateAnimationDeltas(text, animation = 'reveal', delay = 50) {
+    const fig = await init();
+    const result = fig.generateAnimationDeltas(0, text, animation, delay);
+    if (result.error) {
+        throw new Error(result.error);
+    }
+    return result.deltas;
+}
+
+/**
+ * Generate animation frames as cell grids with palette indexes, for canvas
+ * or WebGL renderers. With diff, frames after the first only list the
+ * cells that changed.
+ * @param {string} text - The text to animate
+ * @param {string} [animation] - Animation name
+ * @param {number} [delay] - Delay between frames in milliseconds
+ * @param {boolean} [diff] - List only changed cells after the first frame
+ * @returns {Promise<object>} - The palette and the frames
+ */
+async function generateAnimationStructured(text, animation = 'reveal', delay = 50, diff = false) {
+    const fig = await init();
+    const result = fig.generateAnimationStructured(0, text, animation, delay, diff);
+    if (result.error) {
+        throw new Error(result.error);
+    }
+    return result;
+}
+
+/**
+ * Rebuild full frames from deltas
+ * @param {object[]} deltas - Frame deltas from generateAnimationDeltas
+ * @returns {object[]} - Frames with their content, delay and baseline
+ */
+function decodeFrames(deltas) {
+    let lines = [];
+    return deltas.map((delta) => {
+        lines = lines.slice(0, delta.lines);
+        while (lines.length < delta.lines) {
+            lines.push('');
+        }
+        for (const change of delta.changes) {
+            const kept = change.column > 0 ? Array.from(lines[change.line]).slice(0, change.column).join('') : '';
+            lines[change.line] = kept + change.text;
+        }
+        return {
+            content: lines.join('\n'),
+            delay: delta.delay,
+            baselineOffset: delta.baselineOffset,
+            baseline: delta.baseline,
+        };
+    });
+}
+
 /**
  * Get the FIGlet version
  * @returns {Promise<string>} - Version string
@@ -140,6 +201,19 @@ class FIGlet {
         return this.wasm.listFonts();
     }
 
+    generateAnimationDeltas(text, animation = 'reveal', delay = 50) {
+        return this.wasm.generateAnimationDeltas(this.handle, text, animation, delay);
+    }
+
+    generateAnimationStructured(text, animation = 'reveal', delay = 50, diff = false) {
+        return this.wasm.generateAnimationStructured(this.handle, text, animation, delay, diff);
+    }
+
+    setAnimationBudget(maxFrames, maxCells) {
+        const result = this.wasm.setAnimationBudget(this.handle, maxFrames, maxCells);
+        return result.success;
+    }
+
     getVersion() {
         return this.wasm.getVersion();
     }
@@ -170,6 +244,26 @@ class FIGlet {
         return result.success;
     }
 
+    getSmushRules() {
+        const result = this.wasm.getSmushRules(this.handle);
+        return result.rules;
+    }
+
+    setSmushRules(rules) {
+        const result = this.wasm.setSmushRules(this.handle, rules);
+        return result.success;
+    }
+
+    getSettings() {
+        const result = this.wasm.getSettings(this.handle);
+        return JSON.parse(result.settings || '{}');
+    }
+
+    setSettings(settings) {
+        const result = this.wasm.setSettings(this.handle, JSON.stringify(settings));
+        return result.success;
+    }
+
     setRightToLeft(mode) {
         const result = this.wasm.setRightToLeft(this.handle, mode);
         return result.success;
@@ -267,6 +361,9 @@ module.exports = {
     render,
     renderWithFont,
     listFonts,
+    generateAnimationDeltas,
+    generateAnimationStructured,
+    decodeFrames,
     getVersion,
     createInstance,
 };
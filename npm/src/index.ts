@@ -23,6 +23,56 @@ export interface ListFontsResult {
     fonts: string[];
 }
 
+export interface SmushRules {
+    mode?: 'full-width' | 'kerning' | 'smushing';
+    equal?: boolean;
+    underscore?: boolean;
+    hierarchy?: boolean;
+    oppositePairs?: boolean;
+    bigX?: boolean;
+    hardblank?: boolean;
+}
+
+// Settings that can be saved and restored as JSON, see figlet.Settings
+export interface Settings {
+    font?: string;
+    width?: number;
+    justification?: 'auto' | 'left' | 'center' | 'right' | 'full';
+    direction?: 'auto' | 'left-to-right' | 'right-to-left';
+    smush?: SmushRules;
+    colors?: string[];
+    style?: string;
+    colorDepth?: '16' | '256' | 'truecolor';
+    parser?: string;
+    border?: 'none' | 'single' | 'double' | 'round' | 'heavy' | 'ascii';
+}
+
+// Animation frame stored as the lines changed since the previous frame
+export interface AnimationDelta {
+    // Each change keeps the first `column` code points of the line and appends `text`
+    changes: { line: number; column: number; text: string }[];
+    lines: number;
+    delay: number;
+    baselineOffset: number;
+    baseline: number;
+}
+
+// Animation frame as cells: full rows, or [x, y, char, color] changes from
+// the previous frame. Colors index the palette; -1 means uncolored.
+export interface StructuredFrame {
+    rows?: { text: string; colors: number[] }[];
+    changes?: [number, number, string, number][];
+    delay: number;
+    baselineOffset: number;
+    baseline: number;
+}
+
+export interface StructuredAnimation {
+    error: string | null;
+    palette: string[];
+    frames: StructuredFrame[];
+}
+
 // Public API interface
 export interface FigletInstance {
     render(text: string): RenderResult;
@@ -31,12 +81,19 @@ export interface FigletInstance {
     listFonts(): ListFontsResult;
     listAnimations(): { error: string | null; animations: string[] };
     generateAnimation(text: string, animation?: string, delay?: number): { error: string | null; frames: any[] };
+    generateAnimationDeltas(text: string, animation?: string, delay?: number): { error: string | null; deltas: AnimationDelta[] };
+    generateAnimationStructured(text: string, animation?: string, delay?: number, diff?: boolean): StructuredAnimation;
+    setAnimationBudget(maxFrames: number, maxCells: number): boolean;
     getVersion(): string;
     setWidth(width: number): boolean;
     setJustification(align: 'left' | 'center' | 'right' | 'auto'): boolean;
     setColors(colors: string[]): boolean;
     setParser(parser: string): boolean;
     setSmushMode(mode: number): boolean;
+    getSmushRules(): SmushRules;
+    setSmushRules(rules: SmushRules): boolean;
+    getSettings(): Settings;
+    setSettings(settings: Settings): boolean;
     setRightToLeft(mode: number): boolean;
     setParagraph(enabled: boolean): boolean;
     setDeutsch(enabled: boolean): boolean;
@@ -57,6 +114,10 @@ interface WasmFiglet {
     setColors(handle: number, colors: string[]): { success: boolean };
     setParser(handle: number, parser: string): { success: boolean };
     setSmushMode(handle: number, mode: number): { success: boolean };
+    getSmushRules(handle: number): { error: string | null; rules: SmushRules };
+    setSmushRules(handle: number, rules: SmushRules): { success: boolean };
+    getSettings(handle: number): { error: string | null; settings: string };
+    setSettings(handle: number, settings: string): { error: string | null; success: boolean };
     setRightToLeft(handle: number, mode: number): { success: boolean };
     setParagraph(handle: number, enabled: boolean): { success: boolean };
     setDeutsch(handle: number, enabled: boolean): { success: boolean };
@@ -64,6 +125,9 @@ interface WasmFiglet {
     clearControlFiles(handle: number): { success: boolean };
     listAnimations(): { error: string | null; animations: string[] };
     generateAnimation(handle: number, text: string, animation: string, delay: number): { error: string | null; frames: any[] };
+    generateAnimationDeltas(handle: number, text: string, animation: string, delay: number): { error: string | null; deltas: AnimationDelta[] };
+    generateAnimationStructured(handle: number, text: string, animation: string, delay: number, diff: boolean): StructuredAnimation;
+    setAnimationBudget(handle: number, maxFrames: number, maxCells: number): { success: boolean };
 }
 
 declare global {
@@ -199,6 +263,56 @@ export async function generateAnimation(text: string, animation: string = 'revea
     return result.frames;
 }
 
+/**
+ * Generate animation frames as deltas, which are much smaller to transfer
+ * for long animations. Use decodeFrames to get full frames back.
+ */
+export async function generateAnimationDeltas(text: string, animation: string = 'reveal', delay: number = 50): Promise<AnimationDelta[]> {
+    const fig = await init();
+    const result = fig.generateAnimationDeltas(0, text, animation, delay);
+    if (result.error) {
+        throw new Error(result.error);
+    }
+    return result.deltas;
+}
+
+/**
+ * Generate animation frames as cell grids with palette indexes, for canvas
+ * or WebGL renderers. With diff, frames after the first only list the
+ * cells that changed.
+ */
+export async function generateAnimationStructured(text: string, animation: string = 'reveal', delay: number = 50, diff: boolean = false): Promise<StructuredAnimation> {
+    const fig = await init();
+    const result = fig.generateAnimationStructured(0, text, animation, delay, diff);
+    if (result.error) {
+        throw new Error(result.error);
+    }
+    return result;
+}
+
+/**
+ * Rebuild full frames from deltas
+ */
+export function decodeFrames(deltas: AnimationDelta[]): { content: string; delay: number; baselineOffset: number; baseline: number }[] {
+    let lines: string[] = [];
+    return deltas.map((delta) => {
+        lines = lines.slice(0, delta.lines);
+        while (lines.length < delta.lines) {
+            lines.push('');
+        }
+        for (const change of delta.changes) {
+            const kept = change.column > 0 ? Array.from(lines[change.line]).slice(0, change.column).join('') : '';
+            lines[change.line] = kept + change.text;
+        }
+        return {
+            content: lines.join('\n'),
+            delay: delta.delay,
+            baselineOffset: delta.baselineOffset,
+            baseline: delta.baseline,
+        };
+    });
+}
+
 /**
  * Get the FIGlet version
  */
@@ -240,6 +354,19 @@ class FIGlet implements FigletInstance {
         return this.wasm.generateAnimation(this.handle, text, animation, delay);
     }
 
+    generateAnimationDeltas(text: string, animation: string = 'reveal', delay: number = 50): { error: string | null; deltas: AnimationDelta[] } {
+        return this.wasm.generateAnimationDeltas(this.handle, text, animation, delay);
+    }
+
+    generateAnimationStructured(text: string, animation: string = 'reveal', delay: number = 50, diff: boolean = false): StructuredAnimation {
+        return this.wasm.generateAnimationStructured(this.handle, text, animation, delay, diff);
+    }
+
+    setAnimationBudget(maxFrames: number, maxCells: number): boolean {
+        const result = this.wasm.setAnimationBudget(this.handle, maxFrames, maxCells);
+        return result.success;
+    }
+
     getVersion(): string {
         return this.wasm.getVersion();
     }
@@ -270,6 +397,26 @@ class FIGlet implements FigletInstance {
         return result.success;
     }
 
+    getSmushRules(): SmushRules {
+        const result = this.wasm.getSmushRules(this.handle);
+        return result.rules;
+    }
+
+    setSmushRules(rules: SmushRules): boolean {
+        const result = this.wasm.setSmushRules(this.handle, rules);
+        return result.success;
+    }
+
+    getSettings(): Settings {
+        const result = this.wasm.getSettings(this.handle);
+        return JSON.parse(result.settings || '{}');
+    }
+
+    setSettings(settings: Settings): boolean {
+        const result = this.wasm.setSettings(this.handle, JSON.stringify(settings));
+        return result.success;
+    }
+
     setRightToLeft(mode: number): boolean {
         const result = this.wasm.setRightToLeft(this.handle, mode);
         return result.success;
@@ -369,6 +516,9 @@ export default {
     listFonts,
     listAnimations,
     generateAnimation,
+    generateAnimationDeltas,
+    generateAnimationStructured,
+    decodeFrames,
     getVersion,
     createInstance,
 };
@@ -48,6 +48,30 @@ describe('FIGlet NPM Package', () => {
         expect(kArt.length).toBeLessThan(fwArt.length);
     });
 
+    test('Smush rules', async () => {
+        const instance = await createInstance({ font: 'standard' });
+        const rules = instance.getSmushRules();
+        expect(rules.mode).toBe('smushing');
+        expect(rules.hierarchy).toBe(true);
+
+        expect(instance.setSmushRules({ mode: 'kerning' })).toBe(true);
+        expect(instance.getSmushRules().mode).toBe('kerning');
+    });
+
+    test('Settings', async () => {
+        const instance = await createInstance({ font: 'slant', width: 60 });
+        expect(instance.setSettings({ justification: 'center', colors: ['red'] })).toBe(true);
+        const settings = instance.getSettings();
+        expect(settings.font).toBe('slant');
+        expect(settings.justification).toBe('center');
+        expect(settings.parser).toBe('terminal-color');
+
+        const copy = await createInstance();
+        expect(copy.setSettings(settings)).toBe(true);
+        expect(copy.render('Hi').result).toBe(instance.render('Hi').result);
+        expect(copy.setSettings({ justification: 'middle' as any })).toBe(false);
+    });
+
     test('Colors and HTML parser', async () => {
         const instance = await createInstance({
             colors: ['red', 'green'],
@@ -23,6 +23,10 @@ run_test "Scroll animation" "./figlet-go --animation scroll 'Test' --animation-d
 run_test "Rain animation" "./figlet-go --animation rain 'Test' --animation-delay 1"
 run_test "Wave animation" "./figlet-go --animation wave 'Test' --animation-delay 1"
 run_test "Explosion animation" "./figlet-go --animation explosion 'Test' --animation-delay 1"
+run_test "Drop animation" "./figlet-go --animation drop 'Test' --animation-delay 1"
+run_test "Slide animation" "./figlet-go --animation slide 'Test' --animation-delay 1"
+run_test "Spin animation" "./figlet-go --animation spin 'Test' --animation-delay 1"
+run_test "Highlight animation" "./figlet-go --animation highlight 'Test' --animation-delay 1"
 
 # Test export and file playback
 run_test "Export reveal animation" "./figlet-go --animation reveal 'Export' --export test.ani --animation-delay 1"
@@ -1,7 +1,7 @@
 #!/bin/sh
 
 # Script to run the FIGlet library test suite
-# Runs Go tests for the figlet package with various options
+# Runs Go tests for the figlet and figlethttp packages with various options
 #
 # Usage: ./run-lib-tests.sh [options]
 #   -v    Verbose output
@@ -60,7 +60,7 @@ echo "Running tests..." | tee -a "$LOGFILE"
 echo "" | tee -a "$LOGFILE"
 
 # Build the test command
-TEST_CMD="go test $VERBOSE $COVERAGE $RACE $BENCHMARKS ./figlet/..."
+TEST_CMD="go test $VERBOSE $COVERAGE $RACE $BENCHMARKS ./figlet/... ./figlethttp/..."
 
 echo "Command: $TEST_CMD" | tee -a "$LOGFILE"
 echo "" | tee -a "$LOGFILE"
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
 	"sync"
 	"syscall/js"
 	"time"
@@ -9,11 +11,18 @@ import (
 )
 
 var (
-	configs = make(map[int]*figlet.Config)
+	configs = make(map[int]*instance)
 	nextID  = 1
 	mu      sync.Mutex
 )
 
+// instance is a FIGlet instance with its animation frame and cell budgets
+type instance struct {
+	cfg       *figlet.Config
+	maxFrames int
+	maxCells  int
+}
+
 // loadFont loads the font and keeps config values that might be overwritten
 func loadFont(cfg *figlet.Config) error {
 	// Preserve settings that might be overwritten by LoadFont
@@ -51,19 +60,24 @@ func init() {
 	mu.Lock()
 	defer mu.Unlock()
 	cfg := figlet.New()
-	configs[0] = cfg
+	configs[0] = &instance{cfg: cfg}
 	// Load the default font (standard)
 	loadFont(cfg)
 }
 
 // getConfig gets a config by handle or return the default if not a number
 func getConfig(args []js.Value) (*figlet.Config, []js.Value) {
+	inst, args := getInstance(args)
+	return inst.cfg, args
+}
+
+// getInstance gets an instance by handle or return the default if not a number
+func getInstance(args []js.Value) (*instance, []js.Value) {
+	mu.Lock()
+	defer mu.Unlock()
 	if len(args) > 0 && args[0].Type() == js.TypeNumber {
-		id := args[0].Int()
-		mu.Lock()
-		defer mu.Unlock()
-		if cfg, ok := configs[id]; ok {
-			return cfg, args[1:]
+		if inst, ok := configs[args[0].Int()]; ok {
+			return inst, args[1:]
 		}
 	}
 	return configs[0], args
@@ -76,7 +90,7 @@ func createInstance(this js.Value, args []js.Value) interface{} {
 	id := nextID
 	nextID++
 	cfg := figlet.New()
-	configs[id] = cfg
+	configs[id] = &instance{cfg: cfg}
 	if err := loadFont(cfg); err != nil {
 		return map[string]interface{}{
 			"error":  err.Error(),
@@ -205,14 +219,8 @@ func setWidth(this js.Value, args []js.Value) interface{} {
 		}
 	}
 
+	// The line buffers follow the width on the next render; no reload needed
 	cfg.Outputwidth = width
-	// Reload font to recalculate internal buffers with new width
-	if err := loadFont(cfg); err != nil {
-		return map[string]interface{}{
-			"error":   err.Error(),
-			"success": false,
-		}
-	}
 
 	return map[string]interface{}{
 		"error":   nil,
@@ -263,33 +271,12 @@ func setColors(this js.Value, args []js.Value) interface{} {
 			continue
 		}
 
-		var color figlet.Color
-		switch colorStr {
-		case "black":
-			color = figlet.ColorBlack
-		case "red":
-			color = figlet.ColorRed
-		case "green":
-			color = figlet.ColorGreen
-		case "yellow":
-			color = figlet.ColorYellow
-		case "blue":
-			color = figlet.ColorBlue
-		case "magenta":
-			color = figlet.ColorMagenta
-		case "cyan":
-			color = figlet.ColorCyan
-		case "white":
-			color = figlet.ColorWhite
-		default:
-			tc, err := figlet.NewTrueColorFromHexString(colorStr)
-			if err != nil {
-				return map[string]interface{}{
-					"error":   "invalid color: " + colorStr,
-					"success": false,
-				}
+		color, err := figlet.ParseColor(colorStr)
+		if err != nil {
+			return map[string]interface{}{
+				"error":   err.Error(),
+				"success": false,
 			}
-			color = tc
 		}
 		colors = append(colors, color)
 	}
@@ -341,18 +328,113 @@ func setSmushMode(this js.Value, args []js.Value) interface{} {
 			"success": false,
 		}
 	}
-	mode := args[0].Int()
-	if mode < -1 {
-		cfg.Smushoverride = figlet.SMO_NO
-	} else if mode == 0 {
-		cfg.Smushmode = figlet.SM_KERN
-		cfg.Smushoverride = figlet.SMO_YES
-	} else if mode == -1 {
-		cfg.Smushmode = 0
-		cfg.Smushoverride = figlet.SMO_YES
-	} else {
-		cfg.Smushmode = (mode & 63) | figlet.SM_SMUSH
-		cfg.Smushoverride = figlet.SMO_YES
+	figlet.WithSmushMode(args[0].Int())(cfg)
+	return map[string]interface{}{
+		"error":   nil,
+		"success": true,
+	}
+}
+
+// getSmushRules returns the layout in effect as named rules
+func getSmushRules(this js.Value, args []js.Value) interface{} {
+	cfg, _ := getConfig(args)
+	rules := cfg.SmushRules()
+	return map[string]interface{}{
+		"error": nil,
+		"rules": map[string]interface{}{
+			"mode":          rules.Mode.String(),
+			"equal":         rules.Equal,
+			"underscore":    rules.Underscore,
+			"hierarchy":     rules.Hierarchy,
+			"oppositePairs": rules.OppositePairs,
+			"bigX":          rules.BigX,
+			"hardblank":     rules.Hardblank,
+		},
+	}
+}
+
+// setSmushRules overrides the font's layout with named rules
+func setSmushRules(this js.Value, args []js.Value) interface{} {
+	cfg, args := getConfig(args)
+	if len(args) < 1 || args[0].Type() != js.TypeObject {
+		return map[string]interface{}{
+			"error":   "smush rules object required",
+			"success": false,
+		}
+	}
+	obj := args[0]
+	flag := func(name string) bool {
+		v := obj.Get(name)
+		return v.Type() == js.TypeBoolean && v.Bool()
+	}
+	rules := figlet.SmushRules{
+		Equal:         flag("equal"),
+		Underscore:    flag("underscore"),
+		Hierarchy:     flag("hierarchy"),
+		OppositePairs: flag("oppositePairs"),
+		BigX:          flag("bigX"),
+		Hardblank:     flag("hardblank"),
+	}
+	switch mode := obj.Get("mode"); {
+	case mode.IsUndefined(), mode.String() == "smushing":
+		rules.Mode = figlet.LayoutSmushing
+	case mode.String() == "kerning":
+		rules.Mode = figlet.LayoutKerning
+	case mode.String() == "full-width":
+		rules.Mode = figlet.LayoutFullWidth
+	default:
+		return map[string]interface{}{
+			"error":   "unknown layout mode: " + mode.String(),
+			"success": false,
+		}
+	}
+	figlet.WithSmushRules(rules)(cfg)
+	return map[string]interface{}{
+		"error":   nil,
+		"success": true,
+	}
+}
+
+// getSettings returns the settings as a JSON string, see figlet.Settings
+func getSettings(this js.Value, args []js.Value) interface{} {
+	cfg, _ := getConfig(args)
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return map[string]interface{}{
+			"error":    err.Error(),
+			"settings": "",
+		}
+	}
+	return map[string]interface{}{
+		"error":    nil,
+		"settings": string(data),
+	}
+}
+
+// setSettings applies settings given as a JSON string, loading the font
+// when it changes
+func setSettings(this js.Value, args []js.Value) interface{} {
+	cfg, args := getConfig(args)
+	if len(args) < 1 {
+		return map[string]interface{}{
+			"error":   "settings argument required",
+			"success": false,
+		}
+	}
+	font := cfg.Fontname
+	if err := json.Unmarshal([]byte(args[0].String()), cfg); err != nil {
+		return map[string]interface{}{
+			"error":   err.Error(),
+			"success": false,
+		}
+	}
+	if cfg.Fontname != font {
+		if err := loadFont(cfg); err != nil {
+			return map[string]interface{}{
+				"error":   err.Error(),
+				"success": false,
+			}
+		}
 	}
 	return map[string]interface{}{
 		"error":   nil,
@@ -435,6 +517,35 @@ func clearControlFiles(this js.Value, args []js.Value) interface{} {
 	}
 }
 
+// setAnimationBudget caps the frames and grid cells of the animations
+// generated from now on, 0 for no limit, so that long animations of wide
+// text stay quick to generate
+func setAnimationBudget(this js.Value, args []js.Value) interface{} {
+	inst, args := getInstance(args)
+	if len(args) < 2 {
+		return map[string]interface{}{
+			"error":   "maxFrames and maxCells arguments required",
+			"success": false,
+		}
+	}
+	mu.Lock()
+	inst.maxFrames, inst.maxCells = args[0].Int(), args[1].Int()
+	mu.Unlock()
+	return map[string]interface{}{
+		"error":   nil,
+		"success": true,
+	}
+}
+
+// newAnimator returns an animator for the instance with its animation budget
+func (inst *instance) newAnimator() *figlet.Animator {
+	animator := figlet.NewAnimator(inst.cfg)
+	mu.Lock()
+	animator.MaxFrames, animator.MaxCells = inst.maxFrames, inst.maxCells
+	mu.Unlock()
+	return animator
+}
+
 // listAnimations returns available animations
 func listAnimations(this js.Value, args []js.Value) interface{} {
 	animations := figlet.ListAnimations()
@@ -450,7 +561,7 @@ func listAnimations(this js.Value, args []js.Value) interface{} {
 
 // generateAnimation generates frames for an animation
 func generateAnimation(this js.Value, args []js.Value) interface{} {
-	cfg, args := getConfig(args)
+	inst, args := getInstance(args)
 	if len(args) < 1 {
 		return map[string]interface{}{
 			"error": "text argument required",
@@ -468,7 +579,7 @@ func generateAnimation(this js.Value, args []js.Value) interface{} {
 		delayMs = args[2].Int()
 	}
 
-	animator := figlet.NewAnimator(cfg)
+	animator := inst.newAnimator()
 	frames, err := animator.GenerateAnimation(text, animType, time.Duration(delayMs)*time.Millisecond)
 	if err != nil {
 		return map[string]interface{}{
@@ -483,6 +594,7 @@ func generateAnimation(this js.Value, args []js.Value) interface{} {
 			"content":        f.Content,
 			"delay":          f.Delay.Milliseconds(),
 			"baselineOffset": f.BaselineOffset,
+			"baseline":       f.Baseline,
 		}
 	}
 
@@ -492,27 +604,170 @@ func generateAnimation(this js.Value, args []js.Value) interface{} {
 	}
 }
 
+// generateAnimationDeltas generates an animation as deltas: each frame only
+// carries the lines that changed since the previous one
+func generateAnimationDeltas(this js.Value, args []js.Value) interface{} {
+	inst, args := getInstance(args)
+	if len(args) < 1 {
+		return map[string]interface{}{
+			"error": "text argument required",
+		}
+	}
+
+	text := args[0].String()
+	animType := ""
+	if len(args) > 1 {
+		animType = args[1].String()
+	}
+
+	delayMs := 50
+	if len(args) > 2 {
+		delayMs = args[2].Int()
+	}
+
+	animator := inst.newAnimator()
+	deltas, err := animator.GenerateDeltas(text, animType, time.Duration(delayMs)*time.Millisecond)
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+
+	jsDeltas := make([]interface{}, len(deltas))
+	for i, d := range deltas {
+		changes := make([]interface{}, len(d.Changes))
+		for j, c := range d.Changes {
+			changes[j] = map[string]interface{}{
+				"line":   c.Line,
+				"column": c.Column,
+				"text":   c.Text,
+			}
+		}
+		jsDeltas[i] = map[string]interface{}{
+			"changes":        changes,
+			"lines":          d.Lines,
+			"delay":          d.Delay.Milliseconds(),
+			"baselineOffset": d.BaselineOffset,
+			"baseline":       d.Baseline,
+		}
+	}
+
+	return map[string]interface{}{
+		"error":  nil,
+		"deltas": jsDeltas,
+	}
+}
+
+// generateAnimationStructured generates an animation as cell grids, or as
+// cell changes from the previous frame when the diff argument is true, with
+// the palette the color indexes refer to
+func generateAnimationStructured(this js.Value, args []js.Value) interface{} {
+	inst, args := getInstance(args)
+	if len(args) < 1 {
+		return map[string]interface{}{
+			"error": "text argument required",
+		}
+	}
+
+	text := args[0].String()
+	animType := ""
+	if len(args) > 1 {
+		animType = args[1].String()
+	}
+
+	delayMs := 50
+	if len(args) > 2 {
+		delayMs = args[2].Int()
+	}
+
+	diff := len(args) > 3 && args[3].Truthy()
+
+	animator := inst.newAnimator()
+	frames, err := animator.GenerateStructured(text, animType, time.Duration(delayMs)*time.Millisecond)
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+
+	colors := animator.Palette()
+	palette := make([]interface{}, len(colors))
+	for i, c := range colors {
+		tc, _ := figlet.RGB(c)
+		palette[i] = fmt.Sprintf("#%02x%02x%02x", tc.R, tc.G, tc.B)
+	}
+
+	jsFrames := make([]interface{}, len(frames))
+	var prev [][]figlet.Cell
+	for i, f := range frames {
+		frame := map[string]interface{}{
+			"delay":          f.Delay.Milliseconds(),
+			"baselineOffset": f.BaselineOffset,
+			"baseline":       f.Baseline,
+		}
+		if diff {
+			// Each change is [x, y, char, color]
+			changes := figlet.DiffCells(prev, f.Cells)
+			jsChanges := make([]interface{}, len(changes))
+			for j, c := range changes {
+				jsChanges[j] = []interface{}{c.X, c.Y, string(c.Cell.Char), c.Cell.Color}
+			}
+			frame["changes"] = jsChanges
+		} else {
+			// Each row is its text and one color index per character
+			rows := make([]interface{}, len(f.Cells))
+			for y, line := range f.Cells {
+				chars := make([]rune, len(line))
+				colors := make([]interface{}, len(line))
+				for x, c := range line {
+					chars[x], colors[x] = c.Char, c.Color
+				}
+				rows[y] = map[string]interface{}{
+					"text":   string(chars),
+					"colors": colors,
+				}
+			}
+			frame["rows"] = rows
+		}
+		jsFrames[i] = frame
+		prev = f.Cells
+	}
+
+	return map[string]interface{}{
+		"error":   nil,
+		"palette": palette,
+		"frames":  jsFrames,
+	}
+}
+
 func main() {
 	// Register functions to be called from JavaScript
 	js.Global().Set("figlet", js.ValueOf(map[string]interface{}{
-		"createInstance":    js.FuncOf(createInstance),
-		"render":            js.FuncOf(render),
-		"renderWithFont":    js.FuncOf(renderWithFont),
-		"setFont":           js.FuncOf(setFont),
-		"listFonts":         js.FuncOf(listFonts),
-		"getVersion":        js.FuncOf(getVersion),
-		"setWidth":          js.FuncOf(setWidth),
-		"setJustification":  js.FuncOf(setJustification),
-		"setColors":         js.FuncOf(setColors),
-		"setParser":         js.FuncOf(setParser),
-		"setSmushMode":      js.FuncOf(setSmushMode),
-		"setRightToLeft":    js.FuncOf(setRightToLeft),
-		"setParagraph":      js.FuncOf(setParagraphMode),
-		"setDeutsch":        js.FuncOf(setDeutschFlag),
-		"addControlFile":    js.FuncOf(addControlFile),
-		"clearControlFiles": js.FuncOf(clearControlFiles),
-		"listAnimations":    js.FuncOf(listAnimations),
-		"generateAnimation": js.FuncOf(generateAnimation),
+		"createInstance":              js.FuncOf(createInstance),
+		"render":                      js.FuncOf(render),
+		"renderWithFont":              js.FuncOf(renderWithFont),
+		"setFont":                     js.FuncOf(setFont),
+		"listFonts":                   js.FuncOf(listFonts),
+		"getVersion":                  js.FuncOf(getVersion),
+		"setWidth":                    js.FuncOf(setWidth),
+		"setJustification":            js.FuncOf(setJustification),
+		"setColors":                   js.FuncOf(setColors),
+		"setParser":                   js.FuncOf(setParser),
+		"setSmushMode":                js.FuncOf(setSmushMode),
+		"getSmushRules":               js.FuncOf(getSmushRules),
+		"setSmushRules":               js.FuncOf(setSmushRules),
+		"getSettings":                 js.FuncOf(getSettings),
+		"setSettings":                 js.FuncOf(setSettings),
+		"setRightToLeft":              js.FuncOf(setRightToLeft),
+		"setParagraph":                js.FuncOf(setParagraphMode),
+		"setDeutsch":                  js.FuncOf(setDeutschFlag),
+		"addControlFile":              js.FuncOf(addControlFile),
+		"clearControlFiles":           js.FuncOf(clearControlFiles),
+		"listAnimations":              js.FuncOf(listAnimations),
+		"generateAnimation":           js.FuncOf(generateAnimation),
+		"generateAnimationDeltas":     js.FuncOf(generateAnimationDeltas),
+		"generateAnimationStructured": js.FuncOf(generateAnimationStructured),
+		"setAnimationBudget":          js.FuncOf(setAnimationBudget),
 	}))
 
 	// Signal that WASM is ready in browser environment